@@ -0,0 +1,258 @@
+// SPDX-FileCopyrightText: 2023 Weston Schmidt <weston_schmidt@alumni.purdue.edu>
+// SPDX-License-Identifier: Apache-2.0
+
+package goschtalt
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ArchiveFS provides the contents of a zip archive as an fs.FS so it can be
+// used with [AddTree](), [AddDir](), [AddFiles]() or any other option that
+// accepts an fs.FS.
+func ArchiveFS(r io.ReaderAt, size int64) (fs.FS, error) {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, fmt.Errorf("%w, the zip archive is invalid: %v", ErrInvalidInput, err) //nolint:errorlint
+	}
+
+	return zr, nil
+}
+
+// TarFS provides the contents of a tar archive as an fs.FS so it can be used
+// with [AddTree](), [AddDir](), [AddFiles]() or any other option that accepts
+// an fs.FS.  A gzip compressed archive (.tar.gz or .tgz) is detected and
+// decompressed automatically.
+//
+// The archive is read entirely into memory.  Only regular files and
+// directories are included; links and other special files are ignored.
+func TarFS(r io.Reader) (fs.FS, error) {
+	br := bufio.NewReader(r)
+
+	// Check for the gzip magic number.
+	if magic, err := br.Peek(2); err == nil && magic[0] == 0x1f && magic[1] == 0x8b {
+		gz, err := gzip.NewReader(br)
+		if err != nil {
+			return nil, fmt.Errorf("%w, the gzip stream is invalid: %v", ErrInvalidInput, err) //nolint:errorlint
+		}
+		defer gz.Close()
+		r = gz
+	} else {
+		r = br
+	}
+
+	m := memFS{
+		".": {
+			name:  ".",
+			mode:  fs.ModeDir | 0555,
+			isDir: true,
+		},
+	}
+
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w, the tar archive is invalid: %v", ErrInvalidInput, err) //nolint:errorlint
+		}
+
+		name := path.Clean(strings.TrimLeft(hdr.Name, "/"))
+		if name == "." || !fs.ValidPath(name) {
+			continue
+		}
+
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			entry := m.mkdirAll(name)
+			entry.mode = fs.ModeDir | hdr.FileInfo().Mode().Perm()
+			entry.modTime = hdr.ModTime
+		case tar.TypeReg:
+			data, err := io.ReadAll(tr)
+			if err != nil {
+				return nil, fmt.Errorf("%w, the tar archive is invalid: %v", ErrInvalidInput, err) //nolint:errorlint
+			}
+
+			parent := m.mkdirAll(path.Dir(name))
+			if _, found := m[name]; !found {
+				parent.children = append(parent.children, path.Base(name))
+			}
+			m[name] = &memEntry{
+				name:    path.Base(name),
+				data:    data,
+				mode:    hdr.FileInfo().Mode().Perm(),
+				modTime: hdr.ModTime,
+			}
+		}
+	}
+
+	for _, entry := range m {
+		sort.Strings(entry.children)
+	}
+
+	return m, nil
+}
+
+// memFS is a simple read only in memory fs.FS keyed by the full path of each
+// file and directory.
+type memFS map[string]*memEntry
+
+// memEntry is a single file or directory.
+type memEntry struct {
+	name     string
+	data     []byte
+	mode     fs.FileMode
+	modTime  time.Time
+	isDir    bool
+	children []string
+}
+
+var _ fs.ReadDirFS = memFS{}
+
+// mkdirAll ensures the directory and all of its parents exist and returns the
+// directory.
+func (m memFS) mkdirAll(dir string) *memEntry {
+	if entry, found := m[dir]; found {
+		if !entry.isDir {
+			// A file and a directory have the same name; the directory wins.
+			entry.isDir = true
+			entry.mode = fs.ModeDir | 0555
+			entry.data = nil
+		}
+		return entry
+	}
+
+	parent := m.mkdirAll(path.Dir(dir))
+	parent.children = append(parent.children, path.Base(dir))
+
+	entry := &memEntry{
+		name:  path.Base(dir),
+		mode:  fs.ModeDir | 0555,
+		isDir: true,
+	}
+	m[dir] = entry
+	return entry
+}
+
+// Open opens the named file or directory.
+func (m memFS) Open(name string) (fs.File, error) {
+	entry, err := m.lookup("open", name)
+	if err != nil {
+		return nil, err
+	}
+
+	if entry.isDir {
+		return &memDir{fs: m, path: name, entry: entry}, nil
+	}
+
+	return &memFile{entry: entry, r: bytes.NewReader(entry.data)}, nil
+}
+
+// ReadDir reads the named directory and returns the entries sorted by name.
+func (m memFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	entry, err := m.lookup("readdir", name)
+	if err != nil {
+		return nil, err
+	}
+
+	if !entry.isDir {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: errors.New("not a directory")}
+	}
+
+	return m.entries(name, entry.children), nil
+}
+
+func (m memFS) lookup(op, name string) (*memEntry, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: op, Path: name, Err: fs.ErrInvalid}
+	}
+
+	entry, found := m[name]
+	if !found {
+		return nil, &fs.PathError{Op: op, Path: name, Err: fs.ErrNotExist}
+	}
+
+	return entry, nil
+}
+
+func (m memFS) entries(dir string, names []string) []fs.DirEntry {
+	rv := make([]fs.DirEntry, 0, len(names))
+	for _, name := range names {
+		full := name
+		if dir != "." {
+			full = dir + "/" + name
+		}
+		rv = append(rv, memInfo{entry: m[full]})
+	}
+	return rv
+}
+
+// memInfo provides both the fs.FileInfo and fs.DirEntry for an entry.
+type memInfo struct {
+	entry *memEntry
+}
+
+func (i memInfo) Name() string               { return i.entry.name }
+func (i memInfo) Size() int64                { return int64(len(i.entry.data)) }
+func (i memInfo) Mode() fs.FileMode          { return i.entry.mode }
+func (i memInfo) ModTime() time.Time         { return i.entry.modTime }
+func (i memInfo) IsDir() bool                { return i.entry.isDir }
+func (i memInfo) Sys() any                   { return nil }
+func (i memInfo) Type() fs.FileMode          { return i.entry.mode.Type() }
+func (i memInfo) Info() (fs.FileInfo, error) { return i, nil }
+
+// memFile is an open file.
+type memFile struct {
+	entry *memEntry
+	r     *bytes.Reader
+}
+
+func (f *memFile) Stat() (fs.FileInfo, error) { return memInfo{entry: f.entry}, nil }
+func (f *memFile) Read(b []byte) (int, error) { return f.r.Read(b) }
+func (f *memFile) Close() error               { return nil }
+
+// memDir is an open directory.
+type memDir struct {
+	fs     memFS
+	path   string
+	entry  *memEntry
+	offset int
+}
+
+func (d *memDir) Stat() (fs.FileInfo, error) { return memInfo{entry: d.entry}, nil }
+func (d *memDir) Close() error               { return nil }
+
+func (d *memDir) Read([]byte) (int, error) {
+	return 0, &fs.PathError{Op: "read", Path: d.path, Err: errors.New("is a directory")}
+}
+
+// ReadDir reads the next count entries of the directory, or all of them if
+// count is not positive.
+func (d *memDir) ReadDir(count int) ([]fs.DirEntry, error) {
+	remaining := d.entry.children[d.offset:]
+	if count > 0 {
+		if len(remaining) == 0 {
+			return nil, io.EOF
+		}
+		if count < len(remaining) {
+			remaining = remaining[:count]
+		}
+	}
+
+	d.offset += len(remaining)
+	return d.fs.entries(d.path, remaining), nil
+}
@@ -0,0 +1,192 @@
+// SPDX-FileCopyrightText: 2023 Weston Schmidt <weston_schmidt@alumni.purdue.edu>
+// SPDX-License-Identifier: Apache-2.0
+
+package goschtalt
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	iofs "io/fs"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var archiveFiles = []struct {
+	name string
+	data string
+}{
+	{name: "conf/1.json", data: `{"a":"1"}`},
+	{name: "conf/2.json", data: `{"b":"2"}`},
+	{name: "conf/sub/3.json", data: `{"c":"3"}`},
+	{name: "conf/sub/deep/4.json", data: `{"d":"4"}`},
+	{name: "other/5.json", data: `{"e":"5"}`},
+}
+
+func makeZip(t *testing.T) []byte {
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+	for _, f := range archiveFiles {
+		fw, err := w.Create(f.name)
+		require.NoError(t, err)
+		_, err = fw.Write([]byte(f.data))
+		require.NoError(t, err)
+	}
+	require.NoError(t, w.Close())
+	return buf.Bytes()
+}
+
+func makeTar(t *testing.T, compress bool) []byte {
+	var buf bytes.Buffer
+
+	var tw *tar.Writer
+	var gz *gzip.Writer
+	if compress {
+		gz = gzip.NewWriter(&buf)
+		tw = tar.NewWriter(gz)
+	} else {
+		tw = tar.NewWriter(&buf)
+	}
+
+	modTime := time.Date(2023, 1, 2, 3, 4, 5, 0, time.UTC)
+	require.NoError(t, tw.WriteHeader(&tar.Header{
+		Typeflag: tar.TypeDir,
+		Name:     "./conf/",
+		Mode:     0755,
+		ModTime:  modTime,
+	}))
+	for _, f := range archiveFiles {
+		require.NoError(t, tw.WriteHeader(&tar.Header{
+			Typeflag: tar.TypeReg,
+			Name:     f.name,
+			Mode:     0644,
+			Size:     int64(len(f.data)),
+			ModTime:  modTime,
+		}))
+		_, err := tw.Write([]byte(f.data))
+		require.NoError(t, err)
+	}
+	require.NoError(t, tw.WriteHeader(&tar.Header{
+		Typeflag: tar.TypeSymlink,
+		Name:     "conf/link.json",
+		Linkname: "1.json",
+	}))
+
+	require.NoError(t, tw.Close())
+	if gz != nil {
+		require.NoError(t, gz.Close())
+	}
+	return buf.Bytes()
+}
+
+func TestArchiveFS(t *testing.T) {
+	zipData := makeZip(t)
+	zipFS, err := ArchiveFS(bytes.NewReader(zipData), int64(len(zipData)))
+	require.NoError(t, err)
+
+	tarFS, err := TarFS(bytes.NewReader(makeTar(t, false)))
+	require.NoError(t, err)
+
+	tgzFS, err := TarFS(bytes.NewReader(makeTar(t, true)))
+	require.NoError(t, err)
+
+	mapFS := fstest.MapFS{}
+	for _, f := range archiveFiles {
+		mapFS[f.name] = &fstest.MapFile{Data: []byte(f.data), Mode: 0644}
+	}
+
+	tests := []struct {
+		description string
+		opt         func(iofs.FS) Option
+		expect      map[string]any
+	}{
+		{
+			description: "AddTree",
+			opt: func(fs iofs.FS) Option {
+				return AddTree(fs, "conf")
+			},
+			expect: map[string]any{"a": "1", "b": "2", "c": "3", "d": "4"},
+		}, {
+			description: "AddTree with MaxDepth",
+			opt: func(fs iofs.FS) Option {
+				return AddTree(fs, "conf", MaxDepth(1))
+			},
+			expect: map[string]any{"a": "1", "b": "2", "c": "3"},
+		}, {
+			description: "AddDir",
+			opt: func(fs iofs.FS) Option {
+				return AddDir(fs, "conf")
+			},
+			expect: map[string]any{"a": "1", "b": "2"},
+		}, {
+			description: "AddFiles with a glob",
+			opt: func(fs iofs.FS) Option {
+				return AddFiles(fs, "*/5.json", "conf/sub/3.json")
+			},
+			expect: map[string]any{"c": "3", "e": "5"},
+		},
+	}
+
+	filesystems := []struct {
+		name string
+		fs   iofs.FS
+	}{
+		{name: "map", fs: mapFS},
+		{name: "zip", fs: zipFS},
+		{name: "tar", fs: tarFS},
+		{name: "tar.gz", fs: tgzFS},
+	}
+
+	for _, tc := range tests {
+		for _, fsys := range filesystems {
+			t.Run(tc.description+" "+fsys.name, func(t *testing.T) {
+				assert := assert.New(t)
+				require := require.New(t)
+
+				cfg, err := New(
+					WithDecoder(&testDecoder{extensions: []string{"json"}}),
+					tc.opt(fsys.fs),
+				)
+				require.NoError(err)
+
+				got, err := Unmarshal[map[string]any](cfg, Root)
+				require.NoError(err)
+				assert.Equal(tc.expect, got)
+			})
+		}
+	}
+}
+
+func TestTarFS(t *testing.T) {
+	require := require.New(t)
+
+	fs, err := TarFS(bytes.NewReader(makeTar(t, true)))
+	require.NoError(err)
+
+	expected := make([]string, 0, len(archiveFiles))
+	for _, f := range archiveFiles {
+		expected = append(expected, f.name)
+	}
+
+	require.NoError(fstest.TestFS(fs, expected...))
+
+	// The symlink is ignored.
+	_, err = fs.Open("conf/link.json")
+	assert.ErrorIs(t, err, iofs.ErrNotExist)
+}
+
+func TestArchiveErrors(t *testing.T) {
+	_, err := ArchiveFS(bytes.NewReader([]byte("not a zip")), 9)
+	assert.ErrorIs(t, err, ErrInvalidInput)
+
+	_, err = TarFS(bytes.NewReader([]byte{0x1f, 0x8b, 0x00}))
+	assert.ErrorIs(t, err, ErrInvalidInput)
+
+	_, err = TarFS(bytes.NewReader(bytes.Repeat([]byte("x"), 1024)))
+	assert.ErrorIs(t, err, ErrInvalidInput)
+}
@@ -0,0 +1,333 @@
+// SPDX-FileCopyrightText: 2023 Weston Schmidt <weston_schmidt@alumni.purdue.edu>
+// SPDX-License-Identifier: Apache-2.0
+
+package goschtalt
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/goschtalt/goschtalt/internal/print"
+	"github.com/goschtalt/goschtalt/pkg/meta"
+)
+
+// AddArgs adds configuration values from a list of key=value strings, like
+// the ones provided on a command line:
+//
+//	goschtalt.AddArgs("zz-args", []string{"server.port=8080", "debug=true"})
+//
+// Each key is split using the key delimiter to produce a nested configuration
+// tree.  The value is everything after the first '=' and may be empty.  If the
+// same key is present more than once the last value is used.  An entry without
+// an '=' or with an empty key is an error.
+//
+// All values are added as strings, but they are weakly typed when unmarshaled:
+// a value that is unmarshaled into a bool, integer or floating point field is
+// parsed, so "debug=true" unmarshals into a bool and "server.port=8080" into
+// an int.  The adapters provided by [AdaptFromCfg]() are tried first, so
+// values like durations can be converted by the adapters found in
+// [github.com/goschtalt/goschtalt/pkg/adapter].  Only values that are still
+// the ones from the args when the compilation finishes are weakly typed.
+//
+// The args are merged after the other records since they have a priority of
+// 1 (see [Priority]()) unless a different priority is provided or [AsDefault]()
+// is used.  The recordName field is used for sorting the args relative to other
+// records with the same priority.  The origin of each value is the recordName
+// and the position of the entry in the list.
+//
+// Valid Option Types:
+//   - [BufferOption]
+//   - [BufferValueOption]
+//   - [GlobalOption]
+func AddArgs(recordName string, args []string, opts ...BufferOption) Option {
+	text := print.P("AddArgs", print.String(recordName), print.Strings(args), print.LiteralStringers(opts))
+
+	pairs := make([]argPair, 0, len(args))
+	for _, arg := range args {
+		key, val, found := strings.Cut(arg, "=")
+		if !found || len(key) == 0 {
+			return WithError(
+				fmt.Errorf("%w, AddArgs entry '%s' is not in the form key=value", ErrInvalidInput, arg),
+			)
+		}
+		pairs = append(pairs, argPair{key: key, val: val})
+	}
+
+	return &argList{
+		text:       text,
+		recordName: recordName,
+		pairs:      pairs,
+		opts:       opts,
+	}
+}
+
+// argPair is a single key and value.
+type argPair struct {
+	key string
+	val string
+}
+
+type argList struct {
+	// The text to use when String() is called.
+	text string
+
+	// The record name.
+	recordName string
+
+	// The key and value pairs in the order provided.
+	pairs []argPair
+
+	// Options that configure how the args are treated and processed.
+	opts []BufferOption
+}
+
+func (a argList) apply(opts *options) error {
+	if len(a.recordName) == 0 {
+		return fmt.Errorf("%w: a recordName with length > 0 must be specified.", ErrInvalidInput)
+	}
+
+	r := record{
+		name: a.recordName,
+		args: &a,
+	}
+
+	var isDefault bool
+	for _, opt := range a.opts {
+		var info bufferOptions
+		if err := opt.bufferApply(&info); err != nil {
+			return err
+		}
+		isDefault = isDefault || info.isDefault
+		if info.priority != nil {
+			r.priority = info.priority
+		}
+	}
+
+	if isDefault {
+		opts.defaults = append(opts.defaults, r)
+		return nil
+	}
+
+	if r.priority == nil {
+		priority := argsPriority
+		r.priority = &priority
+	}
+
+	opts.values = append(opts.values, r)
+	return nil
+}
+
+// argsPriority is the priority of the args unless one is provided, so they
+// are merged after the files and values without a priority.
+const argsPriority = 1
+
+func (_ argList) ignoreDefaults() bool {
+	return false
+}
+
+func (a argList) String() string {
+	return a.text
+}
+
+// toTree converts the key and value pairs into a meta.Object tree.  This will
+// happen during the compilation stage.
+func (a *argList) toTree(delimiter string) (meta.Object, error) {
+	var cfg bufferOptions
+	for _, opt := range a.opts {
+		if err := opt.bufferApply(&cfg); err != nil {
+			return meta.Object{}, err
+		}
+	}
+
+	tree := meta.Object{
+		Origins: []meta.Origin{{File: a.recordName}},
+		Map:     make(map[string]meta.Object),
+	}
+
+	for i, pair := range a.pairs {
+		var err error
+		tree, err = tree.Add(delimiter, pair.key, pair.val,
+			meta.Origin{File: a.recordName, Line: i + 1})
+		if err != nil {
+			return meta.Object{}, fmt.Errorf("AddArgs entry '%s=%s' %w", pair.key, pair.val, err)
+		}
+	}
+
+	if len(cfg.origin) > 0 {
+		tree = stampOrigin(tree, cfg.origin)
+	}
+
+	return tree, nil
+}
+
+// weakKeys tracks the keys of the values from the args that are in effect
+// while the records are merged.
+type weakKeys map[string]struct{}
+
+// update adds the leaf keys of the tree if it came from args, otherwise the
+// keys the tree replaces are removed.
+func (w weakKeys) update(tree meta.Object, fromArgs bool, delimiter string) {
+	resolved, err := tree.ResolveCommands()
+	if err != nil {
+		return
+	}
+
+	_ = walk(resolved, nil, delimiter, func(key string, _ any, _ []meta.Origin) error {
+		if fromArgs {
+			w[key] = struct{}{}
+		} else {
+			delete(w, key)
+		}
+		return nil
+	})
+}
+
+// list returns the sorted keys, relative to the root if a root is provided.
+func (w weakKeys) list(root, delimiter string) []string {
+	rv := make([]string, 0, len(w))
+	for key := range w {
+		if len(root) > 0 {
+			var found bool
+			key, found = strings.CutPrefix(key, root+delimiter)
+			if !found {
+				continue
+			}
+		}
+		rv = append(rv, key)
+	}
+	sort.Strings(rv)
+	return rv
+}
+
+// weakValue is the internal type used to mark the string values from the args
+// so they are weakly typed when unmarshaled.
+type weakValue string
+
+// markWeak returns a copy of the tree with the string values at the specified
+// keys marked as weakly typed.  Only the keys at or below the key being
+// unmarshaled are marked.
+func (c *Config) markWeak(tree meta.Object, key string, keys []string) (meta.Object, error) {
+	marked := false
+	for _, k := range keys {
+		if len(key) > 0 && k != key && !strings.HasPrefix(k, key+c.opts.keyDelimiter) {
+			continue
+		}
+
+		obj, err := tree.Fetch(strings.Split(k, c.opts.keyDelimiter), c.opts.keyDelimiter)
+		if err != nil {
+			continue
+		}
+
+		s, ok := obj.Value.(string)
+		if obj.Kind() != meta.Value || !ok {
+			continue
+		}
+
+		if !marked {
+			tree = tree.Clone()
+			marked = true
+		}
+		tree, err = tree.Add(c.opts.keyDelimiter, k, weakValue(s), obj.Origins...)
+		if err != nil {
+			return meta.Object{}, err
+		}
+	}
+
+	return tree, nil
+}
+
+// weakHook wraps the decode hook so the weakValues are provided to the hook
+// as strings.  If the hook still provides a string, it is parsed based on the
+// type of the destination.
+func weakHook(hook func(reflect.Value, reflect.Value) (any, error)) func(reflect.Value, reflect.Value) (any, error) {
+	return func(from, to reflect.Value) (any, error) {
+		if !from.IsValid() || !from.CanInterface() || isLazy(to) {
+			// The Lazy values keep the weakValues so they are weakly typed
+			// when the value is needed.
+			return hook(from, to)
+		}
+
+		w, ok := from.Interface().(weakValue)
+		if !ok {
+			if to.Kind() == reflect.Interface && to.IsNil() {
+				// The maps and arrays are assigned to an empty interface
+				// as is, without visiting the values they hold.
+				from = reflect.ValueOf(unweak(from.Interface()))
+			}
+			return hook(from, to)
+		}
+
+		out, err := hook(reflect.ValueOf(string(w)), to)
+		if err != nil {
+			return nil, err
+		}
+		if s, ok := out.(string); ok {
+			return weaklyTyped(s, to)
+		}
+
+		return out, nil
+	}
+}
+
+// unweak returns the value with any weakValues in the maps and arrays
+// replaced by strings.
+func unweak(v any) any {
+	switch v := v.(type) {
+	case weakValue:
+		return string(v)
+	case map[string]any:
+		if v == nil {
+			return v
+		}
+		m := make(map[string]any, len(v))
+		for key, val := range v {
+			m[key] = unweak(val)
+		}
+		return m
+	case []any:
+		if v == nil {
+			return v
+		}
+		a := make([]any, len(v))
+		for i, val := range v {
+			a[i] = unweak(val)
+		}
+		return a
+	}
+	return v
+}
+
+// weaklyTyped parses the string into the kind of value the destination holds,
+// if it is a bool or a number.  Otherwise the string is returned.
+func weaklyTyped(s string, to reflect.Value) (any, error) {
+	typ := to.Type()
+	if to.Kind() == reflect.Interface && !to.IsNil() {
+		typ = to.Elem().Type()
+	}
+	for typ.Kind() == reflect.Pointer {
+		typ = typ.Elem()
+	}
+
+	var v any
+	var err error
+	switch typ.Kind() {
+	case reflect.Bool:
+		v, err = strconv.ParseBool(s)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		v, err = strconv.ParseInt(s, 0, typ.Bits())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		v, err = strconv.ParseUint(s, 0, typ.Bits())
+	case reflect.Float32, reflect.Float64:
+		v, err = strconv.ParseFloat(s, typ.Bits())
+	default:
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: '%s' can't be converted to a %s: %v", ErrDecoding, s, typ, err) //nolint:errorlint
+	}
+
+	return v, nil
+}
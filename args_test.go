@@ -0,0 +1,253 @@
+// SPDX-FileCopyrightText: 2023 Weston Schmidt <weston_schmidt@alumni.purdue.edu>
+// SPDX-License-Identifier: Apache-2.0
+
+package goschtalt
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/goschtalt/goschtalt/pkg/meta"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAddArgs(t *testing.T) {
+	testErr := errors.New("test error")
+
+	tests := []struct {
+		description string
+		opts        []Option
+		expect      map[string]any
+		records     []string
+		expectedErr error
+	}{
+		{
+			description: "Simple args",
+			opts: []Option{
+				AddArgs("record", []string{"server.port=8080", "debug=true"}),
+			},
+			expect: map[string]any{
+				"debug": "true",
+				"server": map[string]any{
+					"port": "8080",
+				},
+			},
+			records: []string{"record"},
+		}, {
+			description: "No args",
+			opts: []Option{
+				AddArgs("record", nil),
+			},
+			records: []string{"record"},
+		}, {
+			description: "Empty values and values with '='",
+			opts: []Option{
+				AddArgs("record", []string{"empty=", "query=a=b"}),
+			},
+			expect: map[string]any{
+				"empty": "",
+				"query": "a=b",
+			},
+			records: []string{"record"},
+		}, {
+			description: "The last value wins",
+			opts: []Option{
+				AddArgs("record", []string{"a=1", "a=2"}),
+			},
+			expect: map[string]any{
+				"a": "2",
+			},
+			records: []string{"record"},
+		}, {
+			description: "A different key delimiter",
+			opts: []Option{
+				SetKeyDelimiter("/"),
+				AddArgs("record", []string{"server/port=8080", "a.b=c"}),
+			},
+			expect: map[string]any{
+				"a.b": "c",
+				"server": map[string]any{
+					"port": "8080",
+				},
+			},
+			records: []string{"record"},
+		}, {
+			description: "The args override other records",
+			opts: []Option{
+				AddArgs("2", []string{"server.port=9090"}),
+				AddValue("1", Root, map[string]any{
+					"server": map[string]any{
+						"host": "localhost",
+						"port": "8080",
+					},
+				}),
+			},
+			expect: map[string]any{
+				"server": map[string]any{
+					"host": "localhost",
+					"port": "9090",
+				},
+			},
+			records: []string{"1", "2"},
+		}, {
+			description: "The args override records that sort after them",
+			opts: []Option{
+				AddArgs("0", []string{"server.port=9090"}),
+				WithDecoder(&testDecoder{extensions: []string{"json"}}),
+				AddBuffer("9.json", []byte(`{"server":{"host":"localhost","port":"8080"}}`)),
+			},
+			expect: map[string]any{
+				"server": map[string]any{
+					"host": "localhost",
+					"port": "9090",
+				},
+			},
+			records: []string{"9.json", "0"},
+		}, {
+			description: "The args as a default",
+			opts: []Option{
+				AddValue("1", Root, map[string]any{"a": "value"}),
+				AddArgs("2", []string{"a=default", "b=default"}, AsDefault()),
+			},
+			expect: map[string]any{
+				"a": "value",
+				"b": "default",
+			},
+			records: []string{"2", "1"},
+		}, {
+			description: "An entry without an '='",
+			opts: []Option{
+				AddArgs("record", []string{"a=1", "debug"}),
+			},
+			expectedErr: ErrInvalidInput,
+		}, {
+			description: "An entry without a key",
+			opts: []Option{
+				AddArgs("record", []string{"=1"}),
+			},
+			expectedErr: ErrInvalidInput,
+		}, {
+			description: "A missing record name",
+			opts: []Option{
+				AddArgs("", []string{"a=1"}),
+			},
+			expectedErr: ErrInvalidInput,
+		}, {
+			description: "An option error",
+			opts: []Option{
+				AddArgs("record", []string{"a=1"}, WithError(testErr)),
+			},
+			expectedErr: testErr,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			cfg, err := New(tc.opts...)
+
+			if tc.expectedErr != nil {
+				assert.ErrorIs(err, tc.expectedErr)
+				return
+			}
+
+			require.NoError(err)
+			require.NotNil(cfg)
+
+			got, err := Unmarshal[map[string]any](cfg, Root)
+			require.NoError(err)
+			assert.Equal(tc.expect, got)
+			assert.Equal(tc.records, cfg.records)
+		})
+	}
+}
+
+func TestAddArgsWeaklyTyped(t *testing.T) {
+	type Server struct {
+		Host    string
+		Port    int
+		Timeout float64
+		Workers *uint
+	}
+	type Config struct {
+		Debug  bool
+		Server Server
+		Labels map[string]any
+	}
+
+	tests := []struct {
+		description string
+		args        []string
+		expect      Config
+		expectedErr error
+	}{
+		{
+			description: "Bools and numbers",
+			args: []string{
+				"debug=true",
+				"server.host=localhost",
+				"server.port=8080",
+				"server.timeout=1.5",
+				"server.workers=4",
+				"labels.team=blue",
+			},
+			expect: Config{
+				Debug: true,
+				Server: Server{
+					Host:    "localhost",
+					Port:    8080,
+					Timeout: 1.5,
+					Workers: func() *uint { u := uint(4); return &u }(),
+				},
+				Labels: map[string]any{"team": "blue"},
+			},
+		}, {
+			description: "A value that isn't a bool",
+			args:        []string{"debug=yes please"},
+			expectedErr: ErrDecoding,
+		}, {
+			description: "A value that isn't an int",
+			args:        []string{"server.port=http"},
+			expectedErr: ErrDecoding,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			cfg, err := New(AddArgs("args", tc.args), ConfigIs("flatcase"))
+			require.NoError(err)
+
+			got, err := Unmarshal[Config](cfg, Root)
+			if tc.expectedErr != nil {
+				assert.ErrorIs(err, tc.expectedErr)
+				return
+			}
+
+			require.NoError(err)
+			assert.Equal(tc.expect, got)
+		})
+	}
+}
+
+func TestAddArgsOrigins(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	cfg, err := New(AddArgs("args", []string{"a=1", "b.c=2"}))
+	require.NoError(err)
+
+	obj, err := cfg.tree.Fetch([]string{"b", "c"}, ".")
+	require.NoError(err)
+	assert.Equal([]meta.Origin{{File: "args", Line: 2}}, obj.Origins)
+}
+
+func TestAddArgsString(t *testing.T) {
+	opt := AddArgs("record", []string{"a=1", "b=2"}, AsDefault())
+	assert.Equal(t, "AddArgs( 'record', 'a=1', 'b=2', AsDefault() )", opt.String())
+}
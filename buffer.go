@@ -4,6 +4,7 @@
 package goschtalt
 
 import (
+	"context"
 	"fmt"
 	"path"
 	"strings"
@@ -34,6 +35,38 @@ func (f BufferGetterFunc) Get(rn string, u Unmarshaler) ([]byte, error) {
 
 var _ BufferGetter = (*BufferGetterFunc)(nil)
 
+// BufferGetterCtx is the same as [BufferGetter] except the context passed to
+// [Config.CompileCtx]() is provided, so long running getters are able to stop
+// and return ctx.Err() when the context is done.
+type BufferGetterCtx interface {
+	// GetCtx is called each time the configuration is compiled.  The context
+	// of the compilation, the recordName and an Unmarshaler with the present
+	// stage of configuration and expanded variables are provided to assist.
+	// A slice of bytes or an error is returned.
+	GetCtx(ctx context.Context, recordName string, u Unmarshaler) ([]byte, error)
+}
+
+// The BufferGetterCtxFunc type is an adapter to allow the use of ordinary
+// functions as BufferGetterCtxs. If f is a function with the appropriate
+// signature, BufferGetterCtxFunc(f) is a BufferGetterCtx that calls f.
+type BufferGetterCtxFunc func(context.Context, string, Unmarshaler) ([]byte, error)
+
+// GetCtx calls f(ctx, rn, u)
+func (f BufferGetterCtxFunc) GetCtx(ctx context.Context, rn string, u Unmarshaler) ([]byte, error) {
+	return f(ctx, rn, u)
+}
+
+var _ BufferGetterCtx = (*BufferGetterCtxFunc)(nil)
+
+// bufferGetterNoCtx adapts a BufferGetter into a BufferGetterCtx.
+type bufferGetterNoCtx struct {
+	BufferGetter
+}
+
+func (b bufferGetterNoCtx) GetCtx(_ context.Context, rn string, u Unmarshaler) ([]byte, error) {
+	return b.Get(rn, u)
+}
+
 // AddBuffer adds a buffer of bytes for inclusion when compiling the configuration.
 // The format of the bytes is determined by the extension of the recordName field.
 // The recordName field is also used for sorting this configuration value relative
@@ -47,8 +80,8 @@ func AddBuffer(recordName string, in []byte, opts ...BufferOption) Option {
 	return &buffer{
 		text:       print.P("AddBuffer", print.String(recordName), print.Bytes(in), print.LiteralStringers(opts)),
 		recordName: recordName,
-		getter: BufferGetterFunc(
-			func(_ string, _ Unmarshaler) ([]byte, error) {
+		getter: BufferGetterCtxFunc(
+			func(context.Context, string, Unmarshaler) ([]byte, error) {
 				return in, nil
 			}),
 		opts: opts,
@@ -70,10 +103,32 @@ func AddBuffer(recordName string, in []byte, opts ...BufferOption) Option {
 //   - [BufferValueOption]
 //   - [GlobalOption]
 func AddBufferGetter(recordName string, getter BufferGetter, opts ...BufferOption) Option {
+	var ctxGetter BufferGetterCtx
+	if getter != nil {
+		ctxGetter = bufferGetterNoCtx{getter}
+	}
+
 	return &buffer{
 		text:       print.P("AddBufferGetter", print.String(recordName), print.Obj(getter), print.LiteralStringers(opts)),
 		recordName: recordName,
 		opts:       opts,
+		getter:     ctxGetter,
+	}
+}
+
+// AddBufferGetterCtx is the same as [AddBufferGetter]() except the getter is
+// provided the context used to compile the configuration.  See
+// [Config.CompileCtx]().
+//
+// Valid Option Types:
+//   - [BufferOption]
+//   - [BufferValueOption]
+//   - [GlobalOption]
+func AddBufferGetterCtx(recordName string, getter BufferGetterCtx, opts ...BufferOption) Option {
+	return &buffer{
+		text:       print.P("AddBufferGetterCtx", print.String(recordName), print.Obj(getter), print.LiteralStringers(opts)),
+		recordName: recordName,
+		opts:       opts,
 		getter:     getter,
 	}
 }
@@ -86,7 +141,7 @@ type buffer struct {
 	recordName string
 
 	// The getter to use to get the value.
-	getter BufferGetter
+	getter BufferGetterCtx
 
 	// Options that configure how this buffer is treated and processed.
 	// These options are in addition to any default settings set with
@@ -108,17 +163,23 @@ func (b buffer) apply(opts *options) error {
 		buf:  &b,
 	}
 
+	var isDefault bool
 	for _, opt := range b.opts {
 		var info bufferOptions
 		if err := opt.bufferApply(&info); err != nil {
 			return err
 		}
-		if info.isDefault {
-			opts.defaults = append(opts.defaults, r)
-			return nil
+		isDefault = isDefault || info.isDefault
+		if info.priority != nil {
+			r.priority = info.priority
 		}
 	}
 
+	if isDefault {
+		opts.defaults = append(opts.defaults, r)
+		return nil
+	}
+
 	opts.values = append(opts.values, r)
 	return nil
 }
@@ -133,26 +194,40 @@ func (b buffer) String() string {
 
 // toTree converts an buffer into a meta.Object tree.  This will happen
 // during the compilation stage.
-func (b *buffer) toTree(delimiter string, u Unmarshaler, decoders *codecRegistry[decoder.Decoder]) (meta.Object, error) {
-	data, err := b.getter.Get(b.recordName, u)
+func (b *buffer) toTree(ctx context.Context, delimiter string, u Unmarshaler, decoders *codecRegistry[decoder.Decoder], rejectDuplicateKeys bool) (meta.Object, error) {
+	var cfg bufferOptions
+	for _, opt := range b.opts {
+		if err := opt.bufferApply(&cfg); err != nil {
+			return meta.Object{}, err
+		}
+	}
+
+	data, err := b.getter.GetCtx(ctx, b.recordName, u)
 	if err != nil {
 		return meta.Object{}, err
 	}
 
 	ext := strings.TrimPrefix(path.Ext(b.recordName), ".")
+	if len(cfg.contentType) > 0 {
+		ext, err = mimeToExtension(cfg.contentType)
+		if err != nil {
+			return meta.Object{}, err
+		}
+	}
 
 	dec, err := decoders.find(ext)
 	if err != nil {
 		return meta.Object{}, err
 	}
 
-	ctx := decoder.Context{
-		Filename:  b.recordName,
-		Delimiter: delimiter,
+	decCtx := decoder.Context{
+		Filename:            b.recordName,
+		Delimiter:           delimiter,
+		RejectDuplicateKeys: rejectDuplicateKeys,
 	}
 
 	var tree meta.Object
-	err = dec.Decode(ctx, data, &tree)
+	err = dec.Decode(decCtx, data, &tree)
 	if err != nil {
 		err = fmt.Errorf("decoder error for extension '%s' processing buffer '%s' %w %v",
 			ext, b.recordName, ErrDecoding, err) //nolint:errorlint
@@ -160,6 +235,10 @@ func (b *buffer) toTree(delimiter string, u Unmarshaler, decoders *codecRegistry
 		return meta.Object{}, err
 	}
 
+	if len(cfg.origin) > 0 {
+		tree = stampOrigin(tree, cfg.origin)
+	}
+
 	return tree, nil
 }
 
@@ -174,5 +253,42 @@ type BufferOption interface {
 }
 
 type bufferOptions struct {
-	isDefault bool
+	isDefault   bool
+	origin      string
+	contentType string
+	priority    *int
+}
+
+// WithContentType specifies the MIME type of the buffer so the decoder can be
+// selected when the record name does not have a useful extension.  This is
+// handy when the buffer is fetched via http and the Content-Type header is
+// the only indication of the format.
+//
+// The MIME type is mapped to the extension of the decoder to use.  Some
+// common types are mapped explicitly (application/yaml -> yaml), structured
+// syntax suffixes are honored (application/vnd.api+json -> json), otherwise
+// the subtype is used with any "x-" prefix removed (application/toml -> toml).
+// Parameters like charset are ignored.
+//
+// An empty string restores the default behavior of using the extension of
+// the record name.
+func WithContentType(mimeType string) BufferOption {
+	return contentTypeOption(mimeType)
+}
+
+type contentTypeOption string
+
+func (c contentTypeOption) bufferApply(opts *bufferOptions) error {
+	if len(c) > 0 {
+		if _, err := mimeToExtension(string(c)); err != nil {
+			return err
+		}
+	}
+
+	opts.contentType = string(c)
+	return nil
+}
+
+func (c contentTypeOption) String() string {
+	return print.P("WithContentType", print.String(string(c)), print.SubOpt())
 }
@@ -0,0 +1,121 @@
+// SPDX-FileCopyrightText: 2023 Weston Schmidt <weston_schmidt@alumni.purdue.edu>
+// SPDX-License-Identifier: Apache-2.0
+
+package goschtalt
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithContentType(t *testing.T) {
+	tests := []struct {
+		description string
+		recordName  string
+		contentType string
+		str         string
+		expect      map[string]any
+		expectedErr error
+	}{
+		{
+			description: "A buffer without an extension",
+			recordName:  "http://example.com/config",
+			contentType: "application/json",
+			str:         "WithContentType('application/json')",
+			expect:      map[string]any{"Foo": "bar"},
+		}, {
+			description: "A content type with parameters",
+			recordName:  "config",
+			contentType: "application/x-json; charset=utf-8",
+			str:         "WithContentType('application/x-json; charset=utf-8')",
+			expect:      map[string]any{"Foo": "bar"},
+		}, {
+			description: "A content type with a suffix",
+			recordName:  "config",
+			contentType: "application/vnd.example+json",
+			str:         "WithContentType('application/vnd.example+json')",
+			expect:      map[string]any{"Foo": "bar"},
+		}, {
+			description: "The content type takes precedence over the extension",
+			recordName:  "config.yml",
+			contentType: "text/json",
+			str:         "WithContentType('text/json')",
+			expect:      map[string]any{"Foo": "bar"},
+		}, {
+			description: "An empty content type uses the extension",
+			recordName:  "config.json",
+			str:         "WithContentType('')",
+			expect:      map[string]any{"Foo": "bar"},
+		}, {
+			description: "An unsupported content type",
+			recordName:  "config.json",
+			contentType: "application/yaml",
+			str:         "WithContentType('application/yaml')",
+			expectedErr: ErrCodecNotFound,
+		}, {
+			description: "An invalid content type",
+			recordName:  "config.json",
+			contentType: "application/",
+			str:         "WithContentType('application/')",
+			expectedErr: ErrInvalidInput,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			opt := WithContentType(tc.contentType)
+			assert.Equal(tc.str, opt.String())
+
+			cfg, err := New(
+				WithDecoder(&testDecoder{extensions: []string{"json"}}),
+				AddBuffer(tc.recordName, []byte(`{"Foo":"bar"}`), opt),
+				AutoCompile(),
+			)
+
+			if tc.expectedErr != nil {
+				assert.ErrorIs(err, tc.expectedErr)
+				assert.Nil(cfg)
+				return
+			}
+
+			require.NoError(err)
+
+			got, err := Unmarshal[map[string]any](cfg, Root)
+			require.NoError(err)
+			assert.Equal(tc.expect, got)
+		})
+	}
+}
+
+func TestMimeToExtension(t *testing.T) {
+	tests := []struct {
+		in          string
+		expect      string
+		expectedErr error
+	}{
+		{in: "application/json", expect: "json"},
+		{in: "application/yaml", expect: "yaml"},
+		{in: "application/x-yaml", expect: "yaml"},
+		{in: "text/yaml; charset=utf-8", expect: "yaml"},
+		{in: "application/TOML", expect: "toml"},
+		{in: "application/problem+json", expect: "json"},
+		{in: "text/x-java-properties", expect: "properties"},
+		{in: "application/x-", expectedErr: ErrInvalidInput},
+		{in: "", expectedErr: ErrInvalidInput},
+		{in: "json", expectedErr: ErrInvalidInput},
+	}
+	for _, tc := range tests {
+		t.Run(tc.in, func(t *testing.T) {
+			assert := assert.New(t)
+
+			got, err := mimeToExtension(tc.in)
+
+			assert.ErrorIs(err, tc.expectedErr)
+			assert.Equal(tc.expect, got)
+		})
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"fmt"
 
 	"github.com/goschtalt/goschtalt/internal/print"
+	"github.com/goschtalt/goschtalt/pkg/meta"
 )
 
 // BufferValueOption can be used as a BufferOption or a ValueOption.
@@ -45,3 +46,93 @@ func (o optionalAsDefault) valueApply(opts *valueOptions) error {
 func (o optionalAsDefault) String() string {
 	return print.P("AsDefault", print.BoolSilentTrue(bool(o)), print.SubOpt())
 }
+
+// WithRecordOrigin sets the origin of every value from the record to the
+// provided human readable string, like "--set flag" or "defaults".  This
+// origin is reported when marshaling with [IncludeOrigins]().
+//
+// An empty string restores the default behavior.
+//
+// # Default
+//
+// Buffers use the origins provided by the decoder.  Values use the record
+// name as the origin.
+func WithRecordOrigin(origin string) BufferValueOption {
+	return recordOriginOption(origin)
+}
+
+type recordOriginOption string
+
+func (r recordOriginOption) bufferApply(opts *bufferOptions) error {
+	opts.origin = string(r)
+	return nil
+}
+
+func (r recordOriginOption) valueApply(opts *valueOptions) error {
+	opts.origin = string(r)
+	return nil
+}
+
+func (r recordOriginOption) String() string {
+	return print.P("WithRecordOrigin", print.String(string(r)), print.SubOpt())
+}
+
+// Priority sets an explicit priority for the record that is used to order the
+// records when they are merged.  Records are merged from the lowest priority
+// to the highest, so a record with a higher priority overrides the records
+// with a lower priority.  Records without an explicit priority have a
+// priority of 0.  Records with the same priority keep the normal order: the
+// defaults in the order they were specified, followed by the other records
+// sorted by record name.
+//
+// This allows a default to override a specific value by giving it a positive
+// priority, or a value to be overridden by the defaults by giving it a
+// negative priority.
+//
+// # Default
+//
+// The record has a priority of 0.
+func Priority(priority int) BufferValueOption {
+	return priorityOption(priority)
+}
+
+type priorityOption int
+
+func (p priorityOption) bufferApply(opts *bufferOptions) error {
+	priority := int(p)
+	opts.priority = &priority
+	return nil
+}
+
+func (p priorityOption) valueApply(opts *valueOptions) error {
+	priority := int(p)
+	opts.priority = &priority
+	return nil
+}
+
+func (p priorityOption) String() string {
+	return print.P("Priority", print.Int(int(p)), print.SubOpt())
+}
+
+// stampOrigin replaces the origins of every node in the tree with the
+// specified origin.
+func stampOrigin(obj meta.Object, origin string) meta.Object {
+	obj.Origins = []meta.Origin{{File: origin}}
+
+	switch obj.Kind() {
+	case meta.Array:
+		array := make([]meta.Object, len(obj.Array))
+		for i, val := range obj.Array {
+			array[i] = stampOrigin(val, origin)
+		}
+		obj.Array = array
+	case meta.Map:
+		m := make(map[string]meta.Object, len(obj.Map))
+		for key, val := range obj.Map {
+			m[key] = stampOrigin(val, origin)
+		}
+		obj.Map = m
+	}
+
+	return obj
+}
@@ -7,7 +7,9 @@ import (
 	"errors"
 	"testing"
 
+	"github.com/goschtalt/goschtalt/pkg/meta"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestBufferValueOptions(t *testing.T) {
@@ -16,6 +18,8 @@ func TestBufferValueOptions(t *testing.T) {
 		description string
 		opt         BufferValueOption
 		asDefault   bool
+		origin      string
+		priority    *int
 		str         string
 		expectedErr error
 	}{
@@ -37,6 +41,16 @@ func TestBufferValueOptions(t *testing.T) {
 			description: "Verify WithError(testErr)",
 			opt:         WithError(testErr),
 			str:         "WithError( 'test error' )",
+		}, {
+			description: "Verify WithRecordOrigin(--set flag)",
+			opt:         WithRecordOrigin("--set flag"),
+			origin:      "--set flag",
+			str:         "WithRecordOrigin('--set flag')",
+		}, {
+			description: "Verify Priority(-2)",
+			opt:         Priority(-2),
+			priority:    func() *int { p := -2; return &p }(),
+			str:         "Priority(-2)",
 		},
 	}
 	for _, tc := range tests {
@@ -52,6 +66,10 @@ func TestBufferValueOptions(t *testing.T) {
 			if tc.expectedErr == nil {
 				assert.Equal(tc.asDefault, bo.isDefault)
 				assert.Equal(tc.asDefault, vo.isDefault)
+				assert.Equal(tc.origin, bo.origin)
+				assert.Equal(tc.origin, vo.origin)
+				assert.Equal(tc.priority, bo.priority)
+				assert.Equal(tc.priority, vo.priority)
 
 				assert.Equal(tc.str, tc.opt.String())
 				return
@@ -62,3 +80,116 @@ func TestBufferValueOptions(t *testing.T) {
 		})
 	}
 }
+
+func TestPriority(t *testing.T) {
+	tests := []struct {
+		description string
+		opts        []Option
+		expect      string
+		records     []string
+	}{
+		{
+			description: "Without priorities the normal order is used",
+			opts: []Option{
+				AddValue("2", "Foo", "2"),
+				AddValue("1", "Foo", "1"),
+				AddValue("default", "Foo", "default", AsDefault()),
+			},
+			expect:  "2",
+			records: []string{"default", "1", "2"},
+		}, {
+			description: "A default overrides the values",
+			opts: []Option{
+				AddValue("2", "Foo", "2"),
+				AddValue("1", "Foo", "1"),
+				AddValue("default", "Foo", "default", AsDefault(), Priority(1)),
+			},
+			expect:  "default",
+			records: []string{"1", "2", "default"},
+		}, {
+			description: "A value is overridden by the defaults",
+			opts: []Option{
+				AddValue("2", "Foo", "2", Priority(-1)),
+				AddValue("1", "Foo", "1"),
+				AddValue("default", "Foo", "default", AsDefault()),
+			},
+			expect:  "1",
+			records: []string{"2", "default", "1"},
+		}, {
+			description: "Mixed record types and priorities",
+			opts: []Option{
+				AddBuffer("a.json", []byte(`{"Foo":"a"}`), Priority(5)),
+				AddKVStore("b", mockKV{m: map[string]string{"Foo": "b"}}, "", Priority(5)),
+				AddArgs("c", []string{"Foo=c"}),
+				AddValue("d", "Foo", "d", Priority(-5)),
+				AddValue("e", "Foo", "e", AsDefault(), Priority(2)),
+			},
+			expect:  "b",
+			records: []string{"d", "c", "e", "a.json", "b"},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			opts := append([]Option{
+				WithDecoder(&testDecoder{extensions: []string{"json"}}),
+			}, tc.opts...)
+
+			cfg, err := New(opts...)
+			require.NoError(err)
+
+			got, err := Unmarshal[string](cfg, "Foo")
+			require.NoError(err)
+			assert.Equal(tc.expect, got)
+			assert.Equal(tc.records, cfg.records)
+		})
+	}
+}
+
+func TestWithRecordOrigin(t *testing.T) {
+	tests := []struct {
+		description string
+		opt         Option
+		expected    []meta.Origin
+	}{
+		{
+			description: "A buffer with the decoder origins",
+			opt:         AddBuffer("1.json", []byte(`{"Foo":"bar"}`)),
+			expected:    []meta.Origin{{File: "1.json", Line: 2, Col: 123}},
+		}, {
+			description: "A buffer with a record origin",
+			opt:         AddBuffer("1.json", []byte(`{"Foo":"bar"}`), WithRecordOrigin("defaults")),
+			expected:    []meta.Origin{{File: "defaults"}},
+		}, {
+			description: "A value with the default origin",
+			opt:         AddValue("record", "Foo", "bar"),
+			expected:    []meta.Origin{{File: "record"}},
+		}, {
+			description: "A value with a record origin",
+			opt:         AddValue("record", "Foo", "bar", WithRecordOrigin("--set flag")),
+			expected:    []meta.Origin{{File: "--set flag"}},
+		}, {
+			description: "A kv store with a record origin",
+			opt:         AddKVStore("record", mockKV{m: map[string]string{"Foo": "bar"}}, "", WithRecordOrigin("kv")),
+			expected:    []meta.Origin{{File: "kv"}},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			cfg, err := New(
+				WithDecoder(&testDecoder{extensions: []string{"json"}}),
+				tc.opt,
+			)
+			require.NoError(err)
+
+			got, err := cfg.tree.Fetch([]string{"Foo"}, ".")
+			require.NoError(err)
+			assert.Equal(tc.expected, got.Origins)
+		})
+	}
+}
@@ -0,0 +1,127 @@
+// SPDX-FileCopyrightText: 2023 Weston Schmidt <weston_schmidt@alumni.purdue.edu>
+// SPDX-License-Identifier: Apache-2.0
+
+package goschtalt
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/goschtalt/goschtalt/internal/print"
+	"github.com/goschtalt/goschtalt/pkg/meta"
+)
+
+// WithDerivedDefault provides a default value for the key that is computed
+// from the rest of the configuration.  The function is called after all the
+// records are merged during [Config.Compile]() but only if the key is unset
+// (missing or null).  The *Config passed to the function represents the
+// configuration merged so far and may be used with [Unmarshal]() and similar
+// functions to inspect it.  If the function returns false, the key is left
+// unset.  For example:
+//
+//	goschtalt.WithDerivedDefault("tls.port", func(c *goschtalt.Config) (any, bool) {
+//		enabled, err := goschtalt.Unmarshal[bool](c, "tls.enabled")
+//		if err != nil || !enabled {
+//			return nil, false
+//		}
+//		return 443, true
+//	})
+//
+// The value returned is added to the configuration as is, so it should be a
+// basic type (bool, numbers, strings, time.Time) or a []any or map[string]any
+// of them.  The derived defaults are applied before the final expansion of the
+// configuration, so variables in the value returned are expanded by the
+// [Expand]() and [ExpandEnv]() options like the values from the records.
+//
+// Multiple derived defaults are evaluated in the order they are registered,
+// and each sees the values provided by the ones before it.
+//
+// Valid Option Types:
+//   - [GlobalOption]
+func WithDerivedDefault(key string, fn func(*Config) (any, bool)) Option {
+	return &derivedDefault{
+		text: print.P("WithDerivedDefault", print.String(key), print.Obj(fn)),
+		key:  key,
+		fn:   fn,
+	}
+}
+
+// derivedDefaultName is the origin of the values provided by the derived
+// defaults.
+const derivedDefaultName = "WithDerivedDefault"
+
+type derivedDefault struct {
+	text string
+	key  string
+	fn   func(*Config) (any, bool)
+}
+
+func (d derivedDefault) apply(opts *options) error {
+	if len(d.key) == 0 {
+		return fmt.Errorf("%w, WithDerivedDefault requires a key", ErrInvalidInput)
+	}
+	if d.fn == nil {
+		return fmt.Errorf("%w, WithDerivedDefault requires a function", ErrInvalidInput)
+	}
+
+	opts.derivedDefaults = append(opts.derivedDefaults, d)
+	return nil
+}
+
+func (_ derivedDefault) ignoreDefaults() bool {
+	return false
+}
+
+func (d derivedDefault) String() string {
+	return d.text
+}
+
+// applyDerivedDefaults evaluates the derived defaults in order against the
+// merged tree and returns the resulting tree.  The merged tree isn't expanded
+// yet, so each function gets an expanded snapshot of it.
+func (c *Config) applyDerivedDefaults(merged meta.Object, timings *Timings) (meta.Object, error) {
+	for _, d := range c.opts.derivedDefaults {
+		path := strings.Split(d.key, c.opts.keyDelimiter)
+
+		obj, err := merged.Fetch(path, c.opts.keyDelimiter)
+		if err == nil && (obj.Kind() != meta.Value || obj.Value != nil) {
+			continue
+		}
+		if err != nil && !errors.Is(err, meta.ErrNotFound) {
+			return meta.Object{}, err
+		}
+
+		// The function gets a compiled snapshot of the configuration so far.
+		started := timings.now()
+		expanded, _, err := expandTree(merged, c.opts.exapansionMax, c.opts.expansions)
+		timings.record(phaseExpand, started, 1)
+		if err != nil {
+			return meta.Object{}, err
+		}
+
+		snapshot := Config{
+			tree:       expanded,
+			compiledAt: time.Now(),
+			cache:      c.cache,
+			opts:       c.opts,
+		}
+
+		val, ok := d.fn(&snapshot)
+		if !ok {
+			continue
+		}
+
+		patch := meta.ObjectFromRawWithOrigin(val,
+			[]meta.Origin{{File: derivedDefaultName}},
+			path...)
+
+		merged, err = merged.Merge(patch, c.mergeOptions()...)
+		if err != nil {
+			return meta.Object{}, err
+		}
+	}
+
+	return merged, nil
+}
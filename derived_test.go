@@ -0,0 +1,155 @@
+// SPDX-FileCopyrightText: 2023 Weston Schmidt <weston_schmidt@alumni.purdue.edu>
+// SPDX-License-Identifier: Apache-2.0
+
+package goschtalt
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithDerivedDefault(t *testing.T) {
+	tlsPort := WithDerivedDefault("tls.port", func(c *Config) (any, bool) {
+		enabled, err := Unmarshal[bool](c, "tls.enabled")
+		if err != nil || !enabled {
+			return nil, false
+		}
+		return 443, true
+	})
+
+	vars := ExpanderFunc(func(s string) (string, bool) {
+		if s == "HOST" {
+			return "example.com", true
+		}
+		return "", false
+	})
+
+	tests := []struct {
+		description string
+		opts        []Option
+		expect      map[string]any
+	}{
+		{
+			description: "The default is provided when enabled and unset.",
+			opts: []Option{
+				AddValue("1", Root, map[string]any{
+					"tls": map[string]any{"enabled": true},
+				}),
+				tlsPort,
+			},
+			expect: map[string]any{
+				"tls": map[string]any{"enabled": true, "port": 443},
+			},
+		}, {
+			description: "The default is not provided when disabled.",
+			opts: []Option{
+				AddValue("1", Root, map[string]any{
+					"tls": map[string]any{"enabled": false},
+				}),
+				tlsPort,
+			},
+			expect: map[string]any{
+				"tls": map[string]any{"enabled": false},
+			},
+		}, {
+			description: "The default is not provided when already set.",
+			opts: []Option{
+				AddValue("1", Root, map[string]any{
+					"tls": map[string]any{"enabled": true, "port": 8443},
+				}),
+				tlsPort,
+			},
+			expect: map[string]any{
+				"tls": map[string]any{"enabled": true, "port": 8443},
+			},
+		}, {
+			description: "A null value is unset.",
+			opts: []Option{
+				AddValue("1", Root, map[string]any{
+					"tls": map[string]any{"enabled": true, "port": nil},
+				}),
+				tlsPort,
+			},
+			expect: map[string]any{
+				"tls": map[string]any{"enabled": true, "port": 443},
+			},
+		}, {
+			description: "Derived defaults evaluate in order and see earlier results.",
+			opts: []Option{
+				AddValue("1", Root, map[string]any{
+					"tls": map[string]any{"enabled": true},
+				}),
+				tlsPort,
+				WithDerivedDefault("url", func(c *Config) (any, bool) {
+					port, err := Unmarshal[int](c, "tls.port")
+					if err != nil {
+						return nil, false
+					}
+					return map[string]any{"port": port, "scheme": "https"}, true
+				}),
+			},
+			expect: map[string]any{
+				"tls": map[string]any{"enabled": true, "port": 443},
+				"url": map[string]any{"port": 443, "scheme": "https"},
+			},
+		}, {
+			description: "A derived default can be in a new map.",
+			opts: []Option{
+				WithDerivedDefault("a.b.c", func(*Config) (any, bool) {
+					return "value", true
+				}),
+			},
+			expect: map[string]any{
+				"a": map[string]any{
+					"b": map[string]any{"c": "value"},
+				},
+			},
+		}, {
+			description: "The value provided is expanded.",
+			opts: []Option{
+				WithDerivedDefault("url", func(*Config) (any, bool) {
+					return "https://${HOST}:443", true
+				}),
+				Expand(vars),
+			},
+			expect: map[string]any{
+				"url": "https://example.com:443",
+			},
+		}, {
+			description: "The function sees the expanded values.",
+			opts: []Option{
+				AddValue("1", Root, map[string]any{
+					"host": "${HOST}",
+				}),
+				WithDerivedDefault("url", func(c *Config) (any, bool) {
+					host, err := Unmarshal[string](c, "host")
+					if err != nil {
+						return nil, false
+					}
+					return "https://" + host, true
+				}),
+				Expand(vars),
+			},
+			expect: map[string]any{
+				"host": "example.com",
+				"url":  "https://example.com",
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			cfg, err := New(tc.opts...)
+			require.NoError(err)
+
+			got, err := Unmarshal[map[string]any](cfg, Root)
+			require.NoError(err)
+			assert.Equal(tc.expect, got)
+		})
+	}
+}
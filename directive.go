@@ -0,0 +1,151 @@
+// SPDX-FileCopyrightText: 2023 Weston Schmidt <weston_schmidt@alumni.purdue.edu>
+// SPDX-License-Identifier: Apache-2.0
+
+package goschtalt
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/goschtalt/goschtalt/internal/print"
+	"github.com/goschtalt/goschtalt/pkg/meta"
+)
+
+// MergeDirectives enables merge directives in the records.  A merge directive
+// is a key made of the name of a sibling key followed by the suffix.  The
+// value of the directive is the merge command to use for the sibling key, as
+// if the command was part of the sibling key.  For example, with the suffix
+// ".$merge" the JSON record:
+//
+//	{
+//		"plugins.$merge": "append",
+//		"plugins": ["c"]
+//	}
+//
+// is merged the same as:
+//
+//	{
+//		"plugins((append))": ["c"]
+//	}
+//
+// This is useful for formats or tools that don't allow the `((command))`
+// syntax in keys.  The directives are removed before the record is merged.
+// A directive for a key that isn't present is ignored.  A directive that
+// isn't a string, isn't a known command (like "append" or "replace, secret"),
+// or is for a key that already has a `((command))` fails the compilation.
+//
+// The suffix is matched against the keys after the record is decoded.  The
+// decoders that split keys on the key delimiter (like properties, dotenv and
+// the TOML dotted keys) decode "plugins.$merge" as a "$merge" key inside of
+// the "plugins" map, so the directive is not found.  Use a suffix without the
+// key delimiter (like "$merge" or "_merge") with those formats.
+//
+// An empty suffix disables the merge directives.
+//
+// # Default
+//
+// Merge directives are disabled.
+func MergeDirectives(suffix string) Option {
+	return mergeDirectivesOption(suffix)
+}
+
+type mergeDirectivesOption string
+
+func (m mergeDirectivesOption) apply(opts *options) error {
+	opts.mergeDirective = string(m)
+	return nil
+}
+
+func (_ mergeDirectivesOption) ignoreDefaults() bool {
+	return false
+}
+
+func (m mergeDirectivesOption) String() string {
+	if len(m) == 0 {
+		return print.P("MergeDirectives")
+	}
+	return print.P("MergeDirectives", print.String(string(m)))
+}
+
+// applyMergeDirectives replaces the merge directives in the tree with the
+// commands in the keys they refer to.
+func applyMergeDirectives(tree meta.Object, suffix string) (meta.Object, error) {
+	if len(suffix) == 0 {
+		return tree, nil
+	}
+
+	switch tree.Kind() {
+	case meta.Array:
+		array := make([]meta.Object, len(tree.Array))
+		for i, val := range tree.Array {
+			v, err := applyMergeDirectives(val, suffix)
+			if err != nil {
+				return meta.Object{}, err
+			}
+			array[i] = v
+		}
+		tree.Array = array
+		return tree, nil
+	case meta.Map:
+	default:
+		return tree, nil
+	}
+
+	m := make(map[string]meta.Object, len(tree.Map))
+	withCmd := make(map[string]string)
+	var directives []string
+	for key, val := range tree.Map {
+		if len(key) > len(suffix) && strings.HasSuffix(key, suffix) {
+			directives = append(directives, key)
+			continue
+		}
+
+		// Invalid commands are reported when the tree is merged.
+		if name, cmds, err := meta.SplitKey(key); err == nil && len(cmds) > 0 {
+			withCmd[name] = key
+		}
+
+		v, err := applyMergeDirectives(val, suffix)
+		if err != nil {
+			return meta.Object{}, err
+		}
+		m[key] = v
+	}
+
+	sort.Strings(directives)
+	for _, key := range directives {
+		directive := tree.Map[key]
+		cmd, ok := directive.Value.(string)
+		if !ok || directive.Kind() != meta.Value {
+			return meta.Object{}, fmt.Errorf("%w: the merge directive '%s' at %s must be a string",
+				meta.ErrInvalidCommand, key, directive.OriginString())
+		}
+
+		if _, cmds, err := meta.SplitKey("directive((" + cmd + "))"); err != nil || len(cmds) == 0 {
+			return meta.Object{}, fmt.Errorf("%w: the merge directive '%s' at %s has the unknown command '%s'",
+				meta.ErrInvalidCommand, key, directive.OriginString(), cmd)
+		}
+
+		name := strings.TrimSuffix(key, suffix)
+		existing, found := withCmd[name]
+		if _, cmds, err := meta.SplitKey(name); err == nil && len(cmds) > 0 {
+			existing, found = name, true
+		}
+		if found {
+			return meta.Object{}, fmt.Errorf("%w: the key '%s' has both the merge directive '%s' at %s and a command",
+				meta.ErrInvalidCommand, existing, key, directive.OriginString())
+		}
+
+		val, found := m[name]
+		if !found {
+			continue
+		}
+
+		delete(m, name)
+		m[name+"(("+cmd+"))"] = val
+	}
+
+	tree.Map = m
+	return tree, nil
+}
@@ -0,0 +1,169 @@
+// SPDX-FileCopyrightText: 2023 Weston Schmidt <weston_schmidt@alumni.purdue.edu>
+// SPDX-License-Identifier: Apache-2.0
+
+package goschtalt
+
+import (
+	"testing"
+
+	"github.com/goschtalt/goschtalt/pkg/meta"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMergeDirectives(t *testing.T) {
+	tests := []struct {
+		description string
+		records     []string
+		opts        []Option
+		expect      map[string]any
+		expectedErr error
+	}{
+		{
+			description: "Directives are disabled by default.",
+			records: []string{
+				`{"plugins":["a"]}`,
+				`{"plugins":["b"], "plugins.$merge":"replace"}`,
+			},
+			expect: map[string]any{
+				"plugins":        []any{"a", "b"},
+				"plugins.$merge": "replace",
+			},
+		}, {
+			description: "Three layered records.",
+			records: []string{
+				`{"plugins":["a", "b"]}`,
+				`{"plugins":["c"], "plugins.$merge":"replace"}`,
+				`{"plugins":["d"], "plugins.$merge":"prepend"}`,
+			},
+			opts: []Option{MergeDirectives(".$merge")},
+			expect: map[string]any{
+				"plugins": []any{"d", "c"},
+			},
+		}, {
+			description: "Nested maps and arrays.",
+			records: []string{
+				`{"a":{"b":{"c":"1", "d":"2"}, "list":[{"x":["1"]}]}}`,
+				`{"a":{"b":{"c":"3"}, "b.$merge":"replace", "list":[{"x":["2"], "x.$merge":"replace"}]}}`,
+			},
+			opts: []Option{MergeDirectives(".$merge")},
+			expect: map[string]any{
+				"a": map[string]any{
+					"b":    map[string]any{"c": "3"},
+					"list": []any{map[string]any{"x": []any{"1"}}, map[string]any{"x": []any{"2"}}},
+				},
+			},
+		}, {
+			description: "A directive without a key is ignored.",
+			records: []string{
+				`{"plugins":["a"]}`,
+				`{"other$merge":"replace", "$merge":"is a normal key"}`,
+			},
+			opts: []Option{MergeDirectives("$merge")},
+			expect: map[string]any{
+				"plugins": []any{"a"},
+				"$merge":  "is a normal key",
+			},
+		}, {
+			description: "A directive that isn't a string.",
+			records: []string{
+				`{"plugins":["a"], "plugins.$merge":["replace"]}`,
+			},
+			opts:        []Option{MergeDirectives(".$merge")},
+			expectedErr: meta.ErrInvalidCommand,
+		}, {
+			description: "An invalid command.",
+			records: []string{
+				`{"plugins":["a"]}`,
+				`{"plugins":["b"], "plugins.$merge":"splice"}`,
+			},
+			opts:        []Option{MergeDirectives(".$merge")},
+			expectedErr: meta.ErrInvalidCommand,
+		}, {
+			description: "A directive with a command and secret.",
+			records: []string{
+				`{"plugins":["a"]}`,
+				`{"plugins":["b"], "plugins.$merge":"append, secret"}`,
+			},
+			opts: []Option{MergeDirectives(".$merge")},
+			expect: map[string]any{
+				"plugins": []any{"a", "b"},
+			},
+		}, {
+			description: "An unknown command.",
+			records: []string{
+				`{"plugins":["a"], "plugins.$merge":"merge"}`,
+			},
+			opts:        []Option{MergeDirectives(".$merge")},
+			expectedErr: meta.ErrInvalidCommand,
+		}, {
+			description: "An empty command.",
+			records: []string{
+				`{"plugins":["a"], "plugins.$merge":""}`,
+			},
+			opts:        []Option{MergeDirectives(".$merge")},
+			expectedErr: meta.ErrInvalidCommand,
+		}, {
+			description: "A directive can't add a second command.",
+			records: []string{
+				`{"plugins":["a"]}`,
+				`{"plugins":["b"], "plugins.$merge":"append))((secret"}`,
+			},
+			opts:        []Option{MergeDirectives(".$merge")},
+			expectedErr: meta.ErrInvalidCommand,
+		}, {
+			description: "A directive and a command for the same key.",
+			records: []string{
+				`{"plugins":["a"]}`,
+				`{"plugins((replace))":["b"], "plugins.$merge":"append"}`,
+			},
+			opts:        []Option{MergeDirectives(".$merge")},
+			expectedErr: meta.ErrInvalidCommand,
+		}, {
+			description: "A directive for a key with a command.",
+			records: []string{
+				`{"plugins":["a"]}`,
+				`{"plugins((replace))":["b"], "plugins((replace)).$merge":"append"}`,
+			},
+			opts:        []Option{MergeDirectives(".$merge")},
+			expectedErr: meta.ErrInvalidCommand,
+		}, {
+			description: "Disabled again.",
+			records: []string{
+				`{"plugins":["a"]}`,
+				`{"plugins":["b"], "plugins$merge":"replace"}`,
+			},
+			opts: []Option{MergeDirectives("$merge"), MergeDirectives("")},
+			expect: map[string]any{
+				"plugins":       []any{"a", "b"},
+				"plugins$merge": "replace",
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			opts := []Option{
+				WithDecoder(&testDecoder{extensions: []string{"json"}}),
+			}
+			for i, record := range tc.records {
+				opts = append(opts, AddBuffer(string(rune('1'+i))+".json", []byte(record)))
+			}
+			opts = append(opts, tc.opts...)
+
+			cfg, err := New(opts...)
+			if tc.expectedErr != nil {
+				assert.ErrorIs(err, tc.expectedErr)
+				return
+			}
+			require.NoError(err)
+
+			got, err := Unmarshal[map[string]any](cfg, Root)
+			require.NoError(err)
+			assert.Equal(tc.expect, got)
+		})
+	}
+}
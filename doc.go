@@ -51,6 +51,12 @@
 //   - https://github.com/goschtalt/yaml-encoder
 //   - https://github.com/goschtalt/yaml-decoder
 //
+// The json ([github.com/goschtalt/goschtalt/pkg/codec/json]) and toml
+// ([github.com/goschtalt/goschtalt/pkg/codec/toml]) decoders and encoders only
+// depend on the standard library and are registered by default.  They can be
+// replaced by registering a different codec for the extension or removed using
+// [DisableDefaultPackageOptions].
+//
 // # How do I decorate my configuration files to take full advantage of goschtalt?
 //
 // For most of the decoders you can specify instructions for goschtalt's handling
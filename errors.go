@@ -6,14 +6,19 @@ package goschtalt
 import "errors"
 
 var (
-	ErrAdaptFailure  = errors.New("at least one matching adapt function failed")
-	ErrDecoding      = errors.New("decoding error")
-	ErrEncoding      = errors.New("encoding error")
-	ErrNotApplicable = errors.New("not applicable")
-	ErrNotCompiled   = errors.New("the Compile() function must be called first")
-	ErrCodecNotFound = errors.New("encoder/decoder not found")
-	ErrInvalidInput  = errors.New("input is invalid")
-	ErrFileMissing   = errors.New("required file is missing")
-	ErrUnsupported   = errors.New("feature is unsupported")
-	ErrHint          = errors.New("a hint found an issue")
+	ErrAdaptFailure         = errors.New("at least one matching adapt function failed")
+	ErrDecoding             = errors.New("decoding error")
+	ErrEncoding             = errors.New("encoding error")
+	ErrNotApplicable        = errors.New("not applicable")
+	ErrNotCompiled          = errors.New("the Compile() function must be called first")
+	ErrCodecNotFound        = errors.New("encoder/decoder not found")
+	ErrInvalidInput         = errors.New("input is invalid")
+	ErrFileMissing          = errors.New("required file is missing")
+	ErrUnsupported          = errors.New("feature is unsupported")
+	ErrHint                 = errors.New("a hint found an issue")
+	ErrUnknownKey           = errors.New("unknown key")
+	ErrUnbalancedDelimiters = errors.New("unbalanced delimiters")
+	ErrLeakedSecret         = errors.New("a value looks like a leaked secret")
+	ErrDuplicateRecord      = errors.New("duplicate record name")
+	ErrSchemaViolation      = errors.New("the configuration does not match the schema")
 )
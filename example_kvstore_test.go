@@ -0,0 +1,67 @@
+// SPDX-FileCopyrightText: 2023 Weston Schmidt <weston_schmidt@alumni.purdue.edu>
+// SPDX-License-Identifier: Apache-2.0
+
+package goschtalt_test
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/goschtalt/goschtalt"
+)
+
+// memKV is an in memory KVReader.  An adapter for Consul, etcd, etc would
+// look the same but use the appropriate client to list and get the keys.
+type memKV map[string]string
+
+func (m memKV) List(prefix string) ([]string, error) {
+	var keys []string
+	for k := range m {
+		if strings.HasPrefix(k, prefix) {
+			keys = append(keys, k)
+		}
+	}
+	return keys, nil
+}
+
+func (m memKV) Get(key string) ([]byte, error) {
+	return []byte(m[key]), nil
+}
+
+func ExampleAddKVStore() {
+	kv := memKV{
+		"service/name":      "example",
+		"service/http/port": "8080",
+		"service/http/host": "localhost",
+	}
+
+	g, err := goschtalt.New(
+		goschtalt.AddKVStore("kv", kv, "service/"),
+		goschtalt.AutoCompile(),
+	)
+	if err != nil {
+		panic(err)
+	}
+
+	http, err := goschtalt.Unmarshal[map[string]string](g, "http")
+	if err != nil {
+		panic(err)
+	}
+
+	keys := make([]string, 0, len(http))
+	for k := range http {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	fmt.Println("http:")
+	for _, k := range keys {
+		fmt.Printf("\t%s: %s\n", k, http[k])
+	}
+
+	// Output:
+	// http:
+	// 	host: localhost
+	// 	port: 8080
+}
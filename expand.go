@@ -5,7 +5,12 @@ package goschtalt
 
 import (
 	"fmt"
+	"io/fs"
 	"os"
+	"path"
+	"sort"
+	"strings"
+	"sync"
 
 	"github.com/goschtalt/goschtalt/internal/print"
 	"github.com/goschtalt/goschtalt/pkg/meta"
@@ -38,6 +43,146 @@ func (envExpander) Expand(s string) (string, bool) {
 	return os.LookupEnv(s)
 }
 
+type fileExpander struct {
+	fs fs.FS
+
+	// dir is the directory of the file being expanded.  It is only set when
+	// expanding a single record and is used to resolve the paths that are
+	// relative to the file.
+	dir *string
+}
+
+func (f fileExpander) Expand(s string) (string, bool) {
+	name, found := strings.CutPrefix(s, "file:")
+	if !found || f.fs == nil {
+		return "", false
+	}
+
+	if isFileRelative(name) != (f.dir != nil) {
+		// Paths relative to the file can only be resolved when the file is
+		// known, and the other paths are only resolved by the expander.
+		return "", false
+	}
+
+	if f.dir != nil {
+		name = path.Join(*f.dir, name)
+	} else {
+		// The fs.FS paths are always relative, so allow absolute looking paths
+		// to be used with a filesystem rooted at "/".
+		name = strings.TrimPrefix(name, "/")
+	}
+
+	data, err := fs.ReadFile(f.fs, name)
+	if err != nil {
+		return "", false
+	}
+
+	return strings.TrimRight(string(data), "\r\n"), true
+}
+
+// FileExpander provides an [Expander] that replaces variables in the form of
+// `file:path` with the contents of the file at the path in the fs.FS provided.
+// Any trailing newlines are removed from the contents.  This is useful for
+// secrets that are mounted as files, for example:
+//
+//	password: ${file:/var/run/secrets/db-password}
+//
+// Since fs.FS paths are relative, any leading '/' is removed from the path so
+// using os.DirFS("/") works as expected.  Variables without the `file:` prefix
+// and files that can't be read are reported as not found, so FileExpander can
+// be chained with other expanders like ExpandEnv().
+//
+// Paths that start with "./" or "../" are relative to the configuration file
+// the value is in, and are read from the fs.FS the file came from instead of
+// the one provided.  They are resolved before the configuration files are
+// merged, so they are only expanded in values from files added with options
+// like [AddFile]() or [AddTree]().  A path that is outside the fs.FS is not
+// found.  For example, a file at conf/app.yml in the fs.FS can use:
+//
+//	key: ${file:./certs/key.pem}
+//	ca:  ${file:../shared/ca.pem}
+//
+// Example:
+//
+//	goschtalt.Expand(goschtalt.FileExpander(os.DirFS("/")))
+func FileExpander(fsys fs.FS) Expander {
+	return fileExpander{fs: fsys}
+}
+
+type secretExpander struct {
+	delegate Expander
+
+	mutex sync.Mutex
+	cache map[string]secret
+}
+
+type secret struct {
+	value string
+	found bool
+}
+
+func (s *secretExpander) Expand(in string) (string, bool) {
+	name, found := strings.CutPrefix(in, "secret:")
+	if !found {
+		return "", false
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if got, ok := s.cache[name]; ok {
+		return got.value, got.found
+	}
+
+	var got secret
+	if s.delegate == nil {
+		got.value, got.found = envExpander{}.Expand(name)
+	} else {
+		got.value, got.found = s.delegate.Expand(name)
+	}
+
+	if s.cache == nil {
+		s.cache = make(map[string]secret)
+	}
+	s.cache[name] = got
+
+	return got.value, got.found
+}
+
+// reset forgets the resolved secrets so they are resolved again.
+func (s *secretExpander) reset() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.cache = nil
+}
+
+// SecretExpander provides an [Expander] that replaces variables in the form of
+// `secret:name` with the value the delegate [Expander] provides for the name.
+// Any value changed by a SecretExpander is marked as a secret in the
+// configuration tree, so it is redacted when [RedactSecrets]() is used.  For
+// example:
+//
+//	password: ${secret:DB_PASSWORD}
+//
+// If the delegate is nil, the environment variables are used.  Variables
+// without the `secret:` prefix are reported as not found, so SecretExpander
+// can be chained with other expanders like ExpandEnv().
+//
+// Each secret is resolved by the delegate at most once per compilation, no
+// matter how many values refer to it, so a slow or rate limited secrets
+// backend is only asked once.  The resolved secrets (including the ones that
+// are not found) are forgotten at the start of each compilation, so
+// [Config.Reload] and [Watch] pick up rotated secrets.  The delegate is never
+// called concurrently by the same SecretExpander.
+//
+// Example:
+//
+//	goschtalt.Expand(goschtalt.SecretExpander(nil))
+func SecretExpander(delegate Expander) Expander {
+	return &secretExpander{delegate: delegate}
+}
+
 // ExpandEnv is a simple way to add automatic environment variable expansion
 // after the configuration has been compiled.
 //
@@ -67,6 +212,8 @@ func ExpandEnv(opts ...ExpandOption) Option {
 			print.String(exp.end, "end"),
 			print.String(exp.origin, "origin"),
 			print.Int(exp.maximum, "maximum"),
+			print.BoolSilentFalse(exp.failOnUnbalanced, "failOnUnbalanced"),
+			print.BoolSilentFalse(exp.deferred, "deferred"),
 		),
 	)
 
@@ -109,6 +256,8 @@ func Expand(expander Expander, opts ...ExpandOption) Option {
 			print.String(exp.end, "end"),
 			print.String(exp.origin, "origin"),
 			print.Int(exp.maximum, "maximum"),
+			print.BoolSilentFalse(exp.failOnUnbalanced, "failOnUnbalanced"),
+			print.BoolSilentFalse(exp.deferred, "deferred"),
 		),
 	)
 
@@ -138,15 +287,29 @@ type expand struct {
 	// The maximum expansions of a value before a recursion error is returned.
 	// Defaults to 10000 if set to less than 1.
 	maximum int
+
+	// failOnUnbalanced causes an error to be returned if a value contains a
+	// start delimiter without a matching end delimiter.
+	failOnUnbalanced bool
+
+	// deferred causes the variables to be expanded when the values are
+	// unmarshaled instead of when the configuration is compiled.
+	deferred bool
 }
 
 func (exp expand) apply(opts *options) error {
 	if exp.maximum < 1 {
 		exp.maximum = 10000
 	}
-	if exp.expander != nil {
-		opts.expansions = append(opts.expansions, exp)
+	if exp.expander == nil {
+		return nil
+	}
+
+	if exp.deferred {
+		opts.deferred = append(opts.deferred, exp)
+		return nil
 	}
+	opts.expansions = append(opts.expansions, exp)
 
 	return nil
 }
@@ -159,6 +322,45 @@ func (exp expand) String() string {
 	return exp.text
 }
 
+// isFileRelative returns if the path is relative to the file that contains it.
+func isFileRelative(name string) bool {
+	return strings.HasPrefix(name, "./") || strings.HasPrefix(name, "../")
+}
+
+// expandRelative expands the `file:` variables in the record that are relative
+// to the file the record came from.  Only the expansions using a FileExpander
+// are used.
+func expandRelative(rec record, max int, expansions []expand) (meta.Object, error) {
+	if rec.fs == nil {
+		return rec.tree, nil
+	}
+
+	var local []expand
+	for _, exp := range expansions {
+		if _, ok := exp.expander.(fileExpander); ok {
+			exp.expander = fileExpander{fs: rec.fs, dir: &rec.dir}
+			local = append(local, exp)
+		}
+	}
+
+	if len(local) == 0 {
+		return rec.tree, nil
+	}
+
+	tree, _, err := expandTree(rec.tree, max, local)
+	return tree, err
+}
+
+// resetSecrets forgets the secrets resolved by the SecretExpanders in the
+// expansions.
+func resetSecrets(expansions []expand) {
+	for _, exp := range expansions {
+		if s, ok := exp.expander.(*secretExpander); ok {
+			s.reset()
+		}
+	}
+}
+
 // expandTree is a helper function that expands variables in the configuration
 // tree.  The maximum number of expansions is limited to the max value.
 func expandTree(in meta.Object, max int, expansions []expand) (meta.Object, bool, error) {
@@ -166,8 +368,13 @@ func expandTree(in meta.Object, max int, expansions []expand) (meta.Object, bool
 	for i := 0; changed && i < max; i++ {
 		changed = false
 		for _, exp := range expansions {
+			toExpanded := in.ToExpanded
+			if _, ok := exp.expander.(*secretExpander); ok {
+				toExpanded = in.ToExpandedSecret
+			}
+
 			var err error
-			in, err = in.ToExpanded(
+			in, err = toExpanded(
 				exp.maximum,
 				exp.origin,
 				exp.start,
@@ -190,6 +397,159 @@ func expandTree(in meta.Object, max int, expansions []expand) (meta.Object, bool
 	return in, changed, nil
 }
 
+// deferredExpander returns a function that expands the variables of the
+// deferred expansions in a string.
+func (c *Config) deferredExpander() func(string) (string, error) {
+	max, expansions := c.opts.exapansionMax, c.opts.deferred
+
+	return func(s string) (string, error) {
+		obj, _, err := expandTree(meta.Object{Value: s}, max, expansions)
+		if err != nil {
+			return "", err
+		}
+
+		rv, _ := obj.Value.(string)
+		return rv, nil
+	}
+}
+
+// checkUnbalanced examines the configuration tree for values that contain a
+// start delimiter without a matching end delimiter for any of the expansions
+// that request it.
+func checkUnbalanced(in meta.Object, delimiter string, expansions []expand) error {
+	for _, exp := range expansions {
+		if !exp.failOnUnbalanced {
+			continue
+		}
+
+		if err := findUnbalanced(in, nil, delimiter, exp.start, exp.end); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// findUnbalanced walks the tree and returns an error describing the first
+// unbalanced value found.
+func findUnbalanced(obj meta.Object, path []string, delimiter, start, end string) error {
+	switch obj.Kind() {
+	case meta.Array:
+		for i, val := range obj.Array {
+			err := findUnbalanced(val, append(path[:len(path):len(path)], fmt.Sprintf("%d", i)),
+				delimiter, start, end)
+			if err != nil {
+				return err
+			}
+		}
+	case meta.Map:
+		keys := make([]string, 0, len(obj.Map))
+		for key := range obj.Map {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			err := findUnbalanced(obj.Map[key], append(path[:len(path):len(path)], key),
+				delimiter, start, end)
+			if err != nil {
+				return err
+			}
+		}
+	case meta.Value:
+		s, ok := obj.Value.(string)
+		if ok && isUnbalanced(s, start, end) {
+			return fmt.Errorf("%w: '%s' has an unterminated '%s' at %s",
+				ErrUnbalancedDelimiters, strings.Join(path, delimiter), start, obj.OriginString())
+		}
+	}
+
+	return nil
+}
+
+// isUnbalanced returns if the string contains a start delimiter that is not
+// followed by an end delimiter.
+func isUnbalanced(s, start, end string) bool {
+	for {
+		i := strings.Index(s, start)
+		if i < 0 {
+			return false
+		}
+		s = s[i+len(start):]
+
+		j := strings.Index(s, end)
+		if j < 0 {
+			return true
+		}
+		s = s[j+len(end):]
+	}
+}
+
+// findUnexpanded returns a warning for each variable in the tree that none of
+// the expansions were able to expand.  Expansions that share the same start
+// and end delimiters are only examined once.  The variables with the same
+// delimiters as a deferred expansion are not reported since they are expanded
+// later.
+func findUnexpanded(in meta.Object, delimiter string, expansions, deferred []expand) ([]Warning, error) {
+	var warnings []Warning
+
+	seen := make(map[[2]string]struct{}, len(expansions)+len(deferred))
+	for _, exp := range deferred {
+		seen[[2]string{exp.start, exp.end}] = struct{}{}
+	}
+	for _, exp := range expansions {
+		delims := [2]string{exp.start, exp.end}
+		if _, found := seen[delims]; found {
+			continue
+		}
+		seen[delims] = struct{}{}
+
+		err := walk(in, nil, delimiter, func(key string, value any, origins []meta.Origin) error {
+			s, ok := value.(string)
+			if !ok {
+				return nil
+			}
+
+			for _, name := range variables(s, exp.start, exp.end) {
+				w := Warning{
+					Key:      key,
+					Message:  "the variable '" + exp.start + name + exp.end + "' was not expanded",
+					Severity: SeverityWarning,
+				}
+				if len(origins) > 0 {
+					w.Origin = origins[0]
+				}
+				warnings = append(warnings, w)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return warnings, nil
+}
+
+// variables returns the names of the variables in the string.
+func variables(s, start, end string) []string {
+	var names []string
+	for {
+		i := strings.Index(s, start)
+		if i < 0 {
+			return names
+		}
+		s = s[i+len(start):]
+
+		j := strings.Index(s, end)
+		if j < 0 {
+			return names
+		}
+		names = append(names, s[:j])
+		s = s[j+len(end):]
+	}
+}
+
 // ---- ExpandOption follow --------------------------------------------------
 
 // ExpandOption provides the means to configure options around variable
@@ -241,3 +601,58 @@ func (w withMaximumOption) expandApply(exp *expand) error {
 	exp.maximum = int(w)
 	return nil
 }
+
+// FailOnUnbalanced specifies that an error should be returned if a value in
+// the compiled configuration contains a start delimiter without a matching end
+// delimiter.  For example: "${FOO" when using the default delimiters.
+//
+// Variables that are balanced but not found by the expander are not considered
+// an error by this option.
+//
+// The fail bool value is optional & assumed to be `true` if omitted.  The
+// first specified value is used if provided.  A value of `false` disables the
+// option.
+//
+// The default behavior is to leave unbalanced values as they are.
+func FailOnUnbalanced(fail ...bool) ExpandOption {
+	fail = append(fail, true)
+	return failOnUnbalancedOption(fail[0])
+}
+
+type failOnUnbalancedOption bool
+
+func (f failOnUnbalancedOption) expandApply(exp *expand) error {
+	exp.failOnUnbalanced = bool(f)
+	return nil
+}
+
+// Deferred specifies that the variables are expanded when the values are
+// unmarshaled instead of when the configuration is compiled.  When a value is
+// unmarshaled into a [Lazy] field, the variables are expanded the first time
+// [Lazy.Get]() is called, so an expensive expander (like one that fetches a
+// secret from a vault) is only used if the value is needed.
+//
+// Deferred expansions are evaluated after all the other expansions, in the
+// order specified.  Until the values are unmarshaled the compiled tree contains
+// the variables instead of the expanded values, so functions like
+// [Config.Marshal]() and [Config.Hash]() don't see the expanded values.  Paths
+// relative to the configuration file are not resolved by a deferred
+// [FileExpander]().
+//
+// The deferred bool value is optional & assumed to be `true` if omitted.  The
+// first specified value is used if provided.  A value of `false` disables the
+// option.
+//
+// The default behavior is to expand the variables when the configuration is
+// compiled.
+func Deferred(deferred ...bool) ExpandOption {
+	deferred = append(deferred, true)
+	return deferredOption(deferred[0])
+}
+
+type deferredOption bool
+
+func (d deferredOption) expandApply(exp *expand) error {
+	exp.deferred = bool(d)
+	return nil
+}
@@ -5,9 +5,12 @@ package goschtalt
 
 import (
 	"errors"
+	iofs "io/fs"
 	"testing"
+	"testing/fstest"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestExpand(t *testing.T) {
@@ -21,6 +24,7 @@ func TestExpand(t *testing.T) {
 		str         string
 		in          Option
 		want        []expand
+		deferred    []expand
 		expectErr   error
 	}{
 		{
@@ -48,6 +52,38 @@ func TestExpand(t *testing.T) {
 				expander: &expander,
 				maximum:  10,
 			}},
+		}, {
+			description: "Fail on unbalanced",
+			in:          Expand(&expander, FailOnUnbalanced()),
+			str:         "Expand( *goschtalt.mockExpander, ... ) --> start: '${', end: '}', origin: '', maximum: 0, failOnUnbalanced: true",
+			want: []expand{{
+				start:            "${",
+				end:              "}",
+				expander:         &expander,
+				maximum:          10000,
+				failOnUnbalanced: true,
+			}},
+		}, {
+			description: "Deferred",
+			in:          Expand(&expander, Deferred()),
+			str:         "Expand( *goschtalt.mockExpander, ... ) --> start: '${', end: '}', origin: '', maximum: 0, deferred: true",
+			deferred: []expand{{
+				start:    "${",
+				end:      "}",
+				expander: &expander,
+				maximum:  10000,
+				deferred: true,
+			}},
+		}, {
+			description: "Not deferred",
+			in:          Expand(&expander, Deferred(false)),
+			str:         "Expand( *goschtalt.mockExpander, ... ) --> start: '${', end: '}', origin: '', maximum: 0",
+			want: []expand{{
+				start:    "${",
+				end:      "}",
+				expander: &expander,
+				maximum:  10000,
+			}},
 		}, {
 			description: "Env, fully defined",
 			in:          ExpandEnv(WithOrigin("origin"), WithDelimiters("${{", "}}"), WithMaximum(-1)),
@@ -88,7 +124,11 @@ func TestExpand(t *testing.T) {
 				for i := range c.opts.expansions {
 					c.opts.expansions[i].text = ""
 				}
+				for i := range c.opts.deferred {
+					c.opts.deferred[i].text = ""
+				}
 				assert.Equal(tc.want, c.opts.expansions)
+				assert.Equal(tc.deferred, c.opts.deferred)
 			}
 
 			assert.ErrorIs(err, tc.expectErr)
@@ -158,3 +198,311 @@ func Test_envExpander_Expand(t *testing.T) {
 		})
 	}
 }
+
+func TestFileExpander(t *testing.T) {
+	fs := fstest.MapFS{
+		"var/run/secrets/db-password": &fstest.MapFile{
+			Data: []byte("hunter2\n"),
+			Mode: 0755,
+		},
+		"windows.txt": &fstest.MapFile{
+			Data: []byte("line\r\n"),
+			Mode: 0755,
+		},
+		"multi.txt": &fstest.MapFile{
+			Data: []byte("one\ntwo"),
+			Mode: 0755,
+		},
+	}
+
+	tests := []struct {
+		description string
+		noFS        bool
+		in          string
+		want        string
+		found       bool
+	}{
+		{
+			description: "An absolute path.",
+			in:          "file:/var/run/secrets/db-password",
+			want:        "hunter2",
+			found:       true,
+		}, {
+			description: "A relative path.",
+			in:          "file:var/run/secrets/db-password",
+			want:        "hunter2",
+			found:       true,
+		}, {
+			description: "Trailing carriage returns are removed.",
+			in:          "file:windows.txt",
+			want:        "line",
+			found:       true,
+		}, {
+			description: "Inner newlines are kept.",
+			in:          "file:multi.txt",
+			want:        "one\ntwo",
+			found:       true,
+		}, {
+			description: "A missing file.",
+			in:          "file:missing.txt",
+		}, {
+			description: "No file prefix.",
+			in:          "multi.txt",
+		}, {
+			description: "A nil filesystem.",
+			noFS:        true,
+			in:          "file:multi.txt",
+		}, {
+			description: "A path relative to the file isn't expanded without a file.",
+			in:          "file:./multi.txt",
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			assert := assert.New(t)
+
+			var fsys iofs.FS = fs
+			if tc.noFS {
+				fsys = nil
+			}
+
+			got, found := FileExpander(fsys).Expand(tc.in)
+			assert.Equal(tc.want, got)
+			assert.Equal(tc.found, found)
+		})
+	}
+}
+
+func TestFileExpanderChained(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	t.Setenv("DB_USER", "admin")
+
+	fs := fstest.MapFS{
+		"secrets/db-password": &fstest.MapFile{
+			Data: []byte("hunter2\n"),
+			Mode: 0755,
+		},
+	}
+
+	type db struct {
+		User     string `goschtalt:"user"`
+		Password string `goschtalt:"password"`
+	}
+
+	g, err := New(
+		AddValue("record", Root,
+			map[string]any{
+				"user":     "${DB_USER}",
+				"password": "${file:/secrets/db-password}",
+			}),
+		Expand(FileExpander(fs)),
+		ExpandEnv(),
+	)
+	require.NoError(err)
+
+	got, err := Unmarshal[db](g, Root)
+	require.NoError(err)
+	assert.Equal(db{User: "admin", Password: "hunter2"}, got)
+}
+
+func TestFileExpanderRelative(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	fs := fstest.MapFS{
+		"conf/1.json": &fstest.MapFile{
+			Data: []byte(`{
+				"key": "${file:./certs/key.pem}",
+				"ca": "${file:../shared/ca.pem}",
+				"abs": "${file:/shared/ca.pem}",
+				"outside": "${file:../../ca.pem}"
+			}`),
+			Mode: 0644,
+		},
+		"conf/sub/2.json": &fstest.MapFile{
+			Data: []byte(`{"token": "${file:./token}", "parent": "${file:../certs/key.pem}"}`),
+			Mode: 0644,
+		},
+		"conf/certs/key.pem": &fstest.MapFile{Data: []byte("key\n"), Mode: 0644},
+		"conf/sub/token":     &fstest.MapFile{Data: []byte("token\n"), Mode: 0644},
+		"shared/ca.pem":      &fstest.MapFile{Data: []byte("ca\n"), Mode: 0644},
+	}
+
+	// The expander's filesystem doesn't have the relative files, so they must
+	// come from the filesystem of the file.
+	other := fstest.MapFS{
+		"shared/ca.pem": &fstest.MapFile{Data: []byte("other ca\n"), Mode: 0644},
+	}
+
+	g, err := New(
+		WithDecoder(&testDecoder{extensions: []string{"json"}}),
+		AddTree(fs, "conf"),
+		AddValue("record", Root, map[string]any{
+			"value": "${file:./certs/key.pem}",
+		}),
+		Expand(FileExpander(other)),
+	)
+	require.NoError(err)
+
+	got, err := Unmarshal[map[string]string](g, Root)
+	require.NoError(err)
+	assert.Equal(map[string]string{
+		"key":     "key",
+		"ca":      "ca",
+		"abs":     "other ca",
+		"outside": "${file:../../ca.pem}",
+		"token":   "token",
+		"parent":  "key",
+		"value":   "${file:./certs/key.pem}",
+	}, got)
+}
+
+func TestSecretExpander(t *testing.T) {
+	t.Setenv("ENV_SECRET", "from-env")
+
+	delegate := ExpanderFunc(func(s string) (string, bool) {
+		if s == "DB_PASSWORD" {
+			return "hunter2", true
+		}
+		return "", false
+	})
+
+	tests := []struct {
+		description string
+		delegate    Expander
+		in          string
+		want        string
+		found       bool
+	}{
+		{
+			description: "A secret from the delegate.",
+			delegate:    delegate,
+			in:          "secret:DB_PASSWORD",
+			want:        "hunter2",
+			found:       true,
+		}, {
+			description: "A secret the delegate doesn't have.",
+			delegate:    delegate,
+			in:          "secret:ENV_SECRET",
+		}, {
+			description: "No secret prefix.",
+			delegate:    delegate,
+			in:          "DB_PASSWORD",
+		}, {
+			description: "A nil delegate uses the environment.",
+			in:          "secret:ENV_SECRET",
+			want:        "from-env",
+			found:       true,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			assert := assert.New(t)
+
+			got, found := SecretExpander(tc.delegate).Expand(tc.in)
+			assert.Equal(tc.want, got)
+			assert.Equal(tc.found, found)
+		})
+	}
+}
+
+func TestSecretExpanderRedacted(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	t.Setenv("DB_USER", "admin")
+	t.Setenv("DB_PASSWORD", "hunter2")
+
+	g, err := New(
+		AddValue("record", Root,
+			map[string]any{
+				"user":     "${DB_USER}",
+				"password": "${secret:DB_PASSWORD}",
+				"dsn":      "${DB_USER}:${secret:DB_PASSWORD}@db",
+			}),
+		Expand(SecretExpander(nil)),
+		ExpandEnv(),
+	)
+	require.NoError(err)
+
+	got, err := Unmarshal[map[string]any](g, Root)
+	require.NoError(err)
+	assert.Equal(map[string]any{
+		"user":     "admin",
+		"password": "hunter2",
+		"dsn":      "admin:hunter2@db",
+	}, got)
+
+	out, err := g.Marshal(FormatAsTable(), RedactSecrets())
+	require.NoError(err)
+	assert.Equal("dsn      = REDACTED\n"+
+		"password = REDACTED\n"+
+		"user     = admin\n", string(out))
+
+	out, err = g.Marshal(FormatAsTable())
+	require.NoError(err)
+	assert.Contains(string(out), "hunter2")
+}
+
+func TestSecretExpanderCached(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	var calls int
+	password := "hunter2"
+	delegate := ExpanderFunc(func(s string) (string, bool) {
+		calls++
+		if s == "DB_PASSWORD" {
+			return password, true
+		}
+		return "", false
+	})
+
+	g, err := New(
+		AddValue("record", Root,
+			map[string]any{
+				"password": "${secret:DB_PASSWORD}",
+				"dsn":      "admin:${secret:DB_PASSWORD}@db",
+				"backup":   "${secret:DB_PASSWORD}",
+			}),
+		Expand(SecretExpander(delegate)),
+	)
+	require.NoError(err)
+	assert.Equal(1, calls)
+
+	got, err := Unmarshal[string](g, "dsn")
+	require.NoError(err)
+	assert.Equal("admin:hunter2@db", got)
+
+	// The secrets are resolved again by the next compile.
+	password = "rotated"
+	require.NoError(g.Reload())
+	assert.Equal(2, calls)
+
+	got, err = Unmarshal[string](g, "dsn")
+	require.NoError(err)
+	assert.Equal("admin:rotated@db", got)
+}
+
+func TestSecretExpanderNotFoundCached(t *testing.T) {
+	assert := assert.New(t)
+
+	var calls int
+	s := SecretExpander(ExpanderFunc(func(string) (string, bool) {
+		calls++
+		return "", false
+	}))
+
+	for i := 0; i < 3; i++ {
+		got, found := s.Expand("secret:MISSING")
+		assert.Empty(got)
+		assert.False(found)
+	}
+	assert.Equal(1, calls)
+
+	s.(*secretExpander).reset()
+	_, _ = s.Expand("secret:MISSING")
+	assert.Equal(2, calls)
+}
@@ -21,6 +21,16 @@ type Explanation struct {
 	// Options is the ordered list of Options in effect.
 	Options []string
 
+	// DefaultOptions is the ordered list of Options in effect that were
+	// provided by the package instead of the caller.  These are always the
+	// first options in Options.  Only the options that are always required are
+	// present if DisableDefaultPackageOptions() is specified.
+	DefaultOptions []string
+
+	// UserOptions is the ordered list of Options in effect that were provided
+	// by the caller.  These always follow the DefaultOptions in Options.
+	UserOptions []string
+
 	// FileExtensions is the list of file extensions supported in the
 	// configuration provided.
 	FileExtensions []string
@@ -38,6 +48,10 @@ type Explanation struct {
 	// Records is the ordered list of records processed.
 	Records []ExplanationRecord
 
+	// SkippedFileGroups is the ordered list of file groups that were skipped
+	// because their condition was not met.
+	SkippedFileGroups []string
+
 	// VariableExpansions is the ordered list of variable expansion instructions
 	// applied.
 	VariableExpansions []string
@@ -79,13 +93,20 @@ func (er ExplanationRecord) String() string {
 
 func (e *Explanation) reset() {
 	e.Options = []string{}
+	e.DefaultOptions = []string{}
+	e.UserOptions = []string{}
 	e.FileExtensions = []string{}
 	e.compileReset()
 	e.Keyremapping.Reset()
 }
 
-func (e *Explanation) optionInEffect(s string) {
+func (e *Explanation) optionInEffect(s string, isDefault bool) {
 	e.Options = append(e.Options, s)
+	if isDefault {
+		e.DefaultOptions = append(e.DefaultOptions, s)
+		return
+	}
+	e.UserOptions = append(e.UserOptions, s)
 }
 
 func (e *Explanation) extsSupported(s []string) {
@@ -95,6 +116,7 @@ func (e *Explanation) extsSupported(s []string) {
 func (e *Explanation) compileReset() {
 	e.CompileStartedAt = time.Time{}
 	e.Records = []ExplanationRecord{}
+	e.SkippedFileGroups = []string{}
 	e.VariableExpansions = []string{}
 	e.CompileErrors = []error{}
 }
@@ -102,6 +124,7 @@ func (e *Explanation) compileReset() {
 func (e *Explanation) compileStartedAt(t time.Time) {
 	e.CompileStartedAt = t
 	e.Records = []ExplanationRecord{}
+	e.SkippedFileGroups = []string{}
 	e.VariableExpansions = []string{}
 	e.CompileErrors = []error{}
 }
@@ -120,6 +143,10 @@ func (e *Explanation) compileRecord(name string, isDefault bool, now time.Time)
 		})
 }
 
+func (e *Explanation) compileSkippedFileGroup(details string) {
+	e.SkippedFileGroups = append(e.SkippedFileGroups, details)
+}
+
 func (e *Explanation) compileExpansions(details string) {
 	e.VariableExpansions = append(e.VariableExpansions, details)
 }
@@ -138,7 +165,11 @@ func (e Explanation) String() string {
 	fmt.Fprintln(&b, "## Options in effect")
 	fmt.Fprintln(&b, "")
 	for i, opt := range e.Options {
-		fmt.Fprintf(&b, "  %d. %s\n", i+1, opt)
+		user := "user"
+		if i < len(e.DefaultOptions) {
+			user = "default"
+		}
+		fmt.Fprintf(&b, "  %d. %s <%s>\n", i+1, opt, user)
 	}
 	fmt.Fprintln(&b, "")
 	fmt.Fprintln(&b, "## File extensions supported:")
@@ -182,6 +213,16 @@ func (e Explanation) String() string {
 		}
 	}
 	fmt.Fprintln(&b, "")
+	fmt.Fprintln(&b, "## File groups skipped.")
+	fmt.Fprintln(&b, "")
+	if len(e.SkippedFileGroups) == 0 {
+		fmt.Fprintln(&b, "  <none>")
+	} else {
+		for _, grp := range e.SkippedFileGroups {
+			fmt.Fprintf(&b, "  - %s\n", grp)
+		}
+	}
+	fmt.Fprintln(&b, "")
 	fmt.Fprintln(&b, "## Variable expansions processed in order.")
 	fmt.Fprintln(&b, "")
 	if len(e.VariableExpansions) == 0 {
@@ -105,3 +105,72 @@ func TestCompileRecord(t *testing.T) {
 		})
 	}
 }
+
+func TestExplainOptions(t *testing.T) {
+	tests := []struct {
+		description string
+		opts        []Option
+		defaults    []string
+		user        []string
+	}{
+		{
+			description: "The package defaults and user options",
+			opts: []Option{
+				AutoCompile(false),
+				SetKeyDelimiter("/"),
+			},
+			defaults: []string{
+				"SortRecordsNaturally()",
+				"SetKeyDelimiter( '.' )",
+				"SetHasher",
+				"SetMaxExpansions( 10000 )",
+				"SetWatchInterval( 1s )",
+				"DefaultUnmarshalOptions( KeymapReporter(*debug.Collect) )",
+				"DefaultValueOptions( KeymapReporter(*debug.Collect) )",
+				"WithDecoder( 'json' )",
+				"WithEncoder( 'json' )",
+				"WithDecoder( 'toml', 'tml' )",
+				"WithEncoder( 'toml', 'tml' )",
+			},
+			user: []string{
+				"AutoCompile( false )",
+				"SetKeyDelimiter( '/' )",
+			},
+		}, {
+			description: "The package defaults are disabled",
+			opts: []Option{
+				AutoCompile(false),
+				DisableDefaultPackageOptions(),
+			},
+			defaults: []string{
+				"SortRecordsNaturally()",
+				"SetKeyDelimiter( '.' )",
+				"SetHasher",
+				"SetMaxExpansions( 10000 )",
+				"SetWatchInterval( 1s )",
+			},
+			user: []string{
+				"AutoCompile( false )",
+				"DisableDefaultPackageOptions()",
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			assert := assert.New(t)
+
+			cfg, err := New(tc.opts...)
+			assert.NoError(err)
+
+			e := cfg.Explain()
+			assert.Equal(tc.defaults, e.DefaultOptions)
+			assert.Equal(tc.user, e.UserOptions)
+			assert.Equal(append(tc.defaults, tc.user...), e.Options)
+
+			s := e.String()
+			assert.Contains(s, "  1. SortRecordsNaturally() <default>\n")
+			assert.Contains(s, "AutoCompile( false ) <user>\n")
+		})
+	}
+}
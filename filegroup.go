@@ -39,27 +39,99 @@ type filegroup struct {
 
 	// as is the decoder to use for the files described by this filegroup.
 	as string
+
+	// text describes the filegroup for use in the explanation.
+	text string
+
+	// when is the condition that must be true for the filegroup to be
+	// included.  If nil the filegroup is always included.
+	when func() bool
+
+	// maxDepth is how many levels of subdirectories are examined when
+	// recursing.  If nil there is no limit.
+	maxDepth *int
+
+	// policy is how errors encountered while processing the filegroup are
+	// handled.
+	policy errorPolicy
+
+	// secret means all the values found in the files are marked as secrets.
+	secret bool
+
+	// extensions are the only file extensions examined, in lowercase and
+	// without the leading '.'.  If empty all files are examined.
+	extensions []string
+
+	// layer identifies the filegroups added by the same AddLayeredDirs()
+	// option.  Zero if the filegroup isn't part of a layer.
+	layer int
+
+	// rejectDuplicateKeys asks the decoders to reject repeated keys.
+	rejectDuplicateKeys bool
+}
+
+// errorPolicy describes how a filegroup handles errors.
+type errorPolicy int
+
+const (
+	// policyDefault means missing exact files and decoding errors are
+	// fatal, while missing directories are skipped.
+	policyDefault errorPolicy = iota
+
+	// policyStrict means any error, including missing files or directories,
+	// is fatal.
+	policyStrict
+
+	// policyBestEffort means errors are skipped and whatever can be
+	// processed is used.
+	policyBestEffort
+)
+
+// strict returns if missing paths are fatal for the filegroup.
+func (g filegroup) strict() bool {
+	return g.policy == policyStrict || (g.policy == policyDefault && g.exactFile)
 }
 
 // toRecords walks the filegroup and finds all the records that are present and
-// can be processed using the present configuration.
-func (g filegroup) toRecords(delimiter string, decoders *codecRegistry[decoder.Decoder]) ([]record, error) {
+// can be processed using the present configuration.  Any problems that are
+// skipped are returned as warnings.  The timings are recorded if provided.
+func (g filegroup) toRecords(delimiter string, decoders *codecRegistry[decoder.Decoder], timings *Timings) ([]record, []Warning, error) {
+	start := timings.now()
 	files, err := g.enumerate()
+	timings.record(phaseEnumerate, start, len(files))
 	if err != nil {
-		return nil, err
+		if g.policy == policyBestEffort {
+			return nil, []Warning{{
+				Message:  fmt.Sprintf("skipped '%s': %v", strings.Join(g.paths, "', '"), err),
+				Severity: SeverityWarning,
+			}}, nil
+		}
+		return nil, nil, err
 	}
 
+	var warnings []Warning
 	list := make([]record, 0, len(files))
 	for _, file := range files {
+		start := timings.now()
 		r, err := g.toRecord(file, delimiter, decoders)
+		timings.record(phaseDecode, start, len(r))
 		if err != nil {
-			return nil, err
+			if g.policy == policyBestEffort {
+				// Skip the file and keep going.
+				warnings = append(warnings, Warning{
+					Message:  fmt.Sprintf("skipped: %v", err),
+					Severity: SeverityWarning,
+					Origin:   meta.Origin{File: file},
+				})
+				continue
+			}
+			return nil, nil, err
 		}
 
 		list = append(list, r...)
 	}
 
-	return list, nil
+	return list, warnings, nil
 }
 
 // toRecord handles examining a single file and returning it as part of an array
@@ -102,8 +174,9 @@ func (g filegroup) toRecord(file, delimiter string, decoders *codecRegistry[deco
 	}
 
 	ctx := decoder.Context{
-		Filename:  basename,
-		Delimiter: delimiter,
+		Filename:            basename,
+		Delimiter:           delimiter,
+		RejectDuplicateKeys: g.rejectDuplicateKeys,
 	}
 
 	var tree meta.Object
@@ -115,9 +188,18 @@ func (g filegroup) toRecord(file, delimiter string, decoders *codecRegistry[deco
 		return nil, err
 	}
 
+	if g.secret {
+		tree, err = tree.MarkSecret()
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	return []record{{
 		name: basename,
 		tree: tree,
+		fs:   g.fs,
+		dir:  path.Dir(file),
 	}}, nil
 }
 
@@ -153,7 +235,28 @@ func (g filegroup) enumerate() ([]string, error) {
 	}
 	sort.Strings(files)
 
-	return files, nil
+	return g.filterExtensions(files), nil
+}
+
+// filterExtensions returns only the files that have one of the allowed
+// extensions, or all the files if no extensions were specified.
+func (g filegroup) filterExtensions(files []string) []string {
+	if len(g.extensions) == 0 {
+		return files
+	}
+
+	rv := make([]string, 0, len(files))
+	for _, file := range files {
+		ext := strings.ToLower(strings.TrimPrefix(path.Ext(file), "."))
+		for _, allowed := range g.extensions {
+			if ext == allowed {
+				rv = append(rv, file)
+				break
+			}
+		}
+	}
+
+	return rv
 }
 
 // enumeratePath examines a specific path and collects all the appropriate files.
@@ -211,19 +314,27 @@ func (g filegroup) isDir(path string) (dir bool, err error) {
 	return dir, err
 }
 
-// filegroupsToRecords converts a list of filegroups into a list of records.
-func filegroupsToRecords(delimiter string, filegroups []filegroup, decoders *codecRegistry[decoder.Decoder]) ([]record, error) {
+// filegroupsToRecords converts a list of filegroups into a list of records and
+// any warnings encountered.  The timings are recorded if provided.
+func filegroupsToRecords(delimiter string, filegroups []filegroup, decoders *codecRegistry[decoder.Decoder], timings *Timings) ([]record, []Warning, error) {
+	var warnings []Warning
 	rv := make([]record, 0, len(filegroups))
-	for _, grp := range filegroups {
-		tmp, err := grp.toRecords(delimiter, decoders)
+	for i, grp := range filegroups {
+		tmp, w, err := grp.toRecords(delimiter, decoders, timings)
 		if err != nil {
-			if grp.exactFile && errors.Is(err, fs.ErrNotExist) {
-				return nil, ErrFileMissing
+			if grp.strict() && errors.Is(err, fs.ErrNotExist) {
+				return nil, nil, fmt.Errorf("%w: %w", ErrFileMissing, err)
 			}
 			if !errors.Is(err, fs.ErrNotExist) {
-				return nil, err
+				return nil, nil, err
 			}
 		}
+		warnings = append(warnings, w...)
+		for j := range tmp {
+			tmp[j].fromGroup = true
+			tmp[j].firstGroup = (i == 0)
+			tmp[j].layer = grp.layer
+		}
 		rv = append(rv, tmp...)
 
 		// Stop processing because we were told to & we found files.
@@ -232,7 +343,7 @@ func filegroupsToRecords(delimiter string, filegroups []filegroup, decoders *cod
 		}
 	}
 
-	return rv, nil
+	return rv, warnings, nil
 }
 
 // filecollector is a helper structure for collecting files from a directory.
@@ -255,10 +366,17 @@ func (fc *filecollector) isReadable(file string) error {
 // recurse is the function that is called for each file in a directory when
 // recursion is enabled while walking the directory.
 func (fc *filecollector) recurse(file string, d fs.DirEntry, err error) error {
-	if err != nil || d.IsDir() {
+	if err != nil {
 		return normalizeFileError(err)
 	}
 
+	if d.IsDir() {
+		if fc.fg.maxDepth != nil && fc.depth(file) > *fc.fg.maxDepth {
+			return fs.SkipDir
+		}
+		return nil
+	}
+
 	err = fc.isReadable(file)
 	if err == nil {
 		fc.files = append(fc.files, file)
@@ -267,6 +385,21 @@ func (fc *filecollector) recurse(file string, d fs.DirEntry, err error) error {
 	return normalizeFileError(err)
 }
 
+// depth returns how many directories deep the directory is relative to the
+// path being collected.
+func (fc *filecollector) depth(dir string) int {
+	if dir == fc.path {
+		return 0
+	}
+
+	rel := dir
+	if fc.path != "." {
+		rel = strings.TrimPrefix(dir, fc.path+"/")
+	}
+
+	return strings.Count(rel, "/") + 1
+}
+
 // nonrecurse is the function that is called for each file in a directory when
 // recursion is disabled while walking the directory.
 func (fc *filecollector) nonrecurse(file string, d fs.DirEntry, err error) error {
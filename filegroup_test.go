@@ -55,6 +55,10 @@ func (readFailsFileInfo) IsDir() bool         { return false }
 func (readFailsFileInfo) Sys() any            { return nil }
 
 func TestWalk(t *testing.T) {
+	depth := func(d int) *int {
+		return &d
+	}
+
 	tests := []struct {
 		description string
 		grp         filegroup
@@ -94,6 +98,50 @@ func TestWalk(t *testing.T) {
 				`3.json`,
 				`4.json`,
 			},
+		}, {
+			description: "Process a deep tree.",
+			grp: filegroup{
+				paths:   []string{"deep"},
+				recurse: true,
+			},
+			expected: []string{
+				`d0.json`,
+				`d1.json`,
+				`d2.json`,
+				`d3.json`,
+			},
+		}, {
+			description: "Process a deep tree with MaxDepth(0).",
+			grp: filegroup{
+				paths:    []string{"deep"},
+				recurse:  true,
+				maxDepth: depth(0),
+			},
+			expected: []string{
+				`d0.json`,
+			},
+		}, {
+			description: "Process a deep tree with MaxDepth(1).",
+			grp: filegroup{
+				paths:    []string{"deep"},
+				recurse:  true,
+				maxDepth: depth(1),
+			},
+			expected: []string{
+				`d0.json`,
+				`d1.json`,
+			},
+		}, {
+			description: "Process a deep subtree with MaxDepth(1).",
+			grp: filegroup{
+				paths:    []string{"deep/a"},
+				recurse:  true,
+				maxDepth: depth(1),
+			},
+			expected: []string{
+				`d1.json`,
+				`d2.json`,
+			},
 		}, {
 			description: "Process some files.",
 			grp: filegroup{
@@ -167,6 +215,34 @@ func TestWalk(t *testing.T) {
 				exactFile: true,
 			},
 			expectedErr: iofs.ErrNotExist,
+		}, {
+			description: "Process a directory with mixed files.",
+			grp: filegroup{
+				paths: []string{"mixed"},
+			},
+			expected: []string{
+				`5.json`,
+				`6.yml`,
+			},
+		}, {
+			description: "Process a directory with only some extensions.",
+			grp: filegroup{
+				paths:      []string{"mixed"},
+				extensions: []string{"json"},
+			},
+			expected: []string{
+				`5.json`,
+			},
+		}, {
+			description: "Process a tree with only some extensions.",
+			grp: filegroup{
+				paths:      []string{"."},
+				recurse:    true,
+				extensions: []string{"yml"},
+			},
+			expected: []string{
+				`6.yml`,
+			},
 		},
 	}
 	for _, tc := range tests {
@@ -207,14 +283,38 @@ func TestWalk(t *testing.T) {
 					Data: []byte(`ignore this file`),
 					Mode: 0755,
 				},
+				"deep/d0.json": &fstest.MapFile{
+					Data: []byte(`{"depth":"0"}`),
+					Mode: 0755,
+				},
+				"deep/a/d1.json": &fstest.MapFile{
+					Data: []byte(`{"depth":"1"}`),
+					Mode: 0755,
+				},
+				"deep/a/b/d2.json": &fstest.MapFile{
+					Data: []byte(`{"depth":"2"}`),
+					Mode: 0755,
+				},
+				"deep/a/b/c/d3.json": &fstest.MapFile{
+					Data: []byte(`{"depth":"3"}`),
+					Mode: 0755,
+				},
+				"mixed/5.json": &fstest.MapFile{
+					Data: []byte(`{"format":"json"}`),
+					Mode: 0755,
+				},
+				"mixed/6.yml": &fstest.MapFile{
+					Data: []byte(`{"format":"yml"}`),
+					Mode: 0755,
+				},
 			}
 			tc.grp.fs = fs
 
 			dr := newRegistry[decoder.Decoder]()
 			require.NotNil(dr)
-			dr.register(&testDecoder{extensions: []string{"json"}})
+			dr.register(&testDecoder{extensions: []string{"json", "yml"}})
 
-			got, err := tc.grp.toRecords(".", dr)
+			got, _, err := tc.grp.toRecords(".", dr, nil)
 
 			if tc.expectedErr == nil {
 				assert.NoError(err)
@@ -315,6 +415,29 @@ func TestToRecord(t *testing.T) {
 	}
 }
 
+func TestFilegroupsToRecordsMissing(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	dr := newRegistry[decoder.Decoder]()
+	require.NotNil(dr)
+	dr.register(&testDecoder{extensions: []string{"json"}})
+
+	grps := []filegroup{
+		{
+			fs:        fstest.MapFS{},
+			paths:     []string{"conf/missing.json"},
+			exactFile: true,
+		},
+	}
+
+	got, _, err := filegroupsToRecords(".", grps, dr, nil)
+	assert.Nil(got)
+	assert.ErrorIs(err, ErrFileMissing)
+	assert.ErrorIs(err, iofs.ErrNotExist)
+	assert.ErrorContains(err, "conf/missing.json")
+}
+
 func Test_normalizeDirError(t *testing.T) {
 	errUnknown := errors.New("unknown")
 	tests := []struct {
@@ -0,0 +1,155 @@
+// SPDX-FileCopyrightText: 2023 Weston Schmidt <weston_schmidt@alumni.purdue.edu>
+// SPDX-License-Identifier: Apache-2.0
+
+package goschtalt
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/goschtalt/goschtalt/internal/print"
+)
+
+// FileOption provides the means to configure options for handling of a group
+// of configuration files.
+type FileOption interface {
+	fmt.Stringer
+
+	fileApply(*filegroup) error
+}
+
+// MaxDepth limits how deep into the subdirectories of a directory tree the
+// files are collected from.  The depth is relative to the path of the group.
+// A depth of 0 means only the files in the directory itself (equivalent to
+// not recursing), a depth of 1 includes the files in the immediate
+// subdirectories, and so on.  The depth must not be negative.
+//
+// # Default
+//
+// There is no limit to the depth.
+func MaxDepth(depth int) FileOption {
+	return maxDepthOption(depth)
+}
+
+type maxDepthOption int
+
+func (m maxDepthOption) fileApply(g *filegroup) error {
+	if m < 0 {
+		return fmt.Errorf("%w, MaxDepth must not be negative", ErrInvalidInput)
+	}
+
+	depth := int(m)
+	g.maxDepth = &depth
+	return nil
+}
+
+func (m maxDepthOption) String() string {
+	return print.P("MaxDepth", print.Int(int(m)), print.SubOpt())
+}
+
+// When provides a condition that is evaluated each time the configuration is
+// compiled.  If the condition returns false the file group is skipped.  Skipped
+// file groups are listed in the [Explanation].
+//
+// A nil condition is treated as always true.
+//
+// # Default
+//
+// The file group is always included.
+func When(cond func() bool) FileOption {
+	return whenOption(cond)
+}
+
+type whenOption func() bool
+
+func (w whenOption) fileApply(g *filegroup) error {
+	g.when = w
+	return nil
+}
+
+func (w whenOption) String() string {
+	if w == nil {
+		return print.P("When", print.Literal("nil"), print.SubOpt())
+	}
+	return print.P("When", print.Literal("func"), print.SubOpt())
+}
+
+// OnlyExtensions limits the files examined by the file group to the files with
+// one of the specified extensions, regardless of the decoders that are
+// registered.  This is useful when a directory contains files that could be
+// decoded but are not meant to be part of the configuration.  The extensions
+// are case insensitive and may optionally include the leading '.'.
+//
+// If OnlyExtensions() is specified more than once, the last one is used.
+//
+// # Default
+//
+// All files with an extension supported by a decoder are examined.
+func OnlyExtensions(exts ...string) FileOption {
+	return onlyExtensionsOption(exts)
+}
+
+type onlyExtensionsOption []string
+
+func (o onlyExtensionsOption) fileApply(g *filegroup) error {
+	if len(o) == 0 {
+		return fmt.Errorf("%w, OnlyExtensions requires at least one extension", ErrInvalidInput)
+	}
+
+	g.extensions = make([]string, 0, len(o))
+	for _, ext := range o {
+		ext = strings.ToLower(strings.TrimPrefix(ext, "."))
+		if ext == "" {
+			return fmt.Errorf("%w, OnlyExtensions extensions must not be empty", ErrInvalidInput)
+		}
+		g.extensions = append(g.extensions, ext)
+	}
+	return nil
+}
+
+func (o onlyExtensionsOption) String() string {
+	return print.P("OnlyExtensions", print.Strings(o), print.SubOpt())
+}
+
+// Strict causes any error encountered while processing the file group to fail
+// the compilation, including files or directories that are not present.  This
+// is useful for a mandatory base directory of configuration files.
+//
+// The last of Strict() and BestEffort() specified is used.
+//
+// # Default
+//
+// Files that are not present and decoding errors are fatal for [AddFile]() and
+// [AddFileAs]().  For the other file groups, directories that are not present
+// are skipped and decoding errors are fatal.
+func Strict() FileOption {
+	return policyOption(policyStrict)
+}
+
+// BestEffort causes errors encountered while processing the file group to be
+// skipped, using whatever files can be processed.  Files that are not present
+// or can't be decoded are ignored.  This is useful for an optional drop-in
+// directory of configuration files.
+//
+// The last of Strict() and BestEffort() specified is used.
+//
+// # Default
+//
+// See [Strict]().
+func BestEffort() FileOption {
+	return policyOption(policyBestEffort)
+}
+
+type policyOption errorPolicy
+
+func (p policyOption) fileApply(g *filegroup) error {
+	g.policy = errorPolicy(p)
+	return nil
+}
+
+func (p policyOption) String() string {
+	if errorPolicy(p) == policyBestEffort {
+		return print.P("BestEffort", print.SubOpt())
+	}
+	return print.P("Strict", print.SubOpt())
+}
@@ -4,12 +4,20 @@
 package goschtalt
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"path"
 	"sort"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
 
+	"github.com/goschtalt/goschtalt/internal/print"
+	"github.com/goschtalt/goschtalt/pkg/codec/json"
+	"github.com/goschtalt/goschtalt/pkg/codec/toml"
+	"github.com/goschtalt/goschtalt/pkg/debug"
 	"github.com/goschtalt/goschtalt/pkg/decoder"
 	"github.com/goschtalt/goschtalt/pkg/encoder"
 	"github.com/goschtalt/goschtalt/pkg/meta"
@@ -36,12 +44,22 @@ const defaultTag = "goschtalt"
 
 // Config is a configurable, prioritized, merging configuration registry.
 type Config struct {
-	mutex      sync.Mutex
-	records    []string
-	tree       meta.Object
-	compiledAt time.Time
-	hash       []byte
-	explain    Explanation
+	// compileMutex serializes the compilation of the configuration so that
+	// Reload() is able to compile without holding the mutex.  When both are
+	// needed, compileMutex must be locked first.
+	compileMutex   sync.Mutex
+	mutex          sync.Mutex
+	records        []string
+	tree           meta.Object
+	compiledAt     time.Time
+	hash           []byte
+	explain        Explanation
+	warnings       []Warning
+	mergeTrace     []MergeEvent
+	weakKeys       []string
+	compileTimings Timings
+	cache          *valueCache
+	onChange       []func(old, new meta.Object)
 
 	rawOpts []Option
 	opts    options
@@ -50,7 +68,8 @@ type Config struct {
 // New creates a new goschtalt configuration instance with any number of options.
 func New(opts ...Option) (*Config, error) {
 	c := Config{
-		tree: meta.Object{},
+		tree:  meta.Object{},
+		cache: newValueCache(),
 		opts: options{
 			decoders: newRegistry[decoder.Decoder](),
 			encoders: newRegistry[encoder.Encoder](),
@@ -64,6 +83,192 @@ func New(opts ...Option) (*Config, error) {
 	return &c, nil
 }
 
+// FromBuffer creates a new goschtalt configuration instance from a single
+// buffer of bytes and compiles it.  The name is used as the record name of the
+// buffer, so the extension must match a decoder provided via the options.
+// Any number of additional options may be provided.  [AutoCompile] is always
+// enabled.
+//
+// This is a thin wrapper around [New], [AddBuffer] and [AutoCompile] that is
+// handy for tests and simple programs.
+func FromBuffer(name string, data []byte, opts ...Option) (*Config, error) {
+	full := make([]Option, 0, len(opts)+2)
+	full = append(full, opts...)
+	full = append(full, AddBuffer(name, data), AutoCompile())
+
+	return New(full...)
+}
+
+// FromMap creates a new goschtalt configuration instance from a map placed
+// at the root of the configuration tree and compiles it.  Any number of
+// additional options may be provided.  [AutoCompile] is always enabled.
+//
+// This is a thin wrapper around [New], [AddValue] and [AutoCompile] that is
+// handy for tests and simple programs.
+func FromMap(m map[string]any, opts ...Option) (*Config, error) {
+	full := make([]Option, 0, len(opts)+2)
+	full = append(full, opts...)
+	full = append(full, AddValue("FromMap", Root, m), AutoCompile())
+
+	return New(full...)
+}
+
+// Merge combines the compiled configuration trees of the provided Config
+// objects into a new Config.  The trees are merged in the order provided, so
+// the last value wins and the origins of the values are preserved.  This is
+// different from layering records at compile time since it combines already
+// compiled instances.
+//
+// The options of the first Config (codecs, key delimiter, leaf merge function,
+// etc) are used by the new Config.  All of the Config objects must use the
+// same key delimiter or an error is returned.
+//
+// The returned Config is a snapshot and is not changed when the provided
+// Config objects are compiled again.  Calling [Config.With]() or
+// [Config.Compile]() on the returned Config rebuilds it from the options of
+// the first Config, discarding the merged result.
+func Merge(configs ...*Config) (*Config, error) {
+	if len(configs) == 0 {
+		return nil, fmt.Errorf("%w: at least one Config must be provided", ErrInvalidInput)
+	}
+
+	for _, cfg := range configs {
+		if cfg == nil {
+			return nil, fmt.Errorf("%w: a nil Config was provided", ErrInvalidInput)
+		}
+	}
+
+	first := configs[0]
+	first.mutex.Lock()
+	rv := Config{
+		rawOpts: append([]Option{}, first.rawOpts...),
+		opts:    first.opts,
+	}
+	first.mutex.Unlock()
+
+	merged := meta.Object{}
+	var records []string
+	var warnings []Warning
+	for _, cfg := range configs {
+		cfg.mutex.Lock()
+		compiled := !cfg.compiledAt.Equal(time.Time{})
+		delimiter := cfg.opts.keyDelimiter
+		tree := cfg.tree.Clone()
+		records = append(records, cfg.records...)
+		warnings = append(warnings, cfg.warnings...)
+		cfg.mutex.Unlock()
+
+		if !compiled {
+			return nil, ErrNotCompiled
+		}
+
+		if delimiter != rv.opts.keyDelimiter {
+			return nil, fmt.Errorf("%w: key delimiters '%s' and '%s' conflict",
+				ErrInvalidInput, rv.opts.keyDelimiter, delimiter)
+		}
+
+		var err error
+		merged, err = merged.Merge(tree, rv.mergeOptions()...)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	hash, err := rv.opts.hasher.Hash(merged)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	rv.explain.reset()
+	rv.explain.optionInEffect(print.P("Merge", print.Int(len(configs))), false)
+	rv.explain.compileStartedAt(now)
+	for _, record := range records {
+		rv.explain.compileRecord(record, false, now)
+	}
+	rv.explain.CompileFinishedAt = now
+
+	rv.records = records
+	rv.tree = merged
+	rv.compiledAt = now
+	rv.hash = hash
+	rv.warnings = warnings
+
+	return &rv, nil
+}
+
+// SubConfig returns a new compiled Config with the subtree found at the key as
+// the root of the configuration.  This allows a scoped configuration to be
+// passed to a submodule that can then use [Root] as the key without knowing
+// where in the parent configuration the values came from.  The codecs and
+// options of the Config are shared with the sub-config.
+//
+// The sub-config is a snapshot of the subtree when SubConfig() is called and
+// is not linked to the parent.  Later compilations of the parent are not
+// reflected in the sub-config, and compiling the sub-config produces the full
+// configuration again instead of the subtree.
+//
+// If the key is not found an error is returned unless the [Optional]() option
+// is provided, then the sub-config is empty.
+//
+// Valid Option Types:
+//   - [UnmarshalOption]
+func (c *Config) SubConfig(key string, opts ...UnmarshalOption) (*Config, error) {
+	var options unmarshalOptions
+	for _, opt := range opts {
+		if opt != nil {
+			if err := opt.unmarshalApply(&options); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if c.compiledAt.Equal(time.Time{}) {
+		return nil, ErrNotCompiled
+	}
+
+	tree := c.tree
+	if len(key) > 0 {
+		var err error
+		tree, err = c.tree.Fetch(strings.Split(key, c.opts.keyDelimiter), c.opts.keyDelimiter)
+		if err != nil {
+			if !options.optional || !errors.Is(err, meta.ErrNotFound) {
+				return nil, err
+			}
+			tree = meta.Object{}
+		}
+	}
+	tree = tree.Clone()
+
+	hash, err := c.opts.hasher.Hash(tree)
+	if err != nil {
+		return nil, err
+	}
+
+	rv := Config{
+		rawOpts:    append([]Option{}, c.rawOpts...),
+		opts:       c.opts,
+		records:    append([]string{}, c.records...),
+		warnings:   append([]Warning{}, c.warnings...),
+		tree:       tree,
+		compiledAt: time.Now(),
+		hash:       hash,
+	}
+
+	rv.explain.reset()
+	rv.explain.optionInEffect(print.P("SubConfig", print.String(key)), false)
+	rv.explain.compileStartedAt(rv.compiledAt)
+	for _, record := range rv.records {
+		rv.explain.compileRecord(record, false, rv.compiledAt)
+	}
+	rv.explain.CompileFinishedAt = rv.compiledAt
+
+	return &rv, nil
+}
+
 // With takes a list of options and applies them.  Use of With() is optional as
 // New() can take all the same options as well.  If AutoCompile() is not specified
 // Compile() will need to be called to see changes in the configuration based on
@@ -71,6 +276,12 @@ func New(opts ...Option) (*Config, error) {
 //
 // See also: [AutoCompile], [Compile], [New]
 func (c *Config) With(opts ...Option) error {
+	c.compileMutex.Lock()
+	defer c.compileMutex.Unlock()
+
+	var notice changeNotice
+	defer notice.send()
+
 	c.mutex.Lock()
 	defer c.mutex.Unlock()
 
@@ -89,25 +300,32 @@ func (c *Config) With(opts ...Option) error {
 		SetKeyDelimiter("."),
 		SetHasher(nil),
 		SetMaxExpansions(10000),
+		SetWatchInterval(time.Second),
 	}
 
 	if !ignoreDefaultOpts(raw) {
 		local := []Option{
 			DefaultUnmarshalOptions(KeymapReport(&c.explain.Keyremapping)),
 			DefaultValueOptions(KeymapReport(&c.explain.Keyremapping)),
+			WithDecoder(json.Codec{}),
+			WithEncoder(json.Codec{}),
+			WithDecoder(toml.Codec{}),
+			WithEncoder(toml.Codec{}),
 		}
 
 		full = append(full, local...)
 		full = append(full, DefaultOptions...)
 	}
 
+	defaults := len(full)
+
 	full = append(full, c.rawOpts...)
 
 	full = append(full, opts...)
 
-	for _, opt := range full {
+	for i, opt := range full {
 		if opt != nil {
-			c.explain.optionInEffect(opt.String())
+			c.explain.optionInEffect(opt.String(), i < defaults)
 			if err := opt.apply(&cfg); err != nil {
 				return err
 			}
@@ -127,7 +345,7 @@ func (c *Config) With(opts ...Option) error {
 	c.explain.extsSupported(c.opts.decoders.extensions())
 
 	if !c.opts.disableAutoCompile {
-		return c.compile()
+		return c.compileAndNotice(context.Background(), &notice)
 	}
 
 	return nil
@@ -135,71 +353,271 @@ func (c *Config) With(opts ...Option) error {
 
 // Compile reads in all the files configured using the options provided,
 // and merges the configuration trees into a single map for later use.
+//
+// Compile is the same as calling [Config.CompileCtx] with context.Background().
 func (c *Config) Compile() error {
+	return c.CompileCtx(context.Background())
+}
+
+// CompileCtx reads in all the files configured using the options provided,
+// and merges the configuration trees into a single map for later use.  If the
+// context is canceled or the deadline passes before the compilation finishes,
+// the compilation is aborted, ctx.Err() is returned and the previously
+// compiled configuration is left in place.
+//
+// The context is checked before and after each record is fetched.  The
+// context is passed to the [BufferGetterCtx] and [ValueGetterCtx] callbacks
+// so long running callbacks are able to stop early.  Other user provided
+// callbacks (like a [BufferGetter] or [ValueGetter]) are not interrupted.
+func (c *Config) CompileCtx(ctx context.Context) error {
+	c.compileMutex.Lock()
+	defer c.compileMutex.Unlock()
+
+	var notice changeNotice
+	defer notice.send()
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	return c.compileAndNotice(ctx, &notice)
+}
+
+// Reload compiles the configuration using the options presently in effect,
+// like [Config.Compile]().  Unlike Compile(), the compilation is done off to
+// the side without blocking readers.  The new configuration tree replaces the
+// existing tree in a single step only if the compilation succeeds, so readers
+// calling [Config.Unmarshal]() concurrently see either the old or the new tree,
+// never a partial one.  If the compilation fails, the existing tree is left in
+// place and the error is returned.
+//
+// Compilations are serialized, so calling Reload(), Compile() or With() while
+// a Reload() is in progress waits for the Reload() to finish.
+func (c *Config) Reload() error {
+	c.compileMutex.Lock()
+	defer c.compileMutex.Unlock()
+
+	c.mutex.Lock()
+	shadow := Config{
+		records:    c.records,
+		tree:       c.tree,
+		compiledAt: c.compiledAt,
+		hash:       c.hash,
+		explain:    c.explain,
+		warnings:   c.warnings,
+		mergeTrace: c.mergeTrace,
+		weakKeys:   c.weakKeys,
+		cache:      c.cache,
+		rawOpts:    c.rawOpts,
+	}
+
+	// The shadow compile collects the key remapping on its own so it doesn't
+	// race with the readers reporting to the existing explanation.
+	shadow.explain.Keyremapping = debug.Collect{}
+	shadow.opts = c.opts.withKeymap(&c.explain.Keyremapping, &shadow.explain.Keyremapping)
+	c.mutex.Unlock()
+
+	err := shadow.compile(context.Background())
+
+	var notice changeNotice
+	defer notice.send()
+
 	c.mutex.Lock()
 	defer c.mutex.Unlock()
 
-	return c.compile()
+	keymap := c.explain.Keyremapping
+	for from, to := range shadow.explain.Keyremapping.Mapping {
+		keymap.Report(from, to)
+	}
+	c.explain = shadow.explain
+	c.explain.Keyremapping = keymap
+	c.compileTimings = shadow.compileTimings
+	c.publishTimings()
+	if err != nil {
+		return err
+	}
+
+	notice.prepare(c.onChange, c.tree, shadow.tree)
+
+	c.records = shadow.records
+	c.tree = shadow.tree
+	c.compiledAt = shadow.compiledAt
+	c.hash = shadow.hash
+	c.warnings = shadow.warnings
+	c.mergeTrace = shadow.mergeTrace
+	c.weakKeys = shadow.weakKeys
+	return nil
 }
 
 // compile is the internal compile function that ensures the results are also
 // recorded.
-func (c *Config) compile() error {
+func (c *Config) compile(ctx context.Context) error {
 	start := time.Now()
 	c.explain.compileStartedAt(start)
-	e := c.compileInternal(start)
+
+	if !c.opts.keepCache {
+		c.cache.invalidate()
+	}
+	resetSecrets(c.opts.expansions)
+	resetSecrets(c.opts.deferred)
+
+	// The timings are recorded separately and only published when the
+	// compilation finishes, so the timings are never changed while a
+	// compilation is running off to the side.
+	var timings *Timings
+	if c.opts.timings != nil {
+		timings = &Timings{}
+	}
+	e := c.compileInternal(ctx, start, timings)
+	timings.finish(start)
+	if timings != nil {
+		c.compileTimings = *timings
+	}
+
 	c.explain.CompileFinishedAt = time.Now()
 	c.explain.recordError(e)
 	return e
 }
 
+// publishTimings copies the timings of the last compilation into the
+// structure provided by [WithTimings]().  The mutex must be held.
+func (c *Config) publishTimings() {
+	if c.opts.timings != nil {
+		*c.opts.timings = c.compileTimings
+	}
+}
+
 // compileInternal is the internal compile function that does most of the work.
-func (c *Config) compileInternal(start time.Time) error {
-	full, defaultCount, err := c.getOrderedConfigs()
+func (c *Config) compileInternal(ctx context.Context, start time.Time, timings *Timings) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	full, warnings, err := c.getOrderedConfigs(timings)
 	if err != nil {
 		return err
 	}
+	warnings = c.report(warnings)
 
 	merged := meta.Object{Map: make(map[string]meta.Object)}
+	schema := meta.Object{Map: make(map[string]meta.Object)}
+	grouped := meta.Object{Map: make(map[string]meta.Object)}
+	var schemaFound bool
 	records := make([]string, 0, len(full))
+	tracer := newMergeTracer(c.opts.mergeTrace, c.opts.keyDelimiter)
+	weak := make(weakKeys)
 
-	for i, cfg := range full {
+	for _, cfg := range full {
 		// Build an incremental snapshot of the configuration at this step so
 		// user provided functions can use the cfg values to acquire more if
 		// needed.
 		incremental := merged
 
+		started := timings.now()
 		incremental, _, err = expandTree(incremental, c.opts.exapansionMax, c.opts.expansions)
+		timings.record(phaseExpand, started, 1)
 		if err != nil {
 			return err
 		}
 
+		weakList := weak.list("", c.opts.keyDelimiter)
 		unmarshalFunc := func(key string, result any, opts ...UnmarshalOption) error {
 			// Pass in the merged value from this context and stage of processing.
-			return c.unmarshal(key, result, incremental, opts...)
+			return c.unmarshal(key, result, incremental, weakList, opts...)
 		}
 
-		if err = cfg.fetch(c.opts.keyDelimiter, unmarshalFunc, c.opts.decoders, c.opts.valueOptions); err != nil {
+		started = timings.now()
+		err = c.fetchCtx(ctx, &cfg, unmarshalFunc)
+		if cfg.val != nil || cfg.buf != nil || cfg.kv != nil || cfg.args != nil {
+			timings.record(phaseDecode, started, 1)
+		}
+		if err != nil {
 			return err
 		}
-		merged, err = merged.Merge(cfg.tree)
+
+		started = timings.now()
+		tree, err := expandRelative(cfg, c.opts.exapansionMax, c.opts.expansions)
 		if err != nil {
 			return err
 		}
+		tree, err = applyMergeDirectives(tree, c.opts.mergeDirective)
+		if err != nil {
+			return err
+		}
+		if c.opts.nullMode == NullIgnored {
+			tree = tree.FilterNulls()
+		}
+		merged, err = merged.Merge(tree, c.mergeOptions()...)
+		if err != nil {
+			return err
+		}
+		tracer.merged(cfg.name, merged)
+		weak.update(tree, cfg.args != nil, c.opts.keyDelimiter)
+		if cfg.firstGroup {
+			schemaFound = true
+			schema, err = schema.Merge(tree)
+			if err != nil {
+				return err
+			}
+		}
+		if cfg.fromGroup && c.opts.schemaFromFirstGroup {
+			grouped, err = grouped.Merge(tree)
+			if err != nil {
+				return err
+			}
+		}
+		timings.record(phaseMerge, started, 1)
 		records = append(records, cfg.name)
-		c.explain.compileRecord(cfg.name, i < defaultCount, time.Now())
+		c.explain.compileRecord(cfg.name, cfg.isDefault, time.Now())
+	}
+
+	// The derived defaults are applied before the final expansion so the
+	// values they provide are expanded like all the other values.
+	merged, err = c.applyDerivedDefaults(merged, timings)
+	if err != nil {
+		return err
 	}
 
 	// Expand the final tree to ensure all values are expanded.
+	started := timings.now()
 	merged, _, err = expandTree(merged, c.opts.exapansionMax, c.opts.expansions)
+	timings.record(phaseExpand, started, 1)
+	if err != nil {
+		return err
+	}
+
+	missed, err := findUnexpanded(merged, c.opts.keyDelimiter, c.opts.expansions, c.opts.deferred)
+	if err != nil {
+		return err
+	}
+	warnings = append(warnings, c.report(missed)...)
+
+	started = timings.now()
+	found, err := c.validate(merged, grouped, schema, schemaFound)
 	if err != nil {
 		return err
 	}
+	timings.record(phaseValidate, started, 0)
+	warnings = append(warnings, c.report(found)...)
+
+	if len(c.opts.selectRoot) > 0 {
+		path := strings.Split(c.opts.selectRoot, c.opts.keyDelimiter)
+		merged, err = merged.Fetch(path, c.opts.keyDelimiter)
+		if err != nil {
+			if !c.opts.selectRootOptional || !errors.Is(err, meta.ErrNotFound) {
+				return err
+			}
+			merged = meta.Object{Map: make(map[string]meta.Object)}
+		}
+	}
+	tracer.finish(merged, c.opts.selectRoot)
 
 	// Record the expansions in effect.
 	for _, exp := range c.opts.expansions {
 		c.explain.compileExpansions(exp.String())
 	}
+	for _, exp := range c.opts.deferred {
+		c.explain.compileExpansions(exp.String())
+	}
 
 	hash, err := c.opts.hasher.Hash(merged)
 	if err != nil {
@@ -210,26 +628,223 @@ func (c *Config) compileInternal(start time.Time) error {
 	c.tree = merged
 	c.compiledAt = start
 	c.hash = hash
+	c.warnings = warnings
+	c.mergeTrace = tracer.trace()
+	c.weakKeys = weak.list(c.opts.selectRoot, c.opts.keyDelimiter)
 	return nil
 }
 
+// report removes the suppressed warnings and calls the error handler, if any,
+// with each of the remaining warnings.  The remaining warnings are returned.
+// It is called at the end of each stage of the compilation with the warnings
+// found by that stage.
+func (c *Config) report(warnings []Warning) []Warning {
+	var rv []Warning
+	for _, w := range warnings {
+		if suppressed(w, c.opts.suppressWarnings, c.opts.keyDelimiter) {
+			continue
+		}
+
+		if c.opts.errorHandler != nil {
+			c.opts.errorHandler(w)
+		}
+		rv = append(rv, w)
+	}
+
+	return rv
+}
+
+// validate checks the final tree is acceptable and returns any warnings found.
+// The grouped tree is the merge of the records from the filegroups, which are
+// the only ones checked against the schema.
+func (c *Config) validate(merged, grouped, schema meta.Object, schemaFound bool) ([]Warning, error) {
+	if err := checkUnbalanced(merged, c.opts.keyDelimiter, c.opts.expansions); err != nil {
+		return nil, err
+	}
+	if err := checkUnbalanced(merged, c.opts.keyDelimiter, c.opts.deferred); err != nil {
+		return nil, err
+	}
+
+	if c.opts.schemaFromFirstGroup && schemaFound {
+		unknown := unknownKeys(schema, grouped, merged, nil, c.opts.keyDelimiter)
+		if len(unknown) > 0 {
+			sort.Strings(unknown)
+			return nil, fmt.Errorf("%w: not found in the first file group: '%s'",
+				ErrUnknownKey, strings.Join(unknown, "', '"))
+		}
+	}
+
+	if err := validateSchemas(merged, c.opts.keyDelimiter, c.opts.schemas); err != nil {
+		return nil, err
+	}
+
+	return c.opts.leakDetector.detect(merged, c.opts.keyDelimiter)
+}
+
+// mergeOptions returns the meta.MergeOptions to use based on the options.
+func (c *Config) mergeOptions() []meta.MergeOption {
+	var rv []meta.MergeOption
+
+	if c.opts.leafMerge != nil {
+		fn := c.opts.leafMerge
+		delimiter := c.opts.keyDelimiter
+		rv = append(rv, meta.WithLeafMerger(
+			func(path []string, existing, next meta.Object) (meta.Object, bool, error) {
+				got, err := fn(strings.Join(path, delimiter), existing, next)
+				if err != nil {
+					if errors.Is(err, ErrNotApplicable) {
+						return meta.Object{}, false, nil
+					}
+					return meta.Object{}, false, err
+				}
+				return got, true, nil
+			}))
+	}
+
+	switch c.opts.indexMode {
+	case IndexMerges:
+		rv = append(rv, meta.WithIndexMerge(false))
+	case IndexExtends:
+		rv = append(rv, meta.WithIndexMerge(true))
+	}
+
+	if c.opts.dedupArrays {
+		rv = append(rv, meta.WithDedupArrays())
+	}
+
+	if len(c.opts.mergeRules) > 0 {
+		rv = append(rv, meta.WithMergeRule(mergeRuleFunc(c.opts.mergeRules, c.opts.keyDelimiter)))
+	}
+
+	return rv
+}
+
+// mergeRuleFunc returns the meta.MergeRule for the rules.  The last rule that
+// matches is used.
+func mergeRuleFunc(rules []mergeRule, delimiter string) meta.MergeRule {
+	globs := make([][]string, len(rules))
+	for i, rule := range rules {
+		globs[i] = strings.Split(rule.glob, delimiter)
+	}
+
+	return func(path []string) int {
+		for i := len(rules) - 1; i >= 0; i-- {
+			if !matchKey(globs[i], path) {
+				continue
+			}
+
+			switch rules[i].strategy {
+			case MergeReplace:
+				return meta.StrategyReplace
+			case MergeAppend:
+				return meta.StrategyAppend
+			case MergeDeepAppend:
+				return meta.StrategyDeepMerge
+			}
+		}
+		return meta.StrategyDefault
+	}
+}
+
+// unknownKeys returns the full keys of the map entries in the tree that are not
+// present in the schema.  Only the entries that are still present in the final
+// tree are returned.
+func unknownKeys(schema, tree, final meta.Object, path []string, delimiter string) []string {
+	if schema.Kind() != meta.Map || tree.Kind() != meta.Map || final.Kind() != meta.Map {
+		return nil
+	}
+
+	var rv []string
+	for key, val := range tree.Map {
+		f, found := final.Map[key]
+		if !found {
+			continue
+		}
+
+		full := append(path[:len(path):len(path)], key)
+		s, found := schema.Map[key]
+		if !found {
+			rv = append(rv, strings.Join(full, delimiter))
+			continue
+		}
+		rv = append(rv, unknownKeys(s, val, f, full, delimiter)...)
+	}
+
+	return rv
+}
+
+// fetchCtx fetches the record, returning ctx.Err() if the context is done
+// before or after the fetch.
+func (c *Config) fetchCtx(ctx context.Context, rec *record, u Unmarshaler) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	err := rec.fetch(ctx, c.opts.keyDelimiter, u, c.opts.decoders, c.cache, c.opts.valueOptions, c.opts.rejectDuplicateKeys)
+	if err != nil {
+		return err
+	}
+
+	return ctx.Err()
+}
+
 // getOrderedConfigs is a helper function that combines the different groups of
-// configuration files into a single, correctly ordered list and the number of
-// default values that are at the start of the list.
-func (c *Config) getOrderedConfigs() ([]record, int, error) {
-	cfgs, err := filegroupsToRecords(c.opts.keyDelimiter, c.opts.filegroups, c.opts.decoders)
+// configuration files into a single, correctly ordered list.
+func (c *Config) getOrderedConfigs(timings *Timings) ([]record, []Warning, error) {
+	groups := make([]filegroup, 0, len(c.opts.filegroups))
+	for _, grp := range c.opts.filegroups {
+		if grp.when != nil && !grp.when() {
+			c.explain.compileSkippedFileGroup(grp.text)
+			continue
+		}
+		grp.rejectDuplicateKeys = c.opts.rejectDuplicateKeys
+		groups = append(groups, grp)
+	}
+
+	cfgs, warnings, err := filegroupsToRecords(c.opts.keyDelimiter, groups, c.opts.decoders, timings)
 	if err != nil {
-		return nil, 0, err
+		return nil, nil, err
 	}
 
 	cfgs = append(cfgs, c.opts.values...)
 	sorter := c.getSorter()
 	sorter(cfgs)
 
-	defaultCount := len(c.opts.defaults)
-	full := append(c.opts.defaults, cfgs...)
+	full := make([]record, 0, len(c.opts.defaults)+len(cfgs))
+	for _, def := range c.opts.defaults {
+		def.isDefault = true
+		full = append(full, def)
+	}
+	full = append(full, cfgs...)
 
-	return full, defaultCount, nil
+	// Records with an explicit priority are moved relative to the others,
+	// while records with the same priority keep their order.
+	sort.SliceStable(full, func(i, j int) bool {
+		return full[i].getPriority() < full[j].getPriority()
+	})
+
+	if c.opts.uniqueRecordNames {
+		if err := checkUniqueNames(full); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	return full, warnings, nil
+}
+
+// checkUniqueNames returns an error naming the first record name that is used
+// by more than one record.  Records from the same layered option are allowed
+// to share a name.
+func checkUniqueNames(records []record) error {
+	seen := make(map[string]int, len(records))
+	for _, r := range records {
+		if layer, found := seen[r.name]; found && (layer == 0 || layer != r.layer) {
+			return fmt.Errorf("%w: '%s'", ErrDuplicateRecord, r.name)
+		}
+		seen[r.name] = r.layer
+	}
+
+	return nil
 }
 
 // getSorter does the work of making a sorter for the objects we need to sort.
@@ -299,8 +914,140 @@ func (c *Config) Explain() Explanation {
 	return c.explain
 }
 
+// Warnings returns the warnings found the last time the configuration was
+// successfully compiled.  Warnings describe problems that did not prevent the
+// configuration from being compiled, like files that were skipped by a
+// [BestEffort]() file group.
+func (c *Config) Warnings() []Warning {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	return append([]Warning{}, c.warnings...)
+}
+
+// Timings returns the timings of the last compilation if the [WithTimings]()
+// option is used.  Unlike the structure provided to WithTimings(), this is
+// safe to call while [Config.Reload]() or [Config.Watch]() are compiling the
+// configuration.
+func (c *Config) Timings() Timings {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	return c.compileTimings
+}
+
+// InvalidateCache removes the values remembered by the [Cached]() option and
+// kept by the [KeepCache]() option for the specified cache keys, or all the
+// remembered values if no keys are provided.  The ValueGetters are called again the next time the
+// configuration is compiled.  The compiled configuration is not changed.
+func (c *Config) InvalidateCache(keys ...string) {
+	c.cache.invalidate(keys...)
+}
+
+// MergeTrace returns the merge decisions made the last time the configuration
+// was successfully compiled, sorted by key.  The trace is only recorded when
+// the [WithMergeTrace]() option is used, otherwise nil is returned.
+func (c *Config) MergeTrace() []MergeEvent {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if c.mergeTrace == nil {
+		return nil
+	}
+
+	rv := make([]MergeEvent, len(c.mergeTrace))
+	for i, event := range c.mergeTrace {
+		event.Overridden = append([]string(nil), event.Overridden...)
+		event.Origins = append([]meta.Origin(nil), event.Origins...)
+		rv[i] = event
+	}
+	return rv
+}
+
+// Has returns if the key is present in the compiled configuration tree.  A key
+// that is set to a null or empty value is present.  If the configuration has
+// not been compiled false is returned.
+//
+// To check the root use goschtalt.Root [Root] instead of "" for more clarity.
+func (c *Config) Has(key string) bool {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if c.compiledAt.Equal(time.Time{}) {
+		return false
+	}
+
+	if len(key) == 0 {
+		return true
+	}
+
+	_, err := c.tree.Fetch(strings.Split(key, c.opts.keyDelimiter), c.opts.keyDelimiter)
+	return err == nil
+}
+
+// WalkFunc is the type of the function called by [Config.Walk]() for each
+// leaf value in the compiled configuration tree.  The path is the full key
+// of the value joined using the key delimiter.  Returning an error stops the
+// walk and the error is returned by Walk().
+type WalkFunc func(path string, value any, origins []meta.Origin) error
+
+// Walk performs a depth first traversal of the compiled configuration tree,
+// calling fn for each leaf value.  Map keys are visited in sorted order and
+// array elements in index order.  Secret values are provided as they are,
+// not redacted.
+//
+// The walk is performed on a copy of the tree, so fn may call other methods
+// on the Config.
+func (c *Config) Walk(fn WalkFunc) error {
+	c.mutex.Lock()
+	compiled := !c.compiledAt.Equal(time.Time{})
+	tree := c.tree.Clone()
+	delimiter := c.opts.keyDelimiter
+	c.mutex.Unlock()
+
+	if !compiled {
+		return ErrNotCompiled
+	}
+
+	if fn == nil {
+		return fmt.Errorf("%w: a non-nil WalkFunc must be specified", ErrInvalidInput)
+	}
+
+	return walk(tree, nil, delimiter, fn)
+}
+
+func walk(obj meta.Object, path []string, delimiter string, fn WalkFunc) error {
+	switch obj.Kind() {
+	case meta.Array:
+		for i, val := range obj.Array {
+			full := append(path[:len(path):len(path)], strconv.Itoa(i))
+			if err := walk(val, full, delimiter, fn); err != nil {
+				return err
+			}
+		}
+	case meta.Map:
+		keys := make([]string, 0, len(obj.Map))
+		for key := range obj.Map {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			full := append(path[:len(path):len(path)], key)
+			if err := walk(obj.Map[key], full, delimiter, fn); err != nil {
+				return err
+			}
+		}
+	default:
+		return fn(strings.Join(path, delimiter), obj.Value, obj.Origins)
+	}
+
+	return nil
+}
+
 // GetTree returns a copy of the compiled tree.  This is useful for debugging
-// what the configuration tree looks like with a tool like k0kubun/pp.
+// what the configuration tree looks like with a tool like k0kubun/pp or the
+// [meta.Object.Dump]() function.
 //
 // The value returned is a deep clone & has nothing to do with the original
 // that still resides inside the Config object.
@@ -4,14 +4,20 @@
 package goschtalt
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	iofs "io/fs"
 	"reflect"
+	"sort"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"testing/fstest"
 	"time"
 
+	"github.com/goschtalt/goschtalt/pkg/codec/jsonc"
 	"github.com/goschtalt/goschtalt/pkg/debug"
 	"github.com/goschtalt/goschtalt/pkg/meta"
 	"github.com/stretchr/testify/assert"
@@ -659,6 +665,39 @@ func TestCompile(t *testing.T) {
 					assert.Equal(a.T, b.T)
 			},
 		}, {
+			description: "A case with multiple default value adapters.",
+			opts: []Option{
+				AddValue("record1", Root,
+					withAll{
+						Foo:      "string",
+						Duration: time.Second,
+						T:        time.Date(2022, time.December, 30, 0, 0, 0, 0, time.UTC),
+					},
+				),
+				DefaultValueOptions(
+					adaptTimeToCfg("2006-01-02"),
+					adaptDurationToCfg(),
+				),
+				DefaultUnmarshalOptions(
+					adaptStringToTime("2006-01-02"),
+					adaptStringToDuration(),
+				),
+			},
+			expect: withAll{
+				Foo:      "string",
+				Duration: time.Second,
+				T:        time.Date(2022, time.December, 30, 0, 0, 0, 0, time.UTC),
+			},
+			files: []string{"record1"},
+			compare: func(assert *assert.Assertions, z, y any) bool {
+				a := z.(withAll)
+				b := y.(withAll)
+
+				return assert.Equal(a.Foo, b.Foo) &&
+					assert.Equal(a.Duration, b.Duration) &&
+					assert.Equal(a.T, b.T)
+			},
+		}, {
 
 			description: "An empty case.",
 			opts: []Option{
@@ -741,6 +780,50 @@ func TestCompile(t *testing.T) {
 			},
 			expect:      st1{},
 			expectedErr: unknownErr,
+		}, {
+			description: "A best effort group skips a decode failure.",
+			opts: []Option{
+				AddTree(fs1, "."),
+				AddTree(fs4, ".", BestEffort()),
+				WithDecoder(&testDecoder{extensions: []string{"json"}}),
+			},
+			expect: st1{
+				Hello: "Mr. Blue Sky",
+				Blue:  "sky",
+			},
+			files: []string{"1.json", "2.json", "3.json"},
+		}, {
+			description: "A best effort file that is missing is skipped.",
+			opts: []Option{
+				AddTree(fs1, "."),
+				AddFile(fs1, "invalid.json", BestEffort()),
+				WithDecoder(&testDecoder{extensions: []string{"json"}}),
+			},
+			expect: st1{
+				Hello: "Mr. Blue Sky",
+				Blue:  "sky",
+			},
+			files: []string{"1.json", "2.json", "3.json"},
+		}, {
+			description: "A strict group that is missing is a failure.",
+			opts: []Option{
+				AutoCompile(false),
+				AddTree(fs1, "."),
+				AddDir(fs1, "missing", Strict()),
+				WithDecoder(&testDecoder{extensions: []string{"json"}}),
+			},
+			expect:      st1{},
+			expectedErr: ErrFileMissing,
+		}, {
+			description: "A strict group with a decode failure is a failure.",
+			opts: []Option{
+				AutoCompile(false),
+				AddTree(fs1, "."),
+				AddTree(fs4, ".", BestEffort(), Strict()),
+				WithDecoder(&testDecoder{extensions: []string{"json"}}),
+			},
+			expect:      st1{},
+			expectedErr: unknownErr,
 		}, {
 			description: "A recursion case where a failure results",
 			opts: []Option{
@@ -1237,3 +1320,2114 @@ func TestSetMaxExpansions(t *testing.T) {
 		})
 	}
 }
+
+func TestFromBuffer(t *testing.T) {
+	tests := []struct {
+		description string
+		name        string
+		data        []byte
+		opts        []Option
+		expect      map[string]any
+		expectedErr error
+	}{
+		{
+			description: "A simple buffer",
+			name:        "1.json",
+			data:        []byte(`{"Hello":"World"}`),
+			opts:        []Option{WithDecoder(&testDecoder{extensions: []string{"json"}})},
+			expect:      map[string]any{"Hello": "World"},
+		}, {
+			description: "Auto compile can't be disabled",
+			name:        "1.json",
+			data:        []byte(`{"Hello":"World"}`),
+			opts: []Option{
+				WithDecoder(&testDecoder{extensions: []string{"json"}}),
+				AutoCompile(false),
+			},
+			expect: map[string]any{"Hello": "World"},
+		}, {
+			description: "The default json decoder",
+			name:        "1.json",
+			data:        []byte(`{"Hello":"World"}`),
+			expect:      map[string]any{"Hello": "World"},
+		}, {
+			description: "The default json decoder is disabled",
+			name:        "1.json",
+			data:        []byte(`{"Hello":"World"}`),
+			opts:        []Option{DisableDefaultPackageOptions()},
+			expectedErr: ErrCodecNotFound,
+		}, {
+			description: "No decoder for the buffer",
+			name:        "1.yml",
+			data:        []byte(`Hello: World`),
+			expectedErr: ErrCodecNotFound,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			cfg, err := FromBuffer(tc.name, tc.data, tc.opts...)
+
+			if tc.expectedErr != nil {
+				assert.ErrorIs(err, tc.expectedErr)
+				assert.Nil(cfg)
+				return
+			}
+
+			require.NoError(err)
+			require.NotNil(cfg)
+
+			got, err := Unmarshal[map[string]any](cfg, Root)
+			require.NoError(err)
+			assert.Equal(tc.expect, got)
+		})
+	}
+}
+
+func TestFromMap(t *testing.T) {
+	tests := []struct {
+		description string
+		m           map[string]any
+		opts        []Option
+		expect      map[string]any
+		expectedErr error
+	}{
+		{
+			description: "A simple map",
+			m:           map[string]any{"Hello": "World"},
+			expect:      map[string]any{"Hello": "World"},
+		}, {
+			description: "A nested map",
+			m: map[string]any{
+				"Outer": map[string]any{"Inner": "value"},
+			},
+			expect: map[string]any{
+				"Outer": map[string]any{"Inner": "value"},
+			},
+		}, {
+			description: "An option error",
+			m:           map[string]any{"Hello": "World"},
+			opts:        []Option{SetMaxExpansions(-1)},
+			expectedErr: ErrInvalidInput,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			cfg, err := FromMap(tc.m, tc.opts...)
+
+			if tc.expectedErr != nil {
+				assert.ErrorIs(err, tc.expectedErr)
+				assert.Nil(cfg)
+				return
+			}
+
+			require.NoError(err)
+			require.NotNil(cfg)
+
+			got, err := Unmarshal[map[string]any](cfg, Root)
+			require.NoError(err)
+			assert.Equal(tc.expect, got)
+			assert.Equal([]string{"FromMap"}, cfg.records)
+		})
+	}
+}
+
+func TestNullMerge(t *testing.T) {
+	tests := []struct {
+		description string
+		opts        []Option
+		expect      map[string]any
+		expectedErr error
+	}{
+		{
+			description: "The default behavior clears",
+			expect: map[string]any{
+				"Color": nil,
+				"Size":  "large",
+				"Sub": map[string]any{
+					"Name": nil,
+					"Age":  "10",
+				},
+			},
+		}, {
+			description: "NullClears",
+			opts:        []Option{NullMerge(NullClears)},
+			expect: map[string]any{
+				"Color": nil,
+				"Size":  "large",
+				"Sub": map[string]any{
+					"Name": nil,
+					"Age":  "10",
+				},
+			},
+		}, {
+			description: "NullIgnored",
+			opts:        []Option{NullMerge(NullIgnored)},
+			expect: map[string]any{
+				"Color": "red",
+				"Size":  "large",
+				"Sub": map[string]any{
+					"Name": "fred",
+					"Age":  "10",
+				},
+			},
+		}, {
+			description: "An invalid mode",
+			opts:        []Option{NullMerge(NullMode("invalid"))},
+			expectedErr: ErrInvalidInput,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			opts := []Option{
+				WithDecoder(&testDecoder{extensions: []string{"json"}}),
+				AddBuffer("1.json", []byte(`{"Color":"red", "Size":"small", "Sub":{"Name":"fred", "Age":"9"}}`)),
+				AddBuffer("2.json", []byte(`{"Color":null, "Size":"large", "Sub":{"Name":null, "Age":"10"}}`)),
+			}
+			opts = append(opts, tc.opts...)
+
+			cfg, err := New(opts...)
+
+			if tc.expectedErr != nil {
+				assert.ErrorIs(err, tc.expectedErr)
+				return
+			}
+
+			require.NoError(err)
+			require.NotNil(cfg)
+
+			got, err := Unmarshal[map[string]any](cfg, Root)
+			require.NoError(err)
+			assert.Equal(tc.expect, got)
+		})
+	}
+}
+
+func TestIndexMerge(t *testing.T) {
+	servers := func(second string) map[string]any {
+		return map[string]any{
+			"servers": []any{
+				map[string]any{"host": "a", "port": "1"},
+				map[string]any{"host": second, "port": "2"},
+			},
+		}
+	}
+
+	tests := []struct {
+		description string
+		opts        []Option
+		expect      map[string]any
+		expectedErr error
+	}{
+		{
+			description: "The default behavior replaces",
+			opts: []Option{
+				AddValueAt("override", []string{"servers", "1", "host"}, "newhost"),
+			},
+			expect: map[string]any{
+				"servers": map[string]any{
+					"1": map[string]any{"host": "newhost"},
+				},
+			},
+		}, {
+			description: "IndexMerges",
+			opts: []Option{
+				IndexMerge(IndexMerges),
+				AddValueAt("override", []string{"servers", "1", "host"}, "newhost"),
+			},
+			expect: servers("newhost"),
+		}, {
+			description: "IndexMerges with AddValue",
+			opts: []Option{
+				IndexMerge(IndexMerges),
+				AddValue("override", "servers.1.host", "newhost"),
+			},
+			expect: servers("newhost"),
+		}, {
+			description: "IndexMerges out of range",
+			opts: []Option{
+				IndexMerge(IndexMerges),
+				AddValueAt("override", []string{"servers", "2", "host"}, "newhost"),
+			},
+			expectedErr: meta.ErrArrayOutOfBounds,
+		}, {
+			description: "IndexExtends out of range",
+			opts: []Option{
+				IndexMerge(IndexExtends),
+				AddValueAt("override", []string{"servers", "2", "host"}, "c"),
+			},
+			expect: map[string]any{
+				"servers": []any{
+					map[string]any{"host": "a", "port": "1"},
+					map[string]any{"host": "b", "port": "2"},
+					map[string]any{"host": "c"},
+				},
+			},
+		}, {
+			description: "An invalid mode",
+			opts:        []Option{IndexMerge(IndexMode("invalid"))},
+			expectedErr: ErrInvalidInput,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			opts := []Option{
+				WithDecoder(&testDecoder{extensions: []string{"json"}}),
+				AddBuffer("1.json", []byte(`{"servers":[{"host":"a", "port":"1"}, {"host":"b", "port":"2"}]}`)),
+			}
+			opts = append(opts, tc.opts...)
+
+			cfg, err := New(opts...)
+
+			if tc.expectedErr != nil {
+				assert.ErrorIs(err, tc.expectedErr)
+				return
+			}
+
+			require.NoError(err)
+			require.NotNil(cfg)
+
+			got, err := Unmarshal[map[string]any](cfg, Root)
+			require.NoError(err)
+			assert.Equal(tc.expect, got)
+		})
+	}
+}
+
+func TestDedupArrays(t *testing.T) {
+	tests := []struct {
+		description string
+		opts        []Option
+		expect      []string
+	}{
+		{
+			description: "The duplicates are kept by default",
+			expect:      []string{"a", "b", "b", "c", "c", "a", "d"},
+		}, {
+			description: "The duplicates are removed",
+			opts:        []Option{DedupArrays()},
+			expect:      []string{"a", "b", "c", "d"},
+		}, {
+			description: "The duplicates are kept when disabled",
+			opts:        []Option{DedupArrays(false)},
+			expect:      []string{"a", "b", "b", "c", "c", "a", "d"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			opts := []Option{
+				WithDecoder(&testDecoder{extensions: []string{"json"}}),
+				AddBuffer("1.json", []byte(`{"plugins":["a", "b"]}`)),
+				AddBuffer("2.json", []byte(`{"plugins":["b", "c"]}`)),
+				AddBuffer("3.json", []byte(`{"plugins":["c", "a", "d"]}`)),
+			}
+			opts = append(opts, tc.opts...)
+
+			cfg, err := New(opts...)
+			require.NoError(err)
+			require.NotNil(cfg)
+
+			got, err := Unmarshal[[]string](cfg, "plugins")
+			require.NoError(err)
+			assert.Equal(tc.expect, got)
+		})
+	}
+}
+
+func TestWithMergeRule(t *testing.T) {
+	records := []Option{
+		WithDecoder(&testDecoder{extensions: []string{"json"}}),
+		AddBuffer("1.json", []byte(`{
+			"plugins": ["a"],
+			"hosts":   ["one"],
+			"servers": [{"name":"a", "ports":[80]}, {"name":"b"}]
+		}`)),
+		AddBuffer("2.json", []byte(`{
+			"plugins": ["b"],
+			"hosts":   ["two"],
+			"servers": [{"ports":[443], "tls":true}]
+		}`)),
+		AddBuffer("3.json", []byte(`{
+			"plugins": ["c"],
+			"hosts":   ["three"],
+			"servers": [{}, {"name":"c"}, {"name":"d"}]
+		}`)),
+	}
+
+	type server struct {
+		Name  string `goschtalt:"name"`
+		Ports []int  `goschtalt:"ports"`
+		TLS   bool   `goschtalt:"tls"`
+	}
+	type cfg struct {
+		Plugins []string `goschtalt:"plugins"`
+		Hosts   []string `goschtalt:"hosts"`
+		Servers []server `goschtalt:"servers"`
+	}
+
+	tests := []struct {
+		description string
+		opts        []Option
+		expect      cfg
+		expectedErr error
+	}{
+		{
+			description: "The default rules.",
+			expect: cfg{
+				Plugins: []string{"a", "b", "c"},
+				Hosts:   []string{"one", "two", "three"},
+				Servers: []server{
+					{Name: "a", Ports: []int{80}},
+					{Name: "b"},
+					{Ports: []int{443}, TLS: true},
+					{},
+					{Name: "c"},
+					{Name: "d"},
+				},
+			},
+		}, {
+			description: "Replace some and deep merge others.",
+			opts: []Option{
+				WithMergeRule("hosts", MergeReplace),
+				WithMergeRule("servers", MergeDeepAppend),
+			},
+			expect: cfg{
+				Plugins: []string{"a", "b", "c"},
+				Hosts:   []string{"three"},
+				Servers: []server{
+					{Name: "a", Ports: []int{80, 443}, TLS: true},
+					{Name: "c"},
+					{Name: "d"},
+				},
+			},
+		}, {
+			description: "Nested arrays of maps are matched by index.",
+			opts: []Option{
+				WithMergeRule("servers", MergeDeepAppend),
+				WithMergeRule("servers.*.ports", MergeReplace),
+			},
+			expect: cfg{
+				Plugins: []string{"a", "b", "c"},
+				Hosts:   []string{"one", "two", "three"},
+				Servers: []server{
+					{Name: "a", Ports: []int{443}, TLS: true},
+					{Name: "c"},
+					{Name: "d"},
+				},
+			},
+		}, {
+			description: "The last matching rule wins.",
+			opts: []Option{
+				WithMergeRule("**", MergeReplace),
+				WithMergeRule("plugins", MergeAppend),
+			},
+			expect: cfg{
+				Plugins: []string{"a", "b", "c"},
+				Hosts:   []string{"three"},
+				Servers: []server{{}, {Name: "c"}, {Name: "d"}},
+			},
+		}, {
+			description: "The rules use the key delimiter.",
+			opts: []Option{
+				SetKeyDelimiter("/"),
+				WithMergeRule("servers", MergeDeepAppend),
+				WithMergeRule("servers/0/ports", MergeReplace),
+				WithMergeRule("servers.0.name", MergeReplace),
+			},
+			expect: cfg{
+				Plugins: []string{"a", "b", "c"},
+				Hosts:   []string{"one", "two", "three"},
+				Servers: []server{
+					{Name: "a", Ports: []int{443}, TLS: true},
+					{Name: "c"},
+					{Name: "d"},
+				},
+			},
+		}, {
+			description: "An invalid strategy.",
+			opts:        []Option{WithMergeRule("plugins", "MergeSometimes")},
+			expectedErr: ErrInvalidInput,
+		}, {
+			description: "An invalid glob.",
+			opts:        []Option{WithMergeRule("plugins[", MergeReplace)},
+			expectedErr: ErrInvalidInput,
+		}, {
+			description: "An empty glob.",
+			opts:        []Option{WithMergeRule("", MergeReplace)},
+			expectedErr: ErrInvalidInput,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			opts := append(append([]Option{}, records...), tc.opts...)
+			c, err := New(opts...)
+			if tc.expectedErr != nil {
+				assert.ErrorIs(err, tc.expectedErr)
+				return
+			}
+			require.NoError(err)
+
+			got, err := Unmarshal[cfg](c, Root)
+			require.NoError(err)
+			assert.Equal(tc.expect, got)
+		})
+	}
+}
+
+func TestUniqueRecordNames(t *testing.T) {
+	fs := fstest.MapFS{
+		"a/config.json": &fstest.MapFile{
+			Data: []byte(`{"a":"1"}`),
+			Mode: 0755,
+		},
+		"b/config.json": &fstest.MapFile{
+			Data: []byte(`{"b":"2"}`),
+			Mode: 0755,
+		},
+	}
+
+	tests := []struct {
+		description string
+		opts        []Option
+		expectedErr error
+	}{
+		{
+			description: "Unique names",
+			opts: []Option{
+				UniqueRecordNames(),
+				AddBuffer("1.json", []byte(`{"a":"1"}`)),
+				AddValue("2", Root, map[string]any{"b": "2"}),
+				AddValue("3", Root, map[string]any{"c": "3"}, AsDefault()),
+			},
+		}, {
+			description: "Duplicate names are allowed by default",
+			opts: []Option{
+				AddBuffer("config.json", []byte(`{"a":"1"}`)),
+				AddBuffer("config.json", []byte(`{"a":"2"}`)),
+			},
+		}, {
+			description: "Duplicate names are allowed when disabled",
+			opts: []Option{
+				UniqueRecordNames(false),
+				AddBuffer("config.json", []byte(`{"a":"1"}`)),
+				AddBuffer("config.json", []byte(`{"a":"2"}`)),
+			},
+		}, {
+			description: "An accidental duplicate buffer",
+			opts: []Option{
+				UniqueRecordNames(),
+				AddBuffer("config.json", []byte(`{"a":"1"}`)),
+				AddBuffer("config.json", []byte(`{"a":"2"}`)),
+			},
+			expectedErr: ErrDuplicateRecord,
+		}, {
+			description: "A duplicate between a value and a default",
+			opts: []Option{
+				UniqueRecordNames(),
+				AddValue("record", Root, map[string]any{"a": "1"}),
+				AddValue("record", Root, map[string]any{"a": "2"}, AsDefault()),
+			},
+			expectedErr: ErrDuplicateRecord,
+		}, {
+			description: "Files with the same name in different directories",
+			opts: []Option{
+				UniqueRecordNames(),
+				AddTree(fs, "."),
+			},
+			expectedErr: ErrDuplicateRecord,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			opts := append([]Option{
+				WithDecoder(&testDecoder{extensions: []string{"json"}}),
+			}, tc.opts...)
+
+			cfg, err := New(opts...)
+
+			if tc.expectedErr != nil {
+				assert.ErrorIs(err, tc.expectedErr)
+				return
+			}
+
+			require.NoError(err)
+			require.NotNil(cfg)
+		})
+	}
+}
+
+func TestRejectDuplicateKeys(t *testing.T) {
+	fs := fstest.MapFS{
+		"conf/1.json": &fstest.MapFile{
+			Data: []byte("{\n  \"a\": \"1\",\n  \"a\": \"2\"\n}"),
+			Mode: 0755,
+		},
+		"conf/2.jsonc": &fstest.MapFile{
+			Data: []byte(`{"b": {"c": "1", /* again */ "c": "2"}}`),
+			Mode: 0755,
+		},
+	}
+
+	tests := []struct {
+		description string
+		opts        []Option
+		want        map[string]any
+		errText     string
+	}{
+		{
+			description: "Duplicates are allowed by default",
+			opts: []Option{
+				AddFile(fs, "conf/1.json"),
+				AddBuffer("buffer.json", []byte(`{"b": "1", "b": "2"}`)),
+			},
+			want: map[string]any{"a": "2", "b": "2"},
+		}, {
+			description: "Different maps may use the same key",
+			opts: []Option{
+				RejectDuplicateKeys(),
+				AddBuffer("buffer.json", []byte(`{"a": {"a": "1"}, "b": [{"a": "2"}, {"a": "3"}]}`)),
+			},
+			want: map[string]any{
+				"a": map[string]any{"a": "1"},
+				"b": []any{map[string]any{"a": "2"}, map[string]any{"a": "3"}},
+			},
+		}, {
+			description: "A duplicate in a file",
+			opts: []Option{
+				RejectDuplicateKeys(),
+				AddFile(fs, "conf/1.json"),
+			},
+			errText: "'a' at 1.json:3[3]",
+		}, {
+			description: "A duplicate in a jsonc file",
+			opts: []Option{
+				RejectDuplicateKeys(),
+				WithDecoder(jsonc.Codec{}),
+				AddFile(fs, "conf/2.jsonc"),
+			},
+			errText: "'c' at 2.jsonc:1[30]",
+		}, {
+			description: "A duplicate in a buffer",
+			opts: []Option{
+				RejectDuplicateKeys(),
+				AddBuffer("buffer.json", []byte(`{"b": "1", "b": "2"}`)),
+			},
+			errText: "'b' at buffer.json:1[12]",
+		}, {
+			description: "Duplicates are allowed when disabled",
+			opts: []Option{
+				RejectDuplicateKeys(false),
+				AddFile(fs, "conf/1.json"),
+			},
+			want: map[string]any{"a": "2"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			cfg, err := New(tc.opts...)
+
+			if tc.errText != "" {
+				assert.ErrorIs(err, ErrDecoding)
+				assert.ErrorContains(err, tc.errText)
+				return
+			}
+
+			require.NoError(err)
+			got, err := Unmarshal[map[string]any](cfg, Root)
+			require.NoError(err)
+			assert.Equal(tc.want, got)
+		})
+	}
+}
+
+func TestAddLayeredDirs(t *testing.T) {
+	system := fstest.MapFS{
+		"config.json": &fstest.MapFile{
+			Data: []byte(`{"a":"system", "b":"system", "c":"system"}`),
+			Mode: 0755,
+		},
+	}
+	user := fstest.MapFS{
+		"config.json": &fstest.MapFile{
+			Data: []byte(`{"b":"user", "c":"user"}`),
+			Mode: 0755,
+		},
+		"other.json": &fstest.MapFile{
+			Data: []byte(`{"d":"user"}`),
+			Mode: 0755,
+		},
+	}
+	local := fstest.MapFS{
+		"config.json": &fstest.MapFile{
+			Data: []byte(`{"c":"local"}`),
+			Mode: 0755,
+		},
+	}
+	empty := fstest.MapFS{}
+
+	tests := []struct {
+		description string
+		opts        []Option
+		expect      map[string]any
+		expectedErr error
+	}{
+		{
+			description: "Later layers win",
+			opts: []Option{
+				AddLayeredDirs([]iofs.FS{system, user, local}, "config.json"),
+			},
+			expect: map[string]any{"a": "system", "b": "user", "c": "local"},
+		}, {
+			description: "The order of the layers is used",
+			opts: []Option{
+				AddLayeredDirs([]iofs.FS{local, user, system}, "config.json"),
+			},
+			expect: map[string]any{"a": "system", "b": "system", "c": "system"},
+		}, {
+			description: "A missing layer is skipped",
+			opts: []Option{
+				AddLayeredDirs([]iofs.FS{system, empty, local}, "config.json"),
+			},
+			expect: map[string]any{"a": "system", "b": "system", "c": "local"},
+		}, {
+			description: "A missing layer with Strict()",
+			opts: []Option{
+				AddLayeredDirs([]iofs.FS{system, empty, local}, "config.json", Strict()),
+			},
+			expectedErr: ErrFileMissing,
+		}, {
+			description: "Only the named file is used",
+			opts: []Option{
+				AddLayeredDirs([]iofs.FS{system, user}, "config.json"),
+			},
+			expect: map[string]any{"a": "system", "b": "user", "c": "user"},
+		}, {
+			description: "The layers are not duplicates",
+			opts: []Option{
+				UniqueRecordNames(),
+				AddLayeredDirs([]iofs.FS{system, user, local}, "config.json"),
+			},
+			expect: map[string]any{"a": "system", "b": "user", "c": "local"},
+		}, {
+			description: "Two layered options are duplicates",
+			opts: []Option{
+				UniqueRecordNames(),
+				AddLayeredDirs([]iofs.FS{system}, "config.json"),
+				AddLayeredDirs([]iofs.FS{user}, "config.json"),
+			},
+			expectedErr: ErrDuplicateRecord,
+		}, {
+			description: "A missing filename",
+			opts: []Option{
+				AddLayeredDirs([]iofs.FS{system}, ""),
+			},
+			expectedErr: ErrInvalidInput,
+		}, {
+			description: "A nil layer",
+			opts: []Option{
+				AddLayeredDirs([]iofs.FS{system, nil}, "config.json"),
+			},
+			expectedErr: ErrInvalidInput,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			opts := append([]Option{
+				WithDecoder(&testDecoder{extensions: []string{"json"}}),
+			}, tc.opts...)
+
+			cfg, err := New(opts...)
+
+			if tc.expectedErr != nil {
+				assert.ErrorIs(err, tc.expectedErr)
+				return
+			}
+
+			require.NoError(err)
+
+			got, err := Unmarshal[map[string]any](cfg, Root)
+			require.NoError(err)
+			assert.Equal(tc.expect, got)
+		})
+	}
+}
+
+func TestAddSecretFile(t *testing.T) {
+	fs := fstest.MapFS{
+		"conf/1.json": &fstest.MapFile{
+			Data: []byte(`{"user":"bob", "password":"public", "db":{"host":"localhost"}}`),
+			Mode: 0755,
+		},
+		"secret/2.json": &fstest.MapFile{
+			Data: []byte(`{"password":"hunter2", "db":{"token":"abc", "keys":["x","y"]}}`),
+			Mode: 0755,
+		},
+	}
+
+	tests := []struct {
+		description string
+		opts        []Option
+		expect      map[string]any
+		redacted    map[string]any
+		expectedErr error
+	}{
+		{
+			description: "The secret file values are redacted",
+			opts: []Option{
+				AddFile(fs, "conf/1.json"),
+				AddSecretFile(fs, "secret/2.json"),
+			},
+			expect: map[string]any{
+				"user":     "bob",
+				"password": "hunter2",
+				"db": map[string]any{
+					"host":  "localhost",
+					"token": "abc",
+					"keys":  []any{"x", "y"},
+				},
+			},
+			redacted: map[string]any{
+				"user":     "bob",
+				"password": "REDACTED",
+				"db": map[string]any{
+					"host":  "localhost",
+					"token": "REDACTED",
+					"keys":  "REDACTED",
+				},
+			},
+		}, {
+			description: "The secret file is required",
+			opts: []Option{
+				AddFile(fs, "conf/1.json"),
+				AddSecretFile(fs, "secret/missing.json"),
+			},
+			expectedErr: ErrFileMissing,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			opts := []Option{
+				WithDecoder(&testDecoder{extensions: []string{"json"}}),
+			}
+			opts = append(opts, tc.opts...)
+
+			cfg, err := New(opts...)
+
+			if tc.expectedErr != nil {
+				assert.ErrorIs(err, tc.expectedErr)
+				return
+			}
+
+			require.NoError(err)
+			require.NotNil(cfg)
+
+			got, err := Unmarshal[map[string]any](cfg, Root)
+			require.NoError(err)
+			assert.Equal(tc.expect, got)
+
+			assert.Equal(tc.redacted, cfg.GetTree().ToRedacted().ToRaw())
+		})
+	}
+}
+
+func TestCompileCtx(t *testing.T) {
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	tests := []struct {
+		description string
+		ctx         func() (context.Context, context.CancelFunc)
+		opts        []Option
+		expectedErr error
+	}{
+		{
+			description: "A normal compile",
+			ctx: func() (context.Context, context.CancelFunc) {
+				return context.WithTimeout(context.Background(), time.Minute)
+			},
+			opts: []Option{
+				AddValue("record1", Root, map[string]any{"Hello": "World"}),
+			},
+		}, {
+			description: "An already canceled context",
+			ctx: func() (context.Context, context.CancelFunc) {
+				return canceled, func() {}
+			},
+			opts: []Option{
+				AddValue("record1", Root, map[string]any{"Hello": "World"}),
+			},
+			expectedErr: context.Canceled,
+		}, {
+			description: "A getter that runs past the deadline",
+			ctx: func() (context.Context, context.CancelFunc) {
+				return context.WithTimeout(context.Background(), 10*time.Millisecond)
+			},
+			opts: []Option{
+				AddValue("record1", Root, map[string]any{"Hello": "World"}),
+				AddValueGetter("record2", Root,
+					ValueGetterFunc(func(string, Unmarshaler) (any, error) {
+						time.Sleep(50 * time.Millisecond)
+						return map[string]any{"Hello": "Late"}, nil
+					}),
+				),
+				AddValueGetter("record3", Root,
+					ValueGetterFunc(func(string, Unmarshaler) (any, error) {
+						panic("the compilation should have stopped")
+					}),
+				),
+			},
+			expectedErr: context.DeadlineExceeded,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			opts := append([]Option{AutoCompile(false)}, tc.opts...)
+			cfg, err := New(opts...)
+			require.NoError(err)
+			require.NotNil(cfg)
+
+			ctx, cancel := tc.ctx()
+			defer cancel()
+
+			err = cfg.CompileCtx(ctx)
+
+			if tc.expectedErr != nil {
+				assert.ErrorIs(err, tc.expectedErr)
+				assert.True(cfg.CompiledAt().IsZero())
+				assert.ErrorIs(cfg.Explain().CompileErrors[0], tc.expectedErr)
+				return
+			}
+
+			require.NoError(err)
+			assert.False(cfg.CompiledAt().IsZero())
+			assert.Equal([]string{"record1"}, cfg.records)
+		})
+	}
+}
+
+func TestCompileCtxGetters(t *testing.T) {
+	type key struct{}
+
+	assert := assert.New(t)
+	require := require.New(t)
+
+	cfg, err := New(
+		AutoCompile(false),
+		AddValueGetterCtx("1", Root,
+			ValueGetterCtxFunc(func(ctx context.Context, _ string, _ Unmarshaler) (any, error) {
+				return map[string]any{"value": ctx.Value(key{})}, nil
+			}),
+		),
+		AddBufferGetterCtx("2.json",
+			BufferGetterCtxFunc(func(ctx context.Context, _ string, _ Unmarshaler) ([]byte, error) {
+				return []byte(`{"buffer":"` + ctx.Value(key{}).(string) + `"}`), nil
+			}),
+		),
+	)
+	require.NoError(err)
+
+	ctx := context.WithValue(context.Background(), key{}, "from ctx")
+	require.NoError(cfg.CompileCtx(ctx))
+
+	got, err := Unmarshal[map[string]string](cfg, Root)
+	require.NoError(err)
+	assert.Equal(map[string]string{"value": "from ctx", "buffer": "from ctx"}, got)
+
+	// The getters are given a background context by Compile().
+	cfg, err = New(
+		AddValueGetterCtx("1", Root,
+			ValueGetterCtxFunc(func(ctx context.Context, _ string, _ Unmarshaler) (any, error) {
+				assert.NoError(ctx.Err())
+				return map[string]any{"value": "a"}, nil
+			}),
+		),
+		AutoCompile(),
+	)
+	require.NoError(err)
+	assert.True(cfg.Has("value"))
+
+	// A getter is able to see the cancellation and stop.
+	stopped := make(chan error, 1)
+	cfg, err = New(
+		AutoCompile(false),
+		AddValueGetterCtx("1", Root,
+			ValueGetterCtxFunc(func(ctx context.Context, _ string, _ Unmarshaler) (any, error) {
+				<-ctx.Done()
+				stopped <- ctx.Err()
+				return nil, ctx.Err()
+			}),
+		),
+	)
+	require.NoError(err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	assert.ErrorIs(cfg.CompileCtx(ctx), context.DeadlineExceeded)
+	select {
+	case err := <-stopped:
+		assert.ErrorIs(err, context.DeadlineExceeded)
+	case <-time.After(5 * time.Second):
+		assert.Fail("the getter was not canceled")
+	}
+}
+
+func TestSchemaFromFirstGroup(t *testing.T) {
+	base := fstest.MapFS{
+		"base/1.json": &fstest.MapFile{
+			Data: []byte(`{"Name":"fred", "Sub":{"Age":"9", "List":["a"]}, "Any":null}`),
+			Mode: 0644,
+		},
+		"base/2.json": &fstest.MapFile{
+			Data: []byte(`{"Color":"red"}`),
+			Mode: 0644,
+		},
+	}
+	over := fstest.MapFS{
+		"good.json": &fstest.MapFile{
+			Data: []byte(`{"Name":"barney", "Sub":{"Age":"10", "List":[{"x":"y"}]}, "Color":"blue"}`),
+			Mode: 0644,
+		},
+		"typo.json": &fstest.MapFile{
+			Data: []byte(`{"Nmae":"barney", "Sub":{"Aeg":"10"}}`),
+			Mode: 0644,
+		},
+		"any.json": &fstest.MapFile{
+			Data: []byte(`{"Any":{"Thing":"goes"}}`),
+			Mode: 0644,
+		},
+		"subtypo.json": &fstest.MapFile{
+			Data: []byte(`{"Sub":{"Aeg":"10"}}`),
+			Mode: 0644,
+		},
+	}
+
+	tests := []struct {
+		description string
+		opts        []Option
+		expectedErr error
+	}{
+		{
+			description: "All keys are known",
+			opts: []Option{
+				SchemaFromFirstGroup(),
+				AddTree(base, "base"),
+				AddFile(over, "good.json"),
+			},
+		}, {
+			description: "Unknown keys are present",
+			opts: []Option{
+				SchemaFromFirstGroup(),
+				AddTree(base, "base"),
+				AddFile(over, "typo.json"),
+			},
+			expectedErr: ErrUnknownKey,
+		}, {
+			description: "Unknown keys are allowed when disabled",
+			opts: []Option{
+				SchemaFromFirstGroup(false),
+				AddTree(base, "base"),
+				AddFile(over, "typo.json"),
+			},
+		}, {
+			description: "Unknown keys from values are not checked",
+			opts: []Option{
+				SchemaFromFirstGroup(),
+				AddTree(base, "base"),
+				AddValue("value", Root, map[string]any{"Extra": "value", "Sub": map[string]any{"Other": "x"}}),
+				AddArgs("args", []string{"Sub.Aeg=10"}),
+			},
+		}, {
+			description: "Derived defaults are not checked",
+			opts: []Option{
+				SchemaFromFirstGroup(),
+				AddTree(base, "base"),
+				WithDerivedDefault("Derived", func(*Config) (any, bool) {
+					return "value", true
+				}),
+			},
+		}, {
+			description: "Unknown keys from files are caught with values present",
+			opts: []Option{
+				SchemaFromFirstGroup(),
+				AddTree(base, "base"),
+				AddValue("value", Root, map[string]any{"Extra": "value"}),
+				AddFile(over, "typo.json"),
+			},
+			expectedErr: ErrUnknownKey,
+		}, {
+			description: "Unknown keys from files that are later removed are ignored",
+			opts: []Option{
+				SchemaFromFirstGroup(),
+				AddTree(base, "base"),
+				AddFile(over, "subtypo.json"),
+				AddValue("zz", Root, map[string]any{"Sub((replace))": map[string]any{"Age": "1"}}),
+			},
+		}, {
+			description: "A leaf in the schema allows anything below it",
+			opts: []Option{
+				SchemaFromFirstGroup(),
+				AddTree(base, "base"),
+				AddFile(over, "any.json"),
+			},
+		}, {
+			description: "No records in the first group means no check",
+			opts: []Option{
+				SchemaFromFirstGroup(),
+				AddTree(base, "missing"),
+				AddFile(over, "typo.json"),
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			assert := assert.New(t)
+
+			opts := append([]Option{WithDecoder(&testDecoder{extensions: []string{"json"}})}, tc.opts...)
+			_, err := New(opts...)
+
+			if tc.expectedErr != nil {
+				assert.ErrorIs(err, tc.expectedErr)
+				return
+			}
+
+			assert.NoError(err)
+		})
+	}
+}
+
+func TestWithLeafMerge(t *testing.T) {
+	testErr := errors.New("test error")
+
+	sum := func(key string, existing, incoming meta.Object) (meta.Object, error) {
+		if key != "Stats.Count" {
+			return meta.Object{}, ErrNotApplicable
+		}
+		incoming.Value = existing.Value.(int) + incoming.Value.(int)
+		return incoming, nil
+	}
+
+	tests := []struct {
+		description string
+		fn          LeafMergeFunc
+		expect      map[string]any
+		expectedErr error
+	}{
+		{
+			description: "The default behavior",
+			expect: map[string]any{
+				"Stats": map[string]any{
+					"Count": 2,
+					"Name":  "second",
+				},
+			},
+		}, {
+			description: "A summing merger for a specific key",
+			fn:          sum,
+			expect: map[string]any{
+				"Stats": map[string]any{
+					"Count": 3,
+					"Name":  "second",
+				},
+			},
+		}, {
+			description: "A merger that fails",
+			fn: func(string, meta.Object, meta.Object) (meta.Object, error) {
+				return meta.Object{}, testErr
+			},
+			expectedErr: testErr,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			cfg, err := New(
+				AddValue("1", Root, map[string]any{
+					"Stats": map[string]any{"Count": 1, "Name": "first"},
+				}),
+				AddValue("2", Root, map[string]any{
+					"Stats": map[string]any{"Count": 2, "Name": "second"},
+				}),
+				WithLeafMerge(tc.fn),
+			)
+
+			if tc.expectedErr != nil {
+				assert.ErrorIs(err, tc.expectedErr)
+				return
+			}
+
+			require.NoError(err)
+			require.NotNil(cfg)
+
+			got, err := Unmarshal[map[string]any](cfg, Root)
+			require.NoError(err)
+			assert.Equal(tc.expect, got)
+		})
+	}
+}
+
+func TestSelectRoot(t *testing.T) {
+	fs := fstest.MapFS{
+		"tenants.json": &fstest.MapFile{
+			Data: []byte(`{
+				"tenants": {
+					"acme":   {"Name":"Acme", "Region":"west"},
+					"globex": {"Name":"Globex", "Region":"east"}
+				},
+				"shared": "value"
+			}`),
+			Mode: 0644,
+		},
+	}
+
+	tests := []struct {
+		description string
+		opts        []Option
+		expect      map[string]any
+		expectedErr error
+	}{
+		{
+			description: "Select acme",
+			opts:        []Option{SelectRoot("tenants.acme")},
+			expect:      map[string]any{"Name": "Acme", "Region": "west"},
+		}, {
+			description: "Select globex",
+			opts:        []Option{SelectRoot("tenants.globex", Required())},
+			expect:      map[string]any{"Name": "Globex", "Region": "east"},
+		}, {
+			description: "Select a missing tenant",
+			opts:        []Option{SelectRoot("tenants.initech")},
+			expectedErr: meta.ErrNotFound,
+		}, {
+			description: "Select a missing tenant that is optional",
+			opts:        []Option{SelectRoot("tenants.initech", Optional())},
+		}, {
+			description: "Select with an empty key",
+			opts:        []Option{SelectRoot("")},
+			expectedErr: ErrInvalidInput,
+		}, {
+			description: "Select with an invalid option",
+			opts:        []Option{SelectRoot("tenants.acme", Strictness("invalid"))},
+			expectedErr: ErrInvalidInput,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			opts := []Option{
+				WithDecoder(&testDecoder{extensions: []string{"json"}}),
+				AddFile(fs, "tenants.json"),
+			}
+			opts = append(opts, tc.opts...)
+
+			cfg, err := New(opts...)
+
+			if tc.expectedErr != nil {
+				assert.ErrorIs(err, tc.expectedErr)
+				return
+			}
+
+			require.NoError(err)
+			require.NotNil(cfg)
+
+			got, err := Unmarshal[map[string]any](cfg, Root)
+			require.NoError(err)
+			assert.Equal(tc.expect, got)
+		})
+	}
+}
+
+func TestAddValueAt(t *testing.T) {
+	tests := []struct {
+		description string
+		opts        []Option
+		key         string
+		expect      any
+	}{
+		{
+			description: "A path with the delimiter in a segment",
+			opts: []Option{
+				AddValueAt("record", []string{"hosts", "example.com"}, map[string]any{"Port": "443"}),
+			},
+			expect: map[string]any{
+				"hosts": map[string]any{
+					"example.com": map[string]any{"Port": "443"},
+				},
+			},
+		}, {
+			description: "A nil path places the value at the root",
+			opts: []Option{
+				AddValueAt("record", nil, map[string]any{"Port": "443"}),
+			},
+			expect: map[string]any{"Port": "443"},
+		}, {
+			description: "AddValueAt merges with AddValue",
+			opts: []Option{
+				AddValue("1", "a.b", "value"),
+				AddValueAt("2", []string{"a", "c.d"}, "other"),
+			},
+			expect: map[string]any{
+				"a": map[string]any{
+					"b":   "value",
+					"c.d": "other",
+				},
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			cfg, err := New(tc.opts...)
+			require.NoError(err)
+			require.NotNil(cfg)
+
+			got, err := Unmarshal[map[string]any](cfg, Root)
+			require.NoError(err)
+			assert.Equal(tc.expect, got)
+		})
+	}
+}
+
+func TestConditionalFileGroups(t *testing.T) {
+	fs := fstest.MapFS{
+		"base/1.json": &fstest.MapFile{
+			Data: []byte(`{"Platform":"any"}`),
+			Mode: 0644,
+		},
+		"windows.d/2.json": &fstest.MapFile{
+			Data: []byte(`{"Platform":"windows"}`),
+			Mode: 0644,
+		},
+	}
+
+	enabled := true
+	toggle := func() bool {
+		return enabled
+	}
+
+	tests := []struct {
+		description string
+		opt         Option
+		enabled     bool
+		expect      string
+		records     []string
+		skipped     []string
+	}{
+		{
+			description: "AddTreeIf with a true condition",
+			opt:         AddTreeIf(true, fs, "windows.d"),
+			expect:      "windows",
+			records:     []string{"1.json", "2.json"},
+			skipped:     []string{},
+		}, {
+			description: "AddTreeIf with a false condition",
+			opt:         AddTreeIf(false, fs, "windows.d"),
+			expect:      "any",
+			records:     []string{"1.json"},
+			skipped:     []string{"AddTreeIf( false, fs, 'windows.d' )"},
+		}, {
+			description: "When with a true condition",
+			opt:         AddTree(fs, "windows.d", When(toggle)),
+			enabled:     true,
+			expect:      "windows",
+			records:     []string{"1.json", "2.json"},
+			skipped:     []string{},
+		}, {
+			description: "When with a false condition",
+			opt:         AddTree(fs, "windows.d", When(toggle)),
+			enabled:     false,
+			expect:      "any",
+			records:     []string{"1.json"},
+			skipped:     []string{"AddTree( fs, 'windows.d', When(func) )"},
+		}, {
+			description: "When with a nil condition",
+			opt:         AddTree(fs, "windows.d", When(nil)),
+			expect:      "windows",
+			records:     []string{"1.json", "2.json"},
+			skipped:     []string{},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			enabled = tc.enabled
+
+			cfg, err := New(
+				WithDecoder(&testDecoder{extensions: []string{"json"}}),
+				AddTree(fs, "base"),
+				tc.opt,
+			)
+			require.NoError(err)
+			require.NotNil(cfg)
+
+			got, err := Unmarshal[string](cfg, "Platform")
+			require.NoError(err)
+			assert.Equal(tc.expect, got)
+			assert.Equal(tc.records, cfg.records)
+			assert.Equal(tc.skipped, cfg.Explain().SkippedFileGroups)
+		})
+	}
+}
+
+func TestHas(t *testing.T) {
+	tests := []struct {
+		description string
+		key         string
+		notCompiled bool
+		expect      bool
+	}{
+		{
+			description: "The root",
+			key:         Root,
+			expect:      true,
+		}, {
+			description: "A present key",
+			key:         "Foo.Bar",
+			expect:      true,
+		}, {
+			description: "A key set to null",
+			key:         "Null",
+			expect:      true,
+		}, {
+			description: "An array index",
+			key:         "List.1",
+			expect:      true,
+		}, {
+			description: "An absent key",
+			key:         "Foo.Missing",
+		}, {
+			description: "An array index that is out of bounds",
+			key:         "List.2",
+		}, {
+			description: "An array index that is invalid",
+			key:         "List.invalid",
+		}, {
+			description: "Not compiled",
+			key:         "Foo.Bar",
+			notCompiled: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			cfg, err := New(
+				WithDecoder(&testDecoder{extensions: []string{"json"}}),
+				AddBuffer("1.json", []byte(`{"Foo":{"Bar":"value"}, "Null":null, "List":["a","b"]}`)),
+				AutoCompile(!tc.notCompiled),
+			)
+			require.NoError(err)
+			require.NotNil(cfg)
+
+			assert.Equal(tc.expect, cfg.Has(tc.key))
+		})
+	}
+}
+
+func TestFailOnUnbalanced(t *testing.T) {
+	expander := ExpanderFunc(func(s string) (string, bool) {
+		if s == "FOO" {
+			return "foo", true
+		}
+		return "", false
+	})
+
+	tests := []struct {
+		description string
+		json        string
+		opts        []ExpandOption
+		expect      map[string]any
+		expectErr   error
+	}{
+		{
+			description: "Balanced values are expanded",
+			json:        `{"a":"${FOO}", "b":"${BAR}"}`,
+			opts:        []ExpandOption{FailOnUnbalanced()},
+			expect: map[string]any{
+				"a": "foo",
+				"b": "${BAR}",
+			},
+		}, {
+			description: "Unbalanced values are ignored by default",
+			json:        `{"a":"${FOO", "b":["${FOO}"]}`,
+			expect: map[string]any{
+				"a": "${FOO",
+				"b": []any{"foo"},
+			},
+		}, {
+			description: "Unbalanced values are ignored when disabled",
+			json:        `{"a":"${FOO"}`,
+			opts:        []ExpandOption{FailOnUnbalanced(false)},
+			expect: map[string]any{
+				"a": "${FOO",
+			},
+		}, {
+			description: "An unbalanced value is an error",
+			json:        `{"a":{"b":"prefix ${FOO} and ${FOO"}}`,
+			opts:        []ExpandOption{FailOnUnbalanced()},
+			expectErr:   ErrUnbalancedDelimiters,
+		}, {
+			description: "An unbalanced value in an array is an error",
+			json:        `{"a":["ok", "${FOO"]}`,
+			opts:        []ExpandOption{FailOnUnbalanced()},
+			expectErr:   ErrUnbalancedDelimiters,
+		}, {
+			description: "An unbalanced value with custom delimiters is an error",
+			json:        `{"a":"{{FOO} and ${FOO"}`,
+			opts:        []ExpandOption{WithDelimiters("{{", "}}"), FailOnUnbalanced()},
+			expectErr:   ErrUnbalancedDelimiters,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			cfg, err := New(
+				WithDecoder(&testDecoder{extensions: []string{"json"}}),
+				AddBuffer("1.json", []byte(tc.json)),
+				Expand(expander, tc.opts...),
+				AutoCompile(),
+			)
+
+			if tc.expectErr != nil {
+				assert.ErrorIs(err, tc.expectErr)
+				assert.Nil(cfg)
+				return
+			}
+
+			require.NoError(err)
+			require.NotNil(cfg)
+
+			got, err := Unmarshal[map[string]any](cfg, Root)
+			require.NoError(err)
+			assert.Equal(tc.expect, got)
+		})
+	}
+}
+
+func TestMerge(t *testing.T) {
+	newCfg := func(name, json string, opts ...Option) *Config {
+		full := append([]Option{
+			WithDecoder(&testDecoder{extensions: []string{"json"}}),
+			AddBuffer(name, []byte(json)),
+			AutoCompile(),
+		}, opts...)
+		cfg, err := New(full...)
+		if err != nil {
+			panic(err)
+		}
+		return cfg
+	}
+
+	notCompiled, err := New(AutoCompile(false))
+	require.NoError(t, err)
+
+	tests := []struct {
+		description string
+		configs     []*Config
+		expect      map[string]any
+		records     []string
+		originFile  string
+		expectedErr error
+	}{
+		{
+			description: "A single config",
+			configs:     []*Config{newCfg("1.json", `{"a":"1"}`)},
+			expect:      map[string]any{"a": "1"},
+			records:     []string{"1.json"},
+			originFile:  "1.json",
+		}, {
+			description: "The last value wins",
+			configs: []*Config{
+				newCfg("1.json", `{"a":"1", "b":{"c":"1"}}`),
+				newCfg("2.json", `{"a":"2", "b":{"d":"2"}}`),
+			},
+			expect: map[string]any{
+				"a": "2",
+				"b": map[string]any{"c": "1", "d": "2"},
+			},
+			records:    []string{"1.json", "2.json"},
+			originFile: "2.json",
+		}, {
+			description: "No configs",
+			expectedErr: ErrInvalidInput,
+		}, {
+			description: "A nil config",
+			configs:     []*Config{newCfg("1.json", `{"a":"1"}`), nil},
+			expectedErr: ErrInvalidInput,
+		}, {
+			description: "A config that isn't compiled",
+			configs:     []*Config{newCfg("1.json", `{"a":"1"}`), notCompiled},
+			expectedErr: ErrNotCompiled,
+		}, {
+			description: "Conflicting key delimiters",
+			configs: []*Config{
+				newCfg("1.json", `{"a":"1"}`),
+				newCfg("2.json", `{"a":"2"}`, SetKeyDelimiter("/")),
+			},
+			expectedErr: ErrInvalidInput,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			cfg, err := Merge(tc.configs...)
+
+			if tc.expectedErr != nil {
+				assert.ErrorIs(err, tc.expectedErr)
+				assert.Nil(cfg)
+				return
+			}
+
+			require.NoError(err)
+			require.NotNil(cfg)
+
+			got, err := Unmarshal[map[string]any](cfg, Root)
+			require.NoError(err)
+			assert.Equal(tc.expect, got)
+			assert.Equal(tc.records, cfg.records)
+			assert.False(cfg.CompiledAt().IsZero())
+
+			obj, err := cfg.tree.Fetch([]string{"a"}, ".")
+			require.NoError(err)
+			// The line numbers depend on the decoding order, so only the file
+			// is checked.
+			require.Len(obj.Origins, 1)
+			assert.Equal(tc.originFile, obj.Origins[0].File)
+		})
+	}
+}
+
+func TestSubConfig(t *testing.T) {
+	cfg, err := New(
+		WithDecoder(&testDecoder{extensions: []string{"json"}}),
+		AddBuffer("1.json", []byte(`{"db":{"host":"localhost","ports":["1","2"]},"name":"x"}`)),
+	)
+	require.NoError(t, err)
+
+	notCompiled, err := New(AutoCompile(false))
+	require.NoError(t, err)
+
+	tests := []struct {
+		description string
+		cfg         *Config
+		key         string
+		opts        []UnmarshalOption
+		expect      any
+		expectedErr error
+	}{
+		{
+			description: "A subtree.",
+			cfg:         cfg,
+			key:         "db",
+			expect: map[string]any{
+				"host":  "localhost",
+				"ports": []any{"1", "2"},
+			},
+		}, {
+			description: "The root.",
+			cfg:         cfg,
+			key:         Root,
+			expect: map[string]any{
+				"db": map[string]any{
+					"host":  "localhost",
+					"ports": []any{"1", "2"},
+				},
+				"name": "x",
+			},
+		}, {
+			description: "A missing key.",
+			cfg:         cfg,
+			key:         "missing",
+			expectedErr: meta.ErrNotFound,
+		}, {
+			description: "An optional missing key.",
+			cfg:         cfg,
+			key:         "missing",
+			opts:        []UnmarshalOption{Optional()},
+			expect:      map[string]any(nil),
+		}, {
+			description: "A config that isn't compiled.",
+			cfg:         notCompiled,
+			key:         "db",
+			expectedErr: ErrNotCompiled,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			sub, err := tc.cfg.SubConfig(tc.key, tc.opts...)
+
+			if tc.expectedErr != nil {
+				assert.ErrorIs(err, tc.expectedErr)
+				assert.Nil(sub)
+				return
+			}
+
+			require.NoError(err)
+			require.NotNil(sub)
+			assert.False(sub.CompiledAt().IsZero())
+			assert.Equal(tc.cfg.records, sub.records)
+
+			got, err := Unmarshal[map[string]any](sub, Root)
+			require.NoError(err)
+			assert.Equal(tc.expect, got)
+		})
+	}
+}
+
+func TestSubConfigIsASnapshot(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	cfg, err := New(
+		WithDecoder(&testDecoder{extensions: []string{"json"}}),
+		AddBuffer("1.json", []byte(`{"db":{"host":"localhost"}}`)),
+	)
+	require.NoError(err)
+
+	sub, err := cfg.SubConfig("db")
+	require.NoError(err)
+
+	require.NoError(cfg.With(AddValue("override", "db", map[string]any{"host": "remote"}), AutoCompile()))
+
+	got, err := Unmarshal[string](cfg, "db.host")
+	require.NoError(err)
+	assert.Equal("remote", got)
+
+	got, err = Unmarshal[string](sub, "host")
+	require.NoError(err)
+	assert.Equal("localhost", got)
+}
+
+func TestConfigWalk(t *testing.T) {
+	errStop := errors.New("stop")
+
+	type visit struct {
+		path   string
+		value  any
+		origin string
+	}
+
+	tests := []struct {
+		description string
+		notCompiled bool
+		nilFn       bool
+		stopAt      string
+		expect      []visit
+		expectedErr error
+	}{
+		{
+			description: "Walk the whole tree",
+			expect: []visit{
+				{path: "a", value: "1", origin: "1.json"},
+				{path: "b.c", value: "2", origin: "1.json"},
+				{path: "b.d.0", value: "3", origin: "1.json"},
+				{path: "b.d.1", value: "4", origin: "1.json"},
+				{path: "e", value: "5", origin: "2.json"},
+			},
+		}, {
+			description: "Stop the walk",
+			stopAt:      "b.d.0",
+			expect: []visit{
+				{path: "a", value: "1", origin: "1.json"},
+				{path: "b.c", value: "2", origin: "1.json"},
+				{path: "b.d.0", value: "3", origin: "1.json"},
+			},
+			expectedErr: errStop,
+		}, {
+			description: "Not compiled",
+			notCompiled: true,
+			expectedErr: ErrNotCompiled,
+		}, {
+			description: "A nil function",
+			nilFn:       true,
+			expectedErr: ErrInvalidInput,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			cfg, err := New(
+				WithDecoder(&testDecoder{extensions: []string{"json"}}),
+				AddBuffer("1.json", []byte(`{"e":"5", "a":"1", "b":{"d":["3","4"], "c":"2"}}`)),
+				AddBuffer("2.json", []byte(`{"e":"5"}`)),
+				AutoCompile(!tc.notCompiled),
+			)
+			require.NoError(err)
+
+			var got []visit
+			fn := func(path string, value any, origins []meta.Origin) error {
+				files := make([]string, len(origins))
+				for i, origin := range origins {
+					files[i] = origin.File
+				}
+				got = append(got, visit{
+					path:   path,
+					value:  value,
+					origin: strings.Join(files, ", "),
+				})
+				if path == tc.stopAt {
+					return errStop
+				}
+				return nil
+			}
+			if tc.nilFn {
+				fn = nil
+			}
+
+			err = cfg.Walk(fn)
+			assert.ErrorIs(err, tc.expectedErr)
+			assert.Equal(tc.expect, got)
+		})
+	}
+}
+
+func TestReload(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	type pair struct {
+		A int
+		B int
+	}
+
+	var count atomic.Int64
+	var fail atomic.Bool
+	block := make(chan struct{})
+	var blocking atomic.Bool
+
+	getter := ValueGetterFunc(func(string, Unmarshaler) (any, error) {
+		if blocking.Load() {
+			<-block
+		}
+		if fail.Load() {
+			return nil, errOpt
+		}
+		n := count.Add(1)
+		return pair{A: int(n), B: int(n)}, nil
+	})
+
+	type extra struct {
+		Name string
+	}
+
+	cfg, err := New(
+		AddValueGetter("record", Root, getter),
+		AddValue("value", "extra", extra{Name: "extra"}),
+		ConfigIs("two_words"),
+		AutoCompile(),
+	)
+	require.NoError(err)
+
+	got, err := Unmarshal[pair](cfg, Root)
+	require.NoError(err)
+	assert.Equal(pair{A: 1, B: 1}, got)
+
+	// A successful reload swaps the tree.
+	require.NoError(cfg.Reload())
+	got, err = Unmarshal[pair](cfg, Root)
+	require.NoError(err)
+	assert.Equal(pair{A: 2, B: 2}, got)
+	compiledAt := cfg.CompiledAt()
+
+	// A failed reload leaves the existing tree in place.
+	fail.Store(true)
+	assert.ErrorIs(cfg.Reload(), errOpt)
+	got, err = Unmarshal[pair](cfg, Root)
+	require.NoError(err)
+	assert.Equal(pair{A: 2, B: 2}, got)
+	assert.Equal(compiledAt, cfg.CompiledAt())
+	assert.NotEmpty(cfg.Explain().CompileErrors)
+	fail.Store(false)
+
+	// Readers are not blocked while a reload is in progress.
+	blocking.Store(true)
+	done := make(chan error)
+	go func() {
+		done <- cfg.Reload()
+	}()
+
+	got, err = Unmarshal[pair](cfg, Root)
+	require.NoError(err)
+	assert.Equal(pair{A: 2, B: 2}, got)
+
+	blocking.Store(false)
+	close(block)
+	require.NoError(<-done)
+
+	got, err = Unmarshal[pair](cfg, Root)
+	require.NoError(err)
+	assert.Equal(pair{A: 3, B: 3}, got)
+
+	// Concurrent readers always see a consistent tree.
+	var wg, started sync.WaitGroup
+	stop := make(chan struct{})
+	read := func() {
+		got, err := Unmarshal[pair](cfg, Root)
+		assert.NoError(err)
+		assert.Equal(got.A, got.B)
+
+		// The value record and the readers both report the key mappings.
+		e, err := Unmarshal[extra](cfg, "extra")
+		assert.NoError(err)
+		assert.Equal("extra", e.Name)
+	}
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		started.Add(1)
+		go func() {
+			defer wg.Done()
+			read()
+			started.Done()
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+				read()
+			}
+		}()
+	}
+
+	started.Wait()
+	for i := 0; i < 20; i++ {
+		assert.NoError(cfg.Reload())
+	}
+	close(stop)
+	wg.Wait()
+
+	got, err = Unmarshal[pair](cfg, Root)
+	require.NoError(err)
+	assert.Equal(pair{A: 23, B: 23}, got)
+}
+
+func TestAddStructDefaults(t *testing.T) {
+	type Inner struct {
+		Name  string `default:"inner"`
+		Count int
+	}
+	type Outer struct {
+		Host    string `default:"localhost"`
+		Port    int    `default:"8080"`
+		Verbose bool
+		Timeout time.Duration
+		Skipped string `goschtalt:"-" default:"skipped"`
+		Renamed string `goschtalt:"other" default:"renamed"`
+		Inner   Inner
+		Ptr     *Inner
+		Empty   Inner `goschtalt:"empty"`
+		private string
+	}
+	type Result struct {
+		Host    string
+		Port    int
+		Verbose bool
+		Timeout string
+		Other   string `goschtalt:"other"`
+		Inner   Inner
+		Ptr     *Inner
+		Extra   string
+	}
+
+	tests := []struct {
+		description string
+		val         any
+		opts        []Option
+		expect      Result
+		keys        []string
+		expectedErr error
+	}{
+		{
+			description: "Only tagged defaults",
+			val:         Outer{},
+			expect: Result{
+				Host:  "localhost",
+				Port:  8080,
+				Other: "renamed",
+				Inner: Inner{Name: "inner"},
+				Ptr:   &Inner{Name: "inner"},
+			},
+			keys: []string{"Host", "Inner", "Port", "Ptr", "empty", "other"},
+		}, {
+			description: "Non-zero values take precedence",
+			val: &Outer{
+				Port:    9090,
+				Verbose: true,
+				Inner:   Inner{Count: 3},
+				Ptr:     &Inner{Name: "ptr"},
+				private: "ignored",
+			},
+			expect: Result{
+				Host:    "localhost",
+				Port:    9090,
+				Verbose: true,
+				Other:   "renamed",
+				Inner:   Inner{Name: "inner", Count: 3},
+				Ptr:     &Inner{Name: "ptr"},
+			},
+			keys: []string{"Host", "Inner", "Port", "Ptr", "Verbose", "empty", "other"},
+		}, {
+			description: "Later values override the defaults",
+			val:         Outer{},
+			opts: []Option{
+				AddValue("record", "Extra", "extra"),
+				AddValue("record", "Host", "example.com"),
+			},
+			expect: Result{
+				Host:  "example.com",
+				Port:  8080,
+				Other: "renamed",
+				Inner: Inner{Name: "inner"},
+				Ptr:   &Inner{Name: "inner"},
+				Extra: "extra",
+			},
+			keys: []string{"Extra", "Host", "Inner", "Port", "Ptr", "empty", "other"},
+		}, {
+			description: "Not a struct",
+			val:         map[string]any{"Host": "localhost"},
+			expectedErr: ErrInvalidInput,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			opts := append([]Option{
+				AddStructDefaults("defaults", Root, tc.val),
+				AutoCompile(),
+			}, tc.opts...)
+
+			cfg, err := New(opts...)
+			if tc.expectedErr != nil {
+				assert.ErrorIs(err, tc.expectedErr)
+				assert.Nil(cfg)
+				return
+			}
+			require.NoError(err)
+
+			got, err := Unmarshal[Result](cfg, Root)
+			require.NoError(err)
+			assert.Equal(tc.expect, got)
+
+			var keys []string
+			for key := range cfg.tree.Map {
+				keys = append(keys, key)
+			}
+			sort.Strings(keys)
+			assert.Equal(tc.keys, keys)
+
+			assert.True(cfg.Explain().Records[0].Default)
+		})
+	}
+}
+
+func TestDefaultTOMLCodec(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	fs := fstest.MapFS{
+		"conf/1.toml": &fstest.MapFile{
+			Data: []byte("Name = 'example'\n\n[Server]\nPort = 80\n"),
+			Mode: 0755,
+		},
+		"conf/2.json": &fstest.MapFile{
+			Data: []byte(`{"Server": {"Host": "localhost"}}`),
+			Mode: 0755,
+		},
+	}
+
+	type Server struct {
+		Host string
+		Port int
+	}
+	type Config struct {
+		Name   string
+		Server Server
+	}
+
+	cfg, err := New(AddDir(fs, "conf"), AutoCompile())
+	require.NoError(err)
+
+	got, err := Unmarshal[Config](cfg, Root)
+	require.NoError(err)
+	assert.Equal(Config{
+		Name:   "example",
+		Server: Server{Host: "localhost", Port: 80},
+	}, got)
+
+	port := cfg.tree.Map["Server"].Map["Port"]
+	assert.Equal([]meta.Origin{{File: "1.toml", Line: 4, Col: 8}}, port.Origins)
+}
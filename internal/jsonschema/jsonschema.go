@@ -0,0 +1,774 @@
+// SPDX-FileCopyrightText: 2023 Weston Schmidt <weston_schmidt@alumni.purdue.edu>
+// SPDX-License-Identifier: Apache-2.0
+
+// Package jsonschema validates native go trees against a subset of JSON
+// Schema using only the standard library.
+//
+// The supported keywords are:
+//   - type, enum, const
+//   - properties, required, additionalProperties
+//   - items, minItems, maxItems
+//   - minimum, maximum, exclusiveMinimum, exclusiveMaximum
+//   - minLength, maxLength, pattern
+//   - allOf, anyOf, oneOf, not
+//   - $ref to a location in the same document, like "#/$defs/port"
+//
+// Other keywords are ignored.
+//
+// Since configuration values are often strings (for example from environment
+// variables or command line arguments), a string that can be parsed as a
+// number or boolean satisfies the "integer", "number" and "boolean" types and
+// is compared as that type.
+package jsonschema
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+var ErrInvalidSchema = errors.New("invalid schema")
+
+// Violation is a single place where the value doesn't match the schema.
+type Violation struct {
+	// Path is the list of keys (and array indexes) to the value.
+	Path []string
+
+	// Message describes the problem.
+	Message string
+}
+
+// Schema is a parsed schema that can be used to validate values.
+type Schema struct {
+	root *node
+}
+
+// node is a compiled schema or sub-schema.
+type node struct {
+	// always is set for the boolean schemas true and false.
+	always *bool
+
+	types    []string
+	enum     []any
+	constant *any
+
+	properties map[string]*node
+	required   []string
+	additional *node
+
+	items    *node
+	minItems *int
+	maxItems *int
+
+	minimum *float64
+	maximum *float64
+	exclMin *float64
+	exclMax *float64
+
+	minLength *int
+	maxLength *int
+	pattern   *regexp.Regexp
+
+	allOf []*node
+	anyOf []*node
+	oneOf []*node
+	not   *node
+
+	// ref is the location referred to, which is resolved after the whole
+	// document is compiled.
+	ref    string
+	target *node
+}
+
+// compiler keeps track of the references while compiling the document.
+type compiler struct {
+	doc     any
+	refs    map[string]*node
+	pending []*node
+}
+
+// Parse parses and compiles the JSON Schema document.  A $ref that leads back
+// to the schema containing it without descending into a property or an item
+// (like {"$ref": "#"}) is reported as an ErrInvalidSchema.
+func Parse(data []byte) (*Schema, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	var doc any
+	if err := dec.Decode(&doc); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidSchema, err) //nolint:errorlint
+	}
+
+	c := compiler{
+		doc:  doc,
+		refs: make(map[string]*node),
+	}
+
+	root, err := c.compile(doc, "#")
+	if err != nil {
+		return nil, err
+	}
+	c.refs["#"] = root
+
+	for len(c.pending) > 0 {
+		n := c.pending[0]
+		c.pending = c.pending[1:]
+
+		target, err := c.resolve(n.ref)
+		if err != nil {
+			return nil, err
+		}
+		n.target = target
+	}
+
+	if err := checkCycles(root); err != nil {
+		return nil, err
+	}
+
+	return &Schema{root: root}, nil
+}
+
+// checkCycles returns an error if a schema refers back to itself without
+// descending into a property or an item, since validating a value against it
+// would never finish.
+func checkCycles(root *node) error {
+	const (
+		visiting = iota + 1
+		done
+	)
+
+	// state tracks the nodes that apply to the same value as the node being
+	// checked, and stack is the path to the node being checked.
+	state := make(map[*node]int)
+	var stack []*node
+	var inPlace func(*node) error
+	inPlace = func(n *node) error {
+		switch state[n] {
+		case visiting:
+			// Report the first reference in the cycle.
+			i := len(stack) - 1
+			for stack[i] != n {
+				i--
+			}
+			ref := ""
+			for _, prior := range stack[i:] {
+				if prior.ref != "" {
+					ref = prior.ref
+					break
+				}
+			}
+			return fmt.Errorf("%w: the reference '%s' refers back to itself without a property or item",
+				ErrInvalidSchema, ref)
+		case done:
+			return nil
+		}
+
+		state[n] = visiting
+		stack = append(stack, n)
+		for _, next := range n.inPlace() {
+			if err := inPlace(next); err != nil {
+				return err
+			}
+		}
+		stack = stack[:len(stack)-1]
+		state[n] = done
+		return nil
+	}
+
+	seen := make(map[*node]bool)
+	var all func(*node) error
+	all = func(n *node) error {
+		if n == nil || seen[n] {
+			return nil
+		}
+		seen[n] = true
+
+		if err := inPlace(n); err != nil {
+			return err
+		}
+
+		next := append(n.inPlace(), n.additional, n.items)
+		for _, sub := range n.properties {
+			next = append(next, sub)
+		}
+		for _, sub := range next {
+			if err := all(sub); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	return all(root)
+}
+
+// inPlace returns the sub-schemas that are applied to the same value as the
+// node.
+func (n *node) inPlace() []*node {
+	var rv []*node
+	if n.target != nil {
+		rv = append(rv, n.target)
+	}
+	rv = append(rv, n.allOf...)
+	rv = append(rv, n.anyOf...)
+	rv = append(rv, n.oneOf...)
+	if n.not != nil {
+		rv = append(rv, n.not)
+	}
+	return rv
+}
+
+// resolve finds the node at the JSON pointer, compiling it if needed.
+func (c *compiler) resolve(ref string) (*node, error) {
+	if n, found := c.refs[ref]; found {
+		return n, nil
+	}
+
+	pointer, found := strings.CutPrefix(ref, "#")
+	if !found {
+		return nil, fmt.Errorf("%w: only references within the document are supported: '%s'",
+			ErrInvalidSchema, ref)
+	}
+
+	at := c.doc
+	if pointer != "" {
+		for _, token := range strings.Split(strings.TrimPrefix(pointer, "/"), "/") {
+			token = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+
+			switch v := at.(type) {
+			case map[string]any:
+				at, found = v[token]
+			case []any:
+				i, err := strconv.Atoi(token)
+				found = err == nil && i >= 0 && i < len(v)
+				if found {
+					at = v[i]
+				}
+			default:
+				found = false
+			}
+
+			if !found {
+				return nil, fmt.Errorf("%w: the reference '%s' was not found", ErrInvalidSchema, ref)
+			}
+		}
+	}
+
+	n, err := c.compile(at, ref)
+	if err != nil {
+		return nil, err
+	}
+	c.refs[ref] = n
+	return n, nil
+}
+
+// compile converts the raw schema into a node.
+func (c *compiler) compile(raw any, where string) (*node, error) {
+	var n node
+
+	switch v := raw.(type) {
+	case bool:
+		n.always = &v
+		return &n, nil
+	case map[string]any:
+		if err := c.keywords(&n, v, where); err != nil {
+			return nil, err
+		}
+		return &n, nil
+	}
+
+	return nil, fmt.Errorf("%w: '%s' must be an object or boolean", ErrInvalidSchema, where)
+}
+
+func (c *compiler) keywords(n *node, m map[string]any, where string) error {
+	var err error
+	invalid := func(keyword, want string) error {
+		return fmt.Errorf("%w: '%s/%s' must be %s", ErrInvalidSchema, where, keyword, want)
+	}
+
+	if v, found := m["$ref"]; found {
+		ref, ok := v.(string)
+		if !ok {
+			return invalid("$ref", "a string")
+		}
+		n.ref = ref
+		c.pending = append(c.pending, n)
+	}
+
+	if v, found := m["type"]; found {
+		switch t := v.(type) {
+		case string:
+			n.types = []string{t}
+		case []any:
+			for _, item := range t {
+				s, ok := item.(string)
+				if !ok {
+					return invalid("type", "a string or an array of strings")
+				}
+				n.types = append(n.types, s)
+			}
+		default:
+			return invalid("type", "a string or an array of strings")
+		}
+	}
+
+	if v, found := m["enum"]; found {
+		list, ok := v.([]any)
+		if !ok {
+			return invalid("enum", "an array")
+		}
+		n.enum = list
+	}
+
+	if v, found := m["const"]; found {
+		n.constant = &v
+	}
+
+	if v, found := m["properties"]; found {
+		props, ok := v.(map[string]any)
+		if !ok {
+			return invalid("properties", "an object")
+		}
+		n.properties = make(map[string]*node, len(props))
+		for key, val := range props {
+			n.properties[key], err = c.compile(val, where+"/properties/"+key)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	if v, found := m["required"]; found {
+		list, ok := v.([]any)
+		if !ok {
+			return invalid("required", "an array of strings")
+		}
+		for _, item := range list {
+			s, ok := item.(string)
+			if !ok {
+				return invalid("required", "an array of strings")
+			}
+			n.required = append(n.required, s)
+		}
+	}
+
+	subs := []struct {
+		keyword string
+		dst     **node
+	}{
+		{keyword: "additionalProperties", dst: &n.additional},
+		{keyword: "items", dst: &n.items},
+		{keyword: "not", dst: &n.not},
+	}
+	for _, sub := range subs {
+		if v, found := m[sub.keyword]; found {
+			*sub.dst, err = c.compile(v, where+"/"+sub.keyword)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	lists := []struct {
+		keyword string
+		dst     *[]*node
+	}{
+		{keyword: "allOf", dst: &n.allOf},
+		{keyword: "anyOf", dst: &n.anyOf},
+		{keyword: "oneOf", dst: &n.oneOf},
+	}
+	for _, list := range lists {
+		v, found := m[list.keyword]
+		if !found {
+			continue
+		}
+		items, ok := v.([]any)
+		if !ok || len(items) == 0 {
+			return invalid(list.keyword, "a non-empty array")
+		}
+		for i, item := range items {
+			sub, err := c.compile(item, where+"/"+list.keyword+"/"+strconv.Itoa(i))
+			if err != nil {
+				return err
+			}
+			*list.dst = append(*list.dst, sub)
+		}
+	}
+
+	ints := []struct {
+		keyword string
+		dst     **int
+	}{
+		{keyword: "minItems", dst: &n.minItems},
+		{keyword: "maxItems", dst: &n.maxItems},
+		{keyword: "minLength", dst: &n.minLength},
+		{keyword: "maxLength", dst: &n.maxLength},
+	}
+	for _, i := range ints {
+		if v, found := m[i.keyword]; found {
+			num, ok := v.(json.Number)
+			val, err := num.Int64()
+			if !ok || err != nil || val < 0 {
+				return invalid(i.keyword, "a non-negative integer")
+			}
+			tmp := int(val)
+			*i.dst = &tmp
+		}
+	}
+
+	floats := []struct {
+		keyword string
+		dst     **float64
+	}{
+		{keyword: "minimum", dst: &n.minimum},
+		{keyword: "maximum", dst: &n.maximum},
+		{keyword: "exclusiveMinimum", dst: &n.exclMin},
+		{keyword: "exclusiveMaximum", dst: &n.exclMax},
+	}
+	for _, f := range floats {
+		if v, found := m[f.keyword]; found {
+			num, ok := v.(json.Number)
+			val, err := num.Float64()
+			if !ok || err != nil {
+				return invalid(f.keyword, "a number")
+			}
+			*f.dst = &val
+		}
+	}
+
+	if v, found := m["pattern"]; found {
+		s, ok := v.(string)
+		if !ok {
+			return invalid("pattern", "a string")
+		}
+		n.pattern, err = regexp.Compile(s)
+		if err != nil {
+			return invalid("pattern", "a valid regular expression")
+		}
+	}
+
+	return nil
+}
+
+// Validate checks the value against the schema and returns all the places
+// where it doesn't match, sorted by path.  The value is a tree of
+// map[string]any, []any and values like the ones produced by
+// meta.Object.ToRaw().
+func (s *Schema) Validate(v any) []Violation {
+	list := s.root.validate(v, nil)
+	sort.SliceStable(list, func(i, j int) bool {
+		return strings.Join(list[i].Path, "\x00") < strings.Join(list[j].Path, "\x00")
+	})
+	return list
+}
+
+func (n *node) validate(v any, path []string) []Violation {
+	var rv []Violation
+	fail := func(format string, args ...any) {
+		rv = append(rv, Violation{
+			Path:    path,
+			Message: fmt.Sprintf(format, args...),
+		})
+	}
+
+	if n.always != nil {
+		if !*n.always {
+			fail("is not allowed")
+		}
+		return rv
+	}
+
+	if n.target != nil {
+		rv = append(rv, n.target.validate(v, path)...)
+	}
+
+	if len(n.types) > 0 && !matchesAnyType(v, n.types) {
+		fail("must be of type %s", strings.Join(n.types, " or "))
+		// The other checks are not useful if the type is wrong.
+		return rv
+	}
+
+	if n.enum != nil {
+		found := false
+		for _, want := range n.enum {
+			if equal(v, want) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			fail("must be one of the enumerated values")
+		}
+	}
+
+	if n.constant != nil && !equal(v, *n.constant) {
+		fail("must be %v", *n.constant)
+	}
+
+	switch val := v.(type) {
+	case map[string]any:
+		rv = append(rv, n.validateMap(val, path)...)
+	case []any:
+		rv = append(rv, n.validateArray(val, path)...)
+	case string, time.Time:
+		n.validateString(toString(val), fail)
+	}
+
+	if f, ok := toFloat(v); ok {
+		n.validateNumber(f, fail)
+	}
+
+	for _, sub := range n.allOf {
+		rv = append(rv, sub.validate(v, path)...)
+	}
+
+	if n.anyOf != nil && countMatches(n.anyOf, v, path) == 0 {
+		fail("must match at least one schema in anyOf")
+	}
+
+	if n.oneOf != nil && countMatches(n.oneOf, v, path) != 1 {
+		fail("must match exactly one schema in oneOf")
+	}
+
+	if n.not != nil && len(n.not.validate(v, path)) == 0 {
+		fail("must not match the schema in not")
+	}
+
+	return rv
+}
+
+func (n *node) validateMap(m map[string]any, path []string) []Violation {
+	var rv []Violation
+
+	for _, key := range n.required {
+		if _, found := m[key]; !found {
+			rv = append(rv, Violation{
+				Path:    append(path[:len(path):len(path)], key),
+				Message: "is required",
+			})
+		}
+	}
+
+	for key, val := range m {
+		full := append(path[:len(path):len(path)], key)
+		if sub, found := n.properties[key]; found {
+			rv = append(rv, sub.validate(val, full)...)
+			continue
+		}
+		if n.additional != nil {
+			if n.additional.always != nil && !*n.additional.always {
+				rv = append(rv, Violation{
+					Path:    full,
+					Message: "is not an allowed property",
+				})
+				continue
+			}
+			rv = append(rv, n.additional.validate(val, full)...)
+		}
+	}
+
+	return rv
+}
+
+func (n *node) validateArray(a []any, path []string) []Violation {
+	var rv []Violation
+
+	if n.minItems != nil && len(a) < *n.minItems {
+		rv = append(rv, Violation{
+			Path:    path,
+			Message: fmt.Sprintf("must have at least %d items", *n.minItems),
+		})
+	}
+	if n.maxItems != nil && len(a) > *n.maxItems {
+		rv = append(rv, Violation{
+			Path:    path,
+			Message: fmt.Sprintf("must have at most %d items", *n.maxItems),
+		})
+	}
+
+	if n.items != nil {
+		for i, val := range a {
+			rv = append(rv, n.items.validate(val, append(path[:len(path):len(path)], strconv.Itoa(i)))...)
+		}
+	}
+
+	return rv
+}
+
+func (n *node) validateString(s string, fail func(string, ...any)) {
+	length := utf8.RuneCountInString(s)
+	if n.minLength != nil && length < *n.minLength {
+		fail("must be at least %d characters long", *n.minLength)
+	}
+	if n.maxLength != nil && length > *n.maxLength {
+		fail("must be at most %d characters long", *n.maxLength)
+	}
+	if n.pattern != nil && !n.pattern.MatchString(s) {
+		fail("must match the pattern '%s'", n.pattern.String())
+	}
+}
+
+func (n *node) validateNumber(f float64, fail func(string, ...any)) {
+	if n.minimum != nil && f < *n.minimum {
+		fail("must be >= %v", *n.minimum)
+	}
+	if n.maximum != nil && f > *n.maximum {
+		fail("must be <= %v", *n.maximum)
+	}
+	if n.exclMin != nil && f <= *n.exclMin {
+		fail("must be > %v", *n.exclMin)
+	}
+	if n.exclMax != nil && f >= *n.exclMax {
+		fail("must be < %v", *n.exclMax)
+	}
+}
+
+// countMatches returns how many of the schemas the value matches.
+func countMatches(list []*node, v any, path []string) int {
+	var count int
+	for _, sub := range list {
+		if len(sub.validate(v, path)) == 0 {
+			count++
+		}
+	}
+	return count
+}
+
+// matchesAnyType returns if the value is one of the JSON types.
+func matchesAnyType(v any, types []string) bool {
+	for _, t := range types {
+		if matchesType(v, t) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchesType(v any, t string) bool {
+	switch t {
+	case "null":
+		return v == nil
+	case "object":
+		_, ok := v.(map[string]any)
+		return ok
+	case "array":
+		_, ok := v.([]any)
+		return ok
+	case "string":
+		switch v.(type) {
+		case string, time.Time:
+			return true
+		}
+		return false
+	case "boolean":
+		switch b := v.(type) {
+		case bool:
+			return true
+		case string:
+			_, err := strconv.ParseBool(b)
+			return err == nil
+		}
+		return false
+	case "number":
+		_, ok := toFloat(v)
+		return ok
+	case "integer":
+		f, ok := toFloat(v)
+		return ok && f == math.Trunc(f) && !math.IsInf(f, 0)
+	}
+	return false
+}
+
+// toFloat returns the value as a float64 if it is a number or a string that
+// can be parsed as a number.
+func toFloat(v any) (float64, bool) {
+	switch n := v.(type) {
+	case json.Number:
+		f, err := n.Float64()
+		return f, err == nil
+	case string:
+		f, err := strconv.ParseFloat(strings.TrimSpace(n), 64)
+		return f, err == nil && !math.IsNaN(f)
+	}
+
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(rv.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return float64(rv.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return rv.Float(), true
+	}
+
+	return 0, false
+}
+
+func toString(v any) string {
+	if t, ok := v.(time.Time); ok {
+		return t.Format(time.RFC3339Nano)
+	}
+	return v.(string)
+}
+
+// equal compares the value to a value from the schema.  A string value is
+// parsed as a number or boolean when compared to one.
+func equal(v, want any) bool {
+	switch w := want.(type) {
+	case nil:
+		return v == nil
+	case bool:
+		switch b := v.(type) {
+		case bool:
+			return b == w
+		case string:
+			got, err := strconv.ParseBool(b)
+			return err == nil && got == w
+		}
+		return false
+	case json.Number:
+		wf, _ := w.Float64()
+		got, ok := toFloat(v)
+		return ok && got == wf
+	case string:
+		switch s := v.(type) {
+		case string, time.Time:
+			return toString(s) == w
+		}
+		return false
+	case []any:
+		got, ok := v.([]any)
+		if !ok || len(got) != len(w) {
+			return false
+		}
+		for i := range w {
+			if !equal(got[i], w[i]) {
+				return false
+			}
+		}
+		return true
+	case map[string]any:
+		got, ok := v.(map[string]any)
+		if !ok || len(got) != len(w) {
+			return false
+		}
+		for key, val := range w {
+			g, found := got[key]
+			if !found || !equal(g, val) {
+				return false
+			}
+		}
+		return true
+	}
+
+	return false
+}
@@ -0,0 +1,440 @@
+// SPDX-FileCopyrightText: 2023 Weston Schmidt <weston_schmidt@alumni.purdue.edu>
+// SPDX-License-Identifier: Apache-2.0
+
+package jsonschema
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		description string
+		schema      string
+		expectErr   error
+	}{
+		{
+			description: "A boolean schema.",
+			schema:      `true`,
+		}, {
+			description: "All the keywords.",
+			schema: `{
+				"$defs": {"port": {"type": "integer"}},
+				"type": ["object", "null"],
+				"enum": [null, {}],
+				"const": {},
+				"properties": {"port": {"$ref": "#/$defs/port"}},
+				"required": ["port"],
+				"additionalProperties": false,
+				"items": {},
+				"minItems": 1,
+				"maxItems": 2,
+				"minimum": 1,
+				"maximum": 2,
+				"exclusiveMinimum": 0,
+				"exclusiveMaximum": 3,
+				"minLength": 1,
+				"maxLength": 2,
+				"pattern": "^a",
+				"allOf": [true],
+				"anyOf": [true],
+				"oneOf": [true],
+				"not": false
+			}`,
+		}, {
+			description: "Invalid json.",
+			schema:      `{`,
+			expectErr:   ErrInvalidSchema,
+		}, {
+			description: "Not an object.",
+			schema:      `"string"`,
+			expectErr:   ErrInvalidSchema,
+		}, {
+			description: "An invalid type.",
+			schema:      `{"type": 1}`,
+			expectErr:   ErrInvalidSchema,
+		}, {
+			description: "An invalid type list.",
+			schema:      `{"type": ["string", 1]}`,
+			expectErr:   ErrInvalidSchema,
+		}, {
+			description: "An invalid enum.",
+			schema:      `{"enum": 1}`,
+			expectErr:   ErrInvalidSchema,
+		}, {
+			description: "Invalid properties.",
+			schema:      `{"properties": []}`,
+			expectErr:   ErrInvalidSchema,
+		}, {
+			description: "An invalid property.",
+			schema:      `{"properties": {"a": 1}}`,
+			expectErr:   ErrInvalidSchema,
+		}, {
+			description: "An invalid required.",
+			schema:      `{"required": [1]}`,
+			expectErr:   ErrInvalidSchema,
+		}, {
+			description: "An invalid items.",
+			schema:      `{"items": 1}`,
+			expectErr:   ErrInvalidSchema,
+		}, {
+			description: "An empty anyOf.",
+			schema:      `{"anyOf": []}`,
+			expectErr:   ErrInvalidSchema,
+		}, {
+			description: "An invalid oneOf entry.",
+			schema:      `{"oneOf": [1]}`,
+			expectErr:   ErrInvalidSchema,
+		}, {
+			description: "A negative minLength.",
+			schema:      `{"minLength": -1}`,
+			expectErr:   ErrInvalidSchema,
+		}, {
+			description: "An invalid maximum.",
+			schema:      `{"maximum": "1"}`,
+			expectErr:   ErrInvalidSchema,
+		}, {
+			description: "An invalid pattern.",
+			schema:      `{"pattern": "("}`,
+			expectErr:   ErrInvalidSchema,
+		}, {
+			description: "An invalid $ref.",
+			schema:      `{"$ref": 1}`,
+			expectErr:   ErrInvalidSchema,
+		}, {
+			description: "A remote $ref.",
+			schema:      `{"$ref": "http://example.com/schema.json"}`,
+			expectErr:   ErrInvalidSchema,
+		}, {
+			description: "A missing $ref.",
+			schema:      `{"$ref": "#/$defs/missing"}`,
+			expectErr:   ErrInvalidSchema,
+		}, {
+			description: "A $ref to an invalid schema.",
+			schema:      `{"$defs": {"a": 1}, "$ref": "#/$defs/a"}`,
+			expectErr:   ErrInvalidSchema,
+		}, {
+			description: "A $ref to the root.",
+			schema:      `{"$ref": "#"}`,
+			expectErr:   ErrInvalidSchema,
+		}, {
+			description: "A $ref to the property containing it.",
+			schema:      `{"properties": {"a": {"$ref": "#/properties/a"}}}`,
+			expectErr:   ErrInvalidSchema,
+		}, {
+			description: "A $ref to an ancestor.",
+			schema:      `{"properties": {"a": {"allOf": [{"$ref": "#/properties/a"}]}}}`,
+			expectErr:   ErrInvalidSchema,
+		}, {
+			description: "A $ref cycle between definitions.",
+			schema:      `{"$defs": {"a": {"$ref": "#/$defs/b"}, "b": {"not": {"$ref": "#/$defs/a"}}}, "$ref": "#/$defs/a"}`,
+			expectErr:   ErrInvalidSchema,
+		}, {
+			description: "A recursive schema.",
+			schema:      `{"properties": {"child": {"$ref": "#"}}, "items": {"$ref": "#"}}`,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			assert := assert.New(t)
+
+			got, err := Parse([]byte(tc.schema))
+
+			if tc.expectErr != nil {
+				assert.ErrorIs(err, tc.expectErr)
+				assert.Nil(got)
+				return
+			}
+
+			assert.NoError(err)
+			assert.NotNil(got)
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		description string
+		schema      string
+		in          any
+		want        []Violation
+	}{
+		{
+			description: "The true schema.",
+			schema:      `true`,
+			in:          "anything",
+		}, {
+			description: "The false schema.",
+			schema:      `false`,
+			in:          "anything",
+			want:        []Violation{{Message: "is not allowed"}},
+		}, {
+			description: "Types.",
+			schema: `{"properties": {
+				"null":    {"type": "null"},
+				"object":  {"type": "object"},
+				"array":   {"type": "array"},
+				"string":  {"type": "string"},
+				"time":    {"type": "string"},
+				"bool":    {"type": "boolean"},
+				"boolStr": {"type": "boolean"},
+				"number":  {"type": "number"},
+				"numStr":  {"type": "number"},
+				"integer": {"type": "integer"},
+				"intF":    {"type": "integer"},
+				"intStr":  {"type": "integer"},
+				"uint":    {"type": ["string", "integer"]}
+			}}`,
+			in: map[string]any{
+				"null":    nil,
+				"object":  map[string]any{},
+				"array":   []any{},
+				"string":  "s",
+				"time":    time.Now(),
+				"bool":    true,
+				"boolStr": "false",
+				"number":  1.5,
+				"numStr":  "-2.5",
+				"integer": 3,
+				"intF":    4.0,
+				"intStr":  "5",
+				"uint":    uint8(6),
+			},
+		}, {
+			description: "Wrong types.",
+			schema: `{"properties": {
+				"null":    {"type": "null"},
+				"object":  {"type": "object"},
+				"array":   {"type": "array"},
+				"string":  {"type": "string"},
+				"bool":    {"type": "boolean"},
+				"number":  {"type": "number"},
+				"integer": {"type": "integer"},
+				"unknown": {"type": "unknown"}
+			}}`,
+			in: map[string]any{
+				"null":    "null",
+				"object":  []any{},
+				"array":   map[string]any{},
+				"string":  1,
+				"bool":    "yes please",
+				"number":  "seven",
+				"integer": 1.5,
+				"unknown": "x",
+			},
+			want: []Violation{
+				{Path: []string{"array"}, Message: "must be of type array"},
+				{Path: []string{"bool"}, Message: "must be of type boolean"},
+				{Path: []string{"integer"}, Message: "must be of type integer"},
+				{Path: []string{"null"}, Message: "must be of type null"},
+				{Path: []string{"number"}, Message: "must be of type number"},
+				{Path: []string{"object"}, Message: "must be of type object"},
+				{Path: []string{"string"}, Message: "must be of type string"},
+				{Path: []string{"unknown"}, Message: "must be of type unknown"},
+			},
+		}, {
+			description: "Enum and const.",
+			schema: `{"properties": {
+				"level":  {"enum": ["info", "debug"]},
+				"bad":    {"enum": ["info", "debug"]},
+				"port":   {"enum": [80, 443]},
+				"flag":   {"const": true},
+				"none":   {"const": null},
+				"list":   {"const": [1, "a"]},
+				"map":    {"const": {"a": 1}},
+				"badMap": {"const": {"a": 1}}
+			}}`,
+			in: map[string]any{
+				"level":  "debug",
+				"bad":    "trace",
+				"port":   "443",
+				"flag":   "true",
+				"none":   nil,
+				"list":   []any{1, "a"},
+				"map":    map[string]any{"a": "1"},
+				"badMap": map[string]any{"a": 1, "b": 2},
+			},
+			want: []Violation{
+				{Path: []string{"bad"}, Message: "must be one of the enumerated values"},
+				{Path: []string{"badMap"}, Message: "must be map[a:1]"},
+			},
+		}, {
+			description: "Objects.",
+			schema: `{
+				"type": "object",
+				"required": ["name", "port"],
+				"properties": {
+					"name": {"type": "string"},
+					"server": {
+						"properties": {"host": {"type": "string"}},
+						"additionalProperties": false
+					},
+					"labels": {"additionalProperties": {"type": "string"}}
+				}
+			}`,
+			in: map[string]any{
+				"name": "example",
+				"server": map[string]any{
+					"host":  "localhost",
+					"extra": 1,
+				},
+				"labels": map[string]any{
+					"a": "b",
+					"c": 1,
+				},
+			},
+			want: []Violation{
+				{Path: []string{"labels", "c"}, Message: "must be of type string"},
+				{Path: []string{"port"}, Message: "is required"},
+				{Path: []string{"server", "extra"}, Message: "is not an allowed property"},
+			},
+		}, {
+			description: "Arrays.",
+			schema: `{"properties": {
+				"short": {"minItems": 2},
+				"long":  {"maxItems": 1},
+				"ports": {"items": {"type": "integer", "maximum": 65535}}
+			}}`,
+			in: map[string]any{
+				"short": []any{1},
+				"long":  []any{1, 2},
+				"ports": []any{80, 70000, "x"},
+			},
+			want: []Violation{
+				{Path: []string{"long"}, Message: "must have at most 1 items"},
+				{Path: []string{"ports", "1"}, Message: "must be <= 65535"},
+				{Path: []string{"ports", "2"}, Message: "must be of type integer"},
+				{Path: []string{"short"}, Message: "must have at least 2 items"},
+			},
+		}, {
+			description: "Numbers.",
+			schema: `{"properties": {
+				"min":     {"minimum": 1},
+				"max":     {"maximum": 1},
+				"exclMin": {"exclusiveMinimum": 1},
+				"exclMax": {"exclusiveMaximum": 1},
+				"ok":      {"minimum": 1, "maximum": 2}
+			}}`,
+			in: map[string]any{
+				"min":     0,
+				"max":     "2",
+				"exclMin": 1,
+				"exclMax": 1.0,
+				"ok":      int64(2),
+			},
+			want: []Violation{
+				{Path: []string{"exclMax"}, Message: "must be < 1"},
+				{Path: []string{"exclMin"}, Message: "must be > 1"},
+				{Path: []string{"max"}, Message: "must be <= 1"},
+				{Path: []string{"min"}, Message: "must be >= 1"},
+			},
+		}, {
+			description: "Strings.",
+			schema: `{"properties": {
+				"short":   {"minLength": 2},
+				"long":    {"maxLength": 2},
+				"pattern": {"pattern": "^[a-z]+$"},
+				"ok":      {"minLength": 2, "maxLength": 2, "pattern": "é"}
+			}}`,
+			in: map[string]any{
+				"short":   "a",
+				"long":    "abc",
+				"pattern": "ABC",
+				"ok":      "é!",
+			},
+			want: []Violation{
+				{Path: []string{"long"}, Message: "must be at most 2 characters long"},
+				{Path: []string{"pattern"}, Message: "must match the pattern '^[a-z]+$'"},
+				{Path: []string{"short"}, Message: "must be at least 2 characters long"},
+			},
+		}, {
+			description: "Combinations.",
+			schema: `{"properties": {
+				"all":     {"allOf": [{"type": "integer"}, {"minimum": 5}]},
+				"any":     {"anyOf": [{"type": "boolean"}, {"type": "null"}]},
+				"anyOk":   {"anyOf": [{"type": "boolean"}, {"type": "null"}]},
+				"one":     {"oneOf": [{"type": "number"}, {"type": "integer"}]},
+				"oneOk":   {"oneOf": [{"type": "number"}, {"type": "null"}]},
+				"not":     {"not": {"type": "string"}},
+				"notOk":   {"not": {"type": "string"}}
+			}}`,
+			in: map[string]any{
+				"all":   3,
+				"any":   []any{},
+				"anyOk": nil,
+				"one":   1,
+				"oneOk": 1.5,
+				"not":   "s",
+				"notOk": true,
+			},
+			want: []Violation{
+				{Path: []string{"all"}, Message: "must be >= 5"},
+				{Path: []string{"any"}, Message: "must match at least one schema in anyOf"},
+				{Path: []string{"not"}, Message: "must not match the schema in not"},
+				{Path: []string{"one"}, Message: "must match exactly one schema in oneOf"},
+			},
+		}, {
+			description: "References.",
+			schema: `{
+				"definitions": {
+					"port": {"type": "integer", "minimum": 1},
+					"a~b/c": {"type": "string"},
+					"node": {
+						"properties": {
+							"name": {"type": "string"},
+							"child": {"$ref": "#/definitions/node"}
+						}
+					}
+				},
+				"properties": {
+					"http": {"$ref": "#/definitions/port"},
+					"https": {"$ref": "#/definitions/port"},
+					"escaped": {"$ref": "#/definitions/a~0b~1c"},
+					"tree": {"$ref": "#/definitions/node"},
+					"first": {"$ref": "#/properties/list/allOf/0"},
+					"list": {"allOf": [{"type": "string"}]},
+					"root": {"$ref": "#"}
+				}
+			}`,
+			in: map[string]any{
+				"http":    80,
+				"https":   0,
+				"escaped": 1,
+				"first":   1,
+				"tree": map[string]any{
+					"name": "a",
+					"child": map[string]any{
+						"name": 1,
+					},
+				},
+				"root": map[string]any{
+					"http": "x",
+				},
+			},
+			want: []Violation{
+				{Path: []string{"escaped"}, Message: "must be of type string"},
+				{Path: []string{"first"}, Message: "must be of type string"},
+				{Path: []string{"https"}, Message: "must be >= 1"},
+				{Path: []string{"root", "http"}, Message: "must be of type integer"},
+				{Path: []string{"tree", "child", "name"}, Message: "must be of type string"},
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			s, err := Parse([]byte(tc.schema))
+			require.NoError(err)
+
+			assert.Equal(tc.want, s.Validate(tc.in))
+		})
+	}
+}
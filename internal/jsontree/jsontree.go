@@ -0,0 +1,186 @@
+// SPDX-FileCopyrightText: 2023 Weston Schmidt <weston_schmidt@alumni.purdue.edu>
+// SPDX-License-Identifier: Apache-2.0
+
+// Package jsontree converts JSON documents into meta.Object trees including
+// the line and column origins of each value.
+package jsontree
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+
+	"github.com/goschtalt/goschtalt/pkg/decoder"
+	"github.com/goschtalt/goschtalt/pkg/meta"
+)
+
+var ErrTrailingData = errors.New("unexpected data after the top level value")
+
+// Decode converts the JSON document into a meta.Object tree where each Object
+// has the file, line and column it originated from.  An empty document (or
+// one containing only whitespace) results in an empty Object.
+func Decode(file string, b []byte) (meta.Object, error) {
+	return decode(file, b, false)
+}
+
+// DecodeUniqueKeys is the same as Decode except a key that is repeated in the
+// same JSON object is an error wrapping decoder.ErrDuplicateKey.  The error
+// includes the key and the origin of the repeated key.
+func DecodeUniqueKeys(file string, b []byte) (meta.Object, error) {
+	return decode(file, b, true)
+}
+
+func decode(file string, b []byte, unique bool) (meta.Object, error) {
+	if len(bytes.TrimSpace(b)) == 0 {
+		return meta.Object{}, nil
+	}
+
+	p := parser{
+		file:   file,
+		buf:    b,
+		lines:  lineStarts(b),
+		dec:    json.NewDecoder(bytes.NewReader(b)),
+		unique: unique,
+	}
+	p.dec.UseNumber()
+
+	obj, err := p.value()
+	if err != nil {
+		return meta.Object{}, err
+	}
+
+	if _, err = p.dec.Token(); !errors.Is(err, io.EOF) {
+		return meta.Object{}, ErrTrailingData
+	}
+
+	return obj, nil
+}
+
+// lineStarts returns the offsets of the start of every line.
+func lineStarts(b []byte) []int {
+	starts := []int{0}
+	for i, c := range b {
+		if c == '\n' {
+			starts = append(starts, i+1)
+		}
+	}
+	return starts
+}
+
+type parser struct {
+	file  string
+	buf   []byte
+	lines []int
+	dec   *json.Decoder
+
+	// unique means repeated keys in an object are an error.
+	unique bool
+}
+
+// origin returns the origin of the next token in the stream.
+func (p *parser) origin() []meta.Origin {
+	off := int(p.dec.InputOffset())
+	for off < len(p.buf) && bytes.IndexByte([]byte(" \t\r\n,:"), p.buf[off]) >= 0 {
+		off++
+	}
+
+	line := sort.Search(len(p.lines), func(i int) bool {
+		return p.lines[i] > off
+	})
+
+	return []meta.Origin{{
+		File: p.file,
+		Line: line,
+		Col:  off - p.lines[line-1] + 1,
+	}}
+}
+
+// value parses the next value in the stream, recursing as needed.
+func (p *parser) value() (meta.Object, error) {
+	origins := p.origin()
+
+	tok, err := p.dec.Token()
+	if err != nil {
+		return meta.Object{}, err
+	}
+
+	switch t := tok.(type) {
+	case json.Delim:
+		if t == '{' {
+			return p.object(origins)
+		}
+		return p.array(origins)
+	case json.Number:
+		return meta.Object{Origins: origins, Value: number(t)}, nil
+	default:
+		return meta.Object{Origins: origins, Value: t}, nil
+	}
+}
+
+func (p *parser) object(origins []meta.Origin) (meta.Object, error) {
+	obj := meta.Object{
+		Origins: origins,
+		Map:     make(map[string]meta.Object),
+	}
+
+	for p.dec.More() {
+		at := p.origin()
+		tok, err := p.dec.Token()
+		if err != nil {
+			return meta.Object{}, err
+		}
+
+		key, _ := tok.(string)
+		if _, found := obj.Map[key]; found && p.unique {
+			return meta.Object{}, fmt.Errorf("%w: '%s' at %s", decoder.ErrDuplicateKey, key, at[0])
+		}
+		val, err := p.value()
+		if err != nil {
+			return meta.Object{}, err
+		}
+		obj.Map[key] = val
+	}
+
+	// Consume the closing delimiter.
+	if _, err := p.dec.Token(); err != nil {
+		return meta.Object{}, err
+	}
+
+	return obj, nil
+}
+
+func (p *parser) array(origins []meta.Origin) (meta.Object, error) {
+	obj := meta.Object{
+		Origins: origins,
+		Array:   []meta.Object{},
+	}
+
+	for p.dec.More() {
+		val, err := p.value()
+		if err != nil {
+			return meta.Object{}, err
+		}
+		obj.Array = append(obj.Array, val)
+	}
+
+	// Consume the closing delimiter.
+	if _, err := p.dec.Token(); err != nil {
+		return meta.Object{}, err
+	}
+
+	return obj, nil
+}
+
+// number converts the number into an int if possible, otherwise a float64.
+func number(n json.Number) any {
+	if i, err := strconv.ParseInt(string(n), 10, 0); err == nil {
+		return int(i)
+	}
+
+	f, _ := n.Float64()
+	return f
+}
@@ -0,0 +1,148 @@
+// SPDX-FileCopyrightText: 2023 Weston Schmidt <weston_schmidt@alumni.purdue.edu>
+// SPDX-License-Identifier: Apache-2.0
+
+package jsontree
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/goschtalt/goschtalt/pkg/decoder"
+	"github.com/goschtalt/goschtalt/pkg/meta"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var errUnknown = errors.New("unknown error")
+
+func TestDecode(t *testing.T) {
+	tests := []struct {
+		description string
+		in          string
+		want        meta.Object
+		expectErr   error
+	}{
+		{
+			description: "An empty document.",
+			in:          " \n\t",
+		}, {
+			description: "A value.",
+			in:          `  "a"`,
+			want: meta.Object{
+				Origins: []meta.Origin{{File: "f", Line: 1, Col: 3}},
+				Value:   "a",
+			},
+		}, {
+			description: "Numbers.",
+			in:          "[1,\n 1.5,\n 12345678901234567890]",
+			want: meta.Object{
+				Origins: []meta.Origin{{File: "f", Line: 1, Col: 1}},
+				Array: []meta.Object{
+					{
+						Origins: []meta.Origin{{File: "f", Line: 1, Col: 2}},
+						Value:   1,
+					}, {
+						Origins: []meta.Origin{{File: "f", Line: 2, Col: 2}},
+						Value:   1.5,
+					}, {
+						Origins: []meta.Origin{{File: "f", Line: 3, Col: 2}},
+						Value:   1.2345678901234567e+19,
+					},
+				},
+			},
+		}, {
+			description: "A map.",
+			in:          "{\n\t\"a\": {\"b\": null}\n}",
+			want: meta.Object{
+				Origins: []meta.Origin{{File: "f", Line: 1, Col: 1}},
+				Map: map[string]meta.Object{
+					"a": {
+						Origins: []meta.Origin{{File: "f", Line: 2, Col: 7}},
+						Map: map[string]meta.Object{
+							"b": {
+								Origins: []meta.Origin{{File: "f", Line: 2, Col: 13}},
+							},
+						},
+					},
+				},
+			},
+		}, {
+			description: "Trailing data.",
+			in:          `{} {}`,
+			expectErr:   ErrTrailingData,
+		}, {
+			description: "Invalid document.",
+			in:          `{"a"}`,
+			expectErr:   errUnknown,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			got, err := Decode("f", []byte(tc.in))
+
+			if tc.expectErr != nil {
+				if tc.expectErr != errUnknown {
+					assert.ErrorIs(err, tc.expectErr)
+				}
+				assert.Error(err)
+				return
+			}
+
+			require.NoError(err)
+			assert.Equal(tc.want, got)
+		})
+	}
+}
+
+func TestDecodeUniqueKeys(t *testing.T) {
+	tests := []struct {
+		description string
+		in          string
+		want        any
+		errText     string
+	}{
+		{
+			description: "No duplicates.",
+			in:          `{"a": {"b": 1}, "c": [{"b": 2}, {"b": 3}]}`,
+			want: map[string]any{
+				"a": map[string]any{"b": 1},
+				"c": []any{
+					map[string]any{"b": 2},
+					map[string]any{"b": 3},
+				},
+			},
+		}, {
+			description: "A duplicate key.",
+			in:          "{\n  \"a\": 1,\n  \"a\": 2\n}",
+			errText:     "duplicate key: 'a' at f:3[3]",
+		}, {
+			description: "A nested duplicate key.",
+			in:          `{"a": [{"b": 1, "c": 2, "b": 3}]}`,
+			errText:     "duplicate key: 'b' at f:1[25]",
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			got, err := DecodeUniqueKeys("f", []byte(tc.in))
+
+			if tc.errText != "" {
+				assert.ErrorIs(err, decoder.ErrDuplicateKey)
+				assert.EqualError(err, tc.errText)
+
+				// Without the check the last value is used.
+				_, err = Decode("f", []byte(tc.in))
+				assert.NoError(err)
+				return
+			}
+
+			require.NoError(err)
+			assert.Equal(tc.want, got.ToRaw())
+		})
+	}
+}
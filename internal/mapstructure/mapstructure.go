@@ -173,6 +173,8 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+
+	structtags "github.com/goschtalt/goschtalt/internal/structs"
 )
 
 // DecodeHookFunc is the callback function that can be used for
@@ -1369,6 +1371,7 @@ func (d *Decoder) decodeStructFromMap(name string, dataVal, val reflect.Value) e
 	}
 
 	targetValKeysUnused := make(map[interface{}]struct{})
+	var requiredMissing []string
 	var errs []error
 
 	// This slice will keep track of all the structs we'll be decoding.
@@ -1478,6 +1481,9 @@ func (d *Decoder) decodeStructFromMap(name string, dataVal, val reflect.Value) e
 				// There was no matching key in the map for the value in
 				// the struct. Remember it for potential errors and metadata.
 				targetValKeysUnused[fieldName] = struct{}{}
+				if _, opts := structtags.ParseTag(field.Tag.Get(d.config.TagName)); opts.Has("required") {
+					requiredMissing = append(requiredMissing, fieldName)
+				}
 				continue
 			}
 		}
@@ -1537,6 +1543,13 @@ func (d *Decoder) decodeStructFromMap(name string, dataVal, val reflect.Value) e
 		errs = append(errs, ErrDecoding, err)
 	}
 
+	if len(requiredMissing) > 0 {
+		sort.Strings(requiredMissing)
+
+		err := fmt.Errorf("'%s' is missing required fields: %s", name, strings.Join(requiredMissing, ", "))
+		errs = append(errs, ErrDecoding, err)
+	}
+
 	if d.config.ErrorUnset && len(targetValKeysUnused) > 0 {
 		keys := make([]string, 0, len(targetValKeysUnused))
 		for rawKey := range targetValKeysUnused {
@@ -8,6 +8,7 @@ package mapstructure
 
 import (
 	"encoding/json"
+	"errors"
 	"io"
 	"reflect"
 	"sort"
@@ -1412,6 +1413,62 @@ func TestDecoder_ErrorUnset(t *testing.T) {
 	}
 }
 
+func TestDecoder_Required(t *testing.T) {
+	t.Parallel()
+
+	type Required struct {
+		Name  string `mapstructure:"name,required"`
+		Port  int    `mapstructure:"port,required"`
+		Extra string `mapstructure:"extra"`
+	}
+
+	tests := []struct {
+		name  string
+		input map[string]interface{}
+		err   string
+	}{
+		{
+			name:  "all present",
+			input: map[string]interface{}{"name": "app", "port": 80, "extra": "x"},
+		}, {
+			name:  "optional missing",
+			input: map[string]interface{}{"name": "app", "port": 80},
+		}, {
+			name:  "one missing",
+			input: map[string]interface{}{"name": "app", "extra": "x"},
+			err:   "'' is missing required fields: port",
+		}, {
+			name:  "all missing",
+			input: map[string]interface{}{"extra": "x"},
+			err:   "'' is missing required fields: name, port",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var result Required
+			err := Decode(tc.input, &result)
+
+			if tc.err == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %s", err)
+				}
+				return
+			}
+
+			if err == nil {
+				t.Fatal("expected error")
+			}
+			if !errors.Is(err, ErrDecoding) {
+				t.Fatalf("expected ErrDecoding, got: %s", err)
+			}
+			if !strings.Contains(err.Error(), tc.err) {
+				t.Fatalf("expected error containing %q, got: %s", tc.err, err)
+			}
+		})
+	}
+}
+
 func TestMap(t *testing.T) {
 	t.Parallel()
 
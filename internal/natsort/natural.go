@@ -21,7 +21,7 @@ func determineNumberBlock(s string, val byte, max int, pos *int) int {
 	num_len := 1
 	for *pos+num_len < max {
 		x := s[*pos+num_len]
-		if val == '0' {
+		if val == '0' && isDigit(x) {
 			*pos += 1
 			val = x
 			continue
@@ -42,7 +42,9 @@ func determineNumberBlock(s string, val byte, max int, pos *int) int {
 // like 2 integers separated by the '.' rune.  See the test file for a list
 // examples.
 //
-// Note that any leading 0 values are dropped from the number.
+// Note that any leading 0 values are dropped from the number.  A 0 that is
+// the last digit of a number is kept, so "0_a" is the number 0 followed by
+// "_a".
 func Compare(a, b string) bool {
 	len_a := len(a)
 	len_b := len(b)
@@ -158,6 +158,28 @@ func TestSort(t *testing.T) {
 				"99_mine.yml",
 				"100_alpha.yml",
 			},
+		}, {
+			description: "Test a zero followed by something other than a digit.",
+			want: []string{
+				"list[0]",
+				"list[1]",
+				"list[2]",
+				"list[9]",
+				"list[10]",
+				"list[0010]x",
+				"list[11]",
+				"list[20]",
+			},
+		}, {
+			description: "Test records starting with a zero.",
+			want: []string{
+				"00_v.json",
+				"0_x.json",
+				"0a.yml",
+				"1_y.json",
+				"9_z.json",
+				"10_w.json",
+			},
 		}, {
 			description: "Floating point numbers... don't use them.",
 			want: []string{
@@ -118,7 +118,7 @@ func (s *Struct) FillMap(out map[string]interface{}) { //nolint:gocognit
 		isSubStruct := false
 		var finalVal interface{}
 
-		tagName, tagOpts := parseTag(field.Tag.Get(s.TagName))
+		tagName, tagOpts := ParseTag(field.Tag.Get(s.TagName))
 		if tagName != "" {
 			name = tagName
 		}
@@ -198,7 +198,7 @@ func (s *Struct) Values() []interface{} {
 	for _, field := range fields {
 		val := s.value.FieldByName(field.Name)
 
-		_, tagOpts := parseTag(field.Tag.Get(s.TagName))
+		_, tagOpts := ParseTag(field.Tag.Get(s.TagName))
 
 		// if the value is a zero value and the field is marked as omitempty do
 		// not include
@@ -340,7 +340,7 @@ func (s *Struct) IsZero() bool {
 	for _, field := range fields {
 		val := s.value.FieldByName(field.Name)
 
-		_, tagOpts := parseTag(field.Tag.Get(s.TagName))
+		_, tagOpts := ParseTag(field.Tag.Get(s.TagName))
 
 		if IsStruct(val.Interface()) && !tagOpts.Has("omitnested") {
 			ok := IsZero(val.Interface())
@@ -387,7 +387,7 @@ func (s *Struct) HasZero() bool {
 	for _, field := range fields {
 		val := s.value.FieldByName(field.Name)
 
-		_, tagOpts := parseTag(field.Tag.Get(s.TagName))
+		_, tagOpts := ParseTag(field.Tag.Get(s.TagName))
 
 		if IsStruct(val.Interface()) && !tagOpts.Has("omitnested") {
 			ok := HasZero(val.Interface())
@@ -8,11 +8,11 @@ package structs
 
 import "strings"
 
-// tagOptions contains a slice of tag options
-type tagOptions []string
+// TagOptions contains a slice of tag options
+type TagOptions []string
 
-// Has returns true if the given option is available in tagOptions
-func (t tagOptions) Has(opt string) bool {
+// Has returns true if the given option is available in TagOptions
+func (t TagOptions) Has(opt string) bool {
 	for _, tagOpt := range t {
 		if tagOpt == opt {
 			return true
@@ -22,10 +22,10 @@ func (t tagOptions) Has(opt string) bool {
 	return false
 }
 
-// parseTag splits a struct field's tag into its name and a list of options
+// ParseTag splits a struct field's tag into its name and a list of options
 // which comes after a name. A tag is in the form of: "name,option1,option2".
 // The name can be neglectected.
-func parseTag(tag string) (string, tagOptions) {
+func ParseTag(tag string) (string, TagOptions) {
 	// tag is one of followings:
 	// ""
 	// "name"
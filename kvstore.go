@@ -0,0 +1,190 @@
+// SPDX-FileCopyrightText: 2023 Weston Schmidt <weston_schmidt@alumni.purdue.edu>
+// SPDX-License-Identifier: Apache-2.0
+
+package goschtalt
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/goschtalt/goschtalt/internal/print"
+	"github.com/goschtalt/goschtalt/pkg/meta"
+)
+
+// KVReader provides the methods needed to read configuration from a key-value
+// store like Consul or etcd.  Keys are '/' separated paths.
+type KVReader interface {
+	// List returns the full keys of all the entries that begin with the
+	// specified prefix.
+	List(prefix string) ([]string, error)
+
+	// Get returns the value for the specified full key.
+	Get(key string) ([]byte, error)
+}
+
+// AddKVStore adds the entries found in a key-value store under the specified
+// prefix for inclusion when compiling the configuration.  The prefix is
+// treated as a directory, so a prefix of "app" reads the keys under "app/" but
+// not the keys under "apple/".  The prefix is removed from each key and the
+// remainder is split on '/' to produce a nested configuration tree.  All values
+// are added as strings.  Directory entries (keys ending in '/') and keys that
+// end up empty after removing the prefix are skipped.  A key with a value that
+// is also the parent of other keys (like "app/db" and "app/db/host") is an
+// error since it can't be both a value and a map.
+//
+// The recordName field is used for sorting this configuration value relative
+// to other configuration values.
+//
+// The KVReader is called each time the configuration is compiled, allowing
+// the values returned to change if desired.
+//
+// Valid Option Types:
+//   - [BufferOption]
+//   - [BufferValueOption]
+//   - [GlobalOption]
+func AddKVStore(recordName string, kv KVReader, prefix string, opts ...BufferOption) Option {
+	return &kvStore{
+		text:       print.P("AddKVStore", print.String(recordName), print.Obj(kv), print.String(prefix), print.LiteralStringers(opts)),
+		recordName: recordName,
+		kv:         kv,
+		prefix:     prefix,
+		opts:       opts,
+	}
+}
+
+type kvStore struct {
+	// The text to use when String() is called.
+	text string
+
+	// The record name.
+	recordName string
+
+	// The key-value store to read from.
+	kv KVReader
+
+	// The prefix of the keys to read.
+	prefix string
+
+	// Options that configure how this store is treated and processed.
+	opts []BufferOption
+}
+
+func (k kvStore) apply(opts *options) error {
+	if len(k.recordName) == 0 {
+		return fmt.Errorf("%w: a recordName with length > 0 must be specified.", ErrInvalidInput)
+	}
+
+	if k.kv == nil {
+		return fmt.Errorf("%w: a non-nil KVReader must be specified.", ErrInvalidInput)
+	}
+
+	r := record{
+		name: k.recordName,
+		kv:   &k,
+	}
+
+	var isDefault bool
+	for _, opt := range k.opts {
+		var info bufferOptions
+		if err := opt.bufferApply(&info); err != nil {
+			return err
+		}
+		isDefault = isDefault || info.isDefault
+		if info.priority != nil {
+			r.priority = info.priority
+		}
+	}
+
+	if isDefault {
+		opts.defaults = append(opts.defaults, r)
+		return nil
+	}
+
+	opts.values = append(opts.values, r)
+	return nil
+}
+
+func (_ kvStore) ignoreDefaults() bool {
+	return false
+}
+
+func (k kvStore) String() string {
+	return k.text
+}
+
+// toTree converts the key-value pairs into a meta.Object tree.  This will
+// happen during the compilation stage.
+func (k *kvStore) toTree() (meta.Object, error) {
+	var cfg bufferOptions
+	for _, opt := range k.opts {
+		if err := opt.bufferApply(&cfg); err != nil {
+			return meta.Object{}, err
+		}
+	}
+
+	// The prefix is a directory, so "app" doesn't match the keys of "apple".
+	prefix := k.prefix
+	if len(prefix) > 0 && !strings.HasSuffix(prefix, "/") {
+		prefix += "/"
+	}
+
+	keys, err := k.kv.List(prefix)
+	if err != nil {
+		return meta.Object{}, err
+	}
+
+	// Sort the keys so the resulting tree is deterministic.
+	sort.Strings(keys)
+
+	rels := make(map[string]string, len(keys))
+	for _, key := range keys {
+		// The directory entries aren't values.
+		if !strings.HasPrefix(key, prefix) || strings.HasSuffix(key, "/") {
+			continue
+		}
+
+		rel := strings.Trim(strings.TrimPrefix(key, prefix), "/")
+		if len(rel) == 0 {
+			continue
+		}
+		rels[rel] = key
+	}
+
+	tree := meta.Object{
+		Origins: []meta.Origin{{File: k.recordName}},
+		Map:     make(map[string]meta.Object),
+	}
+
+	for _, key := range keys {
+		rel := strings.Trim(strings.TrimPrefix(key, prefix), "/")
+		if rels[rel] != key {
+			continue
+		}
+
+		// A key can't be both a value and the parent of other values.
+		for parent := path.Dir(rel); parent != "."; parent = path.Dir(parent) {
+			if other, found := rels[parent]; found {
+				return meta.Object{}, fmt.Errorf("%w: the key '%s' has a value and is the parent of the key '%s'",
+					ErrDecoding, other, key)
+			}
+		}
+
+		val, err := k.kv.Get(key)
+		if err != nil {
+			return meta.Object{}, err
+		}
+
+		tree, err = tree.Add("/", rel, string(val), meta.Origin{File: key})
+		if err != nil {
+			return meta.Object{}, err
+		}
+	}
+
+	if len(cfg.origin) > 0 {
+		tree = stampOrigin(tree, cfg.origin)
+	}
+
+	return tree, nil
+}
@@ -0,0 +1,231 @@
+// SPDX-FileCopyrightText: 2023 Weston Schmidt <weston_schmidt@alumni.purdue.edu>
+// SPDX-License-Identifier: Apache-2.0
+
+package goschtalt
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type mockKV struct {
+	m       map[string]string
+	listErr error
+	getErr  error
+}
+
+func (m mockKV) List(prefix string) ([]string, error) {
+	if m.listErr != nil {
+		return nil, m.listErr
+	}
+
+	var rv []string
+	for k := range m.m {
+		if strings.HasPrefix(k, prefix) {
+			rv = append(rv, k)
+		}
+	}
+	return rv, nil
+}
+
+func (m mockKV) Get(key string) ([]byte, error) {
+	if m.getErr != nil {
+		return nil, m.getErr
+	}
+	return []byte(m.m[key]), nil
+}
+
+func TestAddKVStore(t *testing.T) {
+	testErr := errors.New("test error")
+	kv := mockKV{
+		m: map[string]string{
+			"app/":                "",
+			"app/name":            "example",
+			"app/http/port":       "8080",
+			"app/http/tls/enable": "true",
+			"other/name":          "ignored",
+			"apple/name":          "ignored",
+		},
+	}
+
+	tests := []struct {
+		description string
+		opts        []Option
+		expect      map[string]any
+		records     []string
+		expectedErr error
+	}{
+		{
+			description: "A simple store",
+			opts: []Option{
+				AddKVStore("record", kv, "app/"),
+			},
+			expect: map[string]any{
+				"name": "example",
+				"http": map[string]any{
+					"port": "8080",
+					"tls": map[string]any{
+						"enable": "true",
+					},
+				},
+			},
+			records: []string{"record"},
+		}, {
+			description: "A prefix without the trailing slash",
+			opts: []Option{
+				AddKVStore("record", kv, "app/http"),
+			},
+			expect: map[string]any{
+				"port": "8080",
+				"tls": map[string]any{
+					"enable": "true",
+				},
+			},
+			records: []string{"record"},
+		}, {
+			description: "An empty prefix reads everything",
+			opts: []Option{
+				AddKVStore("record", kv, ""),
+			},
+			expect: map[string]any{
+				"app": map[string]any{
+					"name": "example",
+					"http": map[string]any{
+						"port": "8080",
+						"tls": map[string]any{
+							"enable": "true",
+						},
+					},
+				},
+				"other": map[string]any{
+					"name": "ignored",
+				},
+				"apple": map[string]any{
+					"name": "ignored",
+				},
+			},
+			records: []string{"record"},
+		}, {
+			description: "A prefix only matches whole path segments",
+			opts: []Option{
+				AddKVStore("record", kv, "app"),
+			},
+			expect: map[string]any{
+				"name": "example",
+				"http": map[string]any{
+					"port": "8080",
+					"tls": map[string]any{
+						"enable": "true",
+					},
+				},
+			},
+			records: []string{"record"},
+		}, {
+			description: "A key that is a value and a parent",
+			opts: []Option{
+				AddKVStore("record", mockKV{
+					m: map[string]string{
+						"app/db":      "value",
+						"app/db-name": "name",
+						"app/db/host": "localhost",
+					},
+				}, "app"),
+			},
+			expectedErr: ErrDecoding,
+		}, {
+			description: "The store merges with other records",
+			opts: []Option{
+				AddKVStore("2", kv, "app/"),
+				AddValue("1", Root, map[string]any{"name": "overwritten", "extra": "value"}),
+			},
+			expect: map[string]any{
+				"name":  "example",
+				"extra": "value",
+				"http": map[string]any{
+					"port": "8080",
+					"tls": map[string]any{
+						"enable": "true",
+					},
+				},
+			},
+			records: []string{"1", "2"},
+		}, {
+			description: "The store as a default",
+			opts: []Option{
+				AddValue("1", Root, map[string]any{"name": "value"}),
+				AddKVStore("2", kv, "app/", AsDefault()),
+			},
+			expect: map[string]any{
+				"name": "value",
+				"http": map[string]any{
+					"port": "8080",
+					"tls": map[string]any{
+						"enable": "true",
+					},
+				},
+			},
+			records: []string{"2", "1"},
+		}, {
+			description: "A list error",
+			opts: []Option{
+				AddKVStore("record", mockKV{listErr: testErr}, "app/"),
+			},
+			expectedErr: testErr,
+		}, {
+			description: "A get error",
+			opts: []Option{
+				AddKVStore("record", mockKV{m: kv.m, getErr: testErr}, "app/"),
+			},
+			expectedErr: testErr,
+		}, {
+			description: "A missing record name",
+			opts: []Option{
+				AddKVStore("", kv, "app/"),
+			},
+			expectedErr: ErrInvalidInput,
+		}, {
+			description: "A missing KVReader",
+			opts: []Option{
+				AddKVStore("record", nil, "app/"),
+			},
+			expectedErr: ErrInvalidInput,
+		}, {
+			description: "An option error",
+			opts: []Option{
+				AddKVStore("record", kv, "app/", WithError(testErr)),
+			},
+			expectedErr: testErr,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			cfg, err := New(tc.opts...)
+
+			if tc.expectedErr != nil {
+				assert.ErrorIs(err, tc.expectedErr)
+				return
+			}
+
+			require.NoError(err)
+			require.NotNil(cfg)
+
+			got, err := Unmarshal[map[string]any](cfg, Root)
+			require.NoError(err)
+			assert.Equal(tc.expect, got)
+			assert.Equal(tc.records, cfg.records)
+		})
+	}
+}
+
+func TestAddKVStoreString(t *testing.T) {
+	opt := AddKVStore("record", mockKV{}, "app/", AsDefault())
+	assert.Equal(t, "AddKVStore( 'record', goschtalt.mockKV, 'app/', AsDefault() )", opt.String())
+}
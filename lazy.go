@@ -0,0 +1,203 @@
+// SPDX-FileCopyrightText: 2023 Weston Schmidt <weston_schmidt@alumni.purdue.edu>
+// SPDX-License-Identifier: Apache-2.0
+
+package goschtalt
+
+import (
+	"reflect"
+	"sync"
+
+	"github.com/goschtalt/goschtalt/internal/mapstructure"
+)
+
+// Lazy is a struct field type that defers converting the configuration value
+// into T until [Lazy.Get]() is called.  When a struct containing a Lazy field
+// is unmarshaled, the configuration value is kept and the adapters (like the
+// ones provided by [AdaptFromCfg]()) are not run until the value is needed.
+// This is useful for expensive conversions, like an adapter that fetches a
+// secret from a vault, that should only happen if the value is actually used.
+//
+// The result of the first call to Get() is cached and returned by all later
+// calls, including calls on copies of the Lazy.  Variables are expanded when
+// the configuration is compiled unless the expansion is [Deferred](), in which
+// case the variables in the value are expanded by the first call to Get().
+//
+// Example:
+//
+//	type Config struct {
+//		Password goschtalt.Lazy[string]
+//	}
+//
+//	...
+//
+//	password, err := cfg.Password.Get()
+type Lazy[T any] struct {
+	state *lazyState[T]
+}
+
+type lazyState[T any] struct {
+	once    sync.Once
+	resolve func() (T, error)
+	val     T
+	err     error
+}
+
+// Get returns the value, converting it the first time Get() is called.  If
+// the configuration value was not present the zero value of T is returned.
+func (l Lazy[T]) Get() (T, error) {
+	if l.state == nil {
+		var zero T
+		return zero, nil
+	}
+
+	l.state.once.Do(func() {
+		l.state.val, l.state.err = l.state.resolve()
+		l.state.resolve = nil
+	})
+
+	return l.state.val, l.state.err
+}
+
+// lazyFrom returns a new Lazy that uses the decode function to resolve the
+// value.
+func (_ Lazy[T]) lazyFrom(decode func(result any) error) any {
+	return Lazy[T]{
+		state: &lazyState[T]{
+			resolve: func() (T, error) {
+				var val T
+				err := decode(&val)
+				return val, err
+			},
+		},
+	}
+}
+
+// lazyValue is implemented by all the Lazy types.
+type lazyValue interface {
+	lazyFrom(decode func(result any) error) any
+}
+
+var lazyValueType = reflect.TypeOf((*lazyValue)(nil)).Elem()
+
+// isLazy returns if the destination is a Lazy.
+func isLazy(to reflect.Value) bool {
+	return to.IsValid() && to.Type().Implements(lazyValueType)
+}
+
+// containsLazy returns if the type is or contains a Lazy, so the lazy adapter
+// is only used when it is needed.
+func containsLazy(typ reflect.Type, seen map[reflect.Type]bool) bool {
+	if typ == nil {
+		return false
+	}
+	if typ.Implements(lazyValueType) {
+		return true
+	}
+	if found, ok := seen[typ]; ok {
+		return found
+	}
+	// Recursive types are assumed not to contain a Lazy until proven.
+	seen[typ] = false
+
+	var found bool
+	switch typ.Kind() {
+	case reflect.Pointer, reflect.Slice, reflect.Array:
+		found = containsLazy(typ.Elem(), seen)
+	case reflect.Map:
+		found = containsLazy(typ.Key(), seen) || containsLazy(typ.Elem(), seen)
+	case reflect.Struct:
+		for i := 0; i < typ.NumField() && !found; i++ {
+			found = containsLazy(typ.Field(i).Type, seen)
+		}
+	}
+
+	seen[typ] = found
+	return found
+}
+
+// deferHook wraps the decode hook so the variables of the deferred expansions
+// are expanded before the values are provided to the hook.  The values for a
+// Lazy are not expanded, so the expansion happens when the Lazy is resolved
+// using the same decode hook.
+func deferHook(hook func(reflect.Value, reflect.Value) (any, error), expand func(string) (string, error)) func(reflect.Value, reflect.Value) (any, error) {
+	return func(from, to reflect.Value) (any, error) {
+		if !from.IsValid() || !from.CanInterface() || isLazy(to) {
+			return hook(from, to)
+		}
+
+		switch {
+		case from.Kind() == reflect.String:
+		case to.Kind() == reflect.Interface && to.IsNil():
+			// The maps and arrays are assigned to an empty interface as is,
+			// without visiting the values they hold.
+		default:
+			return hook(from, to)
+		}
+
+		v, err := expandRaw(from.Interface(), expand)
+		if err != nil {
+			return nil, err
+		}
+
+		return hook(reflect.ValueOf(v), to)
+	}
+}
+
+// expandRaw returns the value with the variables in the strings expanded.
+func expandRaw(v any, expand func(string) (string, error)) (any, error) {
+	switch v := v.(type) {
+	case string:
+		return expand(v)
+	case map[string]any:
+		if v == nil {
+			return v, nil
+		}
+		m := make(map[string]any, len(v))
+		for key, val := range v {
+			got, err := expandRaw(val, expand)
+			if err != nil {
+				return nil, err
+			}
+			m[key] = got
+		}
+		return m, nil
+	case []any:
+		if v == nil {
+			return v, nil
+		}
+		a := make([]any, len(v))
+		for i, val := range v {
+			got, err := expandRaw(val, expand)
+			if err != nil {
+				return nil, err
+			}
+			a[i] = got
+		}
+		return a, nil
+	}
+	return v, nil
+}
+
+// adaptLazy returns an adapter that captures the configuration value for a
+// Lazy field so it can be decoded using the same decoder configuration when
+// the value is needed.
+func adaptLazy(cfg *mapstructure.DecoderConfig) adapter {
+	return func(from, to reflect.Value) (any, error) {
+		if !from.IsValid() || !from.CanInterface() || !isLazy(to) || from.Type() == to.Type() {
+			return nil, ErrNotApplicable
+		}
+
+		raw := from.Interface()
+		dc := *cfg
+		decode := func(result any) error {
+			dc.Result = result
+			decoder, err := mapstructure.NewDecoder(&dc)
+			if err != nil {
+				return err
+			}
+			return decoder.Decode(raw)
+		}
+
+		return reflect.Zero(to.Type()).Interface().(lazyValue).lazyFrom(decode), nil
+	}
+}
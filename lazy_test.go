@@ -0,0 +1,227 @@
+// SPDX-FileCopyrightText: 2023 Weston Schmidt <weston_schmidt@alumni.purdue.edu>
+// SPDX-License-Identifier: Apache-2.0
+
+package goschtalt
+
+import (
+	"errors"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLazy(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	type server struct {
+		Host string
+		Port int
+	}
+
+	type config struct {
+		Name     string
+		Password Lazy[string]
+		Bad      Lazy[string]
+		Timeout  Lazy[time.Duration]
+		Server   Lazy[server]
+		Ports    Lazy[[]int]
+		Missing  Lazy[string]
+	}
+
+	var calls atomic.Int32
+	vault := AdapterFromCfgFunc(func(from, to reflect.Value) (any, error) {
+		if from.Kind() != reflect.String || to.Kind() != reflect.String {
+			return nil, ErrNotApplicable
+		}
+
+		name, found := strings.CutPrefix(from.String(), "vault:")
+		if !found {
+			return nil, ErrNotApplicable
+		}
+
+		calls.Add(1)
+		if name == "bad" {
+			return nil, errors.New("vault error")
+		}
+		return "secret-" + name, nil
+	})
+
+	g, err := New(
+		AddValue("record", Root, map[string]any{
+			"Name":     "example",
+			"Password": "vault:db",
+			"Bad":      "vault:bad",
+			"Timeout":  "5s",
+			"Server": map[string]any{
+				"Host": "localhost",
+				"Port": "8080",
+			},
+			"Ports": []any{"80", "443"},
+		}),
+		DefaultUnmarshalOptions(
+			AdaptFromCfg(vault),
+			AdaptFromCfg(AdapterFromCfgFunc(func(from, to reflect.Value) (any, error) {
+				if from.Kind() != reflect.String || to.Type() != reflect.TypeOf(time.Duration(0)) {
+					return nil, ErrNotApplicable
+				}
+				return time.ParseDuration(from.String())
+			})),
+			AdaptFromCfg(AdapterFromCfgFunc(func(from, to reflect.Value) (any, error) {
+				if from.Kind() != reflect.String || to.Kind() != reflect.Int {
+					return nil, ErrNotApplicable
+				}
+				return strconv.Atoi(from.String())
+			})),
+		),
+	)
+	require.NoError(err)
+
+	got, err := Unmarshal[config](g, Root)
+	require.NoError(err)
+	assert.Equal("example", got.Name)
+
+	// Nothing is resolved until it is used.
+	assert.Equal(int32(0), calls.Load())
+
+	copied := got
+
+	pw, err := got.Password.Get()
+	assert.NoError(err)
+	assert.Equal("secret-db", pw)
+	assert.Equal(int32(1), calls.Load())
+
+	// The value is cached, including for copies.
+	pw, err = copied.Password.Get()
+	assert.NoError(err)
+	assert.Equal("secret-db", pw)
+	assert.Equal(int32(1), calls.Load())
+
+	// Errors are reported and cached.
+	_, err = got.Bad.Get()
+	assert.ErrorIs(err, ErrAdaptFailure)
+	_, err = got.Bad.Get()
+	assert.ErrorIs(err, ErrAdaptFailure)
+	assert.Equal(int32(2), calls.Load())
+
+	timeout, err := got.Timeout.Get()
+	assert.NoError(err)
+	assert.Equal(5*time.Second, timeout)
+
+	s, err := got.Server.Get()
+	assert.NoError(err)
+	assert.Equal(server{Host: "localhost", Port: 8080}, s)
+
+	ports, err := got.Ports.Get()
+	assert.NoError(err)
+	assert.Equal([]int{80, 443}, ports)
+
+	missing, err := got.Missing.Get()
+	assert.NoError(err)
+	assert.Equal("", missing)
+}
+
+func TestLazyZeroValue(t *testing.T) {
+	assert := assert.New(t)
+
+	var l Lazy[int]
+	got, err := l.Get()
+	assert.NoError(err)
+	assert.Equal(0, got)
+}
+
+func TestLazyDeferred(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	type config struct {
+		Name     string
+		Password Lazy[string]
+		Labels   map[string]any
+	}
+
+	var calls []string
+	vault := ExpanderFunc(func(s string) (string, bool) {
+		calls = append(calls, s)
+		switch s {
+		case "name":
+			return "example", true
+		case "secret:db":
+			return "hunter2", true
+		}
+		return "", false
+	})
+
+	g, err := New(
+		AddValue("record", Root, map[string]any{
+			"Name":     "${name}",
+			"Password": "${secret:db}",
+			"Labels": map[string]any{
+				"team": "${name}",
+			},
+		}),
+		Expand(vault, Deferred()),
+	)
+	require.NoError(err)
+
+	// Nothing is expanded when compiled.
+	assert.Empty(calls)
+	assert.Empty(g.Warnings())
+
+	got, err := Unmarshal[config](g, Root)
+	require.NoError(err)
+	assert.Equal("example", got.Name)
+	assert.Equal(map[string]any{"team": "example"}, got.Labels)
+	assert.Equal([]string{"name", "name"}, calls)
+
+	// The Lazy is expanded when it is read.
+	pw, err := got.Password.Get()
+	assert.NoError(err)
+	assert.Equal("hunter2", pw)
+	assert.Equal([]string{"name", "name", "secret:db"}, calls)
+
+	pw, err = got.Password.Get()
+	assert.NoError(err)
+	assert.Equal("hunter2", pw)
+	assert.Len(calls, 3)
+}
+
+func TestContainsLazy(t *testing.T) {
+	type recursive struct {
+		Next *recursive
+		Name string
+	}
+	type withLazy struct {
+		Next *withLazy
+		Key  Lazy[string]
+	}
+
+	tests := []struct {
+		description string
+		in          any
+		want        bool
+	}{
+		{description: "nil", in: nil},
+		{description: "A string", in: ""},
+		{description: "A Lazy", in: Lazy[int]{}, want: true},
+		{description: "A pointer to a Lazy", in: &Lazy[int]{}, want: true},
+		{description: "A map of Lazy", in: map[string]Lazy[int]{}, want: true},
+		{description: "A slice of Lazy", in: []Lazy[int]{}, want: true},
+		{description: "An array of Lazy", in: [1]Lazy[int]{}, want: true},
+		{description: "A recursive struct", in: &recursive{}},
+		{description: "A recursive struct with a Lazy", in: &withLazy{}, want: true},
+		{description: "An empty interface", in: new(any)},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			assert.Equal(t, tc.want, containsLazy(reflect.TypeOf(tc.in), make(map[reflect.Type]bool)))
+		})
+	}
+}
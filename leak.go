@@ -0,0 +1,280 @@
+// SPDX-FileCopyrightText: 2023 Weston Schmidt <weston_schmidt@alumni.purdue.edu>
+// SPDX-License-Identifier: Apache-2.0
+
+package goschtalt
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/goschtalt/goschtalt/internal/print"
+	"github.com/goschtalt/goschtalt/pkg/meta"
+)
+
+// The default patterns used to find values that look like secrets.
+var defaultLeakPatterns = []string{
+	`-----BEGIN [A-Z ]*PRIVATE KEY-----`,
+	`\bAKIA[0-9A-Z]{16}\b`,
+}
+
+const (
+	defaultLeakMinLength  = 20
+	defaultLeakMinEntropy = 4.0
+)
+
+// LeakOption provides the means to configure how [DetectLeakedSecrets]()
+// finds values that look like secrets.
+type LeakOption interface {
+	fmt.Stringer
+
+	leakApply(*leakDetector) error
+}
+
+// DetectLeakedSecrets scans the compiled configuration for string values that
+// look like secrets but are not marked as secrets.  Values marked as secrets
+// are not examined.  A value looks like a secret if it matches one of the
+// patterns or if it is a long string with no whitespace and a high entropy,
+// like a random token or password.
+//
+// Each value that looks like a secret is reported as a [Warning] unless the
+// [LeakIsError]() option is provided, then the compilation fails.
+//
+// Valid Option Types:
+//   - [LeakOption]
+//
+// # Default
+//
+// Leaked secrets are not detected.  When enabled the defaults are:
+//   - The patterns match PEM private key headers and AWS access key ids.
+//   - Strings at least 20 characters long with at least 4.0 bits of entropy
+//     per character look like secrets.
+//   - Leaked secrets are reported as warnings.
+func DetectLeakedSecrets(opts ...LeakOption) Option {
+	d := leakDetector{
+		minLength:  defaultLeakMinLength,
+		minEntropy: defaultLeakMinEntropy,
+	}
+
+	for _, pattern := range defaultLeakPatterns {
+		d.patterns = append(d.patterns, regexp.MustCompile(pattern))
+	}
+
+	for _, opt := range opts {
+		if opt != nil {
+			if err := opt.leakApply(&d); err != nil {
+				return WithError(fmt.Errorf("DetectLeakedSecrets() err: %w", err))
+			}
+		}
+	}
+
+	d.text = print.P("DetectLeakedSecrets", print.LiteralStringers(opts))
+
+	return &d
+}
+
+// leakDetector finds values that look like secrets.
+type leakDetector struct {
+	text       string
+	minLength  int
+	minEntropy float64
+	patterns   []*regexp.Regexp
+	fail       bool
+}
+
+func (d leakDetector) apply(opts *options) error {
+	opts.leakDetector = &d
+	return nil
+}
+
+func (_ leakDetector) ignoreDefaults() bool {
+	return false
+}
+
+func (d leakDetector) String() string {
+	return d.text
+}
+
+// LeakEntropy sets the minimum length and entropy (in bits per character) a
+// string without whitespace must have to look like a secret.  A minLength of
+// 0 disables the entropy check.
+//
+// # Default
+//
+// A minimum length of 20 and entropy of 4.0 bits per character.
+func LeakEntropy(minLength int, minBits float64) LeakOption {
+	return &leakEntropyOption{
+		minLength: minLength,
+		minBits:   minBits,
+	}
+}
+
+type leakEntropyOption struct {
+	minLength int
+	minBits   float64
+}
+
+func (l leakEntropyOption) leakApply(d *leakDetector) error {
+	if l.minLength < 0 || l.minBits < 0 {
+		return fmt.Errorf("%w, LeakEntropy values must not be negative", ErrInvalidInput)
+	}
+
+	d.minLength = l.minLength
+	d.minEntropy = l.minBits
+	return nil
+}
+
+func (l leakEntropyOption) String() string {
+	return print.P("LeakEntropy",
+		print.Int(l.minLength),
+		print.Literal(strconv.FormatFloat(l.minBits, 'f', -1, 64)),
+		print.SubOpt())
+}
+
+// LeakPatterns replaces the regular expressions used to find values that look
+// like secrets.  Calling LeakPatterns() with no patterns disables the pattern
+// check.
+//
+// # Default
+//
+// The patterns match PEM private key headers and AWS access key ids.
+func LeakPatterns(patterns ...string) LeakOption {
+	return &leakPatternsOption{
+		patterns: patterns,
+	}
+}
+
+type leakPatternsOption struct {
+	patterns []string
+}
+
+func (l leakPatternsOption) leakApply(d *leakDetector) error {
+	d.patterns = make([]*regexp.Regexp, 0, len(l.patterns))
+	for _, pattern := range l.patterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return fmt.Errorf("%w, LeakPatterns pattern '%s' is invalid: %v", ErrInvalidInput, pattern, err) //nolint:errorlint
+		}
+		d.patterns = append(d.patterns, re)
+	}
+	return nil
+}
+
+func (l leakPatternsOption) String() string {
+	return print.P("LeakPatterns", print.Strings(l.patterns), print.SubOpt())
+}
+
+// LeakIsError causes leaked secrets to fail the compilation with the
+// [ErrLeakedSecret] error instead of being reported as warnings.
+//
+// The fail bool value is optional & assumed to be true if omitted.  The first
+// specified value is used if provided.  A value of false disables the option.
+//
+// # Default
+//
+// Leaked secrets are reported as warnings.
+func LeakIsError(fail ...bool) LeakOption {
+	fail = append(fail, true)
+	return leakIsErrorOption(fail[0])
+}
+
+type leakIsErrorOption bool
+
+func (l leakIsErrorOption) leakApply(d *leakDetector) error {
+	d.fail = bool(l)
+	return nil
+}
+
+func (l leakIsErrorOption) String() string {
+	return print.P("LeakIsError", print.BoolSilentTrue(bool(l)), print.SubOpt())
+}
+
+// detect examines the tree and returns the warnings for any values that look
+// like secrets, or an error if leaks are errors.
+func (d *leakDetector) detect(tree meta.Object, delimiter string) ([]Warning, error) {
+	if d == nil {
+		return nil, nil
+	}
+
+	var leaks []Warning
+
+	// Secret values are redacted so they are never examined.
+	err := walk(tree.ToRedacted(), nil, delimiter,
+		func(path string, value any, origins []meta.Origin) error {
+			s, ok := value.(string)
+			if !ok {
+				return nil
+			}
+
+			reason := d.looksLikeSecret(s)
+			if reason == "" {
+				return nil
+			}
+
+			w := Warning{
+				Key:      path,
+				Message:  "the value looks like a secret (" + reason + ") but is not marked as a secret",
+				Severity: SeverityWarning,
+			}
+			if len(origins) > 0 {
+				w.Origin = origins[0]
+			}
+			leaks = append(leaks, w)
+			return nil
+		})
+	if err != nil {
+		return nil, err
+	}
+
+	if d.fail && len(leaks) > 0 {
+		keys := make([]string, len(leaks))
+		for i, leak := range leaks {
+			keys[i] = leak.Key
+		}
+		return nil, fmt.Errorf("%w: '%s'", ErrLeakedSecret, strings.Join(keys, "', '"))
+	}
+
+	return leaks, nil
+}
+
+// looksLikeSecret returns why the string looks like a secret, or an empty
+// string if it doesn't.
+func (d *leakDetector) looksLikeSecret(s string) string {
+	for _, re := range d.patterns {
+		if re.MatchString(s) {
+			return "matches '" + re.String() + "'"
+		}
+	}
+
+	if d.minLength > 0 && len(s) >= d.minLength &&
+		!strings.ContainsAny(s, " \t\r\n") && entropy(s) >= d.minEntropy {
+		return "high entropy"
+	}
+
+	return ""
+}
+
+// entropy returns the Shannon entropy of the string in bits per character.
+func entropy(s string) float64 {
+	if len(s) == 0 {
+		return 0
+	}
+
+	var counts [256]int
+	for i := 0; i < len(s); i++ {
+		counts[s[i]]++
+	}
+
+	var rv float64
+	n := float64(len(s))
+	for _, count := range counts {
+		if count > 0 {
+			p := float64(count) / n
+			rv -= p * math.Log2(p)
+		}
+	}
+
+	return rv
+}
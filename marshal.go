@@ -4,10 +4,21 @@
 package goschtalt
 
 import (
+	"bytes"
 	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
 
+	"github.com/goschtalt/goschtalt/internal/natsort"
 	"github.com/goschtalt/goschtalt/internal/print"
+	"github.com/goschtalt/goschtalt/pkg/codec/json"
+	"github.com/goschtalt/goschtalt/pkg/codec/toml"
+	"github.com/goschtalt/goschtalt/pkg/encoder"
+	"github.com/goschtalt/goschtalt/pkg/meta"
 )
 
 // Marshal renders the into the format specified ('json', 'yaml' or other extensions
@@ -25,42 +36,347 @@ func (c *Config) Marshal(opts ...MarshalOption) ([]byte, error) {
 		return nil, ErrNotCompiled
 	}
 
-	var cfg marshalOptions
-	exts := c.opts.encoders.extensions()
-	if len(exts) > 0 {
-		cfg.format = exts[0]
+	return c.marshal(c.tree, opts)
+}
+
+// MarshalTo renders the configuration to the writer in the same way
+// [Config.Marshal]() does.  Encoders that implement [encoder.WriterEncoder]
+// write the document directly to w; otherwise the document is rendered in
+// memory and then written.  If an error is returned part of the document may
+// have been written.
+//
+// Valid Option Types:
+//   - [GlobalOption]
+//   - [MarshalOption]
+func (c *Config) MarshalTo(w io.Writer, opts ...MarshalOption) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if c.compiledAt.Equal(time.Time{}) {
+		return ErrNotCompiled
+	}
+
+	return c.marshalTo(w, c.tree, opts)
+}
+
+// MarshalChangesFrom renders only the values that are different from the
+// baseline configuration, in the same way [Config.Marshal]() renders the full
+// configuration.  The result is a valid configuration document that can be
+// used as an overlay on top of the baseline to produce this configuration.
+//
+// A value is included if it is not in the baseline or if it is different.
+// Arrays are included in full if any part of them is different because they
+// can't be partially overlaid.  Since arrays are appended by default when
+// merged, the keys of the arrays include the replace command (for example
+// "list((replace))") so the overlay replaces the arrays in the baseline.  The
+// commands are not included when rendered with [FormatAsTable]().  Keys
+// that are only in the baseline can't be represented in an overlay and are not
+// included.  If nothing changed an empty slice of bytes is returned.
+//
+// Both configurations must be compiled.
+//
+// Valid Option Types:
+//   - [GlobalOption]
+//   - [MarshalOption]
+func (c *Config) MarshalChangesFrom(baseline *Config, opts ...MarshalOption) ([]byte, error) {
+	if baseline == nil {
+		return nil, fmt.Errorf("%w, the baseline configuration is nil", ErrInvalidInput)
+	}
+
+	var base meta.Object
+	if baseline != c {
+		baseline.mutex.Lock()
+		compiled := !baseline.compiledAt.Equal(time.Time{})
+		base = baseline.tree
+		baseline.mutex.Unlock()
+
+		if !compiled {
+			return nil, ErrNotCompiled
+		}
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if c.compiledAt.Equal(time.Time{}) {
+		return nil, ErrNotCompiled
+	}
+
+	if baseline == c {
+		base = c.tree
+	}
+
+	changes, _ := changedFrom(base, c.tree)
+	return c.marshal(changes, append(append([]MarshalOption{}, opts...), replaceArraysOption{}))
+}
+
+// replaceArraysOption adds the replace command to the keys of the arrays just
+// before the tree is encoded, so the command isn't altered by the mappers or
+// rejected by the StrictFormat checks.
+type replaceArraysOption struct{}
+
+func (replaceArraysOption) marshalApply(opts *marshalOptions) error {
+	opts.replaceArrays = true
+	return nil
+}
+
+func (replaceArraysOption) String() string {
+	return print.P("replaceArrays")
+}
+
+// replaceArrays returns a copy of the tree where the keys of the arrays that
+// are reached through maps include the replace command.
+func replaceArrays(tree meta.Object) meta.Object {
+	if tree.Map == nil {
+		return tree
+	}
+
+	m := make(map[string]meta.Object, len(tree.Map))
+	for key, val := range tree.Map {
+		if val.Array != nil {
+			m[key+"((replace))"] = val
+			continue
+		}
+		m[key] = replaceArrays(val)
+	}
+	tree.Map = m
+	return tree
+}
+
+// changedFrom returns the parts of the tree that are different from the base
+// and if there were any.
+func changedFrom(base, tree meta.Object) (meta.Object, bool) {
+	if tree.Kind() != meta.Map || base.Kind() != meta.Map {
+		if sameObject(base, tree) {
+			return meta.Object{}, false
+		}
+		return tree, true
+	}
+
+	rv := tree
+	rv.Map = make(map[string]meta.Object)
+	for key, val := range tree.Map {
+		prior, found := base.Map[key]
+		if !found {
+			rv.Map[key] = val
+			continue
+		}
+
+		if changed, found := changedFrom(prior, val); found {
+			rv.Map[key] = changed
+		}
+	}
+
+	return rv, len(rv.Map) > 0
+}
+
+// sameObject compares the values of the trees, ignoring the origins.
+func sameObject(a, b meta.Object) bool {
+	if a.Kind() != b.Kind() {
+		return false
+	}
+
+	switch a.Kind() {
+	case meta.Array:
+		if len(a.Array) != len(b.Array) {
+			return false
+		}
+		for i := range a.Array {
+			if !sameObject(a.Array[i], b.Array[i]) {
+				return false
+			}
+		}
+		return true
+	case meta.Map:
+		if len(a.Map) != len(b.Map) {
+			return false
+		}
+		for key, val := range a.Map {
+			other, found := b.Map[key]
+			if !found || !sameObject(val, other) {
+				return false
+			}
+		}
+		return true
+	}
+
+	return reflect.DeepEqual(a.Value, b.Value)
+}
+
+// marshal renders the tree provided using the options.
+func (c *Config) marshal(tree meta.Object, opts []MarshalOption) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := c.marshalTo(&buf, tree, opts); err != nil {
+		return nil, err
+	}
+
+	// Issue 52 - always return an empty array of bytes instead of nil.
+	if buf.Len() == 0 {
+		return []byte{}, nil
+	}
+
+	return buf.Bytes(), nil
+}
+
+// marshalTo renders the tree provided to the writer using the options.
+func (c *Config) marshalTo(w io.Writer, tree meta.Object, opts []MarshalOption) error {
+	cfg := marshalOptions{
+		format: c.defaultFormat(),
 	}
 
 	full := append(c.opts.marshalOptions, opts...)
 	for _, opt := range full {
 		if opt != nil {
 			if err := opt.marshalApply(&cfg); err != nil {
-				return nil, err
+				return err
 			}
 		}
 	}
 
-	tree := c.tree
 	if cfg.redactSecrets {
 		tree = tree.ToRedacted()
 	}
 
+	if len(cfg.mappers) > 0 {
+		tree = tree.AlterKeyCase(cfg.mapper)
+	}
+
+	if cfg.flatten {
+		tree = flattenSingleKeyMaps(tree, c.opts.keyDelimiter)
+	}
+
 	// Issue 52 - depending on encoders, they may encode a nil or null object
 	// instead of returning an expected empty array of bytes.
 	if tree.IsEmpty() {
-		return []byte{}, nil
+		return nil
 	}
 
-	enc, err := c.opts.encoders.find(cfg.format)
-	if err != nil {
-		return nil, err
+	if cfg.table {
+		_, err := w.Write(renderTable(tree, c.opts.keyDelimiter, cfg.withOrigins))
+		return err
+	}
+
+	enc := cfg.encoder
+	if enc == nil {
+		var err error
+		enc, err = c.opts.encoders.find(cfg.format)
+		if err != nil {
+			return err
+		}
+	}
+
+	if cfg.strict {
+		if err := c.strictCheck(tree, enc); err != nil {
+			return err
+		}
+	}
+
+	if cfg.replaceArrays {
+		tree = replaceArrays(tree)
 	}
 
+	if we, ok := enc.(encoder.WriterEncoder); ok {
+		if cfg.withOrigins {
+			return we.EncodeExtendedTo(w, tree)
+		}
+		return we.EncodeTo(w, rawWithEmpty(tree))
+	}
+
+	var b []byte
+	var err error
 	if cfg.withOrigins {
-		return enc.EncodeExtended(tree)
+		b, err = enc.EncodeExtended(tree)
+	} else {
+		b, err = enc.Encode(rawWithEmpty(tree))
+	}
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(b)
+	return err
+}
+
+// strictCheck ensures that the tree can be represented by the encoder without
+// losing information.  The leaf values must be basic types (bool, numbers,
+// strings and time.Time) and the encoder may provide additional checks by
+// implementing the encoder.Validator interface.
+func (c *Config) strictCheck(tree meta.Object, enc encoder.Encoder) error {
+	if key, val, found := findUnsupportedValue(tree, nil); found {
+		return fmt.Errorf("%w: '%s' has a value of type %T that is not supported",
+			ErrEncoding, strings.Join(key, c.opts.keyDelimiter), val)
+	}
+
+	if v, ok := enc.(encoder.Validator); ok {
+		if err := v.Validate(tree); err != nil {
+			return fmt.Errorf("%w: %v", ErrEncoding, err) //nolint:errorlint
+		}
+	}
+
+	return nil
+}
+
+// findUnsupportedValue returns the path and value of the first leaf that is
+// not a basic type.
+func findUnsupportedValue(obj meta.Object, path []string) ([]string, any, bool) {
+	switch obj.Kind() {
+	case meta.Array:
+		for i, val := range obj.Array {
+			full := append(path[:len(path):len(path)], strconv.Itoa(i))
+			if key, v, found := findUnsupportedValue(val, full); found {
+				return key, v, true
+			}
+		}
+	case meta.Map:
+		keys := make([]string, 0, len(obj.Map))
+		for key := range obj.Map {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			full := append(path[:len(path):len(path)], key)
+			if key, v, found := findUnsupportedValue(obj.Map[key], full); found {
+				return key, v, true
+			}
+		}
+	default:
+		switch obj.Value.(type) {
+		case nil, bool, string, time.Time,
+			int, int8, int16, int32, int64,
+			uint, uint8, uint16, uint32, uint64,
+			float32, float64:
+		default:
+			return path, obj.Value, true
+		}
 	}
 
-	return enc.Encode(tree.ToRaw())
+	return nil, nil, false
+}
+
+// defaultFormat returns the first registered encoder extension, preferring
+// extensions that are not handled by the built in encoders.  This keeps a user
+// provided encoder (yaml for example) as the default format, followed by json.
+func (c *Config) defaultFormat() string {
+	exts := c.opts.encoders.extensions()
+	for _, ext := range exts {
+		enc, _ := c.opts.encoders.find(ext)
+		switch enc.(type) {
+		case json.Codec, toml.Codec:
+		default:
+			return ext
+		}
+	}
+
+	for _, ext := range exts {
+		enc, _ := c.opts.encoders.find(ext)
+		if _, ok := enc.(json.Codec); ok {
+			return ext
+		}
+	}
+
+	if len(exts) > 0 {
+		return exts[0]
+	}
+	return ""
 }
 
 // ---- MarshalOption options follow -------------------------------------------
@@ -78,6 +394,25 @@ type marshalOptions struct {
 	redactSecrets bool
 	withOrigins   bool
 	format        string
+	strict        bool
+	table         bool
+	flatten       bool
+	mappers       []Mapper
+	replaceArrays bool
+
+	// encoder overrides the registered encoder for the format if set.
+	encoder encoder.Encoder
+}
+
+// mapper is a simple helper that does the mapping based on the specified
+// options.
+func (m marshalOptions) mapper(s string) string {
+	for _, mapper := range m.mappers {
+		if rv := mapper.Map(s); rv != "" {
+			s = rv
+		}
+	}
+	return s
 }
 
 // RedactSecrets enables the replacement of secret portions of the tree with
@@ -138,9 +473,286 @@ type formatAsOption string
 
 func (f formatAsOption) marshalApply(opts *marshalOptions) error {
 	opts.format = string(f)
+	opts.table = false
+	opts.encoder = nil
 	return nil
 }
 
 func (f formatAsOption) String() string {
 	return print.P("FormatAs", print.String(string(f)), print.SubOpt())
 }
+
+// FormatAsTable renders the configuration as an aligned, two column table of
+// `key = value` lines sorted by key in natural sort order instead of using an
+// encoder.  This is handy for displaying the configuration on a terminal.
+// Nested maps are flattened using the key delimiter and array elements are
+// shown as `[i]`.  Empty maps and arrays are shown as `{}` and `[]`.  For
+// example:
+//
+//	db.hosts[0] = one.example.com
+//	db.hosts[1] = two.example.com
+//	db.port     = 5432
+//
+// The [RedactSecrets]() option is honored and the [IncludeOrigins]() option
+// adds a third column with the origins of each value.
+//
+// The last of FormatAs(), FormatAsTable() and [FormatAsJSONOptions] specified
+// is used.
+func FormatAsTable() MarshalOption {
+	return formatAsTableOption{}
+}
+
+type formatAsTableOption struct{}
+
+func (formatAsTableOption) marshalApply(opts *marshalOptions) error {
+	opts.table = true
+	return nil
+}
+
+func (formatAsTableOption) String() string {
+	return print.P("FormatAsTable", print.SubOpt())
+}
+
+// FormatAsJSONOptions renders the configuration as json using the built in
+// json encoder ([github.com/goschtalt/goschtalt/pkg/codec/json]) with the
+// formatting specified, even if a different encoder is registered for the
+// json extension.  For example:
+//
+//	cfg.Marshal(goschtalt.FormatAsJSONOptions{Indent: "\t"})
+//
+// The [IncludeOrigins]() option replaces each value with an object containing
+// the value and its origins.
+//
+// The last of FormatAs(), FormatAsTable() and FormatAsJSONOptions specified
+// is used.
+type FormatAsJSONOptions struct {
+	// Indent is the string used for each level of indentation.  It may only
+	// contain spaces and tabs.  Two spaces are used if it is empty.
+	Indent string
+
+	// Compact renders the document on a single line without indentation.
+	Compact bool
+}
+
+func (f FormatAsJSONOptions) marshalApply(opts *marshalOptions) error {
+	if strings.Trim(f.Indent, " \t") != "" {
+		return fmt.Errorf("%w, the json indent may only contain spaces and tabs", ErrInvalidInput)
+	}
+
+	opts.format = "json"
+	opts.table = false
+	opts.encoder = json.Codec{
+		Indent:  f.Indent,
+		Compact: f.Compact,
+	}
+	return nil
+}
+
+func (f FormatAsJSONOptions) String() string {
+	var indent print.Option
+	if f.Indent != "" {
+		indent = print.String(f.Indent, "Indent")
+	}
+
+	return print.P("FormatAsJSONOptions",
+		indent,
+		print.BoolSilentFalse(f.Compact, "Compact"),
+		print.SubOpt(),
+	)
+}
+
+// StrictFormat causes Marshal to return an error (ErrEncoding) instead of
+// silently degrading the output when the format is unable to represent the
+// configuration tree without losing information.  For example, json is not
+// able to represent NaN or time values.
+//
+// Values that are not basic types (bool, numbers, strings and time.Time) are
+// always considered unrepresentable.  Encoders can provide additional checks
+// by implementing the [encoder.Validator] interface.
+//
+// The strict bool value is optional & assumed to be `true` if omitted.  The
+// first specified value is used if provided.  A value of `false` disables the
+// option.
+//
+// # Default
+//
+// The output is not checked.
+func StrictFormat(strict ...bool) MarshalOption {
+	strict = append(strict, true)
+	return strictFormatOption(strict[0])
+}
+
+type strictFormatOption bool
+
+func (s strictFormatOption) marshalApply(opts *marshalOptions) error {
+	opts.strict = bool(s)
+	return nil
+}
+
+func (s strictFormatOption) String() string {
+	return print.P("StrictFormat", print.BoolSilentTrue(bool(s)), print.SubOpt())
+}
+
+// FlattenSingleKeyMaps collapses maps that contain a single child into a
+// single key joined by the key delimiter when rendering.  Chains of single
+// child maps are collapsed into one key, so this:
+//
+//	server:
+//	  tls:
+//	    cert: new.pem
+//
+// is rendered as:
+//
+//	server.tls.cert: new.pem
+//
+// Maps with more than one child are kept, but their children are collapsed.
+// This is handy when only a few values in a large configuration are shown.
+//
+// The flatten bool value is optional & assumed to be `true` if omitted.  The
+// first specified value is used if provided.  A value of `false` disables the
+// option.
+//
+// # Default
+//
+// Maps are not collapsed.
+func FlattenSingleKeyMaps(flatten ...bool) MarshalOption {
+	flatten = append(flatten, true)
+	return flattenSingleKeyMapsOption(flatten[0])
+}
+
+type flattenSingleKeyMapsOption bool
+
+func (f flattenSingleKeyMapsOption) marshalApply(opts *marshalOptions) error {
+	opts.flatten = bool(f)
+	return nil
+}
+
+func (f flattenSingleKeyMapsOption) String() string {
+	return print.P("FlattenSingleKeyMaps", print.BoolSilentTrue(bool(f)), print.SubOpt())
+}
+
+// WithMarshalMapper adds a [Mapper] that remaps the keys of the output.  The
+// configuration itself is not changed.  This is useful for producing output
+// in a different naming convention, for example:
+//
+//	cfg.Marshal(goschtalt.WithMarshalMapper(goschtalt.SnakeCaseMapper()))
+//
+// Multiple mappers can be specified and are called in the order provided,
+// with any specified via [DefaultMarshalOptions]() first.  Each mapper is
+// passed the output of the previous mapper.  A mapper that returns "" leaves
+// the key unchanged, and a mapper that returns "-" drops the key and its
+// subtree from the output.
+func WithMarshalMapper(mapper Mapper) MarshalOption {
+	return &marshalMapperOption{mapper: mapper}
+}
+
+type marshalMapperOption struct {
+	mapper Mapper
+}
+
+func (m marshalMapperOption) marshalApply(opts *marshalOptions) error {
+	if m.mapper != nil {
+		opts.mappers = append(opts.mappers, m.mapper)
+	}
+	return nil
+}
+
+func (m marshalMapperOption) String() string {
+	return print.P("WithMarshalMapper", print.Obj(m.mapper), print.SubOpt())
+}
+
+// flattenSingleKeyMaps builds a copy of the tree where maps with a single
+// child are collapsed into the key of the parent map.
+func flattenSingleKeyMaps(obj meta.Object, delimiter string) meta.Object {
+	switch obj.Kind() {
+	case meta.Array:
+		array := make([]meta.Object, len(obj.Array))
+		for i, val := range obj.Array {
+			array[i] = flattenSingleKeyMaps(val, delimiter)
+		}
+		obj.Array = array
+	case meta.Map:
+		m := make(map[string]meta.Object, len(obj.Map))
+		for key, val := range obj.Map {
+			for val.Kind() == meta.Map && len(val.Map) == 1 {
+				for k, v := range val.Map {
+					key = key + delimiter + k
+					val = v
+				}
+			}
+			m[key] = flattenSingleKeyMaps(val, delimiter)
+		}
+		obj.Map = m
+	}
+
+	return obj
+}
+
+// tableRow is a single row of the table output.
+type tableRow struct {
+	key     string
+	value   string
+	origins string
+}
+
+// renderTable renders the tree as an aligned table sorted by key in natural
+// sort order.
+func renderTable(tree meta.Object, delimiter string, withOrigins bool) []byte {
+	rows := tableRows(tree, "", delimiter, nil)
+
+	sort.Slice(rows, func(i, j int) bool {
+		return natsort.Compare(rows[i].key, rows[j].key)
+	})
+
+	var keyWidth, valueWidth int
+	for _, row := range rows {
+		keyWidth = max(keyWidth, len(row.key))
+		valueWidth = max(valueWidth, len(row.value))
+	}
+
+	var b strings.Builder
+	for _, row := range rows {
+		if withOrigins {
+			fmt.Fprintf(&b, "%-*s = %-*s  %s\n", keyWidth, row.key, valueWidth, row.value, row.origins)
+			continue
+		}
+		fmt.Fprintf(&b, "%-*s = %s\n", keyWidth, row.key, row.value)
+	}
+
+	return []byte(b.String())
+}
+
+// tableRows flattens the tree into rows.
+func tableRows(obj meta.Object, key, delimiter string, rows []tableRow) []tableRow {
+	switch obj.Kind() {
+	case meta.Array:
+		for i, val := range obj.Array {
+			rows = tableRows(val, fmt.Sprintf("%s[%d]", key, i), delimiter, rows)
+		}
+	case meta.Map:
+		for k, val := range obj.Map {
+			full := k
+			if len(key) > 0 {
+				full = key + delimiter + k
+			}
+			rows = tableRows(val, full, delimiter, rows)
+		}
+	default:
+		value := "null"
+		switch {
+		case obj.Array != nil:
+			value = "[]"
+		case obj.Map != nil:
+			value = "{}"
+		case obj.Value != nil:
+			value = fmt.Sprintf("%v", obj.Value)
+		}
+		rows = append(rows, tableRow{
+			key:     key,
+			value:   value,
+			origins: obj.OriginString(),
+		})
+	}
+
+	return rows
+}
@@ -4,10 +4,15 @@
 package goschtalt
 
 import (
+	"bytes"
 	"errors"
+	"math"
+	"strconv"
 	"testing"
 	"time"
 
+	"github.com/goschtalt/goschtalt/pkg/codec/json"
+	"github.com/goschtalt/goschtalt/pkg/codec/toml"
 	"github.com/goschtalt/goschtalt/pkg/encoder"
 	"github.com/goschtalt/goschtalt/pkg/meta"
 	"github.com/stretchr/testify/assert"
@@ -52,6 +57,113 @@ func TestMarshal(t *testing.T) {
 			input:       `{"foo":"bar"}`,
 			opts:        []MarshalOption{FormatAs("json"), IncludeOrigins(true)},
 			expected:    `{"Origins":[{"File":"file","Line":1,"Col":123}],"Array":null,"Map":{"foo":{"Origins":[{"File":"file","Line":2,"Col":123}],"Array":null,"Map":null,"Value":"bar"}},"Value":null}`,
+		}, {
+			description: "Render a tree as a table.",
+			input:       `{"foo":"bar","list":["a","b"],"nested":{"longer":"c"}}`,
+			opts:        []MarshalOption{FormatAsTable()},
+			expected: "foo           = bar\n" +
+				"list[0]       = a\n" +
+				"list[1]       = b\n" +
+				"nested.longer = c\n",
+		}, {
+			description: "Render a tree as a table in natural order.",
+			input:       `{"list":["0","1","2","3","4","5","6","7","8","9","10"]}`,
+			opts:        []MarshalOption{FormatAsTable()},
+			expected: "list[0]  = 0\n" +
+				"list[1]  = 1\n" +
+				"list[2]  = 2\n" +
+				"list[3]  = 3\n" +
+				"list[4]  = 4\n" +
+				"list[5]  = 5\n" +
+				"list[6]  = 6\n" +
+				"list[7]  = 7\n" +
+				"list[8]  = 8\n" +
+				"list[9]  = 9\n" +
+				"list[10] = 10\n",
+		}, {
+			description: "Render empty maps and arrays in a table.",
+			input:       `{"list":[],"map":{},"other":null}`,
+			opts:        []MarshalOption{FormatAsTable()},
+			expected: "list  = []\n" +
+				"map   = {}\n" +
+				"other = null\n",
+		}, {
+			description: "Render a tree as a table with a redacted secret.",
+			input:       `{"foo((secret))":"bar","other":null}`,
+			opts:        []MarshalOption{FormatAsTable(), RedactSecrets(true)},
+			expected: "foo   = REDACTED\n" +
+				"other = null\n",
+		}, {
+			description: "Render a tree as a table with origins.",
+			input:       `{"foobar":"bar"}`,
+			opts:        []MarshalOption{FormatAsTable(), IncludeOrigins(true)},
+			expected:    "foobar = bar  file:2[123]\n",
+		}, {
+			description: "Remap the output keys.",
+			input:       `{"MaxRetries":"3","Nested":{"HTTPPort":"80"}}`,
+			opts:        []MarshalOption{FormatAs("json"), WithMarshalMapper(SnakeCaseMapper())},
+			expected:    `{"max_retries":"3","nested":{"http_port":"80"}}`,
+		}, {
+			description: "Remap the output keys with a chain of mappers.",
+			input:       `{"MaxRetries":"3","Drop":"me","Keep":"me"}`,
+			opts: []MarshalOption{
+				FormatAs("json"),
+				WithMarshalMapper(wrapMap{m: map[string]string{"Drop": "-", "MaxRetries": "Retries"}}),
+				WithMarshalMapper(KebabCaseMapper()),
+				WithMarshalMapper(nil),
+			},
+			expected: `{"keep":"me","retries":"3"}`,
+		}, {
+			description: "Collapse a chain of single child maps.",
+			input:       `{"server":{"tls":{"cert":"new.pem"}}}`,
+			opts:        []MarshalOption{FormatAs("json"), FlattenSingleKeyMaps()},
+			expected:    `{"server.tls.cert":"new.pem"}`,
+		}, {
+			description: "Collapse the single child maps below a larger map.",
+			input:       `{"a":{"b":{"c":{"d":"1"}},"e":"2","f":{"g":["x",{"h":{"i":"3"}}]}}}`,
+			opts:        []MarshalOption{FormatAs("json"), FlattenSingleKeyMaps()},
+			expected:    `{"a":{"b.c.d":"1","e":"2","f.g":["x",{"h.i":"3"}]}}`,
+		}, {
+			description: "Collapse single child maps in a table.",
+			input:       `{"server":{"tls":{"cert":"new.pem"}}}`,
+			opts:        []MarshalOption{FormatAsTable(), FlattenSingleKeyMaps()},
+			expected:    "server.tls.cert = new.pem\n",
+		}, {
+			description: "Collapsing is disabled.",
+			input:       `{"server":{"tls":{"cert":"new.pem"}}}`,
+			opts:        []MarshalOption{FormatAs("json"), FlattenSingleKeyMaps(false)},
+			expected:    `{"server":{"tls":{"cert":"new.pem"}}}`,
+		}, {
+			description: "The last format specified is used.",
+			input:       `{"foo":"bar"}`,
+			opts:        []MarshalOption{FormatAsTable(), FormatAs("json")},
+			expected:    `{"foo":"bar"}`,
+		}, {
+			description: "Use the built in json encoder.",
+			input:       `{"foo":"bar","list":["a"]}`,
+			opts:        []MarshalOption{FormatAsJSONOptions{Indent: "\t"}},
+			expected:    "{\n\t\"foo\": \"bar\",\n\t\"list\": [\n\t\t\"a\"\n\t]\n}",
+		}, {
+			description: "Use the built in json encoder with origins.",
+			input:       `{"foo":"bar"}`,
+			opts:        []MarshalOption{FormatAsJSONOptions{Compact: true}, IncludeOrigins()},
+			expected:    `{"foo":{"value":"bar","origins":[{"file":"file","line":2,"col":123}]}}`,
+		}, {
+			description: "The built in json encoder is found without a registered one.",
+			input:       `{"foo":"bar"}`,
+			noEncoders:  true,
+			opts:        []MarshalOption{FormatAsJSONOptions{Compact: true}},
+			expected:    `{"foo":"bar"}`,
+		}, {
+			description: "FormatAs replaces FormatAsJSONOptions.",
+			input:       `{"foo":"bar"}`,
+			opts:        []MarshalOption{FormatAsJSONOptions{}, FormatAs("json")},
+			expected:    `{"foo":"bar"}`,
+		}, {
+			description: "An invalid json indent.",
+			input:       `{"foo":"bar"}`,
+			opts:        []MarshalOption{FormatAsJSONOptions{Indent: "--"}},
+			expectedErr: ErrInvalidInput,
 		}, {
 			description: "Import and export an empty tree.",
 			opts:        []MarshalOption{FormatAs("json"), IncludeOrigins(true)},
@@ -121,3 +233,491 @@ func TestMarshal(t *testing.T) {
 		})
 	}
 }
+
+func TestMarshalChangesFrom(t *testing.T) {
+	tests := []struct {
+		description string
+		baseline    string
+		current     string
+		opts        []MarshalOption
+		expected    string
+	}{
+		{
+			description: "Nothing changed.",
+			baseline:    `{"a":"1","b":{"c":["x","y"]}}`,
+			current:     `{"a":"1","b":{"c":["x","y"]}}`,
+		}, {
+			description: "Changed and added values.",
+			baseline:    `{"a":"1","b":{"c":"2","d":"3"},"e":"4"}`,
+			current:     `{"a":"1","b":{"c":"2","d":"changed","new":"5"},"f":{"g":"6"}}`,
+			expected:    `{"b":{"d":"changed","new":"5"},"f":{"g":"6"}}`,
+		}, {
+			description: "Arrays are included in full.",
+			baseline:    `{"list":["a","b","c"],"same":["a"]}`,
+			current:     `{"list":["a","x","c"],"same":["a"]}`,
+			expected:    `{"list((replace))":["a","x","c"]}`,
+		}, {
+			description: "A value replaced by a map.",
+			baseline:    `{"a":"1"}`,
+			current:     `{"a":{"b":"2"}}`,
+			expected:    `{"a":{"b":"2"}}`,
+		}, {
+			description: "Secrets are redacted.",
+			baseline:    `{"pw((secret))":"hunter1","user":"bob"}`,
+			current:     `{"pw((secret))":"hunter2","user":"bob"}`,
+			opts:        []MarshalOption{RedactSecrets(true)},
+			expected:    `{"pw":"REDACTED"}`,
+		}, {
+			description: "The marshal options are used.",
+			baseline:    `{"Server":{"Port":"80"}}`,
+			current:     `{"Server":{"Port":"8080"}}`,
+			opts:        []MarshalOption{FormatAsTable(), WithMarshalMapper(SnakeCaseMapper())},
+			expected:    "server.port = 8080\n",
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			compile := func(input string) *Config {
+				c, err := New(
+					WithDecoder(&testDecoder{extensions: []string{"json"}}),
+					WithEncoder(&testEncoder{extensions: []string{"json"}}),
+					AddBuffer("1.json", []byte(input)),
+				)
+				require.NoError(err)
+				return c
+			}
+
+			baseline := compile(tc.baseline)
+			current := compile(tc.current)
+
+			// The spare capacity must not be used by MarshalChangesFrom.
+			opts := make([]MarshalOption, 0, len(tc.opts)+2)
+			opts = append(opts, FormatAs("json"))
+			opts = append(opts, tc.opts...)
+			got, err := current.MarshalChangesFrom(baseline, opts...)
+			require.NoError(err)
+			assert.Equal(tc.expected, string(got))
+			assert.Nil(opts[:cap(opts)][len(opts)])
+
+			// The configuration is unchanged.
+			full, err := current.Marshal(FormatAs("json"))
+			require.NoError(err)
+			want, err := compile(tc.current).Marshal(FormatAs("json"))
+			require.NoError(err)
+			assert.Equal(string(want), string(full))
+		})
+	}
+}
+
+func TestMarshalChangesFromOverlay(t *testing.T) {
+	tests := []struct {
+		description string
+		baseline    string
+		current     string
+	}{
+		{
+			description: "Changed values.",
+			baseline:    `{"a":"1","b":{"c":"2","d":"3"}}`,
+			current:     `{"a":"1","b":{"c":"2","d":"changed","new":"5"},"f":{"g":"6"}}`,
+		}, {
+			description: "Changed arrays.",
+			baseline:    `{"list":["a","b","c"],"sub":{"list":["a"],"same":["b"]}}`,
+			current:     `{"list":["a","x"],"sub":{"list":["a","b"],"same":["b"]}}`,
+		}, {
+			description: "An emptied array.",
+			baseline:    `{"list":["a","b"]}`,
+			current:     `{"list":[]}`,
+		}, {
+			description: "Arrays of maps.",
+			baseline:    `{"list":[{"a":"1"},{"b":"2"}]}`,
+			current:     `{"list":[{"a":"1"},{"b":"3"}]}`,
+		}, {
+			description: "A new array and an array replaced by a value.",
+			baseline:    `{"a":["1"]}`,
+			current:     `{"a":"1","b":["2"]}`,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			compile := func(input ...string) *Config {
+				opts := []Option{
+					WithDecoder(&testDecoder{extensions: []string{"json"}}),
+					WithEncoder(&testEncoder{extensions: []string{"json"}}),
+				}
+				for i, in := range input {
+					opts = append(opts, AddBuffer(strconv.Itoa(i)+".json", []byte(in)))
+				}
+				c, err := New(opts...)
+				require.NoError(err)
+				return c
+			}
+
+			baseline := compile(tc.baseline)
+			current := compile(tc.current)
+
+			overlay, err := current.MarshalChangesFrom(baseline, FormatAs("json"))
+			require.NoError(err)
+
+			// Merging the overlay onto the baseline produces the configuration.
+			merged := compile(tc.baseline, string(overlay))
+
+			want, err := Unmarshal[map[string]any](current, Root)
+			require.NoError(err)
+			got, err := Unmarshal[map[string]any](merged, Root)
+			require.NoError(err)
+			assert.Equal(want, got)
+		})
+	}
+}
+
+func TestMarshalChangesFromErrors(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	compiled, err := New(
+		WithDecoder(&testDecoder{extensions: []string{"json"}}),
+		AddBuffer("1.json", []byte(`{"a":"1"}`)),
+	)
+	require.NoError(err)
+
+	// Comparing to itself has no changes.
+	got, err := compiled.MarshalChangesFrom(compiled)
+	assert.NoError(err)
+	assert.Empty(got)
+
+	got, err = compiled.MarshalChangesFrom(nil)
+	assert.ErrorIs(err, ErrInvalidInput)
+	assert.Nil(got)
+
+	var notCompiled Config
+	got, err = compiled.MarshalChangesFrom(&notCompiled)
+	assert.ErrorIs(err, ErrNotCompiled)
+	assert.Nil(got)
+
+	got, err = notCompiled.MarshalChangesFrom(compiled)
+	assert.ErrorIs(err, ErrNotCompiled)
+	assert.Nil(got)
+}
+
+func TestMarshalMapperLeavesTree(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	cfg, err := New(
+		WithEncoder(&testEncoder{extensions: []string{"json"}}),
+		DefaultMarshalOptions(WithMarshalMapper(SnakeCaseMapper())),
+		AddValue("record", Root, map[string]any{
+			"MaxRetries": "3",
+			"Server": map[string]any{
+				"HTTPPort": "80",
+			},
+		}),
+	)
+	require.NoError(err)
+
+	got, err := cfg.Marshal(FormatAs("json"),
+		WithMarshalMapper(wrapMap{m: map[string]string{"server": "srv"}}))
+	require.NoError(err)
+	assert.Equal(`{"max_retries":"3","srv":{"http_port":"80"}}`, string(got))
+
+	// The configuration is not changed.
+	tree, err := Unmarshal[map[string]any](cfg, Root)
+	require.NoError(err)
+	assert.Equal(map[string]any{
+		"MaxRetries": "3",
+		"Server": map[string]any{
+			"HTTPPort": "80",
+		},
+	}, tree)
+}
+
+func TestMarshalDefaultFormat(t *testing.T) {
+	tests := []struct {
+		description string
+		opts        []Option
+		expected    string
+	}{
+		{
+			description: "The built in json encoder.",
+			expected:    "{\n  \"foo\": \"bar\"\n}",
+		}, {
+			description: "A registered encoder is preferred.",
+			opts: []Option{
+				WithEncoder(&testEncoder{extensions: []string{"yml"}}),
+			},
+			expected: `{"foo":"bar"}`,
+		}, {
+			description: "The built in json encoder is replaced.",
+			opts: []Option{
+				WithEncoder(&testEncoder{extensions: []string{"json"}}),
+			},
+			expected: `{"foo":"bar"}`,
+		}, {
+			description: "The built in toml encoder without json.",
+			opts: []Option{
+				DisableDefaultPackageOptions(),
+				WithDecoder(json.Codec{}),
+				WithEncoder(toml.Codec{}),
+			},
+			expected: "foo = \"bar\"\n",
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			opts := append([]Option{
+				AddBuffer("1.json", []byte(`{"foo":"bar"}`)),
+				AutoCompile(),
+			}, tc.opts...)
+
+			c, err := New(opts...)
+			require.NoError(err)
+
+			got, err := c.Marshal()
+			require.NoError(err)
+			assert.Equal(tc.expected, string(got))
+		})
+	}
+}
+
+func TestMarshalEmptyArraysAndMaps(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	in := "list = []\ntable = {}\nwhen = 07:32:00\n"
+	cfg, err := New(
+		AddBuffer("1.toml", []byte(in)),
+		AutoCompile(),
+	)
+	require.NoError(err)
+
+	got, err := cfg.Marshal(FormatAs("toml"))
+	require.NoError(err)
+	assert.Equal("list = []\nwhen = 07:32:00\n\n[table]\n", string(got))
+
+	got, err = cfg.Marshal(FormatAs("json"))
+	require.NoError(err)
+	assert.Contains(string(got), `"list": []`)
+	assert.Contains(string(got), `"table": {}`)
+}
+
+// failWriter fails after accepting a number of writes.
+type failWriter struct {
+	writes int
+	err    error
+}
+
+func (f *failWriter) Write(p []byte) (int, error) {
+	if f.writes <= 0 {
+		return 0, f.err
+	}
+	f.writes--
+	return len(p), nil
+}
+
+func TestMarshalTo(t *testing.T) {
+	writeErr := errors.New("write error")
+
+	tests := []struct {
+		description string
+		opts        []MarshalOption
+		writes      int
+		expected    string
+		expectedErr error
+	}{
+		{
+			description: "An encoder without a writer.",
+			opts:        []MarshalOption{FormatAsJSONOptions{Compact: true}},
+			writes:      1,
+			expected:    `{"foo":"bar","list":["a"],"server":{"port":"80"}}`,
+		}, {
+			description: "An encoder with a writer.",
+			opts:        []MarshalOption{FormatAs("toml")},
+			writes:      10,
+			expected: "" +
+				"foo = \"bar\"\n" +
+				"list = [\"a\"]\n" +
+				"\n" +
+				"[server]\n" +
+				"port = \"80\"\n",
+		}, {
+			description: "An encoder with a writer and origins.",
+			opts:        []MarshalOption{FormatAs("toml"), IncludeOrigins()},
+			writes:      10,
+			expected: "" +
+				"# 1.json:1[8]\n" +
+				"foo = \"bar\"\n" +
+				"# 1.json:1[21]\n" +
+				"list = [\"a\"]\n" +
+				"\n" +
+				"# 1.json:1[36]\n" +
+				"[server]\n" +
+				"# 1.json:1[44]\n" +
+				"port = \"80\"\n",
+		}, {
+			description: "A table.",
+			opts:        []MarshalOption{FormatAsTable()},
+			writes:      1,
+			expected: "" +
+				"foo         = bar\n" +
+				"list[0]     = a\n" +
+				"server.port = 80\n",
+		}, {
+			description: "A failing writer without a writer encoder.",
+			opts:        []MarshalOption{FormatAs("json")},
+			expectedErr: writeErr,
+		}, {
+			description: "A failing writer with a writer encoder.",
+			opts:        []MarshalOption{FormatAs("toml")},
+			writes:      2,
+			expectedErr: writeErr,
+		}, {
+			description: "A failing writer with a table.",
+			opts:        []MarshalOption{FormatAsTable()},
+			expectedErr: writeErr,
+		}, {
+			description: "An option error.",
+			opts:        []MarshalOption{FormatAsJSONOptions{Indent: "x"}},
+			writes:      1,
+			expectedErr: ErrInvalidInput,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			cfg, err := New(
+				AddBuffer("1.json", []byte(`{"foo":"bar","list":["a"],"server":{"port":"80"}}`)),
+				AutoCompile(),
+			)
+			require.NoError(err)
+
+			if tc.expectedErr != nil {
+				w := failWriter{writes: tc.writes, err: writeErr}
+				err = cfg.MarshalTo(&w, tc.opts...)
+				assert.ErrorIs(err, tc.expectedErr)
+
+				// Marshal returns the same result as MarshalTo.
+				if !errors.Is(err, writeErr) {
+					got, err := cfg.Marshal(tc.opts...)
+					assert.ErrorIs(err, tc.expectedErr)
+					assert.Nil(got)
+				}
+				return
+			}
+
+			var buf bytes.Buffer
+			require.NoError(cfg.MarshalTo(&buf, tc.opts...))
+			assert.Equal(tc.expected, buf.String())
+
+			got, err := cfg.Marshal(tc.opts...)
+			require.NoError(err)
+			assert.Equal(tc.expected, string(got))
+		})
+	}
+}
+
+func TestMarshalToErrors(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	cfg, err := New(AutoCompile(false))
+	require.NoError(err)
+
+	var buf bytes.Buffer
+	assert.ErrorIs(cfg.MarshalTo(&buf), ErrNotCompiled)
+
+	require.NoError(cfg.Compile())
+	assert.NoError(cfg.MarshalTo(&buf))
+	assert.Equal(0, buf.Len())
+}
+
+func TestStrictFormat(t *testing.T) {
+	tests := []struct {
+		description string
+		tree        meta.Object
+		opts        []MarshalOption
+		expected    string
+		expectedErr error
+	}{
+		{
+			description: "A valid tree.",
+			tree: meta.Object{
+				Map: map[string]meta.Object{
+					"a": {Value: "b"},
+					"c": {Value: 1},
+				},
+			},
+			opts:     []MarshalOption{StrictFormat()},
+			expected: "{\n  \"a\": \"b\",\n  \"c\": 1\n}",
+		}, {
+			description: "An unsupported type.",
+			tree: meta.Object{
+				Map: map[string]meta.Object{
+					"a": {Array: []meta.Object{{Value: complex(1, 2)}}},
+				},
+			},
+			opts:        []MarshalOption{StrictFormat()},
+			expectedErr: ErrEncoding,
+		}, {
+			description: "An unsupported type without strict.",
+			tree: meta.Object{
+				Map: map[string]meta.Object{
+					"a": {Value: time.Date(2022, time.December, 30, 0, 0, 0, 0, time.UTC)},
+				},
+			},
+			opts:     []MarshalOption{StrictFormat(false)},
+			expected: "{\n  \"a\": \"2022-12-30T00:00:00Z\"\n}",
+		}, {
+			description: "A value the encoder can't represent.",
+			tree: meta.Object{
+				Map: map[string]meta.Object{
+					"a": {Value: time.Date(2022, time.December, 30, 0, 0, 0, 0, time.UTC)},
+				},
+			},
+			opts:        []MarshalOption{StrictFormat()},
+			expectedErr: ErrEncoding,
+		}, {
+			description: "A NaN value the encoder can't represent.",
+			tree: meta.Object{
+				Map: map[string]meta.Object{
+					"a": {Value: math.NaN()},
+				},
+			},
+			opts:        []MarshalOption{StrictFormat()},
+			expectedErr: ErrEncoding,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			c, err := New()
+			require.NoError(err)
+
+			c.tree = tc.tree
+
+			got, err := c.Marshal(tc.opts...)
+
+			if tc.expectedErr == nil {
+				assert.NoError(err)
+				assert.Equal(tc.expected, string(got))
+				return
+			}
+
+			assert.ErrorIs(err, tc.expectedErr)
+			assert.Nil(got)
+		})
+	}
+}
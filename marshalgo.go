@@ -0,0 +1,172 @@
+// SPDX-FileCopyrightText: 2023 Weston Schmidt <weston_schmidt@alumni.purdue.edu>
+// SPDX-License-Identifier: Apache-2.0
+
+package goschtalt
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"go/token"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// MarshalGo renders the compiled configuration as Go source code.  The output
+// is a file in the specified package containing a single variable named
+// varName that is assigned the configuration.  The output is formatted using
+// gofmt rules.
+//
+// The variable is a map[string]any composite literal for a configuration that
+// is a map (or is empty), a []any composite literal for an array and the
+// literal of the value for a single value, for example when [SelectRoot]()
+// selects an array or a value.
+//
+// Maps, slices, strings, booleans, integers and floating point values are
+// supported.  Integer and floating point values that are not int or float64
+// are rendered with a conversion to the matching builtin type so the type
+// is preserved.  Any other type results in an [ErrEncoding] error.
+//
+// Only the [RedactSecrets] MarshalOption is honored; all others are ignored.
+//
+// Valid Option Types:
+//   - [GlobalOption]
+//   - [MarshalOption]
+func (c *Config) MarshalGo(pkg, varName string, opts ...MarshalOption) ([]byte, error) {
+	if !token.IsIdentifier(pkg) {
+		return nil, fmt.Errorf("%w: '%s' is not a valid package name", ErrInvalidInput, pkg)
+	}
+	if !token.IsIdentifier(varName) {
+		return nil, fmt.Errorf("%w: '%s' is not a valid variable name", ErrInvalidInput, varName)
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if c.compiledAt.Equal(time.Time{}) {
+		return nil, ErrNotCompiled
+	}
+
+	var cfg marshalOptions
+	full := append(c.opts.marshalOptions, opts...)
+	for _, opt := range full {
+		if opt != nil {
+			if err := opt.marshalApply(&cfg); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	tree := c.tree
+	if cfg.redactSecrets {
+		tree = tree.ToRedacted()
+	}
+
+	// Empty maps and arrays are values to ToRaw(), so they are handled here
+	// to keep the type of the variable.
+	var raw any
+	switch {
+	case len(tree.Array) > 0 || len(tree.Map) > 0 || tree.Value != nil:
+		raw = tree.ToRaw()
+	case tree.Array != nil:
+		raw = []any{}
+	default:
+		raw = map[string]any{}
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "// Code generated by goschtalt. DO NOT EDIT.\n\n")
+	fmt.Fprintf(&buf, "package %s\n\n", pkg)
+	fmt.Fprintf(&buf, "var %s = ", varName)
+	if err := goLiteral(&buf, raw, true); err != nil {
+		return nil, err
+	}
+	buf.WriteString("\n")
+
+	return format.Source(buf.Bytes())
+}
+
+// goLiteral writes the Go literal form of the value to the buffer.  The typed
+// flag determines if composite literals need the type included.
+func goLiteral(buf *bytes.Buffer, v any, typed bool) error {
+	if v == nil {
+		buf.WriteString("nil")
+		return nil
+	}
+
+	switch v := v.(type) {
+	case map[string]any:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		if typed {
+			buf.WriteString("map[string]any")
+		}
+		buf.WriteString("{\n")
+		for _, k := range keys {
+			buf.WriteString(strconv.Quote(k))
+			buf.WriteString(": ")
+			if err := goLiteral(buf, v[k], true); err != nil {
+				return err
+			}
+			buf.WriteString(",\n")
+		}
+		buf.WriteString("}")
+		return nil
+	case []any:
+		if typed {
+			buf.WriteString("[]any")
+		}
+		buf.WriteString("{\n")
+		for _, item := range v {
+			if err := goLiteral(buf, item, true); err != nil {
+				return err
+			}
+			buf.WriteString(",\n")
+		}
+		buf.WriteString("}")
+		return nil
+	}
+
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.String:
+		buf.WriteString(strconv.Quote(rv.String()))
+	case reflect.Bool:
+		buf.WriteString(strconv.FormatBool(rv.Bool()))
+	case reflect.Int:
+		buf.WriteString(strconv.FormatInt(rv.Int(), 10))
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		fmt.Fprintf(buf, "%s(%d)", rv.Kind(), rv.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		fmt.Fprintf(buf, "%s(%d)", rv.Kind(), rv.Uint())
+	case reflect.Float32, reflect.Float64:
+		if math.IsNaN(rv.Float()) || math.IsInf(rv.Float(), 0) {
+			return fmt.Errorf("%w: the value %v can't be rendered as Go source", ErrEncoding, v)
+		}
+		if rv.Kind() == reflect.Float32 {
+			fmt.Fprintf(buf, "float32(%s)", goFloat(rv.Float(), 32))
+			break
+		}
+		buf.WriteString(goFloat(rv.Float(), 64))
+	default:
+		return fmt.Errorf("%w: the type %T can't be rendered as Go source", ErrEncoding, v)
+	}
+
+	return nil
+}
+
+// goFloat renders a float so that it is treated as a floating point constant.
+func goFloat(f float64, bits int) string {
+	s := strconv.FormatFloat(f, 'g', -1, bits)
+	if !strings.ContainsAny(s, ".e") {
+		s += ".0"
+	}
+	return s
+}
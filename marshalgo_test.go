@@ -0,0 +1,213 @@
+// SPDX-FileCopyrightText: 2023 Weston Schmidt <weston_schmidt@alumni.purdue.edu>
+// SPDX-License-Identifier: Apache-2.0
+
+package goschtalt
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMarshalGo(t *testing.T) {
+	tests := []struct {
+		description string
+		pkg         string
+		varName     string
+		opts        []Option
+		marshalOpts []MarshalOption
+		notCompiled bool
+		expected    string
+		expectedErr error
+	}{
+		{
+			description: "An empty configuration",
+			pkg:         "config",
+			varName:     "Defaults",
+			expected: `// Code generated by goschtalt. DO NOT EDIT.
+
+package config
+
+var Defaults = map[string]any{}
+`,
+		}, {
+			description: "A configuration with all the supported types",
+			pkg:         "config",
+			varName:     "Defaults",
+			opts: []Option{
+				AddValue("record", Root, map[string]any{
+					"String":  "hello \"world\"",
+					"Bool":    true,
+					"Int":     10,
+					"Int64":   int64(-64),
+					"Uint8":   uint8(8),
+					"Float":   1.0,
+					"Float32": float32(2.5),
+					"Nested": map[string]any{
+						"List": []any{"a", 1, map[string]any{"k": "v"}},
+					},
+				}),
+			},
+			expected: `// Code generated by goschtalt. DO NOT EDIT.
+
+package config
+
+var Defaults = map[string]any{
+	"Bool":    true,
+	"Float":   1.0,
+	"Float32": float32(2.5),
+	"Int":     10,
+	"Int64":   int64(-64),
+	"Nested": map[string]any{
+		"List": []any{
+			"a",
+			1,
+			map[string]any{
+				"k": "v",
+			},
+		},
+	},
+	"String": "hello \"world\"",
+	"Uint8":  uint8(8),
+}
+`,
+		}, {
+			description: "Secrets are redacted",
+			pkg:         "config",
+			varName:     "Defaults",
+			opts: []Option{
+				AddValue("record", Root, map[string]any{
+					"Password((secret))": "hunter2",
+				}),
+			},
+			marshalOpts: []MarshalOption{RedactSecrets()},
+			expected: `// Code generated by goschtalt. DO NOT EDIT.
+
+package config
+
+var Defaults = map[string]any{
+	"Password": "REDACTED",
+}
+`,
+		}, {
+			description: "An array configuration",
+			pkg:         "config",
+			varName:     "Defaults",
+			opts: []Option{
+				AddValue("record", Root, map[string]any{
+					"List": []any{"a", 1},
+				}),
+				SelectRoot("List"),
+			},
+			expected: `// Code generated by goschtalt. DO NOT EDIT.
+
+package config
+
+var Defaults = []any{
+	"a",
+	1,
+}
+`,
+		}, {
+			description: "An empty array configuration",
+			pkg:         "config",
+			varName:     "Defaults",
+			opts: []Option{
+				AddValue("record", Root, []any{}),
+			},
+			expected: `// Code generated by goschtalt. DO NOT EDIT.
+
+package config
+
+var Defaults = []any{}
+`,
+		}, {
+			description: "A value configuration",
+			pkg:         "config",
+			varName:     "Defaults",
+			opts: []Option{
+				AddValue("record", Root, map[string]any{
+					"Port": uint16(8080),
+				}),
+				SelectRoot("Port"),
+			},
+			expected: `// Code generated by goschtalt. DO NOT EDIT.
+
+package config
+
+var Defaults = uint16(8080)
+`,
+		}, {
+			description: "An unsupported value configuration",
+			pkg:         "config",
+			varName:     "Defaults",
+			opts: []Option{
+				AddValue("record", Root, map[string]any{
+					"Complex": complex(1, 2),
+				}),
+				SelectRoot("Complex"),
+			},
+			expectedErr: ErrEncoding,
+		}, {
+			description: "An unsupported type",
+			pkg:         "config",
+			varName:     "Defaults",
+			opts: []Option{
+				AddValue("record", Root, map[string]any{"Complex": complex(1, 2)}),
+			},
+			expectedErr: ErrEncoding,
+		}, {
+			description: "An unsupported float",
+			pkg:         "config",
+			varName:     "Defaults",
+			opts: []Option{
+				AddValue("record", Root, map[string]any{"NaN": math.NaN()}),
+			},
+			expectedErr: ErrEncoding,
+		}, {
+			description: "An invalid package name",
+			pkg:         "my-config",
+			varName:     "Defaults",
+			expectedErr: ErrInvalidInput,
+		}, {
+			description: "An invalid variable name",
+			pkg:         "config",
+			varName:     "1Defaults",
+			expectedErr: ErrInvalidInput,
+		}, {
+			description: "Not compiled",
+			pkg:         "config",
+			varName:     "Defaults",
+			notCompiled: true,
+			expectedErr: ErrNotCompiled,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			opts := tc.opts
+			if tc.notCompiled {
+				opts = append(opts, AutoCompile(false))
+			}
+			cfg, err := New(opts...)
+			require.NoError(err)
+			require.NotNil(cfg)
+
+			got, err := cfg.MarshalGo(tc.pkg, tc.varName, tc.marshalOpts...)
+
+			if tc.expectedErr != nil {
+				assert.ErrorIs(err, tc.expectedErr)
+				assert.Nil(got)
+				return
+			}
+
+			require.NoError(err)
+			assert.Equal(tc.expected, string(got))
+		})
+	}
+}
@@ -0,0 +1,199 @@
+// SPDX-FileCopyrightText: 2023 Weston Schmidt <weston_schmidt@alumni.purdue.edu>
+// SPDX-License-Identifier: Apache-2.0
+
+package goschtalt
+
+import (
+	"reflect"
+	"sort"
+
+	"github.com/goschtalt/goschtalt/internal/print"
+	"github.com/goschtalt/goschtalt/pkg/meta"
+)
+
+// MergeEvent describes which records contributed to a single value in the
+// compiled configuration.  The events are available via [Config.MergeTrace]()
+// when the [WithMergeTrace]() option is used.
+type MergeEvent struct {
+	// Key is the full key of the value, joined using the key delimiter.  Array
+	// elements are keyed by their index.
+	Key string
+
+	// Winner is the name of the record that provided the final value.
+	Winner string
+
+	// Overridden are the names of the records that provided a value that was
+	// later replaced, in the order they were merged.
+	Overridden []string
+
+	// Origins are the origins of the final value.
+	Origins []meta.Origin
+}
+
+// WithMergeTrace records which records contributed to each value in the
+// compiled configuration and which were overridden so the merge decisions can
+// be audited.  The events are available via [Config.MergeTrace]().
+//
+// Tracing examines the configuration tree after each record is merged, so it
+// is best enabled only when needed.
+//
+// The events describe the final configuration: the origins include the ones
+// added by variable expansion, the values provided by [WithDerivedDefault]()
+// have a Winner of "WithDerivedDefault" and, when [SelectRoot]() is used, only
+// the values under the root are included with keys relative to the root.
+//
+// The enable bool value is optional & assumed to be `true` if omitted.  The
+// first specified value is used if provided.  A value of `false` disables the
+// option.
+//
+// # Default
+//
+// The merge decisions are not recorded.
+func WithMergeTrace(enable ...bool) Option {
+	enable = append(enable, true)
+	return mergeTraceOption(enable[0])
+}
+
+type mergeTraceOption bool
+
+func (m mergeTraceOption) apply(opts *options) error {
+	opts.mergeTrace = bool(m)
+	return nil
+}
+
+func (_ mergeTraceOption) ignoreDefaults() bool {
+	return false
+}
+
+func (m mergeTraceOption) String() string {
+	return print.P("WithMergeTrace", print.BoolSilentTrue(bool(m)))
+}
+
+// leaf is the value and origins of a single value in the tree.
+type leaf struct {
+	value   any
+	origins []meta.Origin
+}
+
+// mergeTracer tracks the merge decisions while compiling.  A nil mergeTracer
+// does nothing so there is no overhead when tracing is disabled.
+type mergeTracer struct {
+	delimiter string
+	leaves    map[string]leaf
+	events    map[string]*MergeEvent
+}
+
+// newMergeTracer returns a mergeTracer if tracing is enabled, otherwise nil.
+func newMergeTracer(enabled bool, delimiter string) *mergeTracer {
+	if !enabled {
+		return nil
+	}
+
+	return &mergeTracer{
+		delimiter: delimiter,
+		leaves:    make(map[string]leaf),
+		events:    make(map[string]*MergeEvent),
+	}
+}
+
+// merged compares the tree after the named record was merged with the tree
+// before it and records the values the record changed.
+func (t *mergeTracer) merged(name string, tree meta.Object) {
+	if t == nil {
+		return
+	}
+
+	current := make(map[string]leaf)
+	_ = walk(tree, nil, t.delimiter,
+		func(key string, value any, origins []meta.Origin) error {
+			current[key] = leaf{
+				value:   value,
+				origins: append([]meta.Origin{}, origins...),
+			}
+			return nil
+		})
+
+	for key, l := range current {
+		if prev, found := t.leaves[key]; found && reflect.DeepEqual(prev, l) {
+			continue
+		}
+
+		event, found := t.events[key]
+		if found {
+			event.Overridden = append(event.Overridden, event.Winner)
+		} else {
+			event = &MergeEvent{Key: key}
+			t.events[key] = event
+		}
+		event.Winner = name
+		event.Origins = l.origins
+	}
+
+	// Values that were removed are no longer part of the configuration.
+	for key := range t.leaves {
+		if _, found := current[key]; !found {
+			delete(t.events, key)
+		}
+	}
+
+	t.leaves = current
+}
+
+// finish updates the events to match the final tree, after the variables are
+// expanded, the derived defaults are added and the root is selected.  The keys
+// are made relative to the root and the origins are replaced by the final
+// origins.  The values only present in the final tree come from the derived
+// defaults.
+func (t *mergeTracer) finish(tree meta.Object, root string) {
+	if t == nil {
+		return
+	}
+
+	events := make(map[string]*MergeEvent, len(t.events))
+	_ = walk(tree, nil, t.delimiter,
+		func(key string, _ any, origins []meta.Origin) error {
+			if len(key) == 0 && tree.Map != nil {
+				// An empty tree has no values.
+				return nil
+			}
+
+			full := key
+			if len(root) > 0 {
+				full = root + t.delimiter + key
+			}
+
+			event, found := t.events[full]
+			switch {
+			case !found:
+				event = &MergeEvent{Winner: derivedDefaultName}
+			case len(origins) > 0 && origins[0].File == derivedDefaultName:
+				// A derived default replaced a null value.
+				event.Overridden = append(event.Overridden, event.Winner)
+				event.Winner = derivedDefaultName
+			}
+			event.Key = key
+			event.Origins = append([]meta.Origin{}, origins...)
+			events[key] = event
+			return nil
+		})
+
+	t.events = events
+}
+
+// trace returns the events sorted by key.
+func (t *mergeTracer) trace() []MergeEvent {
+	if t == nil {
+		return nil
+	}
+
+	rv := make([]MergeEvent, 0, len(t.events))
+	for _, event := range t.events {
+		rv = append(rv, *event)
+	}
+
+	sort.Slice(rv, func(i, j int) bool {
+		return rv[i].Key < rv[j].Key
+	})
+
+	return rv
+}
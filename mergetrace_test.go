@@ -0,0 +1,161 @@
+// SPDX-FileCopyrightText: 2023 Weston Schmidt <weston_schmidt@alumni.purdue.edu>
+// SPDX-License-Identifier: Apache-2.0
+
+package goschtalt
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMergeTrace(t *testing.T) {
+	type event struct {
+		key        string
+		winner     string
+		overridden []string
+	}
+
+	tests := []struct {
+		description string
+		opts        []Option
+		want        []event
+	}{
+		{
+			description: "Tracing is disabled by default.",
+			opts: []Option{
+				AddBuffer("1.json", []byte(`{"a":"1"}`)),
+			},
+		}, {
+			description: "Tracing is disabled.",
+			opts: []Option{
+				WithMergeTrace(false),
+				AddBuffer("1.json", []byte(`{"a":"1"}`)),
+			},
+		}, {
+			description: "An empty configuration.",
+			opts: []Option{
+				WithMergeTrace(),
+			},
+			want: []event{},
+		}, {
+			description: "Values are overridden by later records.",
+			opts: []Option{
+				WithMergeTrace(),
+				AddBuffer("1.json", []byte(`{"a":"1", "b":{"c":"1", "d":"1"}}`)),
+				AddBuffer("2.json", []byte(`{"b":{"c":"2"}}`)),
+				AddBuffer("3.json", []byte(`{"a":"3", "b":{"c":"3"}, "e":"3"}`)),
+			},
+			want: []event{
+				{key: "a", winner: "3.json", overridden: []string{"1.json"}},
+				{key: "b.c", winner: "3.json", overridden: []string{"1.json", "2.json"}},
+				{key: "b.d", winner: "1.json"},
+				{key: "e", winner: "3.json"},
+			},
+		}, {
+			description: "Kept values are not overridden.",
+			opts: []Option{
+				WithMergeTrace(),
+				AddBuffer("1.json", []byte(`{"a":"1"}`)),
+				AddBuffer("2.json", []byte(`{"a((keep))":"2"}`)),
+			},
+			want: []event{
+				{key: "a", winner: "1.json"},
+			},
+		}, {
+			description: "Appended arrays are traced by element.",
+			opts: []Option{
+				WithMergeTrace(),
+				AddBuffer("1.json", []byte(`{"a":["1"]}`)),
+				AddBuffer("2.json", []byte(`{"a":["2"]}`)),
+			},
+			want: []event{
+				{key: "a.0", winner: "1.json"},
+				{key: "a.1", winner: "2.json"},
+			},
+		}, {
+			description: "Removed values are not traced.",
+			opts: []Option{
+				WithMergeTrace(),
+				AddBuffer("1.json", []byte(`{"a":{"b":"1", "c":"1"}}`)),
+				AddBuffer("2.json", []byte(`{"a((replace))":{"c":"2"}}`)),
+			},
+			want: []event{
+				{key: "a.c", winner: "2.json", overridden: []string{"1.json"}},
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			opts := append([]Option{
+				WithDecoder(&testDecoder{extensions: []string{"json"}}),
+			}, tc.opts...)
+
+			c, err := New(opts...)
+			require.NoError(err)
+
+			got := c.MergeTrace()
+			if tc.want == nil {
+				assert.Nil(got)
+				return
+			}
+
+			require.Len(got, len(tc.want))
+			for i, want := range tc.want {
+				assert.Equal(want.key, got[i].Key)
+				assert.Equal(want.winner, got[i].Winner)
+				assert.Equal(want.overridden, got[i].Overridden)
+				require.Len(got[i].Origins, 1)
+				assert.Equal(want.winner, got[i].Origins[0].File)
+			}
+		})
+	}
+}
+
+func TestMergeTraceFinal(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	c, err := New(
+		WithDecoder(&testDecoder{extensions: []string{"json"}}),
+		WithMergeTrace(),
+		AddBuffer("1.json", []byte(`{"app":{"name":"${name}", "port":null}, "other":"1"}`)),
+		AddBuffer("2.json", []byte(`{"app":{"host":"localhost"}}`)),
+		Expand(ExpanderFunc(func(s string) (string, bool) {
+			return "example", s == "name"
+		}), WithOrigin("expander")),
+		WithDerivedDefault("app.port", func(*Config) (any, bool) {
+			return "8080", true
+		}),
+		WithDerivedDefault("app.tls", func(*Config) (any, bool) {
+			return "false", true
+		}),
+		SelectRoot("app"),
+	)
+	require.NoError(err)
+
+	got := c.MergeTrace()
+	require.Len(got, 4)
+
+	assert.Equal("host", got[0].Key)
+	assert.Equal("2.json", got[0].Winner)
+
+	// The origins include the expansion.
+	assert.Equal("name", got[1].Key)
+	assert.Equal("1.json", got[1].Winner)
+	require.Len(got[1].Origins, 2)
+	assert.Equal("expander", got[1].Origins[1].File)
+
+	assert.Equal("port", got[2].Key)
+	assert.Equal("WithDerivedDefault", got[2].Winner)
+	assert.Equal([]string{"1.json"}, got[2].Overridden)
+
+	assert.Equal("tls", got[3].Key)
+	assert.Equal("WithDerivedDefault", got[3].Winner)
+	assert.Empty(got[3].Overridden)
+}
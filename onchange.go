@@ -0,0 +1,69 @@
+// SPDX-FileCopyrightText: 2023 Weston Schmidt <weston_schmidt@alumni.purdue.edu>
+// SPDX-License-Identifier: Apache-2.0
+
+package goschtalt
+
+import (
+	"context"
+
+	"github.com/goschtalt/goschtalt/pkg/meta"
+)
+
+// OnChange registers fn to be called after each successful compilation of the
+// configuration, including the compilations done by [AutoCompile](),
+// [Config.Reload]() and [Config.Watch]().  The fn is called with the
+// previously compiled tree and the newly compiled tree.  Before the first
+// compilation the previous tree is empty.  Use [meta.Object.Diff]() to find
+// the keys that changed.
+//
+// The fn is called after the compilation finishes and without the Config
+// being locked, so the fn may call [Config.Unmarshal]() and similar
+// functions.  Since compilations are serialized, the fn must not compile the
+// configuration (directly or via [Config.With]()) or it will deadlock.
+//
+// Any number of functions may be registered.  They are called in the order
+// they were registered.  Each is given its own copy of the trees.
+func (c *Config) OnChange(fn func(old, new meta.Object)) {
+	if fn == nil {
+		return
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.onChange = append(c.onChange, fn)
+}
+
+// compileAndNotice compiles the configuration and, if successful, prepares
+// the notice for the OnChange listeners.  The mutex must be held.
+func (c *Config) compileAndNotice(ctx context.Context, notice *changeNotice) error {
+	old := c.tree
+	err := c.compile(ctx)
+	c.publishTimings()
+	if err != nil {
+		return err
+	}
+
+	notice.prepare(c.onChange, old, c.tree)
+	return nil
+}
+
+// changeNotice holds what the OnChange listeners are called with so they can
+// be called after the mutex is released.
+type changeNotice struct {
+	listeners []func(old, new meta.Object)
+	old       meta.Object
+	new       meta.Object
+}
+
+func (n *changeNotice) prepare(listeners []func(old, new meta.Object), old, new meta.Object) {
+	n.listeners = append([]func(old, new meta.Object){}, listeners...)
+	n.old = old
+	n.new = new
+}
+
+func (n *changeNotice) send() {
+	for _, fn := range n.listeners {
+		fn(n.old.Clone(), n.new.Clone())
+	}
+}
@@ -0,0 +1,126 @@
+// SPDX-FileCopyrightText: 2023 Weston Schmidt <weston_schmidt@alumni.purdue.edu>
+// SPDX-License-Identifier: Apache-2.0
+
+package goschtalt
+
+import (
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/goschtalt/goschtalt/pkg/meta"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOnChange(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	var count atomic.Int64
+	var fail atomic.Bool
+	getter := ValueGetterFunc(func(string, Unmarshaler) (any, error) {
+		if fail.Load() {
+			return nil, errOpt
+		}
+		n := count.Add(1)
+		return map[string]any{
+			"db":   map[string]any{"host": "localhost", "pool": n},
+			"name": "example",
+		}, nil
+	})
+
+	cfg, err := New(
+		AddValueGetter("record", Root, getter),
+		AutoCompile(false),
+	)
+	require.NoError(err)
+
+	type call struct {
+		old, new any
+		changes  []string
+		pool     int
+	}
+	var calls []call
+	cfg.OnChange(func(old, new meta.Object) {
+		var changes []string
+		for _, c := range old.Diff(new) {
+			changes = append(changes, strings.Join(c.Path, "."))
+		}
+
+		// The configuration can be used from the callback.
+		pool, err := Unmarshal[int](cfg, "db.pool")
+		assert.NoError(err)
+
+		calls = append(calls, call{
+			old:     old.ToRaw(),
+			new:     new.ToRaw(),
+			changes: changes,
+			pool:    pool,
+		})
+	})
+	cfg.OnChange(nil)
+
+	// The first compilation starts from an empty tree.
+	require.NoError(cfg.Compile())
+	require.Len(calls, 1)
+	assert.Nil(calls[0].old)
+	assert.Equal(map[string]any{
+		"db":   map[string]any{"host": "localhost", "pool": int64(1)},
+		"name": "example",
+	}, calls[0].new)
+	assert.Equal([]string{"db", "name"}, calls[0].changes)
+	assert.Equal(1, calls[0].pool)
+
+	// A reload.
+	require.NoError(cfg.Reload())
+	require.Len(calls, 2)
+	assert.Equal(calls[0].new, calls[1].old)
+	assert.Equal([]string{"db.pool"}, calls[1].changes)
+	assert.Equal(2, calls[1].pool)
+
+	// The auto-compile path.
+	require.NoError(cfg.With(AutoCompile()))
+	require.Len(calls, 3)
+	assert.Equal([]string{"db.pool"}, calls[2].changes)
+	assert.Equal(3, calls[2].pool)
+
+	// Failed compilations are not reported.
+	fail.Store(true)
+	assert.Error(cfg.Compile())
+	assert.Error(cfg.Reload())
+	assert.Error(cfg.With())
+	assert.Len(calls, 3)
+	fail.Store(false)
+
+	// Options without a compilation are not reported.
+	require.NoError(cfg.With(AutoCompile(false)))
+	assert.Len(calls, 3)
+}
+
+func TestOnChangeOrderAndCopies(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	cfg, err := New(
+		AddValue("record", Root, map[string]any{"a": "b"}),
+	)
+	require.NoError(err)
+
+	var order []int
+	cfg.OnChange(func(_, new meta.Object) {
+		order = append(order, 1)
+		new.Map["a"] = meta.Object{Value: "changed"}
+	})
+	cfg.OnChange(func(_, new meta.Object) {
+		order = append(order, 2)
+		assert.Equal("b", new.Map["a"].Value)
+	})
+
+	require.NoError(cfg.Compile())
+	assert.Equal([]int{1, 2}, order)
+
+	got, err := Unmarshal[string](cfg, "a")
+	require.NoError(err)
+	assert.Equal("b", got)
+}
@@ -8,14 +8,17 @@ import (
 	"io/fs"
 	"path"
 	"strings"
+	"time"
 
 	"github.com/goschtalt/goschtalt/internal/casbab"
 	"github.com/goschtalt/goschtalt/internal/fspath"
+	"github.com/goschtalt/goschtalt/internal/jsonschema"
 	"github.com/goschtalt/goschtalt/internal/natsort"
 	"github.com/goschtalt/goschtalt/internal/print"
 	"github.com/goschtalt/goschtalt/internal/strs"
 	"github.com/goschtalt/goschtalt/pkg/decoder"
 	"github.com/goschtalt/goschtalt/pkg/encoder"
+	"github.com/goschtalt/goschtalt/pkg/meta"
 )
 
 // Option configures specific behavior of Config as well as the locations used
@@ -65,10 +68,69 @@ type options struct {
 	filegroups []filegroup
 	values     []record
 
+	// Defaults computed from the merged configuration; there can be many.
+	derivedDefaults []derivedDefault
+
 	// Expansions; there can be many.
 	expansions    []expand
+	deferred      []expand
 	exapansionMax int
 
+	// How often Watch() polls the filesystems.
+	watchInterval time.Duration
+
+	// How null values are merged.
+	nullMode NullMode
+
+	// How maps with array index keys are merged onto arrays.
+	indexMode IndexMode
+
+	// Remove duplicate elements from merged arrays.
+	dedupArrays bool
+
+	// The merge rules for specific keys, in the order they were added.
+	mergeRules []mergeRule
+
+	// The suffix of the merge directive keys, or empty if disabled.
+	mergeDirective string
+
+	// Record the merge decisions made while compiling.
+	mergeTrace bool
+
+	// Require every record to have a unique name.
+	uniqueRecordNames bool
+
+	// Reject keys repeated within a single document.
+	rejectDuplicateKeys bool
+
+	// Use the first filegroup as the schema for the configuration.
+	schemaFromFirstGroup bool
+
+	// The function used to merge conflicting values.
+	leafMerge LeafMergeFunc
+
+	// The key of the subtree to use as the root of the configuration.
+	selectRoot         string
+	selectRootOptional bool
+
+	// The detector used to find values that look like secrets, if any.
+	leakDetector *leakDetector
+
+	// The JSON Schemas the configuration must match.
+	schemas []*jsonschema.Schema
+
+	// The timings to populate during compilation.
+	timings *Timings
+
+	// keepCache keeps the values remembered by Cached() across compilations.
+	keepCache bool
+
+	// The function called for each non-fatal problem found while compiling.
+	errorHandler func(error)
+
+	// The key patterns of the warnings to suppress; there can be many.
+	suppressWarnings []string
+
 	// Hints are special options that check that the configuration makes sense;
 	// there can be many.
 	hints []func(*options) error
@@ -79,7 +141,10 @@ type options struct {
 // AddFile adds exactly one file to the list of files to be compiled into a
 // configuration.  The filename must be relative to the fs.  If the file
 // specified cannot be processed it is considered an error.
-func AddFile(fs fs.FS, filename string) Option {
+//
+// Valid Option Types:
+//   - [FileOption]
+func AddFile(fs fs.FS, filename string, opts ...FileOption) Option {
 	return &groupOption{
 		name: "AddFile",
 		grp: filegroup{
@@ -87,12 +152,16 @@ func AddFile(fs fs.FS, filename string) Option {
 			paths:     []string{filename},
 			exactFile: true,
 		},
+		opts: opts,
 	}
 }
 
 // AddFileAs is the same as [AddFile]() except the file is decoded as the
 // specified type.
-func AddFileAs(fs fs.FS, asType, filename string) Option {
+//
+// Valid Option Types:
+//   - [FileOption]
+func AddFileAs(fs fs.FS, asType, filename string, opts ...FileOption) Option {
 	return &groupOption{
 		name: "AddFileAs",
 		grp: filegroup{
@@ -101,6 +170,28 @@ func AddFileAs(fs fs.FS, asType, filename string) Option {
 			exactFile: true,
 			as:        asType,
 		},
+		opts: opts,
+	}
+}
+
+// AddSecretFile is the same as [AddFile]() except all the values in the file
+// are marked as secrets.  This is useful for keeping credentials in a separate
+// file with restricted permissions.  The values are redacted by
+// [RedactSecrets]() when marshaling, the same as values marked secret with the
+// ((secret)) command.
+//
+// Valid Option Types:
+//   - [FileOption]
+func AddSecretFile(fs fs.FS, filename string, opts ...FileOption) Option {
+	return &groupOption{
+		name: "AddSecretFile",
+		grp: filegroup{
+			fs:        fs,
+			paths:     []string{filename},
+			exactFile: true,
+			secret:    true,
+		},
+		opts: opts,
 	}
 }
 
@@ -179,7 +270,10 @@ func AddFilesHaltAs(fs fs.FS, asType string, filenames ...string) Option {
 //
 // All the files that can be processed with a decoder will be compiled into the
 // configuration.
-func AddTree(fs fs.FS, path string) Option {
+//
+// Valid Option Types:
+//   - [FileOption]
+func AddTree(fs fs.FS, path string, opts ...FileOption) Option {
 	return &groupOption{
 		name: "AddTree",
 		grp: filegroup{
@@ -187,6 +281,7 @@ func AddTree(fs fs.FS, path string) Option {
 			paths:   []string{path},
 			recurse: true,
 		},
+		opts: opts,
 	}
 }
 
@@ -199,7 +294,10 @@ func AddTree(fs fs.FS, path string) Option {
 //
 // This is generally going to be useful for configuring a set of paths to search
 // for configuration and stopping when it is found.
-func AddTreeHalt(fs fs.FS, path string) Option {
+//
+// Valid Option Types:
+//   - [FileOption]
+func AddTreeHalt(fs fs.FS, path string, opts ...FileOption) Option {
 	return &groupOption{
 		name: "AddTreeHalt",
 		grp: filegroup{
@@ -208,6 +306,29 @@ func AddTreeHalt(fs fs.FS, path string) Option {
 			recurse: true,
 			halt:    true,
 		},
+		opts: opts,
+	}
+}
+
+// AddTreeIf adds a list of directory trees (including all subdirectories) for
+// inclusion when compiling the configuration if the cond is true.  If the cond
+// is false the directory trees are skipped and listed in the [Explanation].
+//
+// This option works the same as [AddTrees]() otherwise.
+//
+// See also: [When]
+func AddTreeIf(cond bool, fs fs.FS, paths ...string) Option {
+	return &groupOption{
+		name: "AddTreeIf",
+		cond: &cond,
+		grp: filegroup{
+			fs:      fs,
+			paths:   paths,
+			recurse: true,
+			when: func() bool {
+				return cond
+			},
+		},
 	}
 }
 
@@ -240,13 +361,17 @@ func AddTrees(fs fs.FS, paths ...string) Option {
 //
 // All the files that can be processed with a decoder will be compiled into the
 // configuration.
-func AddDir(fs fs.FS, path string) Option {
+//
+// Valid Option Types:
+//   - [FileOption]
+func AddDir(fs fs.FS, path string, opts ...FileOption) Option {
 	return &groupOption{
 		name: "AddDir",
 		grp: filegroup{
 			fs:    fs,
 			paths: []string{path},
 		},
+		opts: opts,
 	}
 }
 
@@ -269,6 +394,91 @@ func AddDirs(fs fs.FS, paths ...string) Option {
 	}
 }
 
+// AddLayeredDirs adds the same file from each of the filesystems provided,
+// with later filesystems taking precedence over earlier ones.  This is handy
+// for the common pattern of system, user and project local configuration
+// directories where the same filename is used at each layer:
+//
+//	goschtalt.AddLayeredDirs([]fs.FS{
+//		os.DirFS("/etc/myapp"),
+//		os.DirFS(userConfigDir),
+//		os.DirFS("."),
+//	}, "config.yml")
+//
+// The filename may be a glob.  It is not an error if a layer doesn't contain
+// the file.  Use [Strict]() if the file must be present in every layer.
+//
+// The records from all the layers share the name of the file, so they are
+// sorted to the same position relative to other records and are merged in the
+// order of the layers.  The records are not considered duplicates by
+// [UniqueRecordNames]().
+//
+// Valid Option Types:
+//   - [FileOption]
+func AddLayeredDirs(dirs []fs.FS, filename string, opts ...FileOption) Option {
+	return &layeredOption{
+		dirs:     dirs,
+		filename: filename,
+		opts:     opts,
+	}
+}
+
+type layeredOption struct {
+	dirs     []fs.FS
+	filename string
+	opts     []FileOption
+}
+
+func (l layeredOption) apply(opts *options) error {
+	if len(l.filename) == 0 {
+		return fmt.Errorf("%w, AddLayeredDirs requires a filename", ErrInvalidInput)
+	}
+
+	// The index of the first filegroup is unique to this option.
+	layer := len(opts.filegroups) + 1
+	for i, dir := range l.dirs {
+		if dir == nil {
+			return fmt.Errorf("%w, AddLayeredDirs layer %d is nil", ErrInvalidInput, i)
+		}
+
+		g := groupOption{
+			name: "AddLayeredDirs",
+			grp: filegroup{
+				fs:    dir,
+				paths: []string{l.filename},
+				layer: layer,
+			},
+			opts: l.opts,
+		}
+		if err := g.apply(opts); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (_ layeredOption) ignoreDefaults() bool {
+	return false
+}
+
+func (l layeredOption) String() string {
+	layers := make([]string, len(l.dirs))
+	for i := range l.dirs {
+		layers[i] = "fs"
+	}
+
+	opts := []print.Option{
+		print.LiteralStrings(layers),
+		print.String(l.filename),
+	}
+	if len(l.opts) > 0 {
+		opts = append(opts, print.LiteralStringers(l.opts))
+	}
+
+	return print.P("AddLayeredDirs", opts...)
+}
+
 // AddJumbled adds any number of files or directories (excluding all
 // subdirectories) for inclusion when compiling the configuration.  The files
 // and directories are sorted into either a relative based filesystem or an
@@ -354,13 +564,28 @@ func addJumbled(name string, abs, rel fs.FS, paths []string, halt bool) Option {
 
 type groupOption struct {
 	name string
+	cond *bool
 	grp  filegroup
+	opts []FileOption
 }
 
 var _ Option = (*groupOption)(nil)
 
 func (g groupOption) apply(opts *options) error {
-	opts.filegroups = append(opts.filegroups, g.grp)
+	grp := g.grp
+	for _, opt := range g.opts {
+		if opt != nil {
+			if err := opt.fileApply(&grp); err != nil {
+				return err
+			}
+		}
+	}
+
+	if grp.when != nil {
+		grp.text = g.String()
+	}
+
+	opts.filegroups = append(opts.filegroups, grp)
 	return nil
 }
 
@@ -369,14 +594,19 @@ func (_ groupOption) ignoreDefaults() bool {
 }
 
 func (o groupOption) String() string {
-	opts := []print.Option{
-		print.Literal("fs"),
+	var opts []print.Option
+	if o.cond != nil {
+		opts = append(opts, print.Bool(*o.cond))
 	}
+	opts = append(opts, print.Literal("fs"))
 
 	if strings.Contains(o.name, "As") {
 		opts = append(opts, print.String(o.grp.as))
 	}
 	opts = append(opts, print.Strings(o.grp.paths))
+	if len(o.opts) > 0 {
+		opts = append(opts, print.LiteralStringers(o.opts))
+	}
 
 	return print.P(o.name, opts...)
 }
@@ -478,6 +708,11 @@ type casemapper struct {
 }
 
 func (c casemapper) Map(in string) string {
+	// Preserve the empty & drop sentinels so the chain still works.
+	if in == "" || in == "-" {
+		return in
+	}
+
 	out, found := c.adjustments[in]
 	if !found {
 		out = c.toCase(in)
@@ -622,10 +857,12 @@ func SortRecordsLexically() Option {
 //
 //   - Don't use floating point numbers.  They are treated like 2 integers separated
 //     by the '.' rune.
-//   - Any leading 0 values are dropped from the number.
+//   - Any leading 0 values are dropped from the number, but a number made of
+//     only zeros is 0.  Records like 0_base.yml sort before 1_foo.yml.
 //
 // Example sort order:
 //
+//	0_base.yml
 //	01_foo.yml
 //	2_foo.yml
 //	98_foo.yml
@@ -784,6 +1021,7 @@ func (w withEncoderOption) String() string {
 
 // DisableDefaultPackageOptions provides a way to explicitly not use any preconfigured
 // default values by this package and instead use just the options specified.
+// This includes the built in json and toml decoders and encoders.
 //
 // See: [DefaultOptions]
 func DisableDefaultPackageOptions() Option {
@@ -859,6 +1097,10 @@ func (d defaultUnmarshalOption) String() string {
 // invocations of the [AddValue]() and [AddValueFunc]() functions.  This should
 // make consistent use use of these functions easier.
 //
+// Multiple adapters ([AdaptToCfg]) may be provided and are composed into a
+// single chain.  The adapters are tried in the order they are provided, with
+// the default adapters tried before any provided to the specific value.
+//
 // Valid Option Types:
 //   - [BufferValueOption]
 //   - [GlobalOption]
@@ -1000,6 +1242,536 @@ func (s setMaxExpansionsOption) String() string {
 	return print.P("SetMaxExpansions", print.Int(int(s)))
 }
 
+// SchemaFromFirstGroup treats the keys found in the first file group (the
+// first AddFile(), AddTree(), AddDir(), etc option) as the complete set of
+// valid keys.  After the configuration is compiled, any key from a later file
+// group that is in the final configuration tree but isn't present in the first
+// file group causes the compilation to fail with [ErrUnknownKey].  This
+// provides a simple guard against typos in later configuration files without
+// needing a separate schema.
+//
+// Only the keys from the file groups are checked.  The keys provided by other
+// records, like [AddBuffer](), [AddValue]() or [AddArgs](), and by
+// [WithDerivedDefault]() are not checked.
+//
+// Arrays and values are treated as leaves; their contents are not checked.
+// If the first file group doesn't produce any records, no check is performed.
+//
+// The enable bool value is optional & assumed to be `true` if omitted.  The
+// first specified value is used if provided.  A value of `false` disables the
+// option.
+//
+// # Default
+//
+// The keys are not checked.
+func SchemaFromFirstGroup(enable ...bool) Option {
+	enable = append(enable, true)
+	return schemaFromFirstGroupOption(enable[0])
+}
+
+type schemaFromFirstGroupOption bool
+
+func (s schemaFromFirstGroupOption) apply(opts *options) error {
+	opts.schemaFromFirstGroup = bool(s)
+	return nil
+}
+
+func (_ schemaFromFirstGroupOption) ignoreDefaults() bool {
+	return false
+}
+
+func (s schemaFromFirstGroupOption) String() string {
+	return print.P("SchemaFromFirstGroup", print.BoolSilentTrue(bool(s)))
+}
+
+// SelectRoot replaces the root of the compiled configuration tree with the
+// subtree found at the specified key.  This is done after all the records are
+// merged, so [Unmarshal]() with [Root] yields only the selected subtree.  Any
+// values outside of the selected subtree are discarded.
+//
+// This is useful when a single configuration contains sections for several
+// tenants (or similar), and only one needs to be used.
+//
+// If the key is not found, compiling the configuration fails unless the
+// [Optional] option is provided, in which case the configuration is empty.
+// Only the [Optional] and [Required] options are honored; all other options
+// are ignored.
+//
+// # Default
+//
+// The entire configuration tree is used.
+func SelectRoot(key string, opts ...UnmarshalOption) Option {
+	if len(key) == 0 {
+		return WithError(
+			fmt.Errorf("%w, SelectRoot requires a non-empty key", ErrInvalidInput),
+		)
+	}
+
+	var info unmarshalOptions
+	for _, opt := range opts {
+		if opt != nil {
+			if err := opt.unmarshalApply(&info); err != nil {
+				return WithError(err)
+			}
+		}
+	}
+
+	return &selectRootOption{
+		key:      key,
+		optional: info.optional,
+		opts:     opts,
+	}
+}
+
+type selectRootOption struct {
+	key      string
+	optional bool
+	opts     []UnmarshalOption
+}
+
+func (s selectRootOption) apply(opts *options) error {
+	opts.selectRoot = s.key
+	opts.selectRootOptional = s.optional
+	return nil
+}
+
+func (_ selectRootOption) ignoreDefaults() bool {
+	return false
+}
+
+func (s selectRootOption) String() string {
+	return print.P("SelectRoot", print.String(s.key), print.LiteralStringers(s.opts))
+}
+
+// LeafMergeFunc is a function that resolves a conflict between an existing
+// value and an incoming value during the merge step of compiling the
+// configuration.  The key is the full key of the value, joined using the
+// configured key delimiter.  The existing and incoming values are provided
+// and the merged value is returned.  If [ErrNotApplicable] is returned the
+// default behavior (last-wins) is used.  Any other error fails the
+// compilation.
+type LeafMergeFunc func(key string, existing, incoming meta.Object) (meta.Object, error)
+
+// WithLeafMerge provides a way to customize how conflicting values
+// (leaves) are merged together when compiling the configuration.  This allows
+// specific keys to be handled in a special way, like summing counters or
+// concatenating strings.
+//
+// The function is only called when a value in a later record conflicts with a
+// value from an earlier record and no merge command (like `((replace))`) is
+// specified for the key.  Maps and arrays are merged using the normal rules.
+//
+// Setting the value to nil restores the default behavior.
+//
+// # Default
+//
+// The default behavior is the last value wins.
+func WithLeafMerge(fn LeafMergeFunc) Option {
+	return &leafMergeOption{
+		fn: fn,
+	}
+}
+
+type leafMergeOption struct {
+	fn LeafMergeFunc
+}
+
+func (l leafMergeOption) apply(opts *options) error {
+	opts.leafMerge = l.fn
+	return nil
+}
+
+func (_ leafMergeOption) ignoreDefaults() bool {
+	return false
+}
+
+func (l leafMergeOption) String() string {
+	if l.fn == nil {
+		return print.P("WithLeafMerge", print.Obj(nil))
+	}
+	return print.P("WithLeafMerge", print.Obj(l.fn))
+}
+
+// NullMode defines how a null value in a configuration record is treated when
+// it is merged onto the existing configuration tree.
+type NullMode string
+
+const (
+	// NullClears causes a null value to replace (clear) any prior value.
+	NullClears NullMode = "NullClears"
+
+	// NullIgnored causes a null value to be treated as if the key was not
+	// set, leaving any prior value in place.
+	NullIgnored NullMode = "NullIgnored"
+)
+
+// NullMerge provides a way to control how null values found in later
+// configuration records are merged onto the values from earlier records.
+//
+//   - NullClears - A null value replaces (clears) the prior value.
+//   - NullIgnored - A null value is treated as unset and the prior value is
+//     kept.  Only null values in maps are ignored; null array entries are
+//     merged as is so array indexes are not altered.
+//
+// # Default
+//
+// NullClears
+func NullMerge(mode NullMode) Option {
+	switch mode {
+	case NullClears, NullIgnored:
+	default:
+		return WithError(
+			fmt.Errorf("%w, NullMerge mode '%s' is not supported", ErrInvalidInput, mode),
+		)
+	}
+	return nullMergeOption(mode)
+}
+
+type nullMergeOption NullMode
+
+func (n nullMergeOption) apply(opts *options) error {
+	opts.nullMode = NullMode(n)
+	return nil
+}
+
+func (_ nullMergeOption) ignoreDefaults() bool {
+	return false
+}
+
+func (n nullMergeOption) String() string {
+	return print.P("NullMerge", print.String(string(n)))
+}
+
+// WithTimings provides a [Timings] structure that is populated with how long
+// each phase of the compilation took each time the configuration is compiled.
+// This is useful for finding out why loading a large configuration is slow.
+//
+// The timings are recorded separately while compiling and copied into the
+// structure when each compilation finishes, while the Config is locked.  If
+// [Config.Reload]() or [Config.Watch]() may compile the configuration while
+// the timings are being read, use [Config.Timings]() instead of reading the
+// structure directly.
+//
+// Setting the value to nil disables recording the timings.
+//
+// # Default
+//
+// The timings are not recorded.
+func WithTimings(t *Timings) Option {
+	return &timingsOption{
+		timings: t,
+	}
+}
+
+type timingsOption struct {
+	timings *Timings
+}
+
+func (t timingsOption) apply(opts *options) error {
+	opts.timings = t.timings
+	return nil
+}
+
+func (_ timingsOption) ignoreDefaults() bool {
+	return false
+}
+
+func (t timingsOption) String() string {
+	if t.timings == nil {
+		return print.P("WithTimings", print.Obj(nil))
+	}
+	return print.P("WithTimings", print.Obj(t.timings))
+}
+
+// WithErrorHandler provides a function that is called for each non-fatal
+// problem found while compiling the configuration, like a file skipped by
+// [BestEffort](), a variable that none of the [Expand]() expanders found or a
+// value found by [DetectLeakedSecrets]().  The problems are provided at the
+// end of each stage of the compilation: after the files are read, after the
+// final variable expansion and after the validation.  This allows the problems
+// to be logged or counted even if a later stage fails the compilation.  The
+// error provided is a [Warning] and can be examined with errors.As().
+//
+// The problems are still available from [Config.Warnings]() after the
+// compilation finishes.  Setting the value to nil removes the handler.
+//
+// # Default
+//
+// No handler is called.
+func WithErrorHandler(fn func(error)) Option {
+	return &errorHandlerOption{
+		fn: fn,
+	}
+}
+
+type errorHandlerOption struct {
+	fn func(error)
+}
+
+func (e errorHandlerOption) apply(opts *options) error {
+	opts.errorHandler = e.fn
+	return nil
+}
+
+func (_ errorHandlerOption) ignoreDefaults() bool {
+	return false
+}
+
+func (e errorHandlerOption) String() string {
+	if e.fn == nil {
+		return print.P("WithErrorHandler", print.Obj(nil))
+	}
+	return print.P("WithErrorHandler", print.Obj(e.fn))
+}
+
+// IndexMode defines how a map with keys that are all array indexes is merged
+// onto an existing array.
+type IndexMode string
+
+const (
+	// IndexReplaces causes the map to replace the array.
+	IndexReplaces IndexMode = "IndexReplaces"
+
+	// IndexMerges causes each element of the map to be merged into the
+	// element of the array at the index.  An index past the end of the array
+	// is an error.
+	IndexMerges IndexMode = "IndexMerges"
+
+	// IndexExtends is like IndexMerges, but an index past the end of the
+	// array extends the array with null values as needed.
+	IndexExtends IndexMode = "IndexExtends"
+)
+
+// IndexMerge provides a way to change a single element of an array in an
+// earlier record instead of replacing the entire array.  When enabled, a map
+// with keys that are all array indexes is merged onto an existing array one
+// element at a time.  For example:
+//
+//	goschtalt.AddValueAt("override", []string{"servers", "1", "host"}, "newhost")
+//
+// only changes the host of the second server.
+//
+//   - IndexReplaces - The map replaces the array.
+//   - IndexMerges - The elements are merged into the array.  An index past
+//     the end of the array fails the compilation.
+//   - IndexExtends - The elements are merged into the array.  An index past
+//     the end of the array extends the array with null values as needed.
+//
+// # Default
+//
+// IndexReplaces
+func IndexMerge(mode IndexMode) Option {
+	switch mode {
+	case IndexReplaces, IndexMerges, IndexExtends:
+	default:
+		return WithError(
+			fmt.Errorf("%w, IndexMerge mode '%s' is not supported", ErrInvalidInput, mode),
+		)
+	}
+	return indexMergeOption(mode)
+}
+
+type indexMergeOption IndexMode
+
+func (i indexMergeOption) apply(opts *options) error {
+	opts.indexMode = IndexMode(i)
+	return nil
+}
+
+func (_ indexMergeOption) ignoreDefaults() bool {
+	return false
+}
+
+func (i indexMergeOption) String() string {
+	return print.P("IndexMerge", print.String(string(i)))
+}
+
+// DedupArrays removes duplicate elements from arrays that are assembled from
+// multiple records by appending or prepending.  Elements are compared by value
+// and the first occurrence of each element is kept.  This is useful for lists
+// like plugins or allowed origins that are built up from several files.
+//
+// Arrays that are replaced are not changed.
+//
+// The enable bool value is optional & assumed to be `true` if omitted.  The
+// first specified value is used if provided.  A value of `false` disables the
+// option.
+//
+// # Default
+//
+// Duplicate elements are kept.
+func DedupArrays(enable ...bool) Option {
+	enable = append(enable, true)
+	return dedupArraysOption(enable[0])
+}
+
+type dedupArraysOption bool
+
+func (d dedupArraysOption) apply(opts *options) error {
+	opts.dedupArrays = bool(d)
+	return nil
+}
+
+func (_ dedupArraysOption) ignoreDefaults() bool {
+	return false
+}
+
+func (d dedupArraysOption) String() string {
+	return print.P("DedupArrays", print.BoolSilentTrue(bool(d)))
+}
+
+// MergeStrategy defines how the value of a key in a record is merged with the
+// value of the same key in the earlier records.
+type MergeStrategy string
+
+const (
+	// MergeReplace causes the value to replace the existing value, even if
+	// both are maps or arrays.
+	MergeReplace MergeStrategy = "MergeReplace"
+
+	// MergeAppend causes an array to be appended to the existing array.  Maps
+	// and values are merged normally.
+	MergeAppend MergeStrategy = "MergeAppend"
+
+	// MergeDeepAppend causes an array to be merged into the existing array
+	// one element at a time.  The elements at the same index are merged
+	// together (so maps in the arrays are deep merged) and the extra elements
+	// are appended.  Maps and values are merged normally.
+	MergeDeepAppend MergeStrategy = "MergeDeepAppend"
+)
+
+// WithMergeRule sets how the values of the keys that match the keyGlob are
+// merged when compiling the configuration.  Normally maps are deep merged,
+// arrays are appended and values are replaced.
+//
+// The keyGlob is split using the key delimiter and each part may be a pattern
+// as supported by path.Match(), so `*` matches any single part of a key.  A
+// part that is exactly `**` matches any number of parts.  Array elements are
+// matched using their index, so `servers.*.tags` matches the tags of every
+// element of the servers array.
+//
+// WithMergeRule may be specified multiple times.  When more than one rule
+// matches a key the rule specified last is used.  A merge command in the key
+// of a record (like `plugins((replace))`) takes precedence over the rules.
+//
+// The rules only apply when a later record has a value for a key that an
+// earlier record also has.
+//
+// # Default
+//
+// No rules are used.
+func WithMergeRule(keyGlob string, strategy MergeStrategy) Option {
+	switch strategy {
+	case MergeReplace, MergeAppend, MergeDeepAppend:
+	default:
+		return WithError(
+			fmt.Errorf("%w, WithMergeRule strategy '%s' is not supported", ErrInvalidInput, strategy),
+		)
+	}
+
+	if _, err := path.Match(keyGlob, ""); err != nil || keyGlob == "" {
+		return WithError(
+			fmt.Errorf("%w, WithMergeRule keyGlob '%s' is invalid", ErrInvalidInput, keyGlob),
+		)
+	}
+
+	return mergeRule{
+		glob:     keyGlob,
+		strategy: strategy,
+	}
+}
+
+type mergeRule struct {
+	glob     string
+	strategy MergeStrategy
+}
+
+func (m mergeRule) apply(opts *options) error {
+	opts.mergeRules = append(opts.mergeRules, m)
+	return nil
+}
+
+func (_ mergeRule) ignoreDefaults() bool {
+	return false
+}
+
+func (m mergeRule) String() string {
+	return print.P("WithMergeRule", print.String(m.glob), print.String(string(m.strategy)))
+}
+
+// UniqueRecordNames causes [Config.Compile]() to return an error if two or
+// more records share the same name.  Records are sorted by name, so records
+// with the same name are merged in an order that is hard to predict.  This
+// catches accidental duplicates like two AddBuffer("config.json", ...) calls.
+//
+// All records are checked, including defaults and the files found by
+// filegroups, which are named by their base filename.
+//
+// The enable bool value is optional & assumed to be `true` if omitted.  The
+// first specified value is used if provided.  A value of `false` disables the
+// option.
+//
+// # Default
+//
+// Records with the same name are allowed.
+func UniqueRecordNames(enable ...bool) Option {
+	enable = append(enable, true)
+	return uniqueRecordNamesOption(enable[0])
+}
+
+type uniqueRecordNamesOption bool
+
+func (u uniqueRecordNamesOption) apply(opts *options) error {
+	opts.uniqueRecordNames = bool(u)
+	return nil
+}
+
+func (_ uniqueRecordNamesOption) ignoreDefaults() bool {
+	return false
+}
+
+func (u uniqueRecordNamesOption) String() string {
+	return print.P("UniqueRecordNames", print.BoolSilentTrue(bool(u)))
+}
+
+// RejectDuplicateKeys causes [Config.Compile]() to return an error if the same
+// key appears more than once in the same map of a single file or buffer.  Most
+// decoders silently keep the last value, which hides authoring mistakes.  The
+// error includes the key and where the repeated key was found.
+//
+// Duplicates are detected by the decoder, so they are only found by decoders
+// that support the RejectDuplicateKeys field of the [decoder.Context].  The
+// built in json and jsonc decoders support it.  Decoders that collapse the
+// duplicates before goschtalt sees the result are not able to report them.
+//
+// The enable bool value is optional & assumed to be `true` if omitted.  The
+// first specified value is used if provided.  A value of `false` disables the
+// option.
+//
+// # Default
+//
+// Duplicate keys are handled by the decoder, which generally keeps the last
+// value.
+func RejectDuplicateKeys(enable ...bool) Option {
+	enable = append(enable, true)
+	return rejectDuplicateKeysOption(enable[0])
+}
+
+type rejectDuplicateKeysOption bool
+
+func (r rejectDuplicateKeysOption) apply(opts *options) error {
+	opts.rejectDuplicateKeys = bool(r)
+	return nil
+}
+
+func (_ rejectDuplicateKeysOption) ignoreDefaults() bool {
+	return false
+}
+
+func (r rejectDuplicateKeysOption) String() string {
+	return print.P("RejectDuplicateKeys", print.BoolSilentTrue(bool(r)))
+}
+
 // ---- Options related helper functions follow --------------------------------
 
 func ignoreDefaultOpts(opts []Option) bool {
@@ -4,8 +4,10 @@
 package goschtalt
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	iofs "io/fs"
 	"path/filepath"
 	"sort"
 	"testing"
@@ -14,6 +16,7 @@ import (
 	"github.com/goschtalt/goschtalt/internal/fspath"
 	"github.com/goschtalt/goschtalt/pkg/decoder"
 	"github.com/goschtalt/goschtalt/pkg/encoder"
+	"github.com/goschtalt/goschtalt/pkg/meta"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -116,6 +119,20 @@ func TestOptions(t *testing.T) {
 					},
 				},
 			},
+		}, {
+			description: "AddSecretFile( /, filename )",
+			opt:         AddSecretFile(fs, "filename"),
+			str:         "AddSecretFile( fs, 'filename' )",
+			goal: options{
+				filegroups: []filegroup{
+					{
+						fs:        fs,
+						paths:     []string{"filename"},
+						exactFile: true,
+						secret:    true,
+					},
+				},
+			},
 		}, {
 			description: "AddFiles( / )",
 			opt:         AddFiles(fs),
@@ -187,6 +204,79 @@ func TestOptions(t *testing.T) {
 					},
 				},
 			},
+		}, {
+			description: "AddTree( /, path, MaxDepth(2) )",
+			opt:         AddTree(fs, "./path", MaxDepth(2)),
+			str:         "AddTree( fs, './path', MaxDepth(2) )",
+			check: func(cfg *options) bool {
+				return len(cfg.filegroups) == 1 &&
+					cfg.filegroups[0].maxDepth != nil &&
+					*cfg.filegroups[0].maxDepth == 2
+			},
+		}, {
+			description: "AddDir( /, path, OnlyExtensions(.JSON, yml) )",
+			opt:         AddDir(fs, "./path", OnlyExtensions(".JSON", "yml")),
+			str:         "AddDir( fs, './path', OnlyExtensions('.JSON', 'yml') )",
+			check: func(cfg *options) bool {
+				return len(cfg.filegroups) == 1 &&
+					len(cfg.filegroups[0].extensions) == 2 &&
+					cfg.filegroups[0].extensions[0] == "json" &&
+					cfg.filegroups[0].extensions[1] == "yml"
+			},
+		}, {
+			description: "AddDir( /, path, OnlyExtensions() )",
+			opt:         AddDir(fs, "./path", OnlyExtensions()),
+			str:         "AddDir( fs, './path', OnlyExtensions('') )",
+			expectErr:   ErrInvalidInput,
+		}, {
+			description: "AddDir( /, path, OnlyExtensions('') )",
+			opt:         AddDir(fs, "./path", OnlyExtensions("")),
+			str:         "AddDir( fs, './path', OnlyExtensions('') )",
+			expectErr:   ErrInvalidInput,
+		}, {
+			description: "AddTree( /, path, Strict() )",
+			opt:         AddTree(fs, "./path", Strict()),
+			str:         "AddTree( fs, './path', Strict() )",
+			check: func(cfg *options) bool {
+				return len(cfg.filegroups) == 1 &&
+					cfg.filegroups[0].policy == policyStrict
+			},
+		}, {
+			description: "AddFile( /, file, BestEffort() )",
+			opt:         AddFile(fs, "file", BestEffort()),
+			str:         "AddFile( fs, 'file', BestEffort() )",
+			check: func(cfg *options) bool {
+				return len(cfg.filegroups) == 1 &&
+					cfg.filegroups[0].exactFile &&
+					cfg.filegroups[0].policy == policyBestEffort
+			},
+		}, {
+			description: "AddTreeHalt( /, path, MaxDepth(-1) )",
+			opt:         AddTreeHalt(fs, "./path", MaxDepth(-1)),
+			str:         "AddTreeHalt( fs, './path', MaxDepth(-1) )",
+			expectErr:   ErrInvalidInput,
+		}, {
+			description: "AddTreeIf( true, /, path1, path2 )",
+			opt:         AddTreeIf(true, fs, "./path1", "./path2"),
+			str:         "AddTreeIf( true, fs, './path1', './path2' )",
+			check: func(cfg *options) bool {
+				return len(cfg.filegroups) == 1 &&
+					cfg.filegroups[0].recurse &&
+					cfg.filegroups[0].when() &&
+					cfg.filegroups[0].text == "AddTreeIf( true, fs, './path1', './path2' )"
+			},
+		}, {
+			description: "AddDir( /, path, When(nil) )",
+			opt:         AddDir(fs, "./path", When(nil)),
+			str:         "AddDir( fs, './path', When(nil) )",
+			goal: options{
+				filegroups: []filegroup{
+					{
+						fs:    fs,
+						paths: []string{"./path"},
+					},
+				},
+			},
 		}, {
 			description: "AddTrees( /, path1, path2 )",
 			opt:         AddTrees(fs, "./path1", "./path2"),
@@ -250,6 +340,30 @@ func TestOptions(t *testing.T) {
 					},
 				},
 			},
+		}, {
+			description: "AddLayeredDirs( [/, /], config.json )",
+			opt:         AddLayeredDirs([]iofs.FS{fs, fs}, "config.json", Strict()),
+			str:         "AddLayeredDirs( fs, fs, 'config.json', Strict() )",
+			goal: options{
+				filegroups: []filegroup{
+					{
+						fs:     fs,
+						paths:  []string{"config.json"},
+						layer:  1,
+						policy: policyStrict,
+					}, {
+						fs:     fs,
+						paths:  []string{"config.json"},
+						layer:  1,
+						policy: policyStrict,
+					},
+				},
+			},
+		}, {
+			description: "AddLayeredDirs( [], '' )",
+			opt:         AddLayeredDirs(nil, ""),
+			str:         "AddLayeredDirs( '' )",
+			expectErr:   ErrInvalidInput,
 		}, {
 			description: "AutoCompile()",
 			opt:         AutoCompile(),
@@ -261,6 +375,227 @@ func TestOptions(t *testing.T) {
 			goal: options{
 				disableAutoCompile: true,
 			},
+		}, {
+			description: "DedupArrays()",
+			opt:         DedupArrays(),
+			str:         "DedupArrays()",
+			goal: options{
+				dedupArrays: true,
+			},
+		}, {
+			description: "DedupArrays(false)",
+			opt:         DedupArrays(false),
+			str:         "DedupArrays( false )",
+		}, {
+			description: "UniqueRecordNames()",
+			opt:         UniqueRecordNames(),
+			str:         "UniqueRecordNames()",
+			goal: options{
+				uniqueRecordNames: true,
+			},
+		}, {
+			description: "UniqueRecordNames(false)",
+			opt:         UniqueRecordNames(false),
+			str:         "UniqueRecordNames( false )",
+		}, {
+			description: "RejectDuplicateKeys()",
+			opt:         RejectDuplicateKeys(),
+			str:         "RejectDuplicateKeys()",
+			goal: options{
+				rejectDuplicateKeys: true,
+			},
+		}, {
+			description: "RejectDuplicateKeys(false)",
+			opt:         RejectDuplicateKeys(false),
+			str:         "RejectDuplicateKeys( false )",
+		}, {
+			description: "SchemaFromFirstGroup()",
+			opt:         SchemaFromFirstGroup(),
+			str:         "SchemaFromFirstGroup()",
+			goal: options{
+				schemaFromFirstGroup: true,
+			},
+		}, {
+			description: "SchemaFromFirstGroup(false)",
+			opt:         SchemaFromFirstGroup(false),
+			str:         "SchemaFromFirstGroup( false )",
+		}, {
+			description: "WithErrorHandler( nil )",
+			opt:         WithErrorHandler(nil),
+			str:         "WithErrorHandler( nil )",
+		}, {
+			description: "WithErrorHandler( fn )",
+			opt:         WithErrorHandler(func(error) {}),
+			str:         "WithErrorHandler( func(error) )",
+			check: func(cfg *options) bool {
+				return cfg.errorHandler != nil
+			},
+		}, {
+			description: "WithDerivedDefault( 'a', fn )",
+			opt:         WithDerivedDefault("a", func(*Config) (any, bool) { return nil, false }),
+			str:         "WithDerivedDefault( 'a', func(*goschtalt.Config) (interface {}, bool) )",
+			check: func(cfg *options) bool {
+				return len(cfg.derivedDefaults) == 1 && cfg.derivedDefaults[0].key == "a"
+			},
+		}, {
+			description: "WithDerivedDefault( '', fn )",
+			opt:         WithDerivedDefault("", func(*Config) (any, bool) { return nil, false }),
+			str:         "WithDerivedDefault( '', func(*goschtalt.Config) (interface {}, bool) )",
+			expectErr:   ErrInvalidInput,
+		}, {
+			description: "WithDerivedDefault( 'a', nil )",
+			opt:         WithDerivedDefault("a", nil),
+			str:         "WithDerivedDefault( 'a', func(*goschtalt.Config) (interface {}, bool) )",
+			expectErr:   ErrInvalidInput,
+		}, {
+			description: "WithMergeTrace()",
+			opt:         WithMergeTrace(),
+			str:         "WithMergeTrace()",
+			goal: options{
+				mergeTrace: true,
+			},
+		}, {
+			description: "KeepCache()",
+			opt:         KeepCache(),
+			str:         "KeepCache()",
+			goal: options{
+				keepCache: true,
+			},
+		}, {
+			description: "KeepCache(false)",
+			opt:         KeepCache(false),
+			str:         "KeepCache( false )",
+			goal:        options{},
+		}, {
+			description: "WithMergeTrace(false)",
+			opt:         WithMergeTrace(false),
+			str:         "WithMergeTrace( false )",
+		}, {
+			description: "SuppressWarnings( a.b, c.* )",
+			opt:         SuppressWarnings("a.b", "c.*"),
+			str:         "SuppressWarnings( 'a.b', 'c.*' )",
+			goal: options{
+				suppressWarnings: []string{"a.b", "c.*"},
+			},
+		}, {
+			description: "SuppressWarnings( [ )",
+			opt:         SuppressWarnings("["),
+			str:         "SuppressWarnings( '[' )",
+			expectErr:   ErrInvalidInput,
+		}, {
+			description: "WithTimings( nil )",
+			opt:         WithTimings(nil),
+			str:         "WithTimings( nil )",
+		}, {
+			description: "WithTimings( &Timings{} )",
+			opt:         WithTimings(&Timings{}),
+			str:         "WithTimings( *goschtalt.Timings )",
+			check: func(cfg *options) bool {
+				return cfg.timings != nil
+			},
+		}, {
+			description: "WithLeafMerge( nil )",
+			opt:         WithLeafMerge(nil),
+			str:         "WithLeafMerge( nil )",
+		}, {
+			description: "WithLeafMerge( fn )",
+			opt: WithLeafMerge(func(string, meta.Object, meta.Object) (meta.Object, error) {
+				return meta.Object{}, nil
+			}),
+			str: "WithLeafMerge( goschtalt.LeafMergeFunc )",
+			check: func(cfg *options) bool {
+				return cfg.leafMerge != nil
+			},
+		}, {
+			description: "SelectRoot( a.b, Optional() )",
+			opt:         SelectRoot("a.b", Optional()),
+			str:         "SelectRoot( 'a.b', Optional() )",
+			goal: options{
+				selectRoot:         "a.b",
+				selectRootOptional: true,
+			},
+		}, {
+			description: "NullMerge( NullIgnored )",
+			opt:         NullMerge(NullIgnored),
+			str:         "NullMerge( 'NullIgnored' )",
+			goal: options{
+				nullMode: NullIgnored,
+			},
+		}, {
+			description: "NullMerge( invalid )",
+			opt:         NullMerge(NullMode("invalid")),
+			str:         "WithError( 'input is invalid, NullMerge mode 'invalid' is not supported' )",
+			expectErr:   ErrInvalidInput,
+		}, {
+			description: "IndexMerge( IndexExtends )",
+			opt:         IndexMerge(IndexExtends),
+			str:         "IndexMerge( 'IndexExtends' )",
+			goal: options{
+				indexMode: IndexExtends,
+			},
+		}, {
+			description: "IndexMerge( invalid )",
+			opt:         IndexMerge(IndexMode("invalid")),
+			str:         "WithError( 'input is invalid, IndexMerge mode 'invalid' is not supported' )",
+			expectErr:   ErrInvalidInput,
+		}, {
+			description: "WithMergeRule( plugins, MergeAppend )",
+			opt:         WithMergeRule("plugins", MergeAppend),
+			str:         "WithMergeRule( 'plugins', 'MergeAppend' )",
+			goal: options{
+				mergeRules: []mergeRule{
+					{glob: "plugins", strategy: MergeAppend},
+				},
+			},
+		}, {
+			description: "WithMergeRule( plugins, invalid )",
+			opt:         WithMergeRule("plugins", MergeStrategy("invalid")),
+			str:         "WithError( 'input is invalid, WithMergeRule strategy 'invalid' is not supported' )",
+			expectErr:   ErrInvalidInput,
+		}, {
+			description: "MergeDirectives( .$merge )",
+			opt:         MergeDirectives(".$merge"),
+			str:         "MergeDirectives( '.$merge' )",
+			goal: options{
+				mergeDirective: ".$merge",
+			},
+		}, {
+			description: "MergeDirectives( '' )",
+			opt:         MergeDirectives(""),
+			str:         "MergeDirectives()",
+		}, {
+			description: "ValidateSchema(...)",
+			opt:         ValidateSchema([]byte(`{"type":"object"}`)),
+			str:         "ValidateSchema( ... )",
+			check: func(cfg *options) bool {
+				return len(cfg.schemas) == 1
+			},
+		}, {
+			description: "ValidateSchema(invalid)",
+			opt:         ValidateSchema([]byte(`[`)),
+			str:         "WithError( 'input is invalid, ValidateSchema() err: invalid schema: unexpected EOF' )",
+			expectErr:   ErrInvalidInput,
+		}, {
+			description: "DetectLeakedSecrets()",
+			opt:         DetectLeakedSecrets(),
+			str:         "DetectLeakedSecrets()",
+			check: func(cfg *options) bool {
+				return cfg.leakDetector != nil &&
+					cfg.leakDetector.minLength == 20 &&
+					len(cfg.leakDetector.patterns) == 2 &&
+					!cfg.leakDetector.fail
+			},
+		}, {
+			description: "DetectLeakedSecrets( LeakEntropy(10, 3.5), LeakPatterns(a, b), LeakIsError() )",
+			opt:         DetectLeakedSecrets(LeakEntropy(10, 3.5), LeakPatterns("a", "b"), LeakIsError()),
+			str:         "DetectLeakedSecrets( LeakEntropy(10, 3.5), LeakPatterns('a', 'b'), LeakIsError() )",
+			check: func(cfg *options) bool {
+				return cfg.leakDetector != nil &&
+					cfg.leakDetector.minLength == 10 &&
+					cfg.leakDetector.minEntropy == 3.5 &&
+					len(cfg.leakDetector.patterns) == 2 &&
+					cfg.leakDetector.fail
+			},
 		}, {
 			description: "SetKeyDelimiter( . )",
 			opt:         SetKeyDelimiter("."),
@@ -399,6 +734,47 @@ func TestOptions(t *testing.T) {
 			goal: options{
 				marshalOptions: []MarshalOption{redactSecretsOption(true), includeOriginsOption(true), formatAsOption("foo")},
 			},
+		}, {
+			description: "DefaultMarshalOptions( FormatAsTable() )",
+			opt:         DefaultMarshalOptions(FormatAsTable()),
+			str:         "DefaultMarshalOptions( FormatAsTable() )",
+			goal: options{
+				marshalOptions: []MarshalOption{formatAsTableOption{}},
+			},
+		}, {
+			description: "DefaultMarshalOptions( FormatAsJSONOptions{} )",
+			opt: DefaultMarshalOptions(
+				FormatAsJSONOptions{},
+				FormatAsJSONOptions{Indent: "    ", Compact: true},
+			),
+			str: "DefaultMarshalOptions( FormatAsJSONOptions(), FormatAsJSONOptions(Indent: '    ', Compact: true) )",
+			goal: options{
+				marshalOptions: []MarshalOption{
+					FormatAsJSONOptions{},
+					FormatAsJSONOptions{Indent: "    ", Compact: true},
+				},
+			},
+		}, {
+			description: "DefaultMarshalOptions( StrictFormat(), StrictFormat(false) )",
+			opt:         DefaultMarshalOptions(StrictFormat(), StrictFormat(false)),
+			str:         "DefaultMarshalOptions( StrictFormat(), StrictFormat(false) )",
+			goal: options{
+				marshalOptions: []MarshalOption{strictFormatOption(true), strictFormatOption(false)},
+			},
+		}, {
+			description: "DefaultMarshalOptions( WithMarshalMapper() )",
+			opt:         DefaultMarshalOptions(WithMarshalMapper(SnakeCaseMapper())),
+			str:         "DefaultMarshalOptions( WithMarshalMapper(*goschtalt.casemapper) )",
+			check: func(cfg *options) bool {
+				return len(cfg.marshalOptions) == 1
+			},
+		}, {
+			description: "DefaultMarshalOptions( FlattenSingleKeyMaps(), FlattenSingleKeyMaps(false) )",
+			opt:         DefaultMarshalOptions(FlattenSingleKeyMaps(), FlattenSingleKeyMaps(false)),
+			str:         "DefaultMarshalOptions( FlattenSingleKeyMaps(), FlattenSingleKeyMaps(false) )",
+			goal: options{
+				marshalOptions: []MarshalOption{flattenSingleKeyMapsOption(true), flattenSingleKeyMapsOption(false)},
+			},
 		}, {
 			description: "DefaultMarshalOptions( RedactSecrets(false), IncludeOrigins(false) )",
 			opt:         DefaultMarshalOptions(RedactSecrets(false), IncludeOrigins(false)),
@@ -442,6 +818,25 @@ func TestOptions(t *testing.T) {
 					&validatorOption{},
 				},
 			},
+		}, {
+			description: "DefaultUnmarshalOptions( WithBoolKeywords() )",
+			opt:         DefaultUnmarshalOptions(WithBoolKeywords()),
+			str:         "DefaultUnmarshalOptions( WithBoolKeywords() )",
+			goal: options{
+				unmarshalOptions: []UnmarshalOption{&boolKeywordsOption{}},
+			},
+		}, {
+			description: "DefaultUnmarshalOptions( WithInterfaceType(a.b, testSquare{}) )",
+			opt:         DefaultUnmarshalOptions(WithInterfaceType("a.b", testSquare{})),
+			str:         "DefaultUnmarshalOptions( WithInterfaceType('a.b', goschtalt.testSquare) )",
+			goal: options{
+				unmarshalOptions: []UnmarshalOption{
+					&interfaceTypeOption{
+						field:    "a.b",
+						concrete: testSquare{},
+					},
+				},
+			},
 		}, {
 			description: "DefaultUnmarshalOptions( Optional(false), Required(false) )",
 			opt:         DefaultUnmarshalOptions(Optional(false), Required(false)),
@@ -525,6 +920,17 @@ func TestOptions(t *testing.T) {
 				},
 			},
 			str: "DefaultUnmarshalOptions( Strictness('NONE'), Strictness('SUBSET'), Strictness('COMPLETE'), Strictness('EXACT'), Strictness('Invalid'), TagName('tag') )",
+		}, {
+			description: "DefaultUnmarshalOptions( WithBase64Decode(...) )",
+			opt:         DefaultUnmarshalOptions(WithBase64Decode("a.b", "c")),
+			goal: options{
+				unmarshalOptions: []UnmarshalOption{
+					&base64DecodeOption{
+						keys: []string{"a.b", "c"},
+					},
+				},
+			},
+			str: "DefaultUnmarshalOptions( WithBase64Decode('a.b', 'c') )",
 		}, {
 			description: "DefaultValueOptions()",
 			opt:         DefaultValueOptions(),
@@ -581,6 +987,61 @@ func TestOptions(t *testing.T) {
 				}
 				return false
 			},
+		}, {
+			description: "AddBufferGetterCtx( filename.ext, nil )",
+			opt:         AddBufferGetterCtx("filename.ext", nil),
+			str:         "AddBufferGetterCtx( 'filename.ext', nil )",
+			expectErr:   unknownErr,
+		}, {
+			description: "AddBufferGetterCtx( filename.ext, func )",
+			opt: AddBufferGetterCtx("filename.ext",
+				BufferGetterCtxFunc(func(context.Context, string, Unmarshaler) ([]byte, error) {
+					return nil, nil
+				}),
+			),
+			str: "AddBufferGetterCtx( 'filename.ext', goschtalt.BufferGetterCtxFunc )",
+			check: func(cfg *options) bool {
+				if len(cfg.values) == 1 {
+					if cfg.values[0].name == "filename.ext" {
+						if cfg.values[0].buf.getter != nil {
+							return true
+						}
+					}
+				}
+				return false
+			},
+		}, {
+			description: "AddValueGetterCtx( record1, '', func )",
+			opt: AddValueGetterCtx("record1", Root,
+				ValueGetterCtxFunc(func(context.Context, string, Unmarshaler) (any, error) {
+					return nil, nil
+				}),
+			),
+			str: "AddValueGetterCtx( 'record1', '', goschtalt.ValueGetterCtxFunc )",
+			check: func(cfg *options) bool {
+				if len(cfg.values) == 1 {
+					if cfg.values[0].name == "record1" {
+						if cfg.values[0].val.getter != nil {
+							return true
+						}
+					}
+				}
+				return false
+			},
+		}, {
+			description: "AddValueGetterCtx( record1, 'key', nil )",
+			opt:         AddValueGetterCtx("record1", "key", nil),
+			str:         "AddValueGetterCtx( 'record1', 'key', nil )",
+			check: func(cfg *options) bool {
+				if len(cfg.values) == 1 {
+					if cfg.values[0].name == "record1" {
+						if cfg.values[0].val.getter == nil {
+							return true
+						}
+					}
+				}
+				return false
+			},
 		}, {
 			description: "AddValueGetter( record1, '', func )",
 			opt: AddValueGetter("record1", Root,
@@ -627,6 +1088,18 @@ func TestOptions(t *testing.T) {
 				}
 				return false
 			},
+		}, {
+			description: "AddValueAt( record1, ['a.b', 'c'], nil, AsDefault )",
+			opt:         AddValueAt("record1", []string{"a.b", "c"}, nil, AsDefault()),
+			str:         "AddValueAt( 'record1', 'a.b', 'c', nil, AsDefault() )",
+			check: func(cfg *options) bool {
+				if len(cfg.defaults) == 1 {
+					if cfg.defaults[0].name == "record1" {
+						return assert.Equal(t, []string{"a.b", "c"}, cfg.defaults[0].val.path)
+					}
+				}
+				return false
+			},
 		}, {
 			description: "AddValue( record1, key, nil, AsDefault )",
 			opt:         AddValue("record1", "key", nil, AsDefault()),
@@ -641,6 +1114,18 @@ func TestOptions(t *testing.T) {
 				}
 				return false
 			},
+		}, {
+			description: "AddStructDefaults( record1, key, nil, AsDefault(false) )",
+			opt:         AddStructDefaults("record1", "key", nil, AsDefault(false)),
+			str:         "AddStructDefaults( 'record1', 'key', nil, AsDefault(false) )",
+			check: func(cfg *options) bool {
+				if len(cfg.defaults) == 1 {
+					if cfg.defaults[0].name == "record1" {
+						return cfg.defaults[0].val.structDefaults
+					}
+				}
+				return false
+			},
 		}, {
 			description: "AddValue( record1, key, nil, AsDefault(false) )",
 			opt:         AddValue("record1", "key", nil, AsDefault(false)),
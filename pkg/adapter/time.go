@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"math"
 	"reflect"
+	"strings"
 	"time"
 
 	"github.com/goschtalt/goschtalt"
@@ -24,21 +25,30 @@ func AllButTime(t any) bool {
 
 // TimeUnmarshal converts a string to a time.Time if possible, or returns an
 // error indicating the failure.  The specified layout is used as the string
-// form.
-func TimeUnmarshal(layout string) goschtalt.UnmarshalOption {
-	return goschtalt.AdaptFromCfg(marshalTime{layout: layout},
-		fmt.Sprintf("TimeUnmarshal['%s']", layout))
+// form.  Any additional layouts are tried in order if the string doesn't
+// match the layout, so both dates and times can be accepted:
+//
+//	adapter.TimeUnmarshal(time.RFC3339, time.DateOnly)
+//
+// If none of the layouts match, the error lists the layouts attempted.
+func TimeUnmarshal(layout string, more ...string) goschtalt.UnmarshalOption {
+	layouts := append([]string{layout}, more...)
+	return goschtalt.AdaptFromCfg(marshalTime{layout: layout, more: more},
+		fmt.Sprintf("TimeUnmarshal['%s']", strings.Join(layouts, "', '")))
 }
 
 // MarshalTime converts a time.Time into its configuration form. The
 // configuration form is a string matching the specified layout.
 func MarshalTime(layout string) goschtalt.ValueOption {
-	return goschtalt.AdaptToCfg(marshalTime{layout},
+	return goschtalt.AdaptToCfg(marshalTime{layout: layout},
 		fmt.Sprintf("MarshalTime['%s']", layout))
 }
 
 type marshalTime struct {
 	layout string
+
+	// Additional layouts to try when parsing.
+	more []string
 }
 
 func (t marshalTime) From(from, to reflect.Value) (any, error) {
@@ -61,15 +71,31 @@ func (t marshalTime) From(from, to reflect.Value) (any, error) {
 		sec = int64(got)
 		nsec = int64((got - float64(sec)) * 1e9)
 	case reflect.String:
-		pt, err := time.Parse(t.layout, from.Interface().(string))
-		if err != nil {
-			return nil, err
-		}
+		return t.parse(from.Interface().(string))
+	}
+
+	return time.Unix(sec, nsec).UTC(), nil
+}
 
+// parse tries each layout in order and returns the first successful result.
+func (t marshalTime) parse(s string) (any, error) {
+	pt, err := time.Parse(t.layout, s)
+	if err == nil {
 		return pt.UTC(), nil
 	}
+	if len(t.more) == 0 {
+		return nil, err
+	}
 
-	return time.Unix(sec, nsec).UTC(), nil
+	for _, layout := range t.more {
+		if pt, err := time.Parse(layout, s); err == nil {
+			return pt.UTC(), nil
+		}
+	}
+
+	layouts := append([]string{t.layout}, t.more...)
+	return nil, fmt.Errorf("'%s' does not match any of the layouts: '%s'",
+		s, strings.Join(layouts, "', '"))
 }
 
 func (t marshalTime) To(from reflect.Value) (any, error) {
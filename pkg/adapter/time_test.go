@@ -6,10 +6,12 @@ package adapter
 import (
 	"math"
 	"net"
+	"reflect"
 	"testing"
 	"time"
 
 	"github.com/goschtalt/goschtalt"
+	"github.com/stretchr/testify/assert"
 )
 
 func TestTimeValueAdapterInternals(t *testing.T) {
@@ -38,6 +40,30 @@ func TestTimeUnmarshalAdapterInternals(t *testing.T) {
 			to:          time.Time{},
 			obj:         marshalTime{layout: "2006-01-02"},
 			expect:      time.Date(2022, time.January, 30, 0, 0, 0, 0, time.UTC),
+		}, {
+			description: "marshalTime with several layouts, the first",
+			from:        "2025-01-01T01:02:03Z",
+			to:          time.Time{},
+			obj:         marshalTime{layout: time.RFC3339, more: []string{time.DateOnly}},
+			expect:      time.Date(2025, time.January, 1, 1, 2, 3, 0, time.UTC),
+		}, {
+			description: "marshalTime with several layouts, the second",
+			from:        "2025-01-01",
+			to:          time.Time{},
+			obj:         marshalTime{layout: time.RFC3339, more: []string{time.DateOnly}},
+			expect:      time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC),
+		}, {
+			description: "marshalTime with several layouts, the third",
+			from:        "01/02/2025",
+			to:          time.Time{},
+			obj:         marshalTime{layout: time.RFC3339, more: []string{time.DateOnly, "01/02/2006"}},
+			expect:      time.Date(2025, time.January, 2, 0, 0, 0, 0, time.UTC),
+		}, {
+			description: "marshalTime with several layouts, none match",
+			from:        "dogs",
+			to:          time.Time{},
+			obj:         marshalTime{layout: time.RFC3339, more: []string{time.DateOnly}},
+			expectErr:   errUnknown,
 		}, {
 			description: "marshalTime from unix time in UTC (int)",
 			from:        int(1737483492),
@@ -79,3 +105,11 @@ func TestTimeUnmarshalAdapterInternals(t *testing.T) {
 
 	testUnmarshalAdapters(t, tests)
 }
+
+func TestTimeUnmarshalLayoutsError(t *testing.T) {
+	_, err := marshalTime{layout: time.RFC3339, more: []string{time.DateOnly}}.
+		From(reflect.ValueOf("dogs"), reflect.ValueOf(time.Time{}))
+
+	assert.EqualError(t, err,
+		"'dogs' does not match any of the layouts: '2006-01-02T15:04:05Z07:00', '2006-01-02'")
+}
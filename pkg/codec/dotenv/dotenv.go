@@ -0,0 +1,377 @@
+// SPDX-FileCopyrightText: 2023 Weston Schmidt <weston_schmidt@alumni.purdue.edu>
+// SPDX-License-Identifier: Apache-2.0
+
+// Package dotenv provides a decoder for dotenv (.env) files.
+//
+// Each line is a KEY=value pair, optionally prefixed with export.  Blank
+// lines and lines starting with # are ignored.  For example:
+//
+//	# The database.
+//	export DB_HOST=localhost
+//	DB_PORT=5432  # A trailing comment.
+//	DB_NAME="app\tdb"
+//	DB_PASSWORD='p@ss#word'
+//
+// Unquoted values are trimmed and end at a # that follows whitespace.  Single
+// quoted values are literal.  Double quoted values support the \n, \r, \t,
+// \", \\ and \$ escape sequences.  Quoted values may span multiple lines.
+// Variables are not expanded, use the goschtalt expansion options instead.
+//
+// All values are strings.  Keys are split into nested maps using the key
+// delimiter from the decoder.Context, so with goschtalt.SetKeyDelimiter("_")
+// the key DB_HOST is decoded as the HOST key inside of the DB map.  With the
+// default "." delimiter, keys like db.host are nested and keys like DB_HOST
+// are kept as they are.  Empty parts of a key are not allowed.
+package dotenv
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/goschtalt/goschtalt/pkg/decoder"
+	"github.com/goschtalt/goschtalt/pkg/meta"
+)
+
+var (
+	ErrSyntax = errors.New("invalid dotenv")
+)
+
+var _ decoder.Decoder = (*Codec)(nil)
+
+// Codec is a dotenv decoder.
+type Codec struct {
+	// Lowercase converts the keys to lower case, so DB_HOST becomes db_host
+	// (or db.host with a key delimiter of "_").
+	Lowercase bool
+}
+
+// Extensions returns the supported extensions.
+func (c Codec) Extensions() []string {
+	return []string{"env"}
+}
+
+// Decode decodes a byte array into the meta.Object tree.
+func (c Codec) Decode(ctx decoder.Context, b []byte, m *meta.Object) error {
+	p := parser{
+		file: ctx.Filename,
+		src:  strings.TrimPrefix(string(b), "\uFEFF"),
+		line: 1,
+		col:  1,
+	}
+
+	root := meta.Object{
+		Origins: []meta.Origin{{File: ctx.Filename, Line: 1, Col: 1}},
+		Map:     make(map[string]meta.Object),
+	}
+
+	for {
+		key, val, found, err := p.next()
+		if err != nil {
+			return fmt.Errorf("%s: %w", ctx.Filename, err)
+		}
+		if !found {
+			break
+		}
+
+		if c.Lowercase {
+			key = strings.ToLower(key)
+		}
+
+		path := []string{key}
+		if ctx.Delimiter != "" {
+			path = strings.Split(key, ctx.Delimiter)
+		}
+
+		if err := c.add(&root, path, val, ctx.RejectDuplicateKeys); err != nil {
+			return fmt.Errorf("%s: %w", ctx.Filename, err)
+		}
+	}
+
+	*m = root
+	return nil
+}
+
+// add sets the value at the path, creating the maps as needed.  The last
+// value specified for a key is used.
+func (c Codec) add(obj *meta.Object, path []string, val meta.Object, unique bool) error {
+	key := path[0]
+	if key == "" {
+		return fmt.Errorf("%w: the key at %s has an empty part", ErrSyntax, val.Origins[0])
+	}
+
+	child, found := obj.Map[key]
+
+	if len(path) == 1 {
+		switch {
+		case found && child.Map != nil:
+			return fmt.Errorf("%w: '%s' at %s is already a map", ErrSyntax, key, val.Origins[0])
+		case found && unique:
+			return fmt.Errorf("%w: '%s' at %s", decoder.ErrDuplicateKey, key, val.Origins[0])
+		}
+
+		obj.Map[key] = val
+		return nil
+	}
+
+	if !found {
+		child = meta.Object{
+			Origins: val.Origins,
+			Map:     make(map[string]meta.Object),
+		}
+	} else if child.Map == nil {
+		return fmt.Errorf("%w: '%s' at %s is already a value", ErrSyntax, key, val.Origins[0])
+	}
+
+	if err := c.add(&child, path[1:], val, unique); err != nil {
+		return err
+	}
+
+	obj.Map[key] = child
+	return nil
+}
+
+type parser struct {
+	file string
+	src  string
+	pos  int
+	line int
+	col  int
+}
+
+func (p *parser) eof() bool {
+	return p.pos >= len(p.src)
+}
+
+func (p *parser) peek() byte {
+	if p.eof() {
+		return 0
+	}
+	return p.src[p.pos]
+}
+
+// advance consumes a single byte, tracking the line and column.
+func (p *parser) advance() byte {
+	c := p.src[p.pos]
+	p.pos++
+	p.col++
+	if c == '\n' {
+		p.line++
+		p.col = 1
+	}
+	return c
+}
+
+func (p *parser) origin() meta.Origin {
+	return meta.Origin{
+		File: p.file,
+		Line: p.line,
+		Col:  p.col,
+	}
+}
+
+func (p *parser) errorf(format string, args ...any) error {
+	return fmt.Errorf("%w: line %d, column %d: %s", ErrSyntax, p.line, p.col,
+		fmt.Sprintf(format, args...))
+}
+
+func (p *parser) skipSpace() {
+	for p.peek() == ' ' || p.peek() == '\t' {
+		p.advance()
+	}
+}
+
+// skipLine consumes everything up to and including the next newline.
+func (p *parser) skipLine() {
+	for !p.eof() {
+		if p.advance() == '\n' {
+			return
+		}
+	}
+}
+
+// endOfLine consumes the optional comment and the newline after a value.
+func (p *parser) endOfLine() error {
+	p.skipSpace()
+	switch p.peek() {
+	case 0, '\n', '#':
+	case '\r':
+		if !strings.HasPrefix(p.src[p.pos:], "\r\n") {
+			return p.errorf("unexpected carriage return")
+		}
+	default:
+		return p.errorf("unexpected character '%c' after the value", p.peek())
+	}
+
+	p.skipLine()
+	return nil
+}
+
+// next returns the next key and value in the document.
+func (p *parser) next() (string, meta.Object, bool, error) {
+	for {
+		p.skipSpace()
+		switch p.peek() {
+		case 0:
+			return "", meta.Object{}, false, nil
+		case '#', '\n':
+			p.skipLine()
+			continue
+		case '\r':
+			if err := p.endOfLine(); err != nil {
+				return "", meta.Object{}, false, err
+			}
+			continue
+		}
+
+		key, err := p.key()
+		if err != nil {
+			return "", meta.Object{}, false, err
+		}
+
+		p.skipSpace()
+		if p.peek() != '=' {
+			return "", meta.Object{}, false, p.errorf("expected '=' after the key '%s'", key)
+		}
+		p.advance()
+		p.skipSpace()
+
+		origin := p.origin()
+		val, err := p.value()
+		if err != nil {
+			return "", meta.Object{}, false, err
+		}
+
+		return key, meta.Object{
+			Origins: []meta.Origin{origin},
+			Value:   val,
+		}, true, nil
+	}
+}
+
+// key reads the key, skipping the optional export prefix.
+func (p *parser) key() (string, error) {
+	if strings.HasPrefix(p.src[p.pos:], "export") {
+		rest := p.src[p.pos+len("export"):]
+		if strings.HasPrefix(rest, " ") || strings.HasPrefix(rest, "\t") {
+			for range "export" {
+				p.advance()
+			}
+			p.skipSpace()
+		}
+	}
+
+	start := p.pos
+	for !p.eof() && isKey(p.peek()) {
+		p.advance()
+	}
+
+	if start == p.pos {
+		return "", p.errorf("expected a key")
+	}
+
+	return p.src[start:p.pos], nil
+}
+
+func isKey(c byte) bool {
+	return c == '_' || c == '.' || c == '-' ||
+		('a' <= c && c <= 'z') ||
+		('A' <= c && c <= 'Z') ||
+		('0' <= c && c <= '9')
+}
+
+// value reads the value and the rest of the line.
+func (p *parser) value() (string, error) {
+	var val string
+	var err error
+
+	switch p.peek() {
+	case '\'':
+		val, err = p.singleQuoted()
+	case '"':
+		val, err = p.doubleQuoted()
+	default:
+		return p.unquoted(), nil
+	}
+
+	if err != nil {
+		return "", err
+	}
+
+	return val, p.endOfLine()
+}
+
+// unquoted reads the value up to the end of the line or a comment that
+// follows whitespace.
+func (p *parser) unquoted() string {
+	start := p.pos
+	end := p.pos
+	for !p.eof() {
+		c := p.peek()
+		if c == '\n' {
+			break
+		}
+		if c == '#' && p.pos > 0 && (p.src[p.pos-1] == ' ' || p.src[p.pos-1] == '\t') {
+			break
+		}
+		p.advance()
+		if c != ' ' && c != '\t' && c != '\r' {
+			end = p.pos
+		}
+	}
+
+	val := p.src[start:end]
+	p.skipLine()
+	return val
+}
+
+func (p *parser) singleQuoted() (string, error) {
+	p.advance()
+
+	start := p.pos
+	for !p.eof() {
+		if p.peek() == '\'' {
+			val := p.src[start:p.pos]
+			p.advance()
+			return val, nil
+		}
+		p.advance()
+	}
+
+	return "", p.errorf("unterminated single quoted value")
+}
+
+func (p *parser) doubleQuoted() (string, error) {
+	p.advance()
+
+	var b strings.Builder
+	for !p.eof() {
+		c := p.advance()
+		switch c {
+		case '"':
+			return b.String(), nil
+		case '\\':
+			if p.eof() {
+				return "", p.errorf("unterminated double quoted value")
+			}
+			switch e := p.advance(); e {
+			case 'n':
+				b.WriteByte('\n')
+			case 'r':
+				b.WriteByte('\r')
+			case 't':
+				b.WriteByte('\t')
+			case '"', '\\', '$':
+				b.WriteByte(e)
+			default:
+				// Unknown escapes are kept as they are.
+				b.WriteByte('\\')
+				b.WriteByte(e)
+			}
+		default:
+			b.WriteByte(c)
+		}
+	}
+
+	return "", p.errorf("unterminated double quoted value")
+}
@@ -0,0 +1,224 @@
+// SPDX-FileCopyrightText: 2023 Weston Schmidt <weston_schmidt@alumni.purdue.edu>
+// SPDX-License-Identifier: Apache-2.0
+
+package dotenv
+
+import (
+	"testing"
+
+	"github.com/goschtalt/goschtalt/pkg/decoder"
+	"github.com/goschtalt/goschtalt/pkg/meta"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExtensions(t *testing.T) {
+	assert := assert.New(t)
+	assert.Equal([]string{"env"}, Codec{}.Extensions())
+}
+
+func TestDecode(t *testing.T) {
+	tests := []struct {
+		description string
+		codec       Codec
+		delimiter   string
+		in          string
+		unique      bool
+		want        any
+		expectErr   error
+	}{
+		{
+			description: "An empty document.",
+			in:          "",
+			want:        map[string]any{},
+		}, {
+			description: "Simple values.",
+			in:          "A=1\nB = two words \nC=\nD=x=y",
+			want:        map[string]any{"A": "1", "B": "two words", "C": "", "D": "x=y"},
+		}, {
+			description: "Comments, blank lines and exports.",
+			in:          "# comment\n\n  \r\nexport A=1 # trailing\n\texport\tB=2\nexport=3\nC=a#b",
+			want:        map[string]any{"A": "1", "B": "2", "export": "3", "C": "a#b"},
+		}, {
+			description: "A comment instead of a value.",
+			in:          "A= # nothing",
+			want:        map[string]any{"A": ""},
+		}, {
+			description: "CRLF line endings.",
+			in:          "A=1\r\nB='2'\r\n",
+			want:        map[string]any{"A": "1", "B": "2"},
+		}, {
+			description: "A byte order mark.",
+			in:          "\uFEFFA=1",
+			want:        map[string]any{"A": "1"},
+		}, {
+			description: "Quoted values.",
+			in: "A='literal \\n # $x'\n" +
+				`B="tab\t newline\n quote\" slash\\ dollar\$ other\q"` + "\n" +
+				"C=\"line one\nline two\" # comment\n" +
+				"D=''",
+			want: map[string]any{
+				"A": "literal \\n # $x",
+				"B": "tab\t newline\n quote\" slash\\ dollar$ other\\q",
+				"C": "line one\nline two",
+				"D": "",
+			},
+		}, {
+			description: "The last value is used.",
+			in:          "A=1\nA=2",
+			want:        map[string]any{"A": "2"},
+		}, {
+			description: "Keys are split and lowercased.",
+			codec:       Codec{Lowercase: true},
+			delimiter:   "_",
+			in:          "DB_HOST=localhost\nDB_PORT=5432\nNAME=app",
+			want: map[string]any{
+				"db":   map[string]any{"host": "localhost", "port": "5432"},
+				"name": "app",
+			},
+		}, {
+			description: "Keys are split without changing the case.",
+			delimiter:   "__",
+			in:          "Server__Http_Port=80",
+			want: map[string]any{
+				"Server": map[string]any{"Http_Port": "80"},
+			},
+		}, {
+			description: "Keys are split on the default delimiter.",
+			in:          "db.host=localhost\nDB_PORT=5432",
+			want: map[string]any{
+				"db":      map[string]any{"host": "localhost"},
+				"DB_PORT": "5432",
+			},
+		}, {
+			description: "Keys are only split on the delimiter.",
+			delimiter:   "-",
+			in:          "db.host=localhost",
+			want:        map[string]any{"db.host": "localhost"},
+		}, {
+			description: "A duplicate key is rejected.",
+			in:          "A=1\nA=2",
+			unique:      true,
+			expectErr:   decoder.ErrDuplicateKey,
+		}, {
+			description: "A duplicate nested key is rejected.",
+			delimiter:   "_",
+			in:          "A_B=1\nA_B=2",
+			unique:      true,
+			expectErr:   decoder.ErrDuplicateKey,
+		}, {
+			description: "A value and a map.",
+			delimiter:   "_",
+			in:          "A=1\nA_B=2",
+			expectErr:   ErrSyntax,
+		}, {
+			description: "A map and a value.",
+			delimiter:   "_",
+			in:          "A_B=1\nA=2",
+			expectErr:   ErrSyntax,
+		}, {
+			description: "An empty part of a key.",
+			delimiter:   "_",
+			in:          "_A=1",
+			expectErr:   ErrSyntax,
+		}, {
+			description: "A missing equals.",
+			in:          "A 1",
+			expectErr:   ErrSyntax,
+		}, {
+			description: "A missing key.",
+			in:          "=1",
+			expectErr:   ErrSyntax,
+		}, {
+			description: "An unterminated single quote.",
+			in:          "A='1",
+			expectErr:   ErrSyntax,
+		}, {
+			description: "An unterminated double quote.",
+			in:          `A="1\"`,
+			expectErr:   ErrSyntax,
+		}, {
+			description: "Text after a quoted value.",
+			in:          `A="1" 2`,
+			expectErr:   ErrSyntax,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			assert := assert.New(t)
+
+			delimiter := "."
+			if tc.delimiter != "" {
+				delimiter = tc.delimiter
+			}
+
+			ctx := decoder.Context{
+				Filename:            "file.env",
+				Delimiter:           delimiter,
+				RejectDuplicateKeys: tc.unique,
+			}
+
+			var got meta.Object
+			err := tc.codec.Decode(ctx, []byte(tc.in), &got)
+
+			if tc.expectErr != nil {
+				assert.ErrorIs(err, tc.expectErr)
+				assert.ErrorContains(err, "file.env")
+				return
+			}
+
+			assert.NoError(err)
+			assert.Equal(tc.want, raw(got))
+		})
+	}
+}
+
+func TestDecodeOrigins(t *testing.T) {
+	assert := assert.New(t)
+
+	in := "" +
+		"# comment\n" +
+		"export DB_HOST=localhost\n" +
+		"\n" +
+		"DB_PORT = \"5432\"\n"
+
+	var got meta.Object
+	ctx := decoder.Context{
+		Filename:  "file.env",
+		Delimiter: "_",
+	}
+	err := Codec{}.Decode(ctx, []byte(in), &got)
+	assert.NoError(err)
+
+	origin := func(line, col int) []meta.Origin {
+		return []meta.Origin{{File: "file.env", Line: line, Col: col}}
+	}
+
+	assert.Equal(origin(1, 1), got.Origins)
+	assert.Equal(origin(2, 16), got.Map["DB"].Origins)
+	assert.Equal(origin(2, 16), got.Map["DB"].Map["HOST"].Origins)
+	assert.Equal(origin(4, 11), got.Map["DB"].Map["PORT"].Origins)
+}
+
+func TestDecodeErrorPosition(t *testing.T) {
+	assert := assert.New(t)
+
+	var got meta.Object
+	err := Codec{}.Decode(decoder.Context{Filename: "file.env"}, []byte("A=1\n\nB 2"), &got)
+	assert.ErrorIs(err, ErrSyntax)
+	assert.ErrorContains(err, "line 3, column 3")
+}
+
+// raw is the same as meta.Object.ToRaw() except empty maps are kept so they
+// can be compared.
+func raw(obj meta.Object) any {
+	if obj.Map == nil {
+		return obj.Value
+	}
+
+	m := make(map[string]any, len(obj.Map))
+	for key, val := range obj.Map {
+		m[key] = raw(val)
+	}
+	return m
+}
@@ -0,0 +1,388 @@
+// SPDX-FileCopyrightText: 2023 Weston Schmidt <weston_schmidt@alumni.purdue.edu>
+// SPDX-License-Identifier: Apache-2.0
+
+// Package hcl provides an encoder that renders the configuration in the HCL
+// native syntax and only depends on the standard library.
+//
+// Maps are rendered as blocks, values as attributes and arrays as tuples.
+// Maps inside of arrays are rendered as objects.  For example:
+//
+//	name = "example"
+//	ports = [80, 443]
+//
+//	server {
+//	  host = "localhost"
+//	}
+//
+// The keys of the configuration are sorted, with the attributes of a body
+// before its blocks.
+package hcl
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/goschtalt/goschtalt/pkg/encoder"
+	"github.com/goschtalt/goschtalt/pkg/meta"
+)
+
+var (
+	ErrUnrepresentable = errors.New("value cannot be represented in hcl")
+)
+
+var (
+	_ encoder.Encoder       = (*Codec)(nil)
+	_ encoder.Validator     = (*Codec)(nil)
+	_ encoder.WriterEncoder = (*Codec)(nil)
+)
+
+const indentation = "  "
+
+// Codec is a HCL encoder.
+type Codec struct{}
+
+// Extensions returns the supported extensions.
+func (c Codec) Extensions() []string {
+	return []string{"hcl"}
+}
+
+// Encode encodes the value provided into HCL.  The value must be a
+// map[string]any since a HCL document is a body of attributes and blocks.
+func (c Codec) Encode(v any) ([]byte, error) {
+	return encode(meta.ObjectFromRaw(v), false)
+}
+
+// EncodeExtended encodes the tree provided into HCL with the origins of each
+// attribute and block as a comment before it.
+func (c Codec) EncodeExtended(obj meta.Object) ([]byte, error) {
+	return encode(obj, true)
+}
+
+// EncodeTo writes the same output as Encode to the writer.
+func (c Codec) EncodeTo(w io.Writer, v any) error {
+	return encodeTo(w, meta.ObjectFromRaw(v), false)
+}
+
+// EncodeExtendedTo writes the same output as EncodeExtended to the writer.
+func (c Codec) EncodeExtendedTo(w io.Writer, obj meta.Object) error {
+	return encodeTo(w, obj, true)
+}
+
+// Validate returns an error if the tree contains values that HCL is not able
+// to represent without losing information.  Non-finite floating point numbers
+// are not supported by HCL and time values become strings.  The keys of the
+// maps that are rendered as attributes and blocks must be HCL identifiers.
+func (c Codec) Validate(obj meta.Object) error {
+	return validate(obj, nil, true)
+}
+
+// validate checks the tree, where body is true if the maps are rendered as the
+// body of the document or a block instead of as objects.
+func validate(obj meta.Object, path []string, body bool) error {
+	switch kind(obj) {
+	case meta.Array:
+		for i, val := range obj.Array {
+			if err := validate(val, append(path[:len(path):len(path)], strconv.Itoa(i)), false); err != nil {
+				return err
+			}
+		}
+	case meta.Map:
+		for _, key := range sortedKeys(obj) {
+			full := append(path[:len(path):len(path)], key)
+			if body && !isIdentifier(key) {
+				return invalidName(full)
+			}
+			if err := validate(obj.Map[key], full, body); err != nil {
+				return err
+			}
+		}
+	default:
+		switch v := obj.Value.(type) {
+		case time.Time:
+			return fmt.Errorf("%w: '%s' is a %T", ErrUnrepresentable, strings.Join(path, "."), v)
+		case float32, float64:
+			f := reflect.ValueOf(v).Float()
+			if math.IsNaN(f) || math.IsInf(f, 0) {
+				return fmt.Errorf("%w: '%s' is %v", ErrUnrepresentable, strings.Join(path, "."), f)
+			}
+		}
+	}
+
+	return nil
+}
+
+func invalidName(path []string) error {
+	return fmt.Errorf("%w: '%s' is not a valid attribute or block name",
+		ErrUnrepresentable, strings.Join(path, "."))
+}
+
+// writer writes the output, keeping the first error.
+type writer struct {
+	out         io.Writer
+	err         error
+	withOrigins bool
+}
+
+func (w *writer) printf(format string, args ...any) {
+	if w.err != nil {
+		return
+	}
+
+	_, w.err = fmt.Fprintf(w.out, format, args...)
+}
+
+func encode(obj meta.Object, withOrigins bool) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := encodeTo(&buf, obj, withOrigins); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+func encodeTo(out io.Writer, obj meta.Object, withOrigins bool) error {
+	if kind(obj) != meta.Map {
+		return fmt.Errorf("%w: the document must be a map, not a %s",
+			ErrUnrepresentable, kindName(obj))
+	}
+
+	w := writer{out: out, withOrigins: withOrigins}
+	if err := w.body(obj, nil, ""); err != nil {
+		return err
+	}
+
+	return w.err
+}
+
+// body writes the attributes followed by the blocks of the map.
+func (w *writer) body(obj meta.Object, path []string, indent string) error {
+	var attrs, blocks []string
+	width := 0
+	for _, key := range sortedKeys(obj) {
+		full := append(path[:len(path):len(path)], key)
+		if !isIdentifier(key) {
+			return invalidName(full)
+		}
+
+		if kind(obj.Map[key]) == meta.Map {
+			blocks = append(blocks, key)
+			continue
+		}
+		attrs = append(attrs, key)
+		width = max(width, len(key))
+	}
+
+	for _, key := range attrs {
+		val := obj.Map[key]
+		full := append(path[:len(path):len(path)], key)
+
+		expr, err := expression(val, full)
+		if err != nil {
+			return err
+		}
+
+		w.origins(val, indent)
+		w.printf("%s%-*s = %s\n", indent, width, key, expr)
+	}
+
+	for i, key := range blocks {
+		val := obj.Map[key]
+		full := append(path[:len(path):len(path)], key)
+
+		if i > 0 || len(attrs) > 0 {
+			w.printf("\n")
+		}
+
+		w.origins(val, indent)
+		w.printf("%s%s {\n", indent, key)
+		if err := w.body(val, full, indent+indentation); err != nil {
+			return err
+		}
+		w.printf("%s}\n", indent)
+	}
+
+	return nil
+}
+
+// origins writes the origins of the object as a comment if requested.
+func (w *writer) origins(obj meta.Object, indent string) {
+	if !w.withOrigins || len(obj.Origins) == 0 {
+		return
+	}
+
+	w.printf("%s# %s\n", indent, obj.OriginString())
+}
+
+// expression renders the object as a single line HCL expression.
+func expression(obj meta.Object, path []string) (string, error) {
+	switch kind(obj) {
+	case meta.Array:
+		list := make([]string, 0, len(obj.Array))
+		for i, val := range obj.Array {
+			expr, err := expression(val, append(path[:len(path):len(path)], strconv.Itoa(i)))
+			if err != nil {
+				return "", err
+			}
+			list = append(list, expr)
+		}
+		return "[" + strings.Join(list, ", ") + "]", nil
+	case meta.Map:
+		list := make([]string, 0, len(obj.Map))
+		for _, key := range sortedKeys(obj) {
+			expr, err := expression(obj.Map[key], append(path[:len(path):len(path)], key))
+			if err != nil {
+				return "", err
+			}
+
+			// Keywords are quoted so they are keys instead of values.
+			name := key
+			if !isIdentifier(key) || isKeyword(key) {
+				name = quote(key)
+			}
+			list = append(list, name+" = "+expr)
+		}
+		if len(list) == 0 {
+			return "{}", nil
+		}
+		return "{ " + strings.Join(list, ", ") + " }", nil
+	}
+
+	return value(obj.Value, path)
+}
+
+// value renders a leaf value.
+func value(v any, path []string) (string, error) {
+	switch v := v.(type) {
+	case nil:
+		return "null", nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	case string:
+		return quote(v), nil
+	case time.Time:
+		return quote(v.Format(time.RFC3339Nano)), nil
+	case int, int8, int16, int32, int64:
+		return strconv.FormatInt(reflect.ValueOf(v).Int(), 10), nil
+	case uint, uint8, uint16, uint32, uint64:
+		return strconv.FormatUint(reflect.ValueOf(v).Uint(), 10), nil
+	case float32, float64:
+		f := reflect.ValueOf(v).Float()
+		if math.IsNaN(f) || math.IsInf(f, 0) {
+			return "", fmt.Errorf("%w: '%s' is %v", ErrUnrepresentable, strings.Join(path, "."), f)
+		}
+		return strconv.FormatFloat(f, 'g', -1, 64), nil
+	}
+
+	return "", fmt.Errorf("%w: '%s' has a value of type %T",
+		ErrUnrepresentable, strings.Join(path, "."), v)
+}
+
+// quote renders the string as a HCL quoted template.  Template sequences are
+// escaped so the string is used literally.
+func quote(s string) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	for i := 0; i < len(s); {
+		r, size := utf8.DecodeRuneInString(s[i:])
+		next := s[i+size:]
+		i += size
+
+		switch {
+		case r == '"':
+			b.WriteString(`\"`)
+		case r == '\\':
+			b.WriteString(`\\`)
+		case r == '\n':
+			b.WriteString(`\n`)
+		case r == '\r':
+			b.WriteString(`\r`)
+		case r == '\t':
+			b.WriteString(`\t`)
+		case (r == '$' || r == '%') && strings.HasPrefix(next, "{"):
+			// Double the marker so "${" and "%{" are not templates.
+			b.WriteRune(r)
+			b.WriteRune(r)
+		case r == utf8.RuneError && size == 1:
+			// Invalid UTF-8 is replaced.
+			b.WriteString(`\ufffd`)
+		case !unicode.IsPrint(r):
+			if r > 0xffff {
+				fmt.Fprintf(&b, `\U%08x`, r)
+			} else {
+				fmt.Fprintf(&b, `\u%04x`, r)
+			}
+		default:
+			b.WriteRune(r)
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
+
+// isIdentifier returns if the key can be used as a HCL identifier.
+func isIdentifier(s string) bool {
+	if len(s) == 0 {
+		return false
+	}
+
+	for i, r := range s {
+		switch {
+		case unicode.IsLetter(r), r == '_':
+		case i > 0 && (unicode.IsDigit(r) || r == '-'):
+		default:
+			return false
+		}
+	}
+
+	return true
+}
+
+// isKeyword returns if the key is a keyword in a HCL expression.
+func isKeyword(s string) bool {
+	switch s {
+	case "true", "false", "null":
+		return true
+	}
+	return false
+}
+
+func sortedKeys(obj meta.Object) []string {
+	keys := make([]string, 0, len(obj.Map))
+	for key := range obj.Map {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// kind is the same as meta.Object.Kind() except empty maps and arrays are
+// kept instead of being treated as values.
+func kind(obj meta.Object) int {
+	switch {
+	case obj.Array != nil:
+		return meta.Array
+	case obj.Map != nil:
+		return meta.Map
+	}
+	return meta.Value
+}
+
+func kindName(obj meta.Object) string {
+	switch kind(obj) {
+	case meta.Array:
+		return "array"
+	case meta.Map:
+		return "map"
+	}
+	return "value"
+}
@@ -0,0 +1,841 @@
+// SPDX-FileCopyrightText: 2023 Weston Schmidt <weston_schmidt@alumni.purdue.edu>
+// SPDX-License-Identifier: Apache-2.0
+
+package hcl
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/goschtalt/goschtalt"
+	"github.com/goschtalt/goschtalt/pkg/meta"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExtensions(t *testing.T) {
+	assert := assert.New(t)
+	assert.Equal([]string{"hcl"}, Codec{}.Extensions())
+}
+
+func TestEncode(t *testing.T) {
+	tests := []struct {
+		description string
+		in          any
+		want        string
+		expectErr   error
+	}{
+		{
+			description: "An empty document.",
+			in:          map[string]any{},
+		}, {
+			description: "Attributes are aligned.",
+			in: map[string]any{
+				"name":    "example",
+				"port":    8080,
+				"enabled": true,
+				"ratio":   0.5,
+				"none":    nil,
+			},
+			want: "" +
+				"enabled = true\n" +
+				"name    = \"example\"\n" +
+				"none    = null\n" +
+				"port    = 8080\n" +
+				"ratio   = 0.5\n",
+		}, {
+			description: "Maps are nested blocks after the attributes.",
+			in: map[string]any{
+				"server": map[string]any{
+					"host": "localhost",
+					"tls": map[string]any{
+						"cert": "server.pem",
+					},
+				},
+				"db":   map[string]any{},
+				"name": "example",
+			},
+			want: "" +
+				"name = \"example\"\n" +
+				"\n" +
+				"db {\n" +
+				"}\n" +
+				"\n" +
+				"server {\n" +
+				"  host = \"localhost\"\n" +
+				"\n" +
+				"  tls {\n" +
+				"    cert = \"server.pem\"\n" +
+				"  }\n" +
+				"}\n",
+		}, {
+			description: "Arrays are tuples and maps in arrays are objects.",
+			in: map[string]any{
+				"ports": []any{80, 443},
+				"users": []any{
+					map[string]any{"name": "bob", "my key": "x", "true": false},
+					map[string]any{},
+				},
+				"nested": []any{[]any{"a"}, []any{}},
+			},
+			want: "" +
+				"nested = [[\"a\"], []]\n" +
+				"ports  = [80, 443]\n" +
+				"users  = [{ \"my key\" = \"x\", name = \"bob\", \"true\" = false }, {}]\n",
+		}, {
+			description: "Strings are escaped.",
+			in: map[string]any{
+				"a": "quote \" slash \\ tab \t newline \n return \r",
+				"b": "${not_a_template} %{if} $ % {}",
+				"c": "bell \a unicode é",
+			},
+			want: "" +
+				`a = "quote \" slash \\ tab \t newline \n return \r"` + "\n" +
+				`b = "$${not_a_template} %%{if} $ % {}"` + "\n" +
+				`c = "bell \u0007 unicode é"` + "\n",
+		}, {
+			description: "Identifiers may contain dashes and digits.",
+			in: map[string]any{
+				"a-b_2": "x",
+			},
+			want: "a-b_2 = \"x\"\n",
+		}, {
+			description: "Other numbers.",
+			in: map[string]any{
+				"a": int8(-5),
+				"b": uint64(math.MaxUint64),
+				"c": float32(1.5),
+				"d": 1e21,
+			},
+			want: "" +
+				"a = -5\n" +
+				"b = 18446744073709551615\n" +
+				"c = 1.5\n" +
+				"d = 1e+21\n",
+		}, {
+			description: "A time is a string.",
+			in: map[string]any{
+				"t": time.Date(2023, 1, 2, 3, 4, 5, 0, time.UTC),
+			},
+			want: "t = \"2023-01-02T03:04:05Z\"\n",
+		}, {
+			description: "An invalid attribute name.",
+			in: map[string]any{
+				"a": map[string]any{
+					"my key": "x",
+				},
+			},
+			expectErr: ErrUnrepresentable,
+		}, {
+			description: "A non-finite float.",
+			in: map[string]any{
+				"a": []any{math.Inf(1)},
+			},
+			expectErr: ErrUnrepresentable,
+		}, {
+			description: "An unsupported type.",
+			in: map[string]any{
+				"a": struct{}{},
+			},
+			expectErr: ErrUnrepresentable,
+		}, {
+			description: "The document is not a map.",
+			in:          []any{"a"},
+			expectErr:   ErrUnrepresentable,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			assert := assert.New(t)
+
+			got, err := Codec{}.Encode(tc.in)
+
+			if tc.expectErr != nil {
+				assert.ErrorIs(err, tc.expectErr)
+				assert.Nil(got)
+				return
+			}
+
+			assert.NoError(err)
+			assert.Equal(tc.want, string(got))
+		})
+	}
+}
+
+func TestEncodeExtended(t *testing.T) {
+	assert := assert.New(t)
+
+	in := meta.Object{
+		Origins: []meta.Origin{{File: "file.json", Line: 1, Col: 1}},
+		Map: map[string]meta.Object{
+			"name": {
+				Origins: []meta.Origin{{File: "file.json", Line: 2, Col: 3}},
+				Value:   "example",
+			},
+			"server": {
+				Origins: []meta.Origin{{File: "file.json", Line: 3, Col: 3}},
+				Map: map[string]meta.Object{
+					"port": {
+						Origins: []meta.Origin{
+							{File: "file.json", Line: 4, Col: 5},
+							{File: "other.json", Line: 1, Col: 1},
+						},
+						Value: 80,
+					},
+					"host": {
+						Value: "localhost",
+					},
+				},
+			},
+		},
+	}
+
+	got, err := Codec{}.EncodeExtended(in)
+	assert.NoError(err)
+	assert.Equal(""+
+		"# file.json:2[3]\n"+
+		"name = \"example\"\n"+
+		"\n"+
+		"# file.json:3[3]\n"+
+		"server {\n"+
+		"  host = \"localhost\"\n"+
+		"  # file.json:4[5], other.json:1[1]\n"+
+		"  port = 80\n"+
+		"}\n", string(got))
+}
+
+// failWriter fails every write.
+type failWriter struct{}
+
+func (failWriter) Write([]byte) (int, error) {
+	return 0, errWrite
+}
+
+var errWrite = errors.New("write error")
+
+func TestEncodeTo(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	in := map[string]any{
+		"name":   "example",
+		"server": map[string]any{"port": 80},
+	}
+	obj := meta.ObjectFromRaw(in)
+
+	want, err := Codec{}.Encode(in)
+	require.NoError(err)
+
+	var buf bytes.Buffer
+	require.NoError(Codec{}.EncodeTo(&buf, in))
+	assert.Equal(string(want), buf.String())
+
+	want, err = Codec{}.EncodeExtended(obj)
+	require.NoError(err)
+
+	buf.Reset()
+	require.NoError(Codec{}.EncodeExtendedTo(&buf, obj))
+	assert.Equal(string(want), buf.String())
+
+	assert.ErrorIs(Codec{}.EncodeTo(failWriter{}, in), errWrite)
+	assert.ErrorIs(Codec{}.EncodeExtendedTo(failWriter{}, obj), errWrite)
+	assert.ErrorIs(Codec{}.EncodeTo(&buf, []any{"a"}), ErrUnrepresentable)
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		description string
+		in          meta.Object
+		expectErr   error
+	}{
+		{
+			description: "Everything is representable.",
+			in: meta.ObjectFromRaw(map[string]any{
+				"a": []any{1, "b", 2.5, true, nil},
+			}),
+		}, {
+			description: "A time.",
+			in: meta.ObjectFromRaw(map[string]any{
+				"a": map[string]any{"b": time.Now()},
+			}),
+			expectErr: ErrUnrepresentable,
+		}, {
+			description: "NaN.",
+			in: meta.ObjectFromRaw(map[string]any{
+				"a": []any{math.NaN()},
+			}),
+			expectErr: ErrUnrepresentable,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			assert := assert.New(t)
+
+			err := Codec{}.Validate(tc.in)
+			assert.ErrorIs(err, tc.expectErr)
+		})
+	}
+}
+
+func TestMarshal(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	cfg, err := goschtalt.New(
+		goschtalt.WithEncoder(Codec{}),
+		goschtalt.AddBuffer("1.json", []byte(`{
+			"name": "example",
+			"ports": [80, 443],
+			"server": {"host": "localhost", "tls": {"enabled": true}}
+		}`)),
+	)
+	require.NoError(err)
+
+	got, err := cfg.Marshal(goschtalt.FormatAs("hcl"))
+	require.NoError(err)
+	assert.Equal(""+
+		"name  = \"example\"\n"+
+		"ports = [80, 443]\n"+
+		"\n"+
+		"server {\n"+
+		"  host = \"localhost\"\n"+
+		"\n"+
+		"  tls {\n"+
+		"    enabled = true\n"+
+		"  }\n"+
+		"}\n", string(got))
+}
+
+func TestParseBack(t *testing.T) {
+	tests := []struct {
+		description string
+		in          map[string]any
+	}{
+		{
+			description: "Attributes and blocks.",
+			in: map[string]any{
+				"name":    "example",
+				"port":    8080,
+				"enabled": true,
+				"ratio":   0.5,
+				"none":    nil,
+				"server": map[string]any{
+					"host": "localhost",
+					"tls": map[string]any{
+						"cert": "server.pem",
+					},
+					"empty": map[string]any{},
+				},
+			},
+		}, {
+			description: "Tuples and objects.",
+			in: map[string]any{
+				"ports":  []any{80, 443},
+				"nested": []any{[]any{"a"}, []any{}, map[string]any{}},
+				"users": []any{
+					map[string]any{
+						"name":   "bob",
+						"my key": "x",
+						"true":   false,
+						"null":   nil,
+						"list":   []any{map[string]any{"a.b": 1}},
+					},
+				},
+			},
+		}, {
+			description: "Strings.",
+			in: map[string]any{
+				"a": "quote \" slash \\ tab \t newline \n return \r",
+				"b": "${not_a_template} %{if} $ % {} $${ %%{ $",
+				"c": "bell \a unicode é \U0001F600  ",
+				"d": "invalid \xff utf-8",
+			},
+		}, {
+			description: "Names and numbers.",
+			in: map[string]any{
+				"a-b_2":  int8(-5),
+				"_under": uint64(math.MaxUint64),
+				"é":      float32(1.5),
+				"big":    1e21,
+				"small":  -1.25e-7,
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			obj := meta.ObjectFromRaw(tc.in)
+			require.NoError(Codec{}.Validate(obj))
+
+			for _, extended := range []bool{false, true} {
+				got, err := encode(obj, extended)
+				require.NoError(err)
+
+				parsed, err := parseHCL(string(got))
+				require.NoError(err, string(got))
+				assert.Equal(normalize(tc.in), parsed, string(got))
+			}
+		})
+	}
+}
+
+func TestValidateMatchesEncode(t *testing.T) {
+	tests := []struct {
+		description string
+		in          map[string]any
+		expectErr   error
+	}{
+		{
+			description: "A key that isn't an identifier.",
+			in:          map[string]any{"my key": "x"},
+			expectErr:   ErrUnrepresentable,
+		}, {
+			description: "A block name that isn't an identifier.",
+			in:          map[string]any{"a.b": map[string]any{"c": "x"}},
+			expectErr:   ErrUnrepresentable,
+		}, {
+			description: "A key in a block that isn't an identifier.",
+			in:          map[string]any{"a": map[string]any{"1st": "x"}},
+			expectErr:   ErrUnrepresentable,
+		}, {
+			description: "An empty key.",
+			in:          map[string]any{"": "x"},
+			expectErr:   ErrUnrepresentable,
+		}, {
+			description: "Object keys may be anything.",
+			in:          map[string]any{"a": []any{map[string]any{"my key": "x", "": "y"}}},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			assert := assert.New(t)
+
+			obj := meta.ObjectFromRaw(tc.in)
+			err := Codec{}.Validate(obj)
+			assert.ErrorIs(err, tc.expectErr)
+
+			_, encErr := Codec{}.Encode(tc.in)
+			assert.ErrorIs(encErr, tc.expectErr)
+		})
+	}
+}
+
+// TestParseHCL checks the test parser rejects what isn't valid HCL.
+func TestParseHCL(t *testing.T) {
+	invalid := []string{
+		"my key = 1\n",
+		"1a = 1\n",
+		"a = 1 b = 2\n",
+		"a = \"${b}\"\n",
+		"a = \"\\q\"\n",
+		"a = \"unterminated\n",
+		"a = b\n",
+		"a { b = 1 }\n",
+		"a {\n",
+		"a = { true = 1 }\n",
+		"a = [1 2]\n",
+		"a = 1\na = 2\n",
+		"a = 1.\n",
+	}
+	for _, in := range invalid {
+		_, err := parseHCL(in)
+		assert.Error(t, err, in)
+	}
+}
+
+// normalize converts the numbers to float64 and the invalid UTF-8 to the
+// replacement character, the same as parseHCL.
+func normalize(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		rv := make(map[string]any, len(v))
+		for key, val := range v {
+			rv[key] = normalize(val)
+		}
+		return rv
+	case []any:
+		rv := make([]any, len(v))
+		for i, val := range v {
+			rv[i] = normalize(val)
+		}
+		return rv
+	case string:
+		return strings.ToValidUTF8(v, "�")
+	case int, int8, int16, int32, int64:
+		return float64(reflect.ValueOf(v).Int())
+	case uint, uint8, uint16, uint32, uint64:
+		return float64(reflect.ValueOf(v).Uint())
+	case float32, float64:
+		return reflect.ValueOf(v).Float()
+	}
+	return v
+}
+
+// parseHCL is a strict parser for the parts of the HCL native syntax the
+// encoder produces, so the tests are able to check the output is valid HCL
+// and has the expected values.  Blocks become maps, tuples become []any and
+// numbers become float64.
+func parseHCL(src string) (map[string]any, error) {
+	p := hclParser{src: src}
+	body, err := p.body(false)
+	if err != nil {
+		return nil, err
+	}
+	if !p.eof() {
+		return nil, p.errorf("unexpected '%c'", p.peek())
+	}
+	return body, nil
+}
+
+type hclParser struct {
+	src string
+	pos int
+}
+
+func (p *hclParser) errorf(format string, args ...any) error {
+	line := strings.Count(p.src[:p.pos], "\n") + 1
+	return fmt.Errorf("line %d: %s", line, fmt.Sprintf(format, args...))
+}
+
+func (p *hclParser) eof() bool {
+	return p.pos >= len(p.src)
+}
+
+func (p *hclParser) peek() byte {
+	return p.src[p.pos]
+}
+
+// space skips spaces and comments, and newlines if newlines is true.
+func (p *hclParser) space(newlines bool) {
+	for !p.eof() {
+		switch c := p.peek(); {
+		case c == ' ' || c == '\t':
+			p.pos++
+		case c == '\n' && newlines:
+			p.pos++
+		case c == '#' || strings.HasPrefix(p.src[p.pos:], "//"):
+			for !p.eof() && p.peek() != '\n' {
+				p.pos++
+			}
+		default:
+			return
+		}
+	}
+}
+
+func (p *hclParser) expect(c byte) error {
+	if p.eof() || p.peek() != c {
+		return p.errorf("expected '%c'", c)
+	}
+	p.pos++
+	return nil
+}
+
+// body parses attributes and blocks until the end of the document or a '}'
+// if in a block.
+func (p *hclParser) body(block bool) (map[string]any, error) {
+	rv := make(map[string]any)
+	for {
+		p.space(true)
+		if p.eof() {
+			if block {
+				return nil, p.errorf("unterminated block")
+			}
+			return rv, nil
+		}
+		if block && p.peek() == '}' {
+			p.pos++
+			return rv, nil
+		}
+
+		name, err := p.identifier()
+		if err != nil {
+			return nil, err
+		}
+		if _, found := rv[name]; found {
+			return nil, p.errorf("'%s' is defined twice", name)
+		}
+
+		p.space(false)
+		switch {
+		case !p.eof() && p.peek() == '=':
+			p.pos++
+			p.space(false)
+			rv[name], err = p.expression()
+		case !p.eof() && p.peek() == '{':
+			p.pos++
+			p.space(false)
+			if err = p.expect('\n'); err == nil {
+				rv[name], err = p.body(true)
+			}
+		default:
+			err = p.errorf("expected '=' or '{' after '%s'", name)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		// Each attribute and block ends with a newline.
+		p.space(false)
+		if !p.eof() {
+			if err := p.expect('\n'); err != nil {
+				return nil, err
+			}
+		}
+	}
+}
+
+func (p *hclParser) identifier() (string, error) {
+	start := p.pos
+	for !p.eof() {
+		r, size := utf8.DecodeRuneInString(p.src[p.pos:])
+		first := p.pos == start
+		if !unicode.IsLetter(r) && r != '_' && (first || (!unicode.IsDigit(r) && r != '-')) {
+			break
+		}
+		p.pos += size
+	}
+	if p.pos == start {
+		return "", p.errorf("expected an identifier")
+	}
+	return p.src[start:p.pos], nil
+}
+
+func (p *hclParser) expression() (any, error) {
+	if p.eof() {
+		return nil, p.errorf("expected an expression")
+	}
+
+	switch c := p.peek(); {
+	case c == '"':
+		return p.template()
+	case c == '[':
+		return p.tuple()
+	case c == '{':
+		return p.object()
+	case c == '-' || isDigit(c):
+		return p.number()
+	}
+
+	word, err := p.identifier()
+	if err != nil {
+		return nil, err
+	}
+	switch word {
+	case "true":
+		return true, nil
+	case "false":
+		return false, nil
+	case "null":
+		return nil, nil
+	}
+	return nil, p.errorf("variables are not supported: '%s'", word)
+}
+
+func isDigit(c byte) bool {
+	return '0' <= c && c <= '9'
+}
+
+func (p *hclParser) number() (any, error) {
+	start := p.pos
+	if p.peek() == '-' {
+		p.pos++
+	}
+	digits := func() bool {
+		begin := p.pos
+		for !p.eof() && isDigit(p.peek()) {
+			p.pos++
+		}
+		return p.pos > begin
+	}
+	if !digits() {
+		return nil, p.errorf("invalid number")
+	}
+	if !p.eof() && p.peek() == '.' {
+		p.pos++
+		if !digits() {
+			return nil, p.errorf("invalid number")
+		}
+	}
+	if !p.eof() && (p.peek() == 'e' || p.peek() == 'E') {
+		p.pos++
+		if !p.eof() && (p.peek() == '+' || p.peek() == '-') {
+			p.pos++
+		}
+		if !digits() {
+			return nil, p.errorf("invalid number")
+		}
+	}
+	return strconv.ParseFloat(p.src[start:p.pos], 64)
+}
+
+func (p *hclParser) template() (string, error) {
+	p.pos++
+
+	var b strings.Builder
+	for {
+		if p.eof() || p.peek() == '\n' {
+			return "", p.errorf("unterminated string")
+		}
+
+		rest := p.src[p.pos:]
+		switch {
+		case rest[0] == '"':
+			p.pos++
+			return b.String(), nil
+		case strings.HasPrefix(rest, "$${") || strings.HasPrefix(rest, "%%{"):
+			b.WriteString(rest[1:3])
+			p.pos += 3
+		case strings.HasPrefix(rest, "${") || strings.HasPrefix(rest, "%{"):
+			return "", p.errorf("templates are not supported")
+		case rest[0] == '\\':
+			if err := p.escape(&b); err != nil {
+				return "", err
+			}
+		default:
+			r, size := utf8.DecodeRuneInString(rest)
+			if r == utf8.RuneError && size == 1 {
+				return "", p.errorf("invalid UTF-8")
+			}
+			b.WriteRune(r)
+			p.pos += size
+		}
+	}
+}
+
+func (p *hclParser) escape(b *strings.Builder) error {
+	p.pos++
+	if p.eof() {
+		return p.errorf("unterminated escape")
+	}
+
+	c := p.peek()
+	p.pos++
+	switch c {
+	case 'n':
+		b.WriteByte('\n')
+	case 'r':
+		b.WriteByte('\r')
+	case 't':
+		b.WriteByte('\t')
+	case '"':
+		b.WriteByte('"')
+	case '\\':
+		b.WriteByte('\\')
+	case 'u', 'U':
+		size := 4
+		if c == 'U' {
+			size = 8
+		}
+		if p.pos+size > len(p.src) {
+			return p.errorf("invalid unicode escape")
+		}
+		r, err := strconv.ParseUint(p.src[p.pos:p.pos+size], 16, 32)
+		if err != nil || !utf8.ValidRune(rune(r)) {
+			return p.errorf("invalid unicode escape")
+		}
+		b.WriteRune(rune(r))
+		p.pos += size
+	default:
+		return p.errorf("invalid escape '\\%c'", c)
+	}
+	return nil
+}
+
+func (p *hclParser) tuple() ([]any, error) {
+	p.pos++
+
+	rv := []any{}
+	for {
+		p.space(true)
+		if p.eof() {
+			return nil, p.errorf("unterminated tuple")
+		}
+		if p.peek() == ']' {
+			p.pos++
+			return rv, nil
+		}
+
+		val, err := p.expression()
+		if err != nil {
+			return nil, err
+		}
+		rv = append(rv, val)
+
+		p.space(true)
+		if !p.eof() && p.peek() == ',' {
+			p.pos++
+			continue
+		}
+		if err := p.expect(']'); err != nil {
+			return nil, err
+		}
+		return rv, nil
+	}
+}
+
+func (p *hclParser) object() (map[string]any, error) {
+	p.pos++
+
+	rv := make(map[string]any)
+	for {
+		p.space(true)
+		if p.eof() {
+			return nil, p.errorf("unterminated object")
+		}
+		if p.peek() == '}' {
+			p.pos++
+			return rv, nil
+		}
+
+		// A bare identifier is the name of the key, except the keywords
+		// which are values.
+		var key string
+		var err error
+		if p.peek() == '"' {
+			key, err = p.template()
+		} else {
+			key, err = p.identifier()
+			if err == nil && (key == "true" || key == "false" || key == "null") {
+				err = p.errorf("the keyword '%s' must be quoted to be a key", key)
+			}
+		}
+		if err != nil {
+			return nil, err
+		}
+		if _, found := rv[key]; found {
+			return nil, p.errorf("'%s' is defined twice", key)
+		}
+
+		p.space(false)
+		if err := p.expect('='); err != nil {
+			return nil, err
+		}
+		p.space(false)
+
+		rv[key], err = p.expression()
+		if err != nil {
+			return nil, err
+		}
+
+		p.space(false)
+		if !p.eof() && (p.peek() == ',' || p.peek() == '\n') {
+			p.pos++
+		}
+	}
+}
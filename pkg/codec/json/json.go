@@ -0,0 +1,190 @@
+// SPDX-FileCopyrightText: 2023 Weston Schmidt <weston_schmidt@alumni.purdue.edu>
+// SPDX-License-Identifier: Apache-2.0
+
+// Package json provides a JSON decoder and encoder that only depend on the
+// standard library.  This codec is registered by default by goschtalt so the
+// json extension is always available.  Registering a different codec for the
+// json extension replaces this one.
+//
+// Since JSON does not support comments, the extended encoding replaces each
+// value with an object that includes the origins of the value:
+//
+//	{
+//	  "name": {
+//	    "value": "example",
+//	    "origins": [
+//	      {
+//	        "file": "config.json",
+//	        "line": 2,
+//	        "col": 11
+//	      }
+//	    ]
+//	  }
+//	}
+package json
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/goschtalt/goschtalt/internal/jsontree"
+	"github.com/goschtalt/goschtalt/pkg/decoder"
+	"github.com/goschtalt/goschtalt/pkg/encoder"
+	"github.com/goschtalt/goschtalt/pkg/meta"
+)
+
+var (
+	ErrUnrepresentable = errors.New("value cannot be represented in json")
+)
+
+var (
+	_ decoder.Decoder   = (*Codec)(nil)
+	_ encoder.Encoder   = (*Codec)(nil)
+	_ encoder.Validator = (*Codec)(nil)
+)
+
+const defaultIndent = "  "
+
+// Codec is a JSON decoder and encoder.  The zero value indents the output
+// with two spaces.
+type Codec struct {
+	// Indent is the string used for each level of indentation.  Two spaces
+	// are used if it is empty.
+	Indent string
+
+	// Compact renders the document on a single line without indentation.
+	Compact bool
+}
+
+// Extensions returns the supported extensions.
+func (c Codec) Extensions() []string {
+	return []string{"json"}
+}
+
+// Decode decodes a byte array into the meta.Object tree.
+func (c Codec) Decode(ctx decoder.Context, b []byte, m *meta.Object) error {
+	decode := jsontree.Decode
+	if ctx.RejectDuplicateKeys {
+		decode = jsontree.DecodeUniqueKeys
+	}
+
+	obj, err := decode(ctx.Filename, b)
+	if err != nil {
+		return fmt.Errorf("%s: %w", ctx.Filename, err)
+	}
+
+	*m = obj
+	return nil
+}
+
+// Encode encodes the value provided into JSON.
+func (c Codec) Encode(v any) ([]byte, error) {
+	if c.Compact {
+		return json.Marshal(v)
+	}
+
+	indent := c.Indent
+	if indent == "" {
+		indent = defaultIndent
+	}
+	return json.MarshalIndent(v, "", indent)
+}
+
+// EncodeExtended encodes the tree provided into JSON where each value is
+// replaced by an object with the value and the origins of the value.  Maps
+// and arrays keep their structure.
+func (c Codec) EncodeExtended(obj meta.Object) ([]byte, error) {
+	return c.Encode(extended(obj))
+}
+
+// leaf is the extended form of a value.
+type leaf struct {
+	Value   any      `json:"value"`
+	Origins []origin `json:"origins"`
+}
+
+type origin struct {
+	File string `json:"file"`
+	Line int    `json:"line,omitempty"`
+	Col  int    `json:"col,omitempty"`
+}
+
+// extended converts the tree into the structure rendered by EncodeExtended.
+func extended(obj meta.Object) any {
+	switch {
+	case obj.Array != nil:
+		list := make([]any, 0, len(obj.Array))
+		for _, val := range obj.Array {
+			list = append(list, extended(val))
+		}
+		return list
+	case obj.Map != nil:
+		m := make(map[string]any, len(obj.Map))
+		for key, val := range obj.Map {
+			m[key] = extended(val)
+		}
+		return m
+	}
+
+	origins := make([]origin, 0, len(obj.Origins))
+	for _, o := range obj.Origins {
+		origins = append(origins, origin{
+			File: o.File,
+			Line: o.Line,
+			Col:  o.Col,
+		})
+	}
+
+	return leaf{
+		Value:   obj.Value,
+		Origins: origins,
+	}
+}
+
+// Validate returns an error if the tree contains values that json is not
+// able to represent without losing information.  Non-finite floating point
+// numbers are not supported by json and time values become strings.
+func (c Codec) Validate(m meta.Object) error {
+	return validate(m, nil)
+}
+
+func validate(obj meta.Object, path []string) error {
+	switch obj.Kind() {
+	case meta.Array:
+		for i, val := range obj.Array {
+			if err := validate(val, append(path[:len(path):len(path)], fmt.Sprintf("%d", i))); err != nil {
+				return err
+			}
+		}
+	case meta.Map:
+		keys := make([]string, 0, len(obj.Map))
+		for key := range obj.Map {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			if err := validate(obj.Map[key], append(path[:len(path):len(path)], key)); err != nil {
+				return err
+			}
+		}
+	default:
+		switch v := obj.Value.(type) {
+		case time.Time:
+			return fmt.Errorf("%w: '%s' is a %T", ErrUnrepresentable, strings.Join(path, "."), v)
+		case float32, float64:
+			f := reflect.ValueOf(v).Float()
+			if math.IsNaN(f) || math.IsInf(f, 0) {
+				return fmt.Errorf("%w: '%s' is %v", ErrUnrepresentable, strings.Join(path, "."), f)
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,273 @@
+// SPDX-FileCopyrightText: 2023 Weston Schmidt <weston_schmidt@alumni.purdue.edu>
+// SPDX-License-Identifier: Apache-2.0
+
+package json
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/goschtalt/goschtalt/pkg/decoder"
+	"github.com/goschtalt/goschtalt/pkg/meta"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExtensions(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Equal([]string{"json"}, Codec{}.Extensions())
+}
+
+func TestDecode(t *testing.T) {
+	tests := []struct {
+		description string
+		in          string
+		want        any
+		origin      meta.Origin
+		expectErr   bool
+	}{
+		{
+			description: "An empty file.",
+			in:          "",
+		}, {
+			description: "A simple object.",
+			in: `{
+  "a": "b",
+  "c": [1, 2.5, true, null]
+}`,
+			want: map[string]any{
+				"a": "b",
+				"c": []any{1, 2.5, true, nil},
+			},
+			origin: meta.Origin{File: "file.json", Line: 1, Col: 1},
+		}, {
+			description: "Comments are not allowed.",
+			in:          `{"a": "b"} // comment`,
+			expectErr:   true,
+		}, {
+			description: "Trailing commas are not allowed.",
+			in:          `{"a": "b",}`,
+			expectErr:   true,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			var got meta.Object
+			ctx := decoder.Context{
+				Filename:  "file.json",
+				Delimiter: ".",
+			}
+			err := Codec{}.Decode(ctx, []byte(tc.in), &got)
+
+			if tc.expectErr {
+				assert.Error(err)
+				return
+			}
+
+			require.NoError(err)
+			assert.Equal(tc.want, got.ToRaw())
+			if tc.want != nil {
+				require.NotEmpty(got.Origins)
+				assert.Equal(tc.origin, got.Origins[0])
+			}
+		})
+	}
+}
+
+func TestDecodeDuplicateKeys(t *testing.T) {
+	assert := assert.New(t)
+
+	in := []byte(`{"a": "b", "a": "c"}`)
+	ctx := decoder.Context{
+		Filename:  "file.json",
+		Delimiter: ".",
+	}
+
+	var got meta.Object
+	assert.NoError(Codec{}.Decode(ctx, in, &got))
+	assert.Equal(map[string]any{"a": "c"}, got.ToRaw())
+
+	ctx.RejectDuplicateKeys = true
+	err := Codec{}.Decode(ctx, in, &got)
+	assert.ErrorIs(err, decoder.ErrDuplicateKey)
+	assert.ErrorContains(err, "'a' at file.json:1[12]")
+}
+
+func TestEncode(t *testing.T) {
+	assert := assert.New(t)
+
+	got, err := Codec{}.Encode(map[string]any{"a": "b", "c": []any{1, 2}})
+	assert.NoError(err)
+	assert.Equal("{\n  \"a\": \"b\",\n  \"c\": [\n    1,\n    2\n  ]\n}", string(got))
+}
+
+func TestEncodeFormatting(t *testing.T) {
+	tests := []struct {
+		description string
+		codec       Codec
+		want        string
+	}{
+		{
+			description: "A custom indent.",
+			codec:       Codec{Indent: "\t"},
+			want:        "{\n\t\"a\": [\n\t\t1\n\t]\n}",
+		}, {
+			description: "Compact output.",
+			codec:       Codec{Compact: true},
+			want:        `{"a":[1]}`,
+		}, {
+			description: "Compact output ignores the indent.",
+			codec:       Codec{Indent: "\t", Compact: true},
+			want:        `{"a":[1]}`,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			assert := assert.New(t)
+
+			got, err := tc.codec.Encode(map[string]any{"a": []any{1}})
+			assert.NoError(err)
+			assert.Equal(tc.want, string(got))
+		})
+	}
+}
+
+func TestEncodeExtended(t *testing.T) {
+	tests := []struct {
+		description string
+		in          meta.Object
+		want        string
+	}{
+		{
+			description: "A value.",
+			in: meta.Object{
+				Origins: []meta.Origin{{File: "file.json", Line: 1, Col: 2}},
+				Value:   "a",
+			},
+			want: `{"value":"a","origins":[{"file":"file.json","line":1,"col":2}]}`,
+		}, {
+			description: "A value without origins.",
+			in:          meta.Object{Value: 1.5},
+			want:        `{"value":1.5,"origins":[]}`,
+		}, {
+			description: "A value with several origins.",
+			in: meta.Object{
+				Origins: []meta.Origin{
+					{File: "file.json", Line: 1, Col: 2},
+					{File: "env"},
+				},
+				Value: nil,
+			},
+			want: `{"value":null,"origins":[{"file":"file.json","line":1,"col":2},{"file":"env"}]}`,
+		}, {
+			description: "Maps and arrays keep their structure.",
+			in: meta.Object{
+				Origins: []meta.Origin{{File: "file.json", Line: 1, Col: 1}},
+				Map: map[string]meta.Object{
+					"a": {
+						Array: []meta.Object{
+							{
+								Origins: []meta.Origin{{File: "file.json", Line: 2, Col: 9}},
+								Value:   true,
+							},
+						},
+					},
+					"b": {Map: map[string]meta.Object{}},
+					"c": {Array: []meta.Object{}},
+				},
+			},
+			want: `{"a":[{"value":true,"origins":[{"file":"file.json","line":2,"col":9}]}],"b":{},"c":[]}`,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			assert := assert.New(t)
+
+			got, err := Codec{Compact: true}.EncodeExtended(tc.in)
+			assert.NoError(err)
+			assert.Equal(tc.want, string(got))
+		})
+	}
+}
+
+func TestEncodeExtendedIndented(t *testing.T) {
+	assert := assert.New(t)
+
+	got, err := Codec{}.EncodeExtended(meta.Object{
+		Map: map[string]meta.Object{
+			"a": {
+				Origins: []meta.Origin{{File: "file.json", Line: 1, Col: 7}},
+				Value:   "b",
+			},
+		},
+	})
+	assert.NoError(err)
+	assert.Equal(""+
+		"{\n"+
+		"  \"a\": {\n"+
+		"    \"value\": \"b\",\n"+
+		"    \"origins\": [\n"+
+		"      {\n"+
+		"        \"file\": \"file.json\",\n"+
+		"        \"line\": 1,\n"+
+		"        \"col\": 7\n"+
+		"      }\n"+
+		"    ]\n"+
+		"  }\n"+
+		"}", string(got))
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		description string
+		in          meta.Object
+		expectErr   error
+	}{
+		{
+			description: "An empty tree.",
+		}, {
+			description: "A valid tree.",
+			in: meta.Object{
+				Map: map[string]meta.Object{
+					"a": {Value: "b"},
+					"c": {Array: []meta.Object{{Value: 1.5}, {Value: float32(2)}}},
+				},
+			},
+		}, {
+			description: "A NaN value.",
+			in: meta.Object{
+				Map: map[string]meta.Object{
+					"a": {Array: []meta.Object{{Value: math.NaN()}}},
+				},
+			},
+			expectErr: ErrUnrepresentable,
+		}, {
+			description: "An infinite value.",
+			in:          meta.Object{Value: float32(math.Inf(-1))},
+			expectErr:   ErrUnrepresentable,
+		}, {
+			description: "A time value.",
+			in: meta.Object{
+				Map: map[string]meta.Object{
+					"a": {Value: time.Now()},
+				},
+			},
+			expectErr: ErrUnrepresentable,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			assert := assert.New(t)
+
+			err := Codec{}.Validate(tc.in)
+			assert.ErrorIs(err, tc.expectErr)
+		})
+	}
+}
@@ -0,0 +1,103 @@
+// SPDX-FileCopyrightText: 2023 Weston Schmidt <weston_schmidt@alumni.purdue.edu>
+// SPDX-License-Identifier: Apache-2.0
+
+// Package jsonc provides a decoder for JSON with comments (jsonc) files.
+//
+// Both line (//) and block (/* */) comments are supported, as are trailing
+// commas in objects and arrays.  Comments are replaced with whitespace before
+// parsing so the line and column information of the values is preserved.
+package jsonc
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+
+	"github.com/goschtalt/goschtalt/internal/jsontree"
+	"github.com/goschtalt/goschtalt/pkg/decoder"
+	"github.com/goschtalt/goschtalt/pkg/meta"
+)
+
+var errUnterminatedComment = errors.New("unterminated block comment")
+
+var _ decoder.Decoder = (*Codec)(nil)
+
+// Codec is a jsonc decoder.
+type Codec struct{}
+
+// Extensions returns the supported extensions.
+func (c Codec) Extensions() []string {
+	return []string{"jsonc"}
+}
+
+// Decode decodes a byte array into the meta.Object tree.
+func (c Codec) Decode(ctx decoder.Context, b []byte, m *meta.Object) error {
+	clean, err := strip(b)
+	if err != nil {
+		return fmt.Errorf("%s: %w", ctx.Filename, err)
+	}
+
+	decode := jsontree.Decode
+	if ctx.RejectDuplicateKeys {
+		decode = jsontree.DecodeUniqueKeys
+	}
+
+	obj, err := decode(ctx.Filename, clean)
+	if err != nil {
+		return fmt.Errorf("%s: %w", ctx.Filename, err)
+	}
+
+	*m = obj
+	return nil
+}
+
+// strip replaces the comments and trailing commas with spaces, leaving the
+// newlines in place so offsets, lines and columns are unchanged.
+func strip(in []byte) ([]byte, error) {
+	out := make([]byte, len(in))
+	copy(out, in)
+
+	// The offset of the last comma seen outside of a string that has not been
+	// followed by anything other than whitespace, or -1.
+	comma := -1
+
+	for i := 0; i < len(out); i++ {
+		switch c := out[i]; {
+		case c == '"':
+			comma = -1
+			for i++; i < len(out) && out[i] != '"'; i++ {
+				if out[i] == '\\' {
+					i++
+				}
+			}
+		case c == '/' && i+1 < len(out) && out[i+1] == '/':
+			for ; i < len(out) && out[i] != '\n'; i++ {
+				out[i] = ' '
+			}
+		case c == '/' && i+1 < len(out) && out[i+1] == '*':
+			end := bytes.Index(out[i+2:], []byte("*/"))
+			if end < 0 {
+				return nil, errUnterminatedComment
+			}
+			end += i + 4
+			for ; i < end; i++ {
+				if out[i] != '\n' {
+					out[i] = ' '
+				}
+			}
+			i--
+		case c == ',':
+			comma = i
+		case c == '}' || c == ']':
+			if comma >= 0 {
+				out[comma] = ' '
+			}
+			comma = -1
+		case c == ' ' || c == '\t' || c == '\r' || c == '\n':
+		default:
+			comma = -1
+		}
+	}
+
+	return out, nil
+}
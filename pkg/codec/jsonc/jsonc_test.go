@@ -0,0 +1,170 @@
+// SPDX-FileCopyrightText: 2023 Weston Schmidt <weston_schmidt@alumni.purdue.edu>
+// SPDX-License-Identifier: Apache-2.0
+
+package jsonc
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/goschtalt/goschtalt/pkg/decoder"
+	"github.com/goschtalt/goschtalt/pkg/meta"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExtensions(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Equal([]string{"jsonc"}, Codec{}.Extensions())
+}
+
+func TestDecode(t *testing.T) {
+	tests := []struct {
+		description string
+		in          string
+		want        any
+		origins     map[string]meta.Origin
+		expectErr   bool
+	}{
+		{
+			description: "An empty file.",
+			in:          "",
+		}, {
+			description: "A file with only comments.",
+			in:          "// nothing here\n/* or here */\n",
+		}, {
+			description: "A simple object.",
+			in:          `{"a": "b", "c": 1, "d": 1.5, "e": true, "f": null}`,
+			want: map[string]any{
+				"a": "b",
+				"c": 1,
+				"d": 1.5,
+				"e": true,
+				"f": nil,
+			},
+		}, {
+			description: "Line comments.",
+			in: `// leading comment
+{
+	"a": "b", // trailing comment
+	// a full line comment
+	"c": "d"
+}
+// final comment`,
+			want: map[string]any{
+				"a": "b",
+				"c": "d",
+			},
+			origins: map[string]meta.Origin{
+				"":  {File: "file.jsonc", Line: 2, Col: 1},
+				"a": {File: "file.jsonc", Line: 3, Col: 7},
+				"c": {File: "file.jsonc", Line: 5, Col: 7},
+			},
+		}, {
+			description: "Block comments.",
+			in: `/* leading
+   comment */ {
+	"a": /* inline */ "b",
+	/*
+	 * multi-line
+	 */
+	"c": ["d", /* inside */ "e"]
+}`,
+			want: map[string]any{
+				"a": "b",
+				"c": []any{"d", "e"},
+			},
+			origins: map[string]meta.Origin{
+				"":    {File: "file.jsonc", Line: 2, Col: 15},
+				"a":   {File: "file.jsonc", Line: 3, Col: 20},
+				"c":   {File: "file.jsonc", Line: 7, Col: 7},
+				"c.1": {File: "file.jsonc", Line: 7, Col: 26},
+			},
+		}, {
+			description: "Comments inside strings are not stripped.",
+			in: `{
+	"url": "http://example.com",
+	"block": "/* not a comment */",
+	"escaped": "quote \" // still in the string"
+}`,
+			want: map[string]any{
+				"url":     "http://example.com",
+				"block":   "/* not a comment */",
+				"escaped": `quote " // still in the string`,
+			},
+		}, {
+			description: "Trailing commas.",
+			in: `{
+	"a": [1, 2, 3,],
+	"b": {"c": "d",},
+	"e": [
+		"f", // comment
+	],
+}`,
+			want: map[string]any{
+				"a": []any{1, 2, 3},
+				"b": map[string]any{"c": "d"},
+				"e": []any{"f"},
+			},
+		}, {
+			description: "A comma inside a string before a brace is kept.",
+			in:          `{"a": ",}"}`,
+			want: map[string]any{
+				"a": ",}",
+			},
+		}, {
+			description: "A top level array.",
+			in:          `["a", "b",]`,
+			want:        []any{"a", "b"},
+		}, {
+			description: "An unterminated block comment.",
+			in:          `{"a": "b"} /* oops`,
+			expectErr:   true,
+		}, {
+			description: "Invalid json.",
+			in:          `{"a": }`,
+			expectErr:   true,
+		}, {
+			description: "Extra data after the value.",
+			in:          `{"a": "b"} {"c": "d"}`,
+			expectErr:   true,
+		}, {
+			description: "Multiple commas are invalid.",
+			in:          `{"a": "b",,}`,
+			expectErr:   true,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			var got meta.Object
+			ctx := decoder.Context{
+				Filename:  "file.jsonc",
+				Delimiter: ".",
+			}
+			err := Codec{}.Decode(ctx, []byte(tc.in), &got)
+
+			if tc.expectErr {
+				assert.Error(err)
+				return
+			}
+
+			require.NoError(err)
+			assert.Equal(tc.want, got.ToRaw())
+
+			for key, want := range tc.origins {
+				var asks []string
+				if key != "" {
+					asks = strings.Split(key, ".")
+				}
+				obj, err := got.Fetch(asks, ".")
+				require.NoError(err)
+				require.NotEmpty(obj.Origins)
+				assert.Equal(want, obj.Origins[0], key)
+			}
+		})
+	}
+}
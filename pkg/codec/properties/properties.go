@@ -0,0 +1,370 @@
+// SPDX-FileCopyrightText: 2023 Weston Schmidt <weston_schmidt@alumni.purdue.edu>
+// SPDX-License-Identifier: Apache-2.0
+
+// Package properties provides a decoder for Java style properties files.
+//
+// The format follows java.util.Properties, except the file is read as UTF-8.
+// For example:
+//
+//	# Comments start with # or !.
+//	server.http.port = 8080
+//	server.name: example
+//	greeting = hello \
+//	           world
+//	path = C:\\temp\\app
+//	key\=with\:separators = \u00e9
+//
+// The key ends at the first unescaped '=', ':' or whitespace.  A line ending
+// in a backslash continues on the next line, with the leading whitespace of
+// the next line removed.  The \t, \n, \r, \f and \uXXXX escape sequences are
+// supported and any other escaped character is used as is.
+//
+// Keys are split on '.' into nested maps and all values are strings.  The
+// last value specified for a key is used.  The JVM keeps the keys flat, so
+// a.b and a.b.c are unrelated keys there, but a tree can't hold both a value
+// and a map for a.b.  The one specified last is used, so:
+//
+//	a.b = 1
+//	a.b.c = 2
+//
+// decodes as a.b.c = 2 and the value of a.b is dropped.
+package properties
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/goschtalt/goschtalt/pkg/decoder"
+	"github.com/goschtalt/goschtalt/pkg/meta"
+)
+
+var (
+	ErrSyntax = errors.New("invalid properties")
+)
+
+var _ decoder.Decoder = (*Codec)(nil)
+
+const separator = "."
+
+// Codec is a properties decoder.
+type Codec struct{}
+
+// Extensions returns the supported extensions.
+func (c Codec) Extensions() []string {
+	return []string{"properties"}
+}
+
+// Decode decodes a byte array into the meta.Object tree.
+func (c Codec) Decode(ctx decoder.Context, b []byte, m *meta.Object) error {
+	p := parser{
+		file: ctx.Filename,
+		src:  strings.TrimPrefix(string(b), "\uFEFF"),
+		state: state{
+			line: 1,
+			col:  1,
+		},
+	}
+
+	root := meta.Object{
+		Origins: []meta.Origin{{File: ctx.Filename, Line: 1, Col: 1}},
+		Map:     make(map[string]meta.Object),
+	}
+
+	for {
+		key, val, found, err := p.next()
+		if err != nil {
+			return fmt.Errorf("%s: %w", ctx.Filename, err)
+		}
+		if !found {
+			break
+		}
+
+		path := strings.Split(key, separator)
+		if err := add(&root, path, val, ctx.RejectDuplicateKeys); err != nil {
+			return fmt.Errorf("%s: %w", ctx.Filename, err)
+		}
+	}
+
+	*m = root
+	return nil
+}
+
+// add sets the value at the path, creating the maps as needed.  A value or
+// map already at the path is replaced.
+func add(obj *meta.Object, path []string, val meta.Object, unique bool) error {
+	key := path[0]
+	if key == "" {
+		return fmt.Errorf("%w: the key at %s has an empty part", ErrSyntax, val.Origins[0])
+	}
+
+	child, found := obj.Map[key]
+
+	if len(path) == 1 {
+		if found && child.Map == nil && unique {
+			return fmt.Errorf("%w: '%s' at %s", decoder.ErrDuplicateKey, key, val.Origins[0])
+		}
+
+		obj.Map[key] = val
+		return nil
+	}
+
+	if !found || child.Map == nil {
+		child = meta.Object{
+			Origins: val.Origins,
+			Map:     make(map[string]meta.Object),
+		}
+	}
+
+	if err := add(&child, path[1:], val, unique); err != nil {
+		return err
+	}
+
+	obj.Map[key] = child
+	return nil
+}
+
+// state is the position in the document.
+type state struct {
+	pos  int
+	line int
+	col  int
+}
+
+type parser struct {
+	file string
+	src  string
+	state
+}
+
+func (p *parser) eof() bool {
+	return p.pos >= len(p.src)
+}
+
+func (p *parser) peek() byte {
+	if p.eof() {
+		return 0
+	}
+	return p.src[p.pos]
+}
+
+// advance consumes n bytes that do not include a line terminator.
+func (p *parser) advance(n int) {
+	p.pos += n
+	p.col += n
+}
+
+// terminator consumes a line terminator (\n, \r or \r\n) if present.
+func (p *parser) terminator() {
+	switch p.peek() {
+	case '\r':
+		p.pos++
+		if p.peek() == '\n' {
+			p.pos++
+		}
+	case '\n':
+		p.pos++
+	default:
+		return
+	}
+	p.line++
+	p.col = 1
+}
+
+func (p *parser) atTerminator() bool {
+	c := p.peek()
+	return c == '\n' || c == '\r'
+}
+
+func (p *parser) origin() meta.Origin {
+	return meta.Origin{
+		File: p.file,
+		Line: p.line,
+		Col:  p.col,
+	}
+}
+
+func (p *parser) errorf(format string, args ...any) error {
+	return fmt.Errorf("%w: line %d, column %d: %s", ErrSyntax, p.line, p.col,
+		fmt.Sprintf(format, args...))
+}
+
+func isSpace[T byte | rune](c T) bool {
+	return c == ' ' || c == '\t' || c == '\f'
+}
+
+// skipSpace skips the whitespace in the natural line.
+func (p *parser) skipSpace() {
+	for isSpace(p.peek()) {
+		p.advance(1)
+	}
+}
+
+// skipLine skips the rest of the natural line including the terminator.
+func (p *parser) skipLine() {
+	for !p.eof() && !p.atTerminator() {
+		p.advance(1)
+	}
+	p.terminator()
+}
+
+// char returns the next character of the logical line.  Continuations are
+// followed and escape sequences are replaced.  The end is true at the end of
+// the logical line.
+func (p *parser) char() (r rune, escaped, end bool, err error) {
+	if p.eof() || p.atTerminator() {
+		return 0, false, true, nil
+	}
+
+	if p.peek() != '\\' {
+		r, size := utf8.DecodeRuneInString(p.src[p.pos:])
+		p.advance(size)
+		return r, false, false, nil
+	}
+
+	p.advance(1)
+	if p.eof() {
+		// A backslash at the end of the document is ignored.
+		return 0, false, true, nil
+	}
+
+	if p.atTerminator() {
+		p.terminator()
+		p.skipSpace()
+		return p.char()
+	}
+
+	c := p.peek()
+	switch c {
+	case 't':
+		r = '\t'
+	case 'n':
+		r = '\n'
+	case 'r':
+		r = '\r'
+	case 'f':
+		r = '\f'
+	case 'u':
+		hex := p.src[p.pos+1 : min(p.pos+5, len(p.src))]
+		n, err := strconv.ParseUint(hex, 16, 16)
+		if err != nil || len(hex) != 4 {
+			return 0, false, false, p.errorf("malformed \\uXXXX escape")
+		}
+		p.advance(5)
+		return rune(n), true, false, nil
+	default:
+		r, size := utf8.DecodeRuneInString(p.src[p.pos:])
+		p.advance(size)
+		return r, true, false, nil
+	}
+
+	p.advance(1)
+	return r, true, false, nil
+}
+
+// skipLogicalSpace skips the whitespace in the logical line.
+func (p *parser) skipLogicalSpace() error {
+	for {
+		save := p.state
+		r, escaped, end, err := p.char()
+		if err != nil {
+			return err
+		}
+		if end || escaped || !isSpace(r) {
+			p.state = save
+			return nil
+		}
+	}
+}
+
+// next returns the next key and value in the document.
+func (p *parser) next() (string, meta.Object, bool, error) {
+	for {
+		p.skipSpace()
+		if p.eof() {
+			return "", meta.Object{}, false, nil
+		}
+
+		switch p.peek() {
+		case '\n', '\r':
+			p.terminator()
+			continue
+		case '#', '!':
+			p.skipLine()
+			continue
+		}
+
+		key, err := p.key()
+		if err != nil {
+			return "", meta.Object{}, false, err
+		}
+
+		origin := p.origin()
+		val, err := p.value()
+		if err != nil {
+			return "", meta.Object{}, false, err
+		}
+		p.terminator()
+
+		return key, meta.Object{
+			Origins: []meta.Origin{origin},
+			Value:   val,
+		}, true, nil
+	}
+}
+
+// key reads the key and the separator after it.
+func (p *parser) key() (string, error) {
+	var b strings.Builder
+	for {
+		r, escaped, end, err := p.char()
+		if err != nil {
+			return "", err
+		}
+		if end {
+			return b.String(), nil
+		}
+
+		if !escaped && (r == '=' || r == ':') {
+			break
+		}
+
+		if !escaped && isSpace(r) {
+			if err := p.skipLogicalSpace(); err != nil {
+				return "", err
+			}
+
+			// The whitespace may be followed by a separator.
+			save := p.state
+			r, escaped, _, err = p.char()
+			if err != nil {
+				return "", err
+			}
+			if escaped || (r != '=' && r != ':') {
+				p.state = save
+			}
+			break
+		}
+
+		b.WriteRune(r)
+	}
+
+	return b.String(), p.skipLogicalSpace()
+}
+
+// value reads the rest of the logical line.
+func (p *parser) value() (string, error) {
+	var b strings.Builder
+	for {
+		r, _, end, err := p.char()
+		if err != nil {
+			return "", err
+		}
+		if end {
+			return b.String(), nil
+		}
+		b.WriteRune(r)
+	}
+}
@@ -0,0 +1,221 @@
+// SPDX-FileCopyrightText: 2023 Weston Schmidt <weston_schmidt@alumni.purdue.edu>
+// SPDX-License-Identifier: Apache-2.0
+
+package properties
+
+import (
+	"testing"
+
+	"github.com/goschtalt/goschtalt/pkg/decoder"
+	"github.com/goschtalt/goschtalt/pkg/meta"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExtensions(t *testing.T) {
+	assert := assert.New(t)
+	assert.Equal([]string{"properties"}, Codec{}.Extensions())
+}
+
+func TestDecode(t *testing.T) {
+	tests := []struct {
+		description string
+		in          string
+		unique      bool
+		want        any
+		expectErr   error
+	}{
+		{
+			description: "An empty document.",
+			in:          "",
+			want:        map[string]any{},
+		}, {
+			description: "The separators.",
+			in:          "a=1\nb:2\nc 3\nd = 4\ne : 5\nf\t\t6\ng  =  7  \nh=:8\ni",
+			want: map[string]any{
+				"a": "1", "b": "2", "c": "3", "d": "4", "e": "5",
+				"f": "6", "g": "7  ", "h": ":8", "i": "",
+			},
+		}, {
+			description: "Comments and blank lines.",
+			in:          "# comment\n! also a comment\n\n   \n  # indented\na=1 # not a comment",
+			want:        map[string]any{"a": "1 # not a comment"},
+		}, {
+			description: "Line endings.",
+			in:          "a=1\r\nb=2\rc=3\n",
+			want:        map[string]any{"a": "1", "b": "2", "c": "3"},
+		}, {
+			description: "Continuation lines.",
+			in: "a = one \\\n    two \\\r\n\tthree\n" +
+				"b = \\\n  \\\n  x\n" +
+				"c\\\n  d = 1\n" +
+				"e = end\\",
+			want: map[string]any{
+				"a":  "one two three",
+				"b":  "x",
+				"cd": "1",
+				"e":  "end",
+			},
+		}, {
+			description: "An even number of backslashes is not a continuation.",
+			in:          "a = x\\\\\nb = y",
+			want:        map[string]any{"a": "x\\", "b": "y"},
+		}, {
+			description: "A comment is not continued.",
+			in:          "# comment \\\na = 1",
+			want:        map[string]any{"a": "1"},
+		}, {
+			description: "Escapes.",
+			in: "a = tab\\t newline\\n return\\r feed\\f \\u00e9\\u20AC \\q\n" +
+				"key\\=with\\:separators\\ and\\ spaces = x\n" +
+				"\\#hash = y\n" +
+				"b = \\ leading",
+			want: map[string]any{
+				"a":                              "tab\t newline\n return\r feed\f é€ q",
+				"key=with:separators and spaces": "x",
+				"#hash":                          "y",
+				"b":                              " leading",
+			},
+		}, {
+			description: "UTF-8 is kept.",
+			in:          "grüße = héllo",
+			want:        map[string]any{"grüße": "héllo"},
+		}, {
+			description: "Dotted keys are nested.",
+			in:          "server.http.port = 8080\nserver.http.host = localhost\nserver.name = example",
+			want: map[string]any{
+				"server": map[string]any{
+					"http": map[string]any{"port": "8080", "host": "localhost"},
+					"name": "example",
+				},
+			},
+		}, {
+			description: "The last value is used.",
+			in:          "a.b = 1\na.b = 2\nc = 3\nc = 4",
+			want: map[string]any{
+				"a": map[string]any{"b": "2"},
+				"c": "4",
+			},
+		}, {
+			description: "A duplicate key is rejected.",
+			in:          "a.b = 1\na.b = 2",
+			unique:      true,
+			expectErr:   decoder.ErrDuplicateKey,
+		}, {
+			description: "A map replaces a value.",
+			in:          "a = 1\na.b = 2\nc = 3",
+			want: map[string]any{
+				"a": map[string]any{"b": "2"},
+				"c": "3",
+			},
+		}, {
+			description: "A value replaces a map.",
+			in:          "a.b = 1\na.c = 2\na = 3",
+			want:        map[string]any{"a": "3"},
+		}, {
+			description: "The last one wins in nested maps.",
+			in:          "a.b.c = 1\na.b = 2\na.b.d = 3\na.e = 4",
+			want: map[string]any{
+				"a": map[string]any{
+					"b": map[string]any{"d": "3"},
+					"e": "4",
+				},
+			},
+		}, {
+			description: "A value and a map are not duplicates.",
+			in:          "a = 1\na.b = 2",
+			unique:      true,
+			want: map[string]any{
+				"a": map[string]any{"b": "2"},
+			},
+		}, {
+			description: "An empty part of a key.",
+			in:          "a..b = 1",
+			expectErr:   ErrSyntax,
+		}, {
+			description: "An empty key.",
+			in:          "= 1",
+			expectErr:   ErrSyntax,
+		}, {
+			description: "A malformed unicode escape.",
+			in:          "a = \\u00g1",
+			expectErr:   ErrSyntax,
+		}, {
+			description: "A short unicode escape.",
+			in:          "a = \\u00",
+			expectErr:   ErrSyntax,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			assert := assert.New(t)
+
+			ctx := decoder.Context{
+				Filename:            "file.properties",
+				Delimiter:           ".",
+				RejectDuplicateKeys: tc.unique,
+			}
+
+			var got meta.Object
+			err := Codec{}.Decode(ctx, []byte(tc.in), &got)
+
+			if tc.expectErr != nil {
+				assert.ErrorIs(err, tc.expectErr)
+				assert.ErrorContains(err, "file.properties")
+				return
+			}
+
+			assert.NoError(err)
+			assert.Equal(tc.want, raw(got))
+		})
+	}
+}
+
+func TestDecodeOrigins(t *testing.T) {
+	assert := assert.New(t)
+
+	in := "" +
+		"# comment\n" +
+		"server.port = 80\n" +
+		"\n" +
+		"greeting = hello \\\n" +
+		"    world\n" +
+		"  name:example\n"
+
+	var got meta.Object
+	err := Codec{}.Decode(decoder.Context{Filename: "file.properties"}, []byte(in), &got)
+	assert.NoError(err)
+
+	origin := func(line, col int) []meta.Origin {
+		return []meta.Origin{{File: "file.properties", Line: line, Col: col}}
+	}
+
+	assert.Equal(origin(1, 1), got.Origins)
+	assert.Equal(origin(2, 15), got.Map["server"].Origins)
+	assert.Equal(origin(2, 15), got.Map["server"].Map["port"].Origins)
+	assert.Equal(origin(4, 12), got.Map["greeting"].Origins)
+	assert.Equal(origin(6, 8), got.Map["name"].Origins)
+}
+
+func TestDecodeErrorPosition(t *testing.T) {
+	assert := assert.New(t)
+
+	var got meta.Object
+	err := Codec{}.Decode(decoder.Context{Filename: "file.properties"}, []byte("a=1\n\nb = x\\uZZZZ"), &got)
+	assert.ErrorIs(err, ErrSyntax)
+	assert.ErrorContains(err, "line 3, column 7")
+}
+
+// raw is the same as meta.Object.ToRaw() except empty maps are kept so they
+// can be compared.
+func raw(obj meta.Object) any {
+	if obj.Map == nil {
+		return obj.Value
+	}
+
+	m := make(map[string]any, len(obj.Map))
+	for key, val := range obj.Map {
+		m[key] = raw(val)
+	}
+	return m
+}
@@ -0,0 +1,950 @@
+// SPDX-FileCopyrightText: 2023 Weston Schmidt <weston_schmidt@alumni.purdue.edu>
+// SPDX-License-Identifier: Apache-2.0
+
+package toml
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/goschtalt/goschtalt/pkg/meta"
+)
+
+// How a table came to exist, which determines how it may be extended.
+const (
+	// implicit tables are created by a header like [a.b] for 'a'.
+	implicit = iota
+
+	// defined tables are created by their own header.
+	defined
+
+	// dotted tables are created by dotted keys like a.b = 1 for 'a'.
+	dotted
+
+	// inline tables are created by {} and may not be extended.
+	inline
+)
+
+// node is the intermediate form of the document that tracks the information
+// needed to validate how tables are defined.
+type node struct {
+	origin meta.Origin
+
+	// Only one of table, array or value is used.
+	table map[string]*node
+	array []*node
+	value any
+
+	// how is how a table was created.
+	how int
+
+	// aot is true for an array of tables, which may be appended to.
+	aot bool
+}
+
+func (n *node) isTable() bool {
+	return n.table != nil
+}
+
+// toObject converts the node into the meta.Object tree.
+func (n *node) toObject() meta.Object {
+	obj := meta.Object{
+		Origins: []meta.Origin{n.origin},
+	}
+
+	switch {
+	case n.table != nil:
+		obj.Map = make(map[string]meta.Object, len(n.table))
+		for key, val := range n.table {
+			obj.Map[key] = val.toObject()
+		}
+	case n.array != nil:
+		obj.Array = make([]meta.Object, 0, len(n.array))
+		for _, val := range n.array {
+			obj.Array = append(obj.Array, val.toObject())
+		}
+	default:
+		obj.Value = n.value
+	}
+
+	return obj
+}
+
+type parser struct {
+	file string
+	src  string
+	pos  int
+	line int
+	col  int
+
+	root    *node
+	current *node
+}
+
+// decode parses the TOML document.
+func decode(file string, b []byte) (meta.Object, error) {
+	src := string(b)
+	if !utf8.ValidString(src) {
+		return meta.Object{}, fmt.Errorf("%w: the document is not valid UTF-8", ErrSyntax)
+	}
+
+	p := parser{
+		file: file,
+		src:  src,
+		line: 1,
+		col:  1,
+	}
+	p.root = &node{
+		origin: p.origin(),
+		table:  make(map[string]*node),
+		how:    defined,
+	}
+	p.current = p.root
+
+	// Skip the byte order mark if present.
+	if strings.HasPrefix(p.src, "\uFEFF") {
+		p.pos += len("\uFEFF")
+	}
+
+	for {
+		p.skipSpace()
+		if p.eof() {
+			break
+		}
+
+		var err error
+		switch p.peek() {
+		case '#', '\n', '\r':
+			err = p.endOfLine()
+		case '[':
+			err = p.header()
+		default:
+			err = p.keyValue(p.current)
+			if err == nil {
+				err = p.endOfLine()
+			}
+		}
+
+		if err != nil {
+			return meta.Object{}, err
+		}
+	}
+
+	return p.root.toObject(), nil
+}
+
+// -- Low level helpers --------------------------------------------------------
+
+func (p *parser) eof() bool {
+	return p.pos >= len(p.src)
+}
+
+func (p *parser) peek() byte {
+	if p.eof() {
+		return 0
+	}
+	return p.src[p.pos]
+}
+
+func (p *parser) hasPrefix(s string) bool {
+	return strings.HasPrefix(p.src[p.pos:], s)
+}
+
+// next consumes a single byte, keeping track of the line and column.
+func (p *parser) next() byte {
+	c := p.src[p.pos]
+	p.pos++
+	if c == '\n' {
+		p.line++
+		p.col = 1
+	} else {
+		p.col++
+	}
+	return c
+}
+
+func (p *parser) skip(n int) {
+	for i := 0; i < n; i++ {
+		p.next()
+	}
+}
+
+func (p *parser) origin() meta.Origin {
+	return meta.Origin{
+		File: p.file,
+		Line: p.line,
+		Col:  p.col,
+	}
+}
+
+func (p *parser) errorf(format string, args ...any) error {
+	return fmt.Errorf("%w: line %d, column %d: %s", ErrSyntax, p.line, p.col,
+		fmt.Sprintf(format, args...))
+}
+
+// skipSpace skips spaces and tabs.
+func (p *parser) skipSpace() {
+	for !p.eof() && (p.peek() == ' ' || p.peek() == '\t') {
+		p.next()
+	}
+}
+
+// skipComment skips a comment if present, leaving the newline.
+func (p *parser) skipComment() error {
+	if p.peek() != '#' {
+		return nil
+	}
+
+	for !p.eof() && p.peek() != '\n' {
+		if p.hasPrefix("\r\n") {
+			return nil
+		}
+		if c := p.peek(); isControl(c) && c != '\t' {
+			return p.errorf("control characters are not allowed in comments")
+		}
+		p.next()
+	}
+	return nil
+}
+
+// skipNewline consumes a newline if present and returns if one was found.
+func (p *parser) skipNewline() bool {
+	switch {
+	case p.hasPrefix("\n"):
+		p.next()
+		return true
+	case p.hasPrefix("\r\n"):
+		p.skip(2)
+		return true
+	}
+	return false
+}
+
+// skipBlank skips whitespace, comments and newlines, as allowed in arrays.
+func (p *parser) skipBlank() error {
+	for {
+		p.skipSpace()
+		if err := p.skipComment(); err != nil {
+			return err
+		}
+		if !p.skipNewline() {
+			return nil
+		}
+	}
+}
+
+// endOfLine ensures only whitespace and a comment remain on the line.
+func (p *parser) endOfLine() error {
+	p.skipSpace()
+	if err := p.skipComment(); err != nil {
+		return err
+	}
+	if p.eof() || p.skipNewline() {
+		return nil
+	}
+	return p.errorf("expected the end of the line, found '%c'", p.peek())
+}
+
+func isControl(c byte) bool {
+	return c < 0x20 || c == 0x7f
+}
+
+func isBare(c byte) bool {
+	return ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z') ||
+		('0' <= c && c <= '9') || c == '_' || c == '-'
+}
+
+// -- Tables -------------------------------------------------------------------
+
+// header parses a [table] or [[array of tables]] header.
+func (p *parser) header() error {
+	origin := p.origin()
+	p.next()
+
+	array := p.peek() == '['
+	if array {
+		p.next()
+	}
+
+	p.skipSpace()
+	keys, _, err := p.key()
+	if err != nil {
+		return err
+	}
+	p.skipSpace()
+
+	if array {
+		if !p.hasPrefix("]]") {
+			return p.errorf("expected ']]' to end the array of tables header")
+		}
+		p.skip(2)
+	} else {
+		if p.peek() != ']' {
+			return p.errorf("expected ']' to end the table header")
+		}
+		p.next()
+	}
+
+	// Find or create the containing tables.
+	t := p.root
+	for i, key := range keys[:len(keys)-1] {
+		t, err = p.descend(t, key, keys[:i+1], origin)
+		if err != nil {
+			return err
+		}
+	}
+
+	last := keys[len(keys)-1]
+	name := strings.Join(keys, ".")
+	existing, found := t.table[last]
+
+	if array {
+		if !found {
+			existing = &node{origin: origin, array: []*node{}, aot: true}
+			t.table[last] = existing
+		}
+		if !existing.aot {
+			return fmt.Errorf("%w: '%s' at %s is not an array of tables", ErrSyntax, name, origin)
+		}
+
+		p.current = &node{origin: origin, table: make(map[string]*node), how: defined}
+		existing.array = append(existing.array, p.current)
+		return p.endOfLine()
+	}
+
+	if !found {
+		p.current = &node{origin: origin, table: make(map[string]*node), how: defined}
+		t.table[last] = p.current
+		return p.endOfLine()
+	}
+
+	if !existing.isTable() || existing.how != implicit {
+		return fmt.Errorf("%w: '%s' at %s is already defined", ErrSyntax, name, origin)
+	}
+
+	existing.how = defined
+	existing.origin = origin
+	p.current = existing
+	return p.endOfLine()
+}
+
+// descend finds or creates the table named key in t for a header.
+func (p *parser) descend(t *node, key string, path []string, origin meta.Origin) (*node, error) {
+	child, found := t.table[key]
+	if !found {
+		child = &node{origin: origin, table: make(map[string]*node), how: implicit}
+		t.table[key] = child
+		return child, nil
+	}
+
+	if child.aot {
+		return child.array[len(child.array)-1], nil
+	}
+
+	if !child.isTable() || child.how == inline {
+		return nil, fmt.Errorf("%w: '%s' at %s is not a table", ErrSyntax, strings.Join(path, "."), origin)
+	}
+
+	return child, nil
+}
+
+// keyValue parses a key = value pair and adds it to the table.
+func (p *parser) keyValue(t *node) error {
+	origin := p.origin()
+	keys, _, err := p.key()
+	if err != nil {
+		return err
+	}
+
+	p.skipSpace()
+	if p.peek() != '=' {
+		return p.errorf("expected '=' after the key")
+	}
+	p.next()
+	p.skipSpace()
+
+	val, err := p.value()
+	if err != nil {
+		return err
+	}
+
+	for i, key := range keys[:len(keys)-1] {
+		child, found := t.table[key]
+		if !found {
+			child = &node{origin: origin, table: make(map[string]*node), how: dotted}
+			t.table[key] = child
+		}
+
+		if !child.isTable() || child.how != dotted {
+			return fmt.Errorf("%w: '%s' at %s can't be extended with a dotted key",
+				ErrSyntax, strings.Join(keys[:i+1], "."), origin)
+		}
+		t = child
+	}
+
+	last := keys[len(keys)-1]
+	if _, found := t.table[last]; found {
+		return fmt.Errorf("%w: '%s' at %s is already defined", ErrSyntax, strings.Join(keys, "."), origin)
+	}
+	t.table[last] = val
+
+	return nil
+}
+
+// key parses a simple or dotted key.
+func (p *parser) key() ([]string, meta.Origin, error) {
+	origin := p.origin()
+
+	var keys []string
+	for {
+		k, err := p.simpleKey()
+		if err != nil {
+			return nil, origin, err
+		}
+		keys = append(keys, k)
+
+		p.skipSpace()
+		if p.peek() != '.' {
+			return keys, origin, nil
+		}
+		p.next()
+		p.skipSpace()
+	}
+}
+
+func (p *parser) simpleKey() (string, error) {
+	switch p.peek() {
+	case '"':
+		if p.hasPrefix(`"""`) {
+			return "", p.errorf("multi-line strings are not allowed as keys")
+		}
+		return p.basicString()
+	case '\'':
+		if p.hasPrefix("'''") {
+			return "", p.errorf("multi-line strings are not allowed as keys")
+		}
+		return p.literalString()
+	}
+
+	start := p.pos
+	for !p.eof() && isBare(p.peek()) {
+		p.next()
+	}
+	if start == p.pos {
+		return "", p.errorf("expected a key")
+	}
+
+	return p.src[start:p.pos], nil
+}
+
+// -- Values -------------------------------------------------------------------
+
+func (p *parser) value() (*node, error) {
+	origin := p.origin()
+
+	if p.eof() {
+		return nil, p.errorf("expected a value")
+	}
+
+	switch c := p.peek(); {
+	case c == '"':
+		var s string
+		var err error
+		if p.hasPrefix(`"""`) {
+			s, err = p.multiLineBasicString()
+		} else {
+			s, err = p.basicString()
+		}
+		return &node{origin: origin, value: s}, err
+	case c == '\'':
+		var s string
+		var err error
+		if p.hasPrefix("'''") {
+			s, err = p.multiLineLiteralString()
+		} else {
+			s, err = p.literalString()
+		}
+		return &node{origin: origin, value: s}, err
+	case c == '[':
+		return p.array(origin)
+	case c == '{':
+		return p.inlineTable(origin)
+	case p.hasPrefix("true") && !p.bareAt(len("true")):
+		p.skip(len("true"))
+		return &node{origin: origin, value: true}, nil
+	case p.hasPrefix("false") && !p.bareAt(len("false")):
+		p.skip(len("false"))
+		return &node{origin: origin, value: false}, nil
+	}
+
+	v, err := p.scalar()
+	if err != nil {
+		return nil, err
+	}
+	return &node{origin: origin, value: v}, nil
+}
+
+// bareAt returns if the byte at the offset from the current position is a
+// bare key character, meaning a keyword is really a longer token.
+func (p *parser) bareAt(offset int) bool {
+	i := p.pos + offset
+	return i < len(p.src) && isBare(p.src[i])
+}
+
+func (p *parser) array(origin meta.Origin) (*node, error) {
+	p.next()
+	n := &node{origin: origin, array: []*node{}}
+
+	for {
+		if err := p.skipBlank(); err != nil {
+			return nil, err
+		}
+		if p.peek() == ']' {
+			p.next()
+			return n, nil
+		}
+
+		val, err := p.value()
+		if err != nil {
+			return nil, err
+		}
+		n.array = append(n.array, val)
+
+		if err := p.skipBlank(); err != nil {
+			return nil, err
+		}
+		switch p.peek() {
+		case ',':
+			p.next()
+		case ']':
+			p.next()
+			return n, nil
+		default:
+			return nil, p.errorf("expected ',' or ']' in the array")
+		}
+	}
+}
+
+func (p *parser) inlineTable(origin meta.Origin) (*node, error) {
+	p.next()
+	n := &node{origin: origin, table: make(map[string]*node), how: inline}
+
+	p.skipSpace()
+	if p.peek() == '}' {
+		p.next()
+		return n, nil
+	}
+
+	for {
+		p.skipSpace()
+		if err := p.keyValue(n); err != nil {
+			return nil, err
+		}
+		p.skipSpace()
+
+		switch p.peek() {
+		case ',':
+			p.next()
+		case '}':
+			p.next()
+			freeze(n)
+			return n, nil
+		default:
+			return nil, p.errorf("expected ',' or '}' in the inline table")
+		}
+	}
+}
+
+// freeze marks the tables created by dotted keys in an inline table as inline
+// too so they can't be extended.
+func freeze(n *node) {
+	n.how = inline
+	for _, child := range n.table {
+		if child.isTable() {
+			freeze(child)
+		}
+	}
+}
+
+// -- Strings ------------------------------------------------------------------
+
+func (p *parser) basicString() (string, error) {
+	p.next()
+
+	var b strings.Builder
+	for {
+		if p.eof() || p.peek() == '\n' || p.hasPrefix("\r\n") {
+			return "", p.errorf("unterminated string")
+		}
+
+		c := p.peek()
+		switch {
+		case c == '"':
+			p.next()
+			return b.String(), nil
+		case c == '\\':
+			if err := p.escape(&b); err != nil {
+				return "", err
+			}
+		case isControl(c) && c != '\t':
+			return "", p.errorf("control characters must be escaped")
+		default:
+			b.WriteByte(p.next())
+		}
+	}
+}
+
+func (p *parser) multiLineBasicString() (string, error) {
+	p.skip(3)
+	p.skipNewline()
+
+	var b strings.Builder
+	for {
+		if p.eof() {
+			return "", p.errorf("unterminated multi-line string")
+		}
+
+		c := p.peek()
+		switch {
+		case p.hasPrefix(`"""`):
+			// Up to two quotes may be next to the closing delimiter.
+			extra := 0
+			for extra < 2 && strings.HasPrefix(p.src[p.pos+3+extra:], `"`) {
+				extra++
+			}
+			for i := 0; i < extra; i++ {
+				b.WriteByte('"')
+			}
+			p.skip(3 + extra)
+			return b.String(), nil
+		case c == '\\':
+			if p.lineEndingBackslash() {
+				continue
+			}
+			if err := p.escape(&b); err != nil {
+				return "", err
+			}
+		case p.hasPrefix("\r\n"):
+			p.skip(2)
+			b.WriteByte('\n')
+		case c == '\n':
+			b.WriteByte(p.next())
+		case isControl(c) && c != '\t':
+			return "", p.errorf("control characters must be escaped")
+		default:
+			b.WriteByte(p.next())
+		}
+	}
+}
+
+// lineEndingBackslash handles a backslash at the end of a line in a
+// multi-line basic string, which trims the whitespace and newlines after it.
+func (p *parser) lineEndingBackslash() bool {
+	i := p.pos + 1
+	for i < len(p.src) && (p.src[i] == ' ' || p.src[i] == '\t') {
+		i++
+	}
+	if !strings.HasPrefix(p.src[i:], "\n") && !strings.HasPrefix(p.src[i:], "\r\n") {
+		return false
+	}
+
+	p.next()
+	for !p.eof() && strings.IndexByte(" \t\r\n", p.peek()) >= 0 {
+		p.next()
+	}
+	return true
+}
+
+func (p *parser) escape(b *strings.Builder) error {
+	p.next()
+	if p.eof() {
+		return p.errorf("unterminated escape sequence")
+	}
+
+	c := p.next()
+	switch c {
+	case 'b':
+		b.WriteByte('\b')
+	case 't':
+		b.WriteByte('\t')
+	case 'n':
+		b.WriteByte('\n')
+	case 'f':
+		b.WriteByte('\f')
+	case 'r':
+		b.WriteByte('\r')
+	case 'e':
+		b.WriteByte(0x1b)
+	case '"':
+		b.WriteByte('"')
+	case '\\':
+		b.WriteByte('\\')
+	case 'u', 'U':
+		size := 4
+		if c == 'U' {
+			size = 8
+		}
+		if p.pos+size > len(p.src) {
+			return p.errorf("invalid unicode escape")
+		}
+		r, err := strconv.ParseUint(p.src[p.pos:p.pos+size], 16, 32)
+		if err != nil || !utf8.ValidRune(rune(r)) {
+			return p.errorf("invalid unicode escape")
+		}
+		p.skip(size)
+		b.WriteRune(rune(r))
+	default:
+		return p.errorf("invalid escape sequence '\\%c'", c)
+	}
+
+	return nil
+}
+
+func (p *parser) literalString() (string, error) {
+	p.next()
+
+	start := p.pos
+	for {
+		if p.eof() || p.peek() == '\n' || p.hasPrefix("\r\n") {
+			return "", p.errorf("unterminated string")
+		}
+
+		c := p.peek()
+		if c == '\'' {
+			s := p.src[start:p.pos]
+			p.next()
+			return s, nil
+		}
+		if isControl(c) && c != '\t' {
+			return "", p.errorf("control characters are not allowed in literal strings")
+		}
+		p.next()
+	}
+}
+
+func (p *parser) multiLineLiteralString() (string, error) {
+	p.skip(3)
+	p.skipNewline()
+
+	var b strings.Builder
+	for {
+		if p.eof() {
+			return "", p.errorf("unterminated multi-line string")
+		}
+
+		c := p.peek()
+		switch {
+		case p.hasPrefix("'''"):
+			extra := 0
+			for extra < 2 && strings.HasPrefix(p.src[p.pos+3+extra:], "'") {
+				extra++
+			}
+			for i := 0; i < extra; i++ {
+				b.WriteByte('\'')
+			}
+			p.skip(3 + extra)
+			return b.String(), nil
+		case p.hasPrefix("\r\n"):
+			p.skip(2)
+			b.WriteByte('\n')
+		case c == '\n':
+			b.WriteByte(p.next())
+		case isControl(c) && c != '\t':
+			return "", p.errorf("control characters are not allowed in literal strings")
+		default:
+			b.WriteByte(p.next())
+		}
+	}
+}
+
+// -- Numbers and dates --------------------------------------------------------
+
+// scalar parses a number or a date/time.
+func (p *parser) scalar() (any, error) {
+	start := p.pos
+	for !p.eof() {
+		c := p.peek()
+		if isBare(c) || c == '+' || c == '.' || c == ':' {
+			p.next()
+			continue
+		}
+
+		// A space may separate the date and time.
+		if c == ' ' && p.pos-start == len("2006-01-02") &&
+			isDate(p.src[start:p.pos]) && p.pos+1 < len(p.src) && isDigit(p.src[p.pos+1]) {
+			p.next()
+			continue
+		}
+		break
+	}
+
+	tok := p.src[start:p.pos]
+	if len(tok) == 0 {
+		return nil, p.errorf("expected a value")
+	}
+
+	if v, ok := parseDateTime(tok); ok {
+		return v, nil
+	}
+	if v, ok, err := parseInteger(tok); ok {
+		if err != nil {
+			return nil, fmt.Errorf("%w: line %d, column %d: the integer '%s' is out of range",
+				ErrSyntax, p.line, p.col-len(tok), tok)
+		}
+		return v, nil
+	}
+	if v, ok := parseFloat(tok); ok {
+		return v, nil
+	}
+
+	return nil, fmt.Errorf("%w: line %d, column %d: invalid value '%s'",
+		ErrSyntax, p.line, p.col-len(tok), tok)
+}
+
+func isDigit(c byte) bool {
+	return '0' <= c && c <= '9'
+}
+
+func isDate(s string) bool {
+	_, err := time.Parse("2006-01-02", s)
+	return err == nil
+}
+
+// The layouts of the date and time values, in the order they are tried, and
+// the location of the values.
+var dateTimeLayouts = []struct {
+	layout string
+	loc    *time.Location
+}{
+	{layout: "2006-01-02T15:04:05.999999999Z07:00", loc: time.UTC},
+	{layout: "2006-01-02T15:04Z07:00", loc: time.UTC},
+	{layout: "2006-01-02T15:04:05.999999999", loc: LocalDatetime},
+	{layout: "2006-01-02T15:04", loc: LocalDatetime},
+	{layout: "2006-01-02", loc: LocalDate},
+	{layout: "15:04:05.999999999", loc: LocalTime},
+	{layout: "15:04", loc: LocalTime},
+}
+
+// parseDateTime parses the offset date-time, local date-time, local date and
+// local time values.  The local values use the LocalDatetime, LocalDate and
+// LocalTime locations.
+func parseDateTime(s string) (time.Time, bool) {
+	if len(s) < len("15:04") || !isDigit(s[0]) {
+		return time.Time{}, false
+	}
+
+	// Normalize the optional forms to the ones time.Parse expects.
+	s = strings.Replace(s, " ", "T", 1)
+	s = strings.Replace(s, "t", "T", 1)
+	if strings.HasSuffix(s, "z") {
+		s = s[:len(s)-1] + "Z"
+	}
+
+	for _, dt := range dateTimeLayouts {
+		if t, err := time.ParseInLocation(dt.layout, s, dt.loc); err == nil {
+			return t, true
+		}
+	}
+
+	return time.Time{}, false
+}
+
+// parseInteger parses decimal, hexadecimal, octal and binary integers.  If the
+// value is an integer that doesn't fit in an int64 (or int) an error is
+// returned.
+func parseInteger(s string) (int, bool, error) {
+	digits := strings.TrimLeft(s, "+-")
+	if len(digits) == 0 || len(s)-len(digits) > 1 {
+		return 0, false, nil
+	}
+
+	prefixed := len(digits) > 1 && digits[0] == '0'
+	if prefixed {
+		switch digits[1] {
+		case 'x', 'o', 'b':
+			// Signs are not allowed with a prefix.
+			if len(digits) != len(s) {
+				return 0, false, nil
+			}
+		default:
+			// Leading zeros are not allowed.
+			return 0, false, nil
+		}
+	}
+
+	if !validUnderscores(digits) {
+		return 0, false, nil
+	}
+
+	i, err := strconv.ParseInt(s, 0, 64)
+	if err != nil {
+		if errors.Is(err, strconv.ErrRange) {
+			return 0, true, err
+		}
+		return 0, false, nil
+	}
+	if int64(int(i)) != i {
+		return 0, true, strconv.ErrRange
+	}
+
+	return int(i), true, nil
+}
+
+// parseFloat parses the floating point values including inf and nan.
+func parseFloat(s string) (float64, bool) {
+	switch s {
+	case "inf", "+inf":
+		return math.Inf(1), true
+	case "-inf":
+		return math.Inf(-1), true
+	case "nan", "+nan", "-nan":
+		return math.NaN(), true
+	}
+
+	digits := strings.TrimLeft(s, "+-")
+	if len(digits) == 0 || len(s)-len(digits) > 1 || !validUnderscores(digits) {
+		return 0, false
+	}
+
+	// A float must have a fractional part, an exponent or both.
+	mantissa, _, hasExp := strings.Cut(strings.ToLower(digits), "e")
+	whole, frac, hasDot := strings.Cut(mantissa, ".")
+	if (!hasDot && !hasExp) || len(whole) == 0 || (hasDot && len(frac) == 0) ||
+		(len(whole) > 1 && whole[0] == '0') {
+		return 0, false
+	}
+
+	for _, c := range []byte(strings.ReplaceAll(digits, "_", "")) {
+		if !isDigit(c) && c != '.' && c != 'e' && c != 'E' && c != '+' && c != '-' {
+			return 0, false
+		}
+	}
+
+	f, err := strconv.ParseFloat(strings.ReplaceAll(s, "_", ""), 64)
+	if err != nil {
+		return 0, false
+	}
+	return f, true
+}
+
+// validUnderscores returns if each underscore is between two digits.
+func validUnderscores(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] != '_' {
+			continue
+		}
+		if i == 0 || i == len(s)-1 || !isHexDigit(s[i-1]) || !isHexDigit(s[i+1]) {
+			return false
+		}
+	}
+	return true
+}
+
+func isHexDigit(c byte) bool {
+	return isDigit(c) || ('a' <= c && c <= 'f') || ('A' <= c && c <= 'F')
+}
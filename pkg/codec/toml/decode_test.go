@@ -0,0 +1,315 @@
+// SPDX-FileCopyrightText: 2023 Weston Schmidt <weston_schmidt@alumni.purdue.edu>
+// SPDX-License-Identifier: Apache-2.0
+
+package toml
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/goschtalt/goschtalt/pkg/meta"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDecodeValues(t *testing.T) {
+	tests := []struct {
+		description string
+		in          string
+		want        any
+		expectErr   error
+	}{
+		{
+			description: "An empty document.",
+			in:          "",
+			want:        map[string]any{},
+		}, {
+			description: "Comments and blank lines.",
+			in:          "# comment\n\n\r\na = 1 # trailing\n",
+			want:        map[string]any{"a": 1},
+		}, {
+			description: "A byte order mark.",
+			in:          "\uFEFFa = 1",
+			want:        map[string]any{"a": 1},
+		}, {
+			description: "Basic strings and escapes.",
+			in:          `a = "tab\t quote\" slash\\ \u00e9 \U0001F600"`,
+			want:        map[string]any{"a": "tab\t quote\" slash\\ é 😀"},
+		}, {
+			description: "Literal strings.",
+			in:          `a = 'C:\path\'`,
+			want:        map[string]any{"a": `C:\path\`},
+		}, {
+			description: "Multi-line basic strings.",
+			in:          "a = \"\"\"\nline one\nline \\\n    two\"\"\"",
+			want:        map[string]any{"a": "line one\nline two"},
+		}, {
+			description: "Multi-line basic strings ending in quotes.",
+			in:          `a = """"quoted"""""`,
+			want:        map[string]any{"a": `"quoted""`},
+		}, {
+			description: "Multi-line literal strings.",
+			in:          "a = '''\nraw \\n\n'''",
+			want:        map[string]any{"a": "raw \\n\n"},
+		}, {
+			description: "Integers.",
+			in:          "a = +99\nb = -17\nc = 1_000\nd = 0xDEAD_beef\ne = 0o755\nf = 0b1101\ng = 0",
+			want: map[string]any{
+				"a": 99, "b": -17, "c": 1000, "d": 0xdeadbeef,
+				"e": 0o755, "f": 13, "g": 0,
+			},
+		}, {
+			description: "Floats.",
+			in:          "a = 1.5\nb = -0.01\nc = 5e+22\nd = 6.626e-34\ne = 9_224.5\nf = -inf\ng = +inf",
+			want: map[string]any{
+				"a": 1.5, "b": -0.01, "c": 5e+22, "d": 6.626e-34,
+				"e": 9224.5, "f": math.Inf(-1), "g": math.Inf(1),
+			},
+		}, {
+			description: "Booleans.",
+			in:          "a = true\nb = false",
+			want:        map[string]any{"a": true, "b": false},
+		}, {
+			description: "Date and time values.",
+			in: "a = 1979-05-27T07:32:00Z\n" +
+				"b = 1979-05-27 07:32:00\n" +
+				"c = 1979-05-27\n" +
+				"d = 07:32:00.5",
+			want: map[string]any{
+				"a": time.Date(1979, 5, 27, 7, 32, 0, 0, time.UTC),
+				"b": time.Date(1979, 5, 27, 7, 32, 0, 0, LocalDatetime),
+				"c": time.Date(1979, 5, 27, 0, 0, 0, 0, LocalDate),
+				"d": time.Date(0, 1, 1, 7, 32, 0, 500000000, LocalTime),
+			},
+		}, {
+			description: "Arrays.",
+			in:          "a = [ 1, 2, ]\nb = [\n  'x', # comment\n  [true],\n]\nc = []",
+			want: map[string]any{
+				"a": []any{1, 2},
+				"b": []any{"x", []any{true}},
+				"c": []any{},
+			},
+		}, {
+			description: "Dotted and quoted keys.",
+			in:          "a.b = 1\na.\"c d\" = 2\n'e'.f = 3\n1.2 = 4",
+			want: map[string]any{
+				"a": map[string]any{"b": 1, "c d": 2},
+				"e": map[string]any{"f": 3},
+				"1": map[string]any{"2": 4},
+			},
+		}, {
+			description: "Tables.",
+			in:          "a = 1\n[b]\nc = 2\n[b.d]\ne = 3\n[f.g]\n[f]\nh = 4",
+			want: map[string]any{
+				"a": 1,
+				"b": map[string]any{"c": 2, "d": map[string]any{"e": 3}},
+				"f": map[string]any{"g": map[string]any{}, "h": 4},
+			},
+		}, {
+			description: "Arrays of tables.",
+			in:          "[[a]]\nb = 1\n[a.c]\nd = 2\n[[a]]\nb = 3\n[[a.e]]\nf = 4",
+			want: map[string]any{
+				"a": []any{
+					map[string]any{"b": 1, "c": map[string]any{"d": 2}},
+					map[string]any{"b": 3, "e": []any{map[string]any{"f": 4}}},
+				},
+			},
+		}, {
+			description: "Inline tables.",
+			in:          "a = { b = 1, c.d = 'x' }\ne = [{}, { f = [1] }]",
+			want: map[string]any{
+				"a": map[string]any{"b": 1, "c": map[string]any{"d": "x"}},
+				"e": []any{map[string]any{}, map[string]any{"f": []any{1}}},
+			},
+		}, {
+			description: "A duplicate key.",
+			in:          "a = 1\na = 2",
+			expectErr:   ErrSyntax,
+		}, {
+			description: "A table defined twice.",
+			in:          "[a]\n[a]",
+			expectErr:   ErrSyntax,
+		}, {
+			description: "A table that redefines a value.",
+			in:          "a = 1\n[a]",
+			expectErr:   ErrSyntax,
+		}, {
+			description: "A dotted key that extends a defined table.",
+			in:          "[a.b]\nc = 1\n[a]\nb.d = 2",
+			expectErr:   ErrSyntax,
+		}, {
+			description: "A header that extends a dotted table.",
+			in:          "a.b = 1\n[a]",
+			expectErr:   ErrSyntax,
+		}, {
+			description: "An inline table may not be extended.",
+			in:          "a = { b = 1 }\n[a.c]",
+			expectErr:   ErrSyntax,
+		}, {
+			description: "An array of tables after a static array.",
+			in:          "a = [1]\n[[a]]",
+			expectErr:   ErrSyntax,
+		}, {
+			description: "A missing value.",
+			in:          "a =",
+			expectErr:   ErrSyntax,
+		}, {
+			description: "Two values on a line.",
+			in:          "a = 1 b = 2",
+			expectErr:   ErrSyntax,
+		}, {
+			description: "An unterminated string.",
+			in:          `a = "abc`,
+			expectErr:   ErrSyntax,
+		}, {
+			description: "A newline in a basic string.",
+			in:          "a = \"abc\n\"",
+			expectErr:   ErrSyntax,
+		}, {
+			description: "An invalid escape.",
+			in:          `a = "\q"`,
+			expectErr:   ErrSyntax,
+		}, {
+			description: "A leading zero.",
+			in:          "a = 01",
+			expectErr:   ErrSyntax,
+		}, {
+			description: "An integer that is too large.",
+			in:          "a = 9223372036854775808",
+			expectErr:   ErrSyntax,
+		}, {
+			description: "An integer that is too small.",
+			in:          "a = -9_223_372_036_854_775_809",
+			expectErr:   ErrSyntax,
+		}, {
+			description: "A hexadecimal integer that is too large.",
+			in:          "a = 0x1_0000_0000_0000_0000",
+			expectErr:   ErrSyntax,
+		}, {
+			description: "A misplaced underscore.",
+			in:          "a = 1__0",
+			expectErr:   ErrSyntax,
+		}, {
+			description: "An invalid date.",
+			in:          "a = 1979-13-27",
+			expectErr:   ErrSyntax,
+		}, {
+			description: "An unterminated header.",
+			in:          "[a",
+			expectErr:   ErrSyntax,
+		}, {
+			description: "A trailing comma in an inline table.",
+			in:          "a = { b = 1, }",
+			expectErr:   ErrSyntax,
+		}, {
+			description: "A newline in an inline table.",
+			in:          "a = { b = 1,\n c = 2 }",
+			expectErr:   ErrSyntax,
+		}, {
+			description: "Invalid UTF-8.",
+			in:          "a = \"\xff\"",
+			expectErr:   ErrSyntax,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			assert := assert.New(t)
+
+			got, err := decode("file.toml", []byte(tc.in))
+
+			if tc.expectErr != nil {
+				assert.ErrorIs(err, tc.expectErr)
+				return
+			}
+
+			assert.NoError(err)
+			assert.Equal(tc.want, raw(got))
+		})
+	}
+}
+
+func TestDecodeOrigins(t *testing.T) {
+	assert := assert.New(t)
+
+	in := "" +
+		"name = 'example'\n" +
+		"\n" +
+		"[server]\n" +
+		"  port = 80\n" +
+		"  tls = { cert = 'a.pem' }\n" +
+		"\n" +
+		"[[users]]\n" +
+		"name = 'bob'\n" +
+		"roles = [\n" +
+		"  'admin',\n" +
+		"]\n"
+
+	got, err := decode("file.toml", []byte(in))
+	assert.NoError(err)
+
+	origin := func(line, col int) []meta.Origin {
+		return []meta.Origin{{File: "file.toml", Line: line, Col: col}}
+	}
+
+	assert.Equal(origin(1, 8), got.Map["name"].Origins)
+	assert.Equal(origin(3, 1), got.Map["server"].Origins)
+	assert.Equal(origin(4, 10), got.Map["server"].Map["port"].Origins)
+	assert.Equal(origin(5, 9), got.Map["server"].Map["tls"].Origins)
+	assert.Equal(origin(5, 18), got.Map["server"].Map["tls"].Map["cert"].Origins)
+	assert.Equal(origin(7, 1), got.Map["users"].Array[0].Origins)
+	assert.Equal(origin(8, 8), got.Map["users"].Array[0].Map["name"].Origins)
+	assert.Equal(origin(9, 9), got.Map["users"].Array[0].Map["roles"].Origins)
+	assert.Equal(origin(10, 3), got.Map["users"].Array[0].Map["roles"].Array[0].Origins)
+}
+
+func TestDecodeErrorPosition(t *testing.T) {
+	assert := assert.New(t)
+
+	_, err := decode("file.toml", []byte("a = 1\n\n[b]\nc = ?\n"))
+	assert.ErrorIs(err, ErrSyntax)
+	assert.ErrorContains(err, "line 4, column 5")
+}
+
+func TestDecodeOffset(t *testing.T) {
+	assert := assert.New(t)
+
+	got, err := decode("file.toml", []byte("a = 1979-05-27T00:32:00.999999-07:00"))
+	assert.NoError(err)
+
+	when, ok := got.Map["a"].Value.(time.Time)
+	assert.True(ok)
+	assert.True(time.Date(1979, 5, 27, 7, 32, 0, 999999000, time.UTC).Equal(when))
+
+	_, offset := when.Zone()
+	assert.Equal(-7*60*60, offset)
+}
+
+func TestDecodeNaN(t *testing.T) {
+	assert := assert.New(t)
+
+	got, err := decode("file.toml", []byte("a = nan\nb = -nan"))
+	assert.NoError(err)
+	assert.True(math.IsNaN(got.Map["a"].Value.(float64)))
+	assert.True(math.IsNaN(got.Map["b"].Value.(float64)))
+}
+
+// raw is the same as meta.Object.ToRaw() except empty maps and arrays are
+// kept so they can be compared.
+func raw(obj meta.Object) any {
+	switch kind(obj) {
+	case meta.Map:
+		m := make(map[string]any, len(obj.Map))
+		for key, val := range obj.Map {
+			m[key] = raw(val)
+		}
+		return m
+	case meta.Array:
+		a := make([]any, 0, len(obj.Array))
+		for _, val := range obj.Array {
+			a = append(a, raw(val))
+		}
+		return a
+	}
+	return obj.Value
+}
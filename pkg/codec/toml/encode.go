@@ -0,0 +1,364 @@
+// SPDX-FileCopyrightText: 2023 Weston Schmidt <weston_schmidt@alumni.purdue.edu>
+// SPDX-License-Identifier: Apache-2.0
+
+package toml
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/goschtalt/goschtalt/pkg/meta"
+)
+
+func validate(obj meta.Object, path []string) error {
+	switch kind(obj) {
+	case meta.Array:
+		for i, val := range obj.Array {
+			if err := validate(val, append(path[:len(path):len(path)], strconv.Itoa(i))); err != nil {
+				return err
+			}
+		}
+	case meta.Map:
+		for _, key := range sortedKeys(obj) {
+			if err := validate(obj.Map[key], append(path[:len(path):len(path)], key)); err != nil {
+				return err
+			}
+		}
+	default:
+		_, err := value(obj.Value, path)
+		return err
+	}
+
+	return nil
+}
+
+// writer writes the output, keeping the first error.
+type writer struct {
+	out         io.Writer
+	err         error
+	wrote       bool
+	withOrigins bool
+}
+
+func (w *writer) printf(format string, args ...any) {
+	if w.err != nil {
+		return
+	}
+
+	_, w.err = fmt.Fprintf(w.out, format, args...)
+	w.wrote = true
+}
+
+func encode(obj meta.Object, withOrigins bool) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := encodeTo(&buf, obj, withOrigins); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+func encodeTo(out io.Writer, obj meta.Object, withOrigins bool) error {
+	if kind(obj) != meta.Map {
+		return fmt.Errorf("%w: the document must be a map, not a %s",
+			ErrUnrepresentable, kindName(obj))
+	}
+
+	w := writer{out: out, withOrigins: withOrigins}
+	if err := w.table(obj, nil); err != nil {
+		return err
+	}
+
+	return w.err
+}
+
+// table writes the values of the table followed by the sub-tables and the
+// arrays of tables.
+func (w *writer) table(obj meta.Object, path []string) error {
+	var values, tables, arrays []string
+	for _, key := range sortedKeys(obj) {
+		switch val := obj.Map[key]; {
+		case kind(val) == meta.Map:
+			tables = append(tables, key)
+		case isArrayOfTables(val):
+			arrays = append(arrays, key)
+		default:
+			values = append(values, key)
+		}
+	}
+
+	for _, key := range values {
+		val := obj.Map[key]
+		full := append(path[:len(path):len(path)], key)
+
+		expr, err := expression(val, full)
+		if err != nil {
+			return err
+		}
+
+		w.origins(val)
+		w.printf("%s = %s\n", quoteKey(key), expr)
+	}
+
+	for _, key := range tables {
+		val := obj.Map[key]
+		full := append(path[:len(path):len(path)], key)
+
+		// A table with only sub-tables doesn't need a header.
+		if needsHeader(val) {
+			w.separate()
+			w.origins(val)
+			w.printf("[%s]\n", headerName(full))
+		}
+
+		if err := w.table(val, full); err != nil {
+			return err
+		}
+	}
+
+	for _, key := range arrays {
+		full := append(path[:len(path):len(path)], key)
+
+		for _, val := range obj.Map[key].Array {
+			w.separate()
+			w.origins(val)
+			w.printf("[[%s]]\n", headerName(full))
+
+			if err := w.table(val, full); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// separate writes a blank line before a header unless it is the first line.
+func (w *writer) separate() {
+	if w.wrote {
+		w.printf("\n")
+	}
+}
+
+// origins writes the origins of the object as a comment if requested.
+func (w *writer) origins(obj meta.Object) {
+	if !w.withOrigins || len(obj.Origins) == 0 {
+		return
+	}
+
+	w.printf("# %s\n", obj.OriginString())
+}
+
+// needsHeader returns if the table has values of its own or is empty.
+func needsHeader(obj meta.Object) bool {
+	if len(obj.Map) == 0 {
+		return true
+	}
+
+	for _, val := range obj.Map {
+		if kind(val) != meta.Map && !isArrayOfTables(val) {
+			return true
+		}
+	}
+	return false
+}
+
+// isArrayOfTables returns if the object is a non-empty array of maps.
+func isArrayOfTables(obj meta.Object) bool {
+	if kind(obj) != meta.Array || len(obj.Array) == 0 {
+		return false
+	}
+
+	for _, val := range obj.Array {
+		if kind(val) != meta.Map {
+			return false
+		}
+	}
+	return true
+}
+
+func headerName(path []string) string {
+	list := make([]string, len(path))
+	for i, key := range path {
+		list[i] = quoteKey(key)
+	}
+	return strings.Join(list, ".")
+}
+
+// expression renders the object as a single line TOML value.
+func expression(obj meta.Object, path []string) (string, error) {
+	switch kind(obj) {
+	case meta.Array:
+		list := make([]string, 0, len(obj.Array))
+		for i, val := range obj.Array {
+			expr, err := expression(val, append(path[:len(path):len(path)], strconv.Itoa(i)))
+			if err != nil {
+				return "", err
+			}
+			list = append(list, expr)
+		}
+		return "[" + strings.Join(list, ", ") + "]", nil
+	case meta.Map:
+		list := make([]string, 0, len(obj.Map))
+		for _, key := range sortedKeys(obj) {
+			expr, err := expression(obj.Map[key], append(path[:len(path):len(path)], key))
+			if err != nil {
+				return "", err
+			}
+			list = append(list, quoteKey(key)+" = "+expr)
+		}
+		if len(list) == 0 {
+			return "{}", nil
+		}
+		return "{ " + strings.Join(list, ", ") + " }", nil
+	}
+
+	return value(obj.Value, path)
+}
+
+// value renders a leaf value.
+func value(v any, path []string) (string, error) {
+	switch v := v.(type) {
+	case nil:
+		return "", fmt.Errorf("%w: '%s' is null", ErrUnrepresentable, strings.Join(path, "."))
+	case bool:
+		return strconv.FormatBool(v), nil
+	case string:
+		return quote(v), nil
+	case time.Time:
+		switch v.Location() {
+		case LocalDatetime:
+			return v.Format("2006-01-02T15:04:05.999999999"), nil
+		case LocalDate:
+			return v.Format("2006-01-02"), nil
+		case LocalTime:
+			return v.Format("15:04:05.999999999"), nil
+		}
+		return v.Format(time.RFC3339Nano), nil
+	case int, int8, int16, int32, int64:
+		return strconv.FormatInt(reflect.ValueOf(v).Int(), 10), nil
+	case uint, uint8, uint16, uint32, uint64:
+		u := reflect.ValueOf(v).Uint()
+		if u > math.MaxInt64 {
+			return "", fmt.Errorf("%w: '%s' is larger than an int64", ErrUnrepresentable, strings.Join(path, "."))
+		}
+		return strconv.FormatUint(u, 10), nil
+	case float32, float64:
+		return float(reflect.ValueOf(v).Float()), nil
+	}
+
+	return "", fmt.Errorf("%w: '%s' has a value of type %T",
+		ErrUnrepresentable, strings.Join(path, "."), v)
+}
+
+// float renders the float so it is always decoded as a float.
+func float(f float64) string {
+	switch {
+	case math.IsNaN(f):
+		return "nan"
+	case math.IsInf(f, 1):
+		return "inf"
+	case math.IsInf(f, -1):
+		return "-inf"
+	}
+
+	s := strconv.FormatFloat(f, 'g', -1, 64)
+	if !strings.ContainsAny(s, ".e") {
+		s += ".0"
+	}
+	return s
+}
+
+// quote renders the string as a TOML basic string.
+func quote(s string) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	for i := 0; i < len(s); {
+		r, size := utf8.DecodeRuneInString(s[i:])
+		i += size
+
+		switch {
+		case r == '"':
+			b.WriteString(`\"`)
+		case r == '\\':
+			b.WriteString(`\\`)
+		case r == '\b':
+			b.WriteString(`\b`)
+		case r == '\t':
+			b.WriteString(`\t`)
+		case r == '\n':
+			b.WriteString(`\n`)
+		case r == '\f':
+			b.WriteString(`\f`)
+		case r == '\r':
+			b.WriteString(`\r`)
+		case r == utf8.RuneError && size == 1:
+			// Invalid UTF-8 is replaced.
+			b.WriteString(`�`)
+		case !unicode.IsPrint(r):
+			if r > 0xffff {
+				fmt.Fprintf(&b, `\U%08X`, r)
+			} else {
+				fmt.Fprintf(&b, `\u%04X`, r)
+			}
+		default:
+			b.WriteRune(r)
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
+
+// quoteKey returns the key as a bare key if possible, otherwise it is quoted.
+func quoteKey(key string) string {
+	if len(key) == 0 {
+		return `""`
+	}
+
+	for i := 0; i < len(key); i++ {
+		if !isBare(key[i]) {
+			return quote(key)
+		}
+	}
+	return key
+}
+
+func sortedKeys(obj meta.Object) []string {
+	keys := make([]string, 0, len(obj.Map))
+	for key := range obj.Map {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// kind is the same as meta.Object.Kind() except empty maps and arrays are
+// kept instead of being treated as values.
+func kind(obj meta.Object) int {
+	switch {
+	case obj.Array != nil:
+		return meta.Array
+	case obj.Map != nil:
+		return meta.Map
+	}
+	return meta.Value
+}
+
+func kindName(obj meta.Object) string {
+	switch kind(obj) {
+	case meta.Array:
+		return "array"
+	case meta.Map:
+		return "map"
+	}
+	return "value"
+}
@@ -0,0 +1,107 @@
+// SPDX-FileCopyrightText: 2023 Weston Schmidt <weston_schmidt@alumni.purdue.edu>
+// SPDX-License-Identifier: Apache-2.0
+
+// Package toml provides a TOML decoder and encoder that only depend on the
+// standard library.
+//
+// The decoder supports TOML v1.0.0, including tables, arrays of tables and
+// inline tables.  Each value includes the file, line and column it came from.
+// Integers are decoded as int, floats as float64 and the date and time values
+// as time.Time.  The local date-time, local date and local time values use the
+// [LocalDatetime], [LocalDate] and [LocalTime] locations so they are encoded
+// the same way, and a local time is on January 1st of year 0.
+//
+// The encoder renders maps as tables and arrays that only contain maps as
+// arrays of tables:
+//
+//	name = "example"
+//	ports = [80, 443]
+//
+//	[server]
+//	host = "localhost"
+//
+//	[[users]]
+//	name = "bob"
+//
+// The keys are sorted, with the values of a table before its sub-tables.
+package toml
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/goschtalt/goschtalt/pkg/decoder"
+	"github.com/goschtalt/goschtalt/pkg/encoder"
+	"github.com/goschtalt/goschtalt/pkg/meta"
+)
+
+var (
+	ErrSyntax          = errors.New("invalid toml")
+	ErrUnrepresentable = errors.New("value cannot be represented in toml")
+)
+
+// The locations of the date and time values without an offset.  They are the
+// same as UTC, but the encoder renders a time.Time in one of these locations
+// as a local date-time, local date or local time.
+var (
+	LocalDatetime = time.FixedZone("datetime-local", 0)
+	LocalDate     = time.FixedZone("date-local", 0)
+	LocalTime     = time.FixedZone("time-local", 0)
+)
+
+var (
+	_ decoder.Decoder       = (*Codec)(nil)
+	_ encoder.Encoder       = (*Codec)(nil)
+	_ encoder.Validator     = (*Codec)(nil)
+	_ encoder.WriterEncoder = (*Codec)(nil)
+)
+
+// Codec is a TOML decoder and encoder.
+type Codec struct{}
+
+// Extensions returns the supported extensions.
+func (c Codec) Extensions() []string {
+	return []string{"toml", "tml"}
+}
+
+// Decode decodes a byte array into the meta.Object tree.
+func (c Codec) Decode(ctx decoder.Context, b []byte, m *meta.Object) error {
+	obj, err := decode(ctx.Filename, b)
+	if err != nil {
+		return fmt.Errorf("%s: %w", ctx.Filename, err)
+	}
+
+	*m = obj
+	return nil
+}
+
+// Encode encodes the value provided into TOML.  The value must be a
+// map[string]any since a TOML document is a table.
+func (c Codec) Encode(v any) ([]byte, error) {
+	return encode(meta.ObjectFromRaw(v), false)
+}
+
+// EncodeExtended encodes the tree provided into TOML with the origins of each
+// value and table as a comment before it.
+func (c Codec) EncodeExtended(obj meta.Object) ([]byte, error) {
+	return encode(obj, true)
+}
+
+// EncodeTo writes the same output as Encode to the writer.
+func (c Codec) EncodeTo(w io.Writer, v any) error {
+	return encodeTo(w, meta.ObjectFromRaw(v), false)
+}
+
+// EncodeExtendedTo writes the same output as EncodeExtended to the writer.
+func (c Codec) EncodeExtendedTo(w io.Writer, obj meta.Object) error {
+	return encodeTo(w, obj, true)
+}
+
+// Validate returns an error if the tree contains values that TOML is not able
+// to represent.  TOML does not have a null value and integers must fit in an
+// int64.
+func (c Codec) Validate(obj meta.Object) error {
+	return validate(obj, nil)
+}
@@ -0,0 +1,417 @@
+// SPDX-FileCopyrightText: 2023 Weston Schmidt <weston_schmidt@alumni.purdue.edu>
+// SPDX-License-Identifier: Apache-2.0
+
+package toml
+
+import (
+	"bytes"
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/goschtalt/goschtalt/pkg/decoder"
+	"github.com/goschtalt/goschtalt/pkg/meta"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExtensions(t *testing.T) {
+	assert := assert.New(t)
+	assert.Equal([]string{"toml", "tml"}, Codec{}.Extensions())
+}
+
+func TestDecode(t *testing.T) {
+	assert := assert.New(t)
+
+	var got meta.Object
+	err := Codec{}.Decode(decoder.Context{Filename: "file.toml"}, []byte("a = 1"), &got)
+	assert.NoError(err)
+	assert.Equal(map[string]any{"a": 1}, got.ToRaw())
+	assert.Equal([]meta.Origin{{File: "file.toml", Line: 1, Col: 5}}, got.Map["a"].Origins)
+
+	err = Codec{}.Decode(decoder.Context{Filename: "file.toml"}, []byte("a = "), &got)
+	assert.ErrorIs(err, ErrSyntax)
+	assert.ErrorContains(err, "file.toml")
+}
+
+func TestEncode(t *testing.T) {
+	tests := []struct {
+		description string
+		in          any
+		want        string
+		expectErr   error
+	}{
+		{
+			description: "An empty document.",
+			in:          map[string]any{},
+		}, {
+			description: "Values.",
+			in: map[string]any{
+				"name":    "example",
+				"port":    8080,
+				"enabled": true,
+				"ratio":   0.5,
+				"whole":   2.0,
+			},
+			want: "" +
+				"enabled = true\n" +
+				"name = \"example\"\n" +
+				"port = 8080\n" +
+				"ratio = 0.5\n" +
+				"whole = 2.0\n",
+		}, {
+			description: "Maps are tables after the values.",
+			in: map[string]any{
+				"server": map[string]any{
+					"host": "localhost",
+					"tls": map[string]any{
+						"cert": "server.pem",
+					},
+				},
+				"a": map[string]any{
+					"b": map[string]any{
+						"c": 1,
+					},
+				},
+				"db":   map[string]any{},
+				"name": "example",
+			},
+			want: "" +
+				"name = \"example\"\n" +
+				"\n" +
+				"[a.b]\n" +
+				"c = 1\n" +
+				"\n" +
+				"[db]\n" +
+				"\n" +
+				"[server]\n" +
+				"host = \"localhost\"\n" +
+				"\n" +
+				"[server.tls]\n" +
+				"cert = \"server.pem\"\n",
+		}, {
+			description: "Arrays of maps are arrays of tables.",
+			in: map[string]any{
+				"users": []any{
+					map[string]any{"name": "bob", "opts": map[string]any{"x": 1}},
+					map[string]any{},
+				},
+			},
+			want: "" +
+				"[[users]]\n" +
+				"name = \"bob\"\n" +
+				"\n" +
+				"[users.opts]\n" +
+				"x = 1\n" +
+				"\n" +
+				"[[users]]\n",
+		}, {
+			description: "Other arrays are inline with inline tables.",
+			in: map[string]any{
+				"ports":  []any{80, 443},
+				"mixed":  []any{1, map[string]any{"my key": "x", "b": []any{}}, map[string]any{}},
+				"nested": []any{[]any{"a"}, []any{}},
+			},
+			want: "" +
+				"mixed = [1, { b = [], \"my key\" = \"x\" }, {}]\n" +
+				"nested = [[\"a\"], []]\n" +
+				"ports = [80, 443]\n",
+		}, {
+			description: "Keys are quoted when needed.",
+			in: map[string]any{
+				"a-b_2": "x",
+				"a.b":   "y",
+				"":      "z",
+				"t": map[string]any{
+					"é": 1,
+				},
+			},
+			want: "" +
+				"\"\" = \"z\"\n" +
+				"a-b_2 = \"x\"\n" +
+				"\"a.b\" = \"y\"\n" +
+				"\n" +
+				"[t]\n" +
+				"\"é\" = 1\n",
+		}, {
+			description: "Strings are escaped.",
+			in: map[string]any{
+				"a": "quote \" slash \\ tab \t newline \n return \r",
+				"b": "bell \a unicode é",
+			},
+			want: "" +
+				`a = "quote \" slash \\ tab \t newline \n return \r"` + "\n" +
+				`b = "bell \u0007 unicode é"` + "\n",
+		}, {
+			description: "Other numbers.",
+			in: map[string]any{
+				"a": int8(-5),
+				"b": uint32(7),
+				"c": float32(1.5),
+				"d": 1e21,
+				"e": math.Inf(1),
+				"f": math.Inf(-1),
+				"g": math.NaN(),
+			},
+			want: "" +
+				"a = -5\n" +
+				"b = 7\n" +
+				"c = 1.5\n" +
+				"d = 1e+21\n" +
+				"e = inf\n" +
+				"f = -inf\n" +
+				"g = nan\n",
+		}, {
+			description: "A time.",
+			in: map[string]any{
+				"t": time.Date(2023, 1, 2, 3, 4, 5, 6, time.UTC),
+			},
+			want: "t = 2023-01-02T03:04:05.000000006Z\n",
+		}, {
+			description: "A null value.",
+			in: map[string]any{
+				"a": []any{nil},
+			},
+			expectErr: ErrUnrepresentable,
+		}, {
+			description: "A value that is too large.",
+			in: map[string]any{
+				"a": uint64(math.MaxUint64),
+			},
+			expectErr: ErrUnrepresentable,
+		}, {
+			description: "An unsupported type.",
+			in: map[string]any{
+				"a": map[string]any{"b": struct{}{}},
+			},
+			expectErr: ErrUnrepresentable,
+		}, {
+			description: "The document is not a map.",
+			in:          []any{"a"},
+			expectErr:   ErrUnrepresentable,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			assert := assert.New(t)
+
+			got, err := Codec{}.Encode(tc.in)
+
+			if tc.expectErr != nil {
+				assert.ErrorIs(err, tc.expectErr)
+				assert.Nil(got)
+				return
+			}
+
+			assert.NoError(err)
+			assert.Equal(tc.want, string(got))
+		})
+	}
+}
+
+func TestEncodeExtended(t *testing.T) {
+	assert := assert.New(t)
+
+	in := meta.Object{
+		Origins: []meta.Origin{{File: "file.toml", Line: 1, Col: 1}},
+		Map: map[string]meta.Object{
+			"name": {
+				Origins: []meta.Origin{{File: "file.toml", Line: 1, Col: 8}},
+				Value:   "example",
+			},
+			"server": {
+				Origins: []meta.Origin{{File: "file.toml", Line: 3, Col: 1}},
+				Map: map[string]meta.Object{
+					"port": {
+						Origins: []meta.Origin{
+							{File: "file.toml", Line: 4, Col: 8},
+							{File: "other.toml", Line: 1, Col: 1},
+						},
+						Value: 80,
+					},
+					"host": {
+						Value: "localhost",
+					},
+				},
+			},
+		},
+	}
+
+	got, err := Codec{}.EncodeExtended(in)
+	assert.NoError(err)
+	assert.Equal(""+
+		"# file.toml:1[8]\n"+
+		"name = \"example\"\n"+
+		"\n"+
+		"# file.toml:3[1]\n"+
+		"[server]\n"+
+		"host = \"localhost\"\n"+
+		"# file.toml:4[8], other.toml:1[1]\n"+
+		"port = 80\n", string(got))
+}
+
+// failWriter fails every write.
+type failWriter struct{}
+
+func (failWriter) Write([]byte) (int, error) {
+	return 0, errWrite
+}
+
+var errWrite = errors.New("write error")
+
+func TestEncodeTo(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	in := map[string]any{
+		"name":   "example",
+		"server": map[string]any{"port": 80},
+	}
+	obj := meta.ObjectFromRaw(in)
+
+	want, err := Codec{}.Encode(in)
+	require.NoError(err)
+
+	var buf bytes.Buffer
+	require.NoError(Codec{}.EncodeTo(&buf, in))
+	assert.Equal(string(want), buf.String())
+
+	want, err = Codec{}.EncodeExtended(obj)
+	require.NoError(err)
+
+	buf.Reset()
+	require.NoError(Codec{}.EncodeExtendedTo(&buf, obj))
+	assert.Equal(string(want), buf.String())
+
+	assert.ErrorIs(Codec{}.EncodeTo(failWriter{}, in), errWrite)
+	assert.ErrorIs(Codec{}.EncodeExtendedTo(failWriter{}, obj), errWrite)
+	assert.ErrorIs(Codec{}.EncodeTo(&buf, []any{"a"}), ErrUnrepresentable)
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		description string
+		in          meta.Object
+		expectErr   error
+	}{
+		{
+			description: "Everything is representable.",
+			in: meta.ObjectFromRaw(map[string]any{
+				"a": []any{1, "b", 2.5, true, time.Now(), math.NaN()},
+			}),
+		}, {
+			description: "A null.",
+			in: meta.ObjectFromRaw(map[string]any{
+				"a": map[string]any{"b": nil},
+			}),
+			expectErr: ErrUnrepresentable,
+		}, {
+			description: "A large integer.",
+			in: meta.ObjectFromRaw(map[string]any{
+				"a": []any{uint64(math.MaxUint64)},
+			}),
+			expectErr: ErrUnrepresentable,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			assert := assert.New(t)
+
+			err := Codec{}.Validate(tc.in)
+			assert.ErrorIs(err, tc.expectErr)
+		})
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	tests := []struct {
+		description string
+		in          string
+	}{
+		{
+			description: "A document.",
+			in: "" +
+				"name = \"example\"\n" +
+				"ports = [80, 443]\n" +
+				"when = 1979-05-27T07:32:00Z\n" +
+				"\n" +
+				"[server]\n" +
+				"host = \"localhost\"\n" +
+				"tls = { cert = \"a.pem\", enabled = true }\n" +
+				"\n" +
+				"[server.limits]\n" +
+				"rate = 1.5\n" +
+				"\n" +
+				"[[users]]\n" +
+				"name = \"bob\"\n" +
+				"\n" +
+				"[[users]]\n" +
+				"name = \"alice\"\n",
+		}, {
+			description: "Local dates and times.",
+			in: "" +
+				"date = 1979-05-27\n" +
+				"datetime = 1979-05-27T07:32:00.5\n" +
+				"list = [07:32:00, 1979-05-27]\n" +
+				"offset = 1979-05-27T07:32:00-07:00\n" +
+				"time = 07:32:00\n",
+		}, {
+			description: "Empty arrays and tables.",
+			in: "" +
+				"list = []\n" +
+				"nested = [[], {}]\n" +
+				"table = {}\n" +
+				"\n" +
+				"[header]\n",
+		}, {
+			description: "The largest integers.",
+			in: "" +
+				"max = 9223372036854775807\n" +
+				"min = -9223372036854775808\n",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			first, err := decode("file.toml", []byte(tc.in))
+			require.NoError(err)
+
+			out, err := Codec{}.EncodeExtended(first)
+			require.NoError(err)
+
+			second, err := decode("out.toml", out)
+			require.NoError(err)
+			assert.Equal(raw(first), raw(second))
+
+			out, err = Codec{}.Encode(raw(first))
+			require.NoError(err)
+
+			third, err := decode("out.toml", out)
+			require.NoError(err)
+			assert.Equal(raw(first), raw(third))
+		})
+	}
+}
+
+func TestEncodeLocal(t *testing.T) {
+	assert := assert.New(t)
+
+	got, err := Codec{}.Encode(map[string]any{
+		"a": time.Date(1979, 5, 27, 7, 32, 0, 0, LocalDatetime),
+		"b": time.Date(1979, 5, 27, 0, 0, 0, 0, LocalDate),
+		"c": time.Date(0, 1, 1, 7, 32, 0, 500000000, LocalTime),
+		"d": time.Date(1979, 5, 27, 7, 32, 0, 0, time.UTC),
+	})
+	assert.NoError(err)
+	assert.Equal(""+
+		"a = 1979-05-27T07:32:00\n"+
+		"b = 1979-05-27\n"+
+		"c = 07:32:00.5\n"+
+		"d = 1979-05-27T07:32:00Z\n", string(got))
+}
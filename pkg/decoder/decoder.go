@@ -3,13 +3,26 @@
 
 package decoder
 
-import "github.com/goschtalt/goschtalt/pkg/meta"
+import (
+	"errors"
+
+	"github.com/goschtalt/goschtalt/pkg/meta"
+)
+
+// ErrDuplicateKey is returned by a decoder when RejectDuplicateKeys is set and
+// a key is repeated within the same map.
+var ErrDuplicateKey = errors.New("duplicate key")
 
 // Context is a way to pass additional information that the decoder may need
 // access to in a more future proof way.
 type Context struct {
 	Filename  string // The filename (not full path) of the file being decoded.
 	Delimiter string // The key delimiter string to use if needed.
+
+	// RejectDuplicateKeys requests that the decoder returns an error wrapping
+	// ErrDuplicateKey if a key is repeated within the same map in the document.
+	// Decoders that are not able to detect duplicates may ignore this.
+	RejectDuplicateKeys bool
 }
 
 // Decoder provides the decoder interface for goschtalt to use.
@@ -3,7 +3,11 @@
 
 package encoder
 
-import "github.com/goschtalt/goschtalt/pkg/meta"
+import (
+	"io"
+
+	"github.com/goschtalt/goschtalt/pkg/meta"
+)
 
 // Encoder provides the encoder interface for goschtalt to use.
 type Encoder interface {
@@ -19,3 +23,26 @@ type Encoder interface {
 	// Extensions provides the list of extensions this decoder is able to decode.
 	Extensions() []string
 }
+
+// Validator is an optional interface an Encoder can implement to report the
+// values it is unable to represent without losing information.  It is used
+// when goschtalt is asked to be strict about the output format.
+type Validator interface {
+	// Validate returns an error describing the first value in the tree that
+	// the encoder cannot represent without losing information.
+	Validate(m meta.Object) error
+}
+
+// WriterEncoder is an optional interface an Encoder can implement to write the
+// document directly to an io.Writer instead of building it in memory.  It is
+// used when goschtalt is asked to write the output to an io.Writer.
+type WriterEncoder interface {
+	// EncodeTo writes the same output as Encode to the writer.  If an error
+	// is returned part of the document may have been written.
+	EncodeTo(w io.Writer, v any) error
+
+	// EncodeExtendedTo writes the same output as EncodeExtended to the
+	// writer.  If an error is returned part of the document may have been
+	// written.
+	EncodeExtendedTo(w io.Writer, m meta.Object) error
+}
@@ -0,0 +1,108 @@
+// SPDX-FileCopyrightText: 2023 Weston Schmidt <weston_schmidt@alumni.purdue.edu>
+// SPDX-License-Identifier: Apache-2.0
+
+package meta
+
+import (
+	"reflect"
+	"sort"
+	"strconv"
+)
+
+const (
+	Added = iota + 1
+	Removed
+	Modified
+)
+
+// Change describes a difference between two Object trees.
+type Change struct {
+	Kind int      // Added, Removed or Modified.
+	Path []string // The path of keys (or array indexes) to the Object.
+	Old  Object   // The Object before the change (empty if Added).
+	New  Object   // The Object after the change (empty if Removed).
+}
+
+// Diff returns the changes needed to turn obj into other.  Maps are compared
+// key by key and arrays are compared index by index, so only the deepest
+// Objects that differ are reported.  When an Object changes from one kind to
+// another (for example from a Value to a Map) the Object is reported as
+// Modified.  Only the values are compared; the origins are ignored.
+//
+// An empty root Object (Object{}) is treated as an empty map, so the changes
+// from an empty tree to a compiled tree are all Added.
+//
+// The changes are ordered by path with map keys in sorted order.
+func (obj Object) Diff(other Object) []Change {
+	return obj.asRoot().diff(nil, other.asRoot(), nil)
+}
+
+// asRoot returns an empty map in place of an empty Object.
+func (obj Object) asRoot() Object {
+	if obj.Array == nil && obj.Map == nil && obj.Value == nil {
+		obj.Map = map[string]Object{}
+	}
+	return obj
+}
+
+func (obj Object) diff(path []string, other Object, changes []Change) []Change {
+	kind := obj.shape()
+	if kind != other.shape() {
+		return append(changes, change(Modified, path, obj, other))
+	}
+
+	switch kind {
+	case Array:
+		for i := 0; i < max(len(obj.Array), len(other.Array)); i++ {
+			next := append(path[:len(path):len(path)], strconv.Itoa(i))
+			switch {
+			case i >= len(obj.Array):
+				changes = append(changes, change(Added, next, Object{}, other.Array[i]))
+			case i >= len(other.Array):
+				changes = append(changes, change(Removed, next, obj.Array[i], Object{}))
+			default:
+				changes = obj.Array[i].diff(next, other.Array[i], changes)
+			}
+		}
+	case Map:
+		keys := make([]string, 0, len(obj.Map)+len(other.Map))
+		for key := range obj.Map {
+			keys = append(keys, key)
+		}
+		for key := range other.Map {
+			if _, found := obj.Map[key]; !found {
+				keys = append(keys, key)
+			}
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			next := append(path[:len(path):len(path)], key)
+			before, inObj := obj.Map[key]
+			after, inOther := other.Map[key]
+			switch {
+			case !inObj:
+				changes = append(changes, change(Added, next, Object{}, after))
+			case !inOther:
+				changes = append(changes, change(Removed, next, before, Object{}))
+			default:
+				changes = before.diff(next, after, changes)
+			}
+		}
+	default:
+		if !reflect.DeepEqual(obj.Value, other.Value) {
+			changes = append(changes, change(Modified, path, obj, other))
+		}
+	}
+
+	return changes
+}
+
+func change(kind int, path []string, old, new Object) Change {
+	return Change{
+		Kind: kind,
+		Path: append([]string{}, path...),
+		Old:  old,
+		New:  new,
+	}
+}
@@ -0,0 +1,119 @@
+// SPDX-FileCopyrightText: 2023 Weston Schmidt <weston_schmidt@alumni.purdue.edu>
+// SPDX-License-Identifier: Apache-2.0
+
+package meta
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiff(t *testing.T) {
+	type change struct {
+		kind int
+		path string
+		old  any
+		new  any
+	}
+
+	tests := []struct {
+		description string
+		obj         string
+		other       string
+		expected    []change
+	}{
+		{
+			description: "No changes.",
+			obj:         `{"a":{"b":[1,"two",{"c":true}]}, "d":null}`,
+			other:       `{"a":{"b":[1,"two",{"c":true}]}, "d":null}`,
+		}, {
+			description: "Added, removed and modified keys.",
+			obj:         `{"db":{"host":"a", "port":5432}, "name":"x"}`,
+			other:       `{"db":{"host":"b", "user":"me"}, "name":"x", "new":1}`,
+			expected: []change{
+				{kind: Modified, path: "db.host", old: "a", new: "b"},
+				{kind: Removed, path: "db.port", old: 5432.0},
+				{kind: Added, path: "db.user", new: "me"},
+				{kind: Added, path: "new", new: 1.0},
+			},
+		}, {
+			description: "Arrays are compared by index.",
+			obj:         `{"a":[1,2,3], "b":[1]}`,
+			other:       `{"a":[1,5], "b":[1,{"c":2}]}`,
+			expected: []change{
+				{kind: Modified, path: "a.1", old: 2.0, new: 5.0},
+				{kind: Removed, path: "a.2", old: 3.0},
+				{kind: Added, path: "b.1", new: map[string]any{"c": 2.0}},
+			},
+		}, {
+			description: "A change of kind.",
+			obj:         `{"a":"value", "b":{"c":1}, "d":[1]}`,
+			other:       `{"a":{"c":1}, "b":[1], "d":"value"}`,
+			expected: []change{
+				{kind: Modified, path: "a", old: "value", new: map[string]any{"c": 1.0}},
+				{kind: Modified, path: "b", old: map[string]any{"c": 1.0}, new: []any{1.0}},
+				{kind: Modified, path: "d", old: []any{1.0}, new: "value"},
+			},
+		}, {
+			description: "The root changes.",
+			obj:         `"a"`,
+			other:       `"b"`,
+			expected: []change{
+				{kind: Modified, old: "a", new: "b"},
+			},
+		}, {
+			description: "From an empty tree.",
+			obj:         `{}`,
+			other:       `{"b":1, "a":{"c":2}}`,
+			expected: []change{
+				{kind: Added, path: "a", new: map[string]any{"c": 2.0}},
+				{kind: Added, path: "b", new: 1.0},
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			assert := assert.New(t)
+
+			obj := decode(tc.obj)
+			other := decode(tc.other)
+
+			var got []change
+			for _, c := range obj.Diff(other) {
+				got = append(got, change{
+					kind: c.Kind,
+					path: strings.Join(c.Path, "."),
+					old:  c.Old.ToRaw(),
+					new:  c.New.ToRaw(),
+				})
+			}
+
+			assert.Equal(tc.expected, got)
+		})
+	}
+}
+
+func TestDiffIgnoresOrigins(t *testing.T) {
+	assert := assert.New(t)
+
+	obj := ObjectFromRawWithOrigin(map[string]any{"a": 1}, []Origin{{File: "one"}})
+	other := ObjectFromRawWithOrigin(map[string]any{"a": 1}, []Origin{{File: "two"}})
+
+	assert.Empty(obj.Diff(other))
+}
+
+func TestDiffPathsAreIndependent(t *testing.T) {
+	assert := assert.New(t)
+
+	obj := decode(`{"a":{"b":1, "c":2, "d":3, "e":4}}`)
+	other := decode(`{"a":{"b":5, "c":6, "d":7, "e":8}}`)
+
+	changes := obj.Diff(other)
+	assert.Len(changes, 4)
+	for i, key := range []string{"b", "c", "d", "e"} {
+		assert.Equal([]string{"a", key}, changes[i].Path)
+	}
+}
@@ -70,3 +70,28 @@ func getCmd(s string) (command, error) {
 
 	return cmd, nil
 }
+
+// SplitKey splits the key into the name and the commands in the optional
+// ((command)) suffix.  An error wrapping ErrInvalidCommand is returned if the
+// suffix is malformed or contains a command that isn't known.  Whether the
+// command is allowed for an Object is checked when the Object is merged.
+func SplitKey(key string) (string, []string, error) {
+	cmd, err := getCmd(key)
+	if err != nil {
+		return "", nil, err
+	}
+
+	var cmds []string
+	switch cmd.cmd {
+	case "":
+	case cmdReplace, cmdKeep, cmdFail, cmdAppend, cmdPrepend, cmdSplice, cmdClear:
+		cmds = append(cmds, cmd.cmd)
+	default:
+		return "", nil, ErrInvalidCommand
+	}
+	if cmd.secret {
+		cmds = append(cmds, cmdSecret)
+	}
+
+	return cmd.final, cmds, nil
+}
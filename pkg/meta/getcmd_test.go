@@ -170,6 +170,61 @@ func TestMap_getCmd(t *testing.T) {
 	}
 }
 
+func TestSplitKey(t *testing.T) {
+	tests := []struct {
+		description string
+		input       string
+		name        string
+		cmds        []string
+		expectedErr error
+	}{
+		{
+			description: "No commands.",
+			input:       "foo",
+			name:        "foo",
+		}, {
+			description: "A command.",
+			input:       "foo (( append ))",
+			name:        "foo",
+			cmds:        []string{"append"},
+		}, {
+			description: "A command and secret.",
+			input:       "foo((secret, splice))",
+			name:        "foo",
+			cmds:        []string{"splice", "secret"},
+		}, {
+			description: "Only secret.",
+			input:       "foo((secret))",
+			name:        "foo",
+			cmds:        []string{"secret"},
+		}, {
+			description: "An unknown command.",
+			input:       "foo((bar))",
+			expectedErr: ErrInvalidCommand,
+		}, {
+			description: "Two commands.",
+			input:       "foo((append))((secret))",
+			expectedErr: ErrInvalidCommand,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			assert := assert.New(t)
+
+			name, cmds, err := SplitKey(tc.input)
+			if tc.expectedErr != nil {
+				assert.ErrorIs(err, tc.expectedErr)
+				return
+			}
+
+			assert.NoError(err)
+			assert.Equal(tc.name, name)
+			assert.Equal(tc.cmds, cmds)
+		})
+	}
+}
+
 func FuzzMap_getCmd(f *testing.F) {
 	f.Add("foo((bar))")
 	f.Fuzz(func(t *testing.T, in string) {
@@ -7,6 +7,7 @@ import (
 	"errors"
 	"fmt"
 	"reflect"
+	"sort"
 	"strconv"
 	"strings"
 )
@@ -93,6 +94,17 @@ func (o Object) Kind() int {
 	return Value
 }
 
+// shape is like Kind() except empty arrays and maps are still arrays and maps.
+func (obj Object) shape() int {
+	switch {
+	case obj.Array != nil:
+		return Array
+	case obj.Map != nil:
+		return Map
+	}
+	return Value
+}
+
 // OriginString provides the string for all origins for this Object.
 func (obj Object) OriginString() string {
 	list := make([]string, len(obj.Origins))
@@ -103,6 +115,60 @@ func (obj Object) OriginString() string {
 	return strings.Join(list, ", ")
 }
 
+// Dump renders the tree in an indented, developer focused form that includes
+// the kind of each Object and the origins that influenced it.  This is useful
+// for debugging merge issues.  The values of secrets are not included.
+//
+// Example output:
+//
+//	Map (file.yml:1[1])
+//	  name: Value string 'example' (file.yml:1[7])
+//	  ports: Array (file.yml:2[8])
+//	    0: Value int '80' (file.yml:2[9])
+func (obj Object) Dump() string {
+	var b strings.Builder
+	obj.dump(&b, "")
+	return b.String()
+}
+
+func (obj Object) dump(b *strings.Builder, indent string) {
+	origins := ""
+	if len(obj.Origins) > 0 {
+		origins = " (" + obj.OriginString() + ")"
+	}
+
+	switch obj.Kind() {
+	case Array:
+		fmt.Fprintf(b, "Array%s\n", origins)
+		for i, val := range obj.Array {
+			fmt.Fprintf(b, "%s  %d: ", indent, i)
+			val.dump(b, indent+"  ")
+		}
+	case Map:
+		fmt.Fprintf(b, "Map%s\n", origins)
+
+		keys := make([]string, 0, len(obj.Map))
+		for key := range obj.Map {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			fmt.Fprintf(b, "%s  %s: ", indent, key)
+			obj.Map[key].dump(b, indent+"  ")
+		}
+	default:
+		switch {
+		case obj.Value == nil:
+			fmt.Fprintf(b, "Value <nil>%s\n", origins)
+		case obj.secret:
+			fmt.Fprintf(b, "Value %T <secret>%s\n", obj.Value, origins)
+		default:
+			fmt.Fprintf(b, "Value %T '%v'%s\n", obj.Value, obj.Value, origins)
+		}
+	}
+}
+
 // Fetch looks up the specific asks in the tree (map keys or array indexes) and
 // returns the found object or provides a contextual error.  The separater is
 // used to provide error context.
@@ -341,18 +407,60 @@ func (obj Object) ToRedacted() Object {
 	return obj
 }
 
+// MarkSecret builds a copy of a tree that has not had the commands resolved
+// where the key of every value and array is given the 'secret' command.  When
+// the tree is merged, all the values are secrets.  Keys that already have the
+// 'secret' command are unchanged.
+func (obj Object) MarkSecret() (Object, error) {
+	if obj.Kind() != Map {
+		return obj, nil
+	}
+
+	m := make(map[string]Object, len(obj.Map))
+	for key, val := range obj.Map {
+		cmd, err := getCmd(key)
+		if err != nil {
+			return Object{}, err
+		}
+
+		if val.Kind() == Map {
+			val, err = val.MarkSecret()
+			if err != nil {
+				return Object{}, err
+			}
+		} else if !cmd.secret {
+			key = cmd.final + "((" + strings.TrimSpace(cmd.cmd+" "+cmdSecret) + "))"
+		}
+		m[key] = val
+	}
+	obj.Map = m
+
+	return obj, nil
+}
+
 // ToExpanded builds a copy of the tree where any matching variables are expanded
 // to the final instance.  The max value is used to prevent recursive substitutions
 // from never returning.  Instead the process is stopped and an error is returned.
 // The resulting tree is returned.
 func (obj Object) ToExpanded(max int, origin, start, end string, expander func(string) (string, bool)) (Object, error) {
+	return obj.toExpanded(max, origin, start, end, expander, false)
+}
+
+// ToExpandedSecret is the same as ToExpanded() except any value that is changed
+// by the expansion is marked as a secret.  This is useful when the expander
+// provides secrets, so the values are redacted by ToRedacted().
+func (obj Object) ToExpandedSecret(max int, origin, start, end string, expander func(string) (string, bool)) (Object, error) {
+	return obj.toExpanded(max, origin, start, end, expander, true)
+}
+
+func (obj Object) toExpanded(max int, origin, start, end string, expander func(string) (string, bool), secret bool) (Object, error) {
 	var err error
 
 	switch obj.Kind() {
 	case Array:
 		array := make([]Object, len(obj.Array))
 		for i, val := range obj.Array {
-			array[i], err = val.ToExpanded(max, origin, start, end, expander)
+			array[i], err = val.toExpanded(max, origin, start, end, expander, secret)
 			if err != nil {
 				return Object{}, err
 			}
@@ -362,7 +470,7 @@ func (obj Object) ToExpanded(max int, origin, start, end string, expander func(s
 		m := make(map[string]Object)
 
 		for key, val := range obj.Map {
-			m[key], err = val.ToExpanded(max, origin, start, end, expander)
+			m[key], err = val.toExpanded(max, origin, start, end, expander, secret)
 			if err != nil {
 				return Object{}, err
 			}
@@ -385,7 +493,7 @@ func (obj Object) ToExpanded(max int, origin, start, end string, expander func(s
 			return Object{
 				Origins: origins,
 				Value:   val,
-				secret:  obj.secret,
+				secret:  obj.secret || (changed && secret),
 			}, nil
 		default:
 		}
@@ -559,9 +667,97 @@ func (obj Object) resolveCommands(secret bool) (Object, error) {
 	return obj, nil
 }
 
+// LeafMerger is a function that is called when a value in the existing tree
+// conflicts with a value in the next tree during a merge and no command is
+// specified.  The path is the list of map keys to the value.  If the handled
+// bool is false the default (last-wins) behavior is used and the returned
+// Object is ignored.
+type LeafMerger func(path []string, existing, next Object) (rv Object, handled bool, err error)
+
+// MergeOption provides a way to adjust how a merge is performed.
+type MergeOption func(*merger)
+
+// WithLeafMerger sets the LeafMerger to use for value conflicts.
+func WithLeafMerger(leaf LeafMerger) MergeOption {
+	return func(m *merger) {
+		m.leaf = leaf
+	}
+}
+
+// WithIndexMerge enables merging a map with keys that are all array indexes
+// into an existing array, one element at a time, instead of replacing the
+// array.  This allows a single element of an array to be changed.  If extend
+// is true, indexes past the end of the array extend the array with null
+// values as needed; otherwise they result in ErrArrayOutOfBounds.
+func WithIndexMerge(extend bool) MergeOption {
+	return func(m *merger) {
+		m.index = true
+		m.extend = extend
+	}
+}
+
+// WithDedupArrays removes the duplicate elements from arrays after they are
+// appended or prepended during a merge.  Elements are compared using their
+// values, not their origins, and the first occurrence of each element is kept.
+func WithDedupArrays() MergeOption {
+	return func(m *merger) {
+		m.dedup = true
+	}
+}
+
+// The merge strategies a MergeRule returns.
+const (
+	// StrategyDefault merges using the default semantics.
+	StrategyDefault = iota
+
+	// StrategyReplace replaces the existing Object with the next Object,
+	// regardless of their kinds.
+	StrategyReplace
+
+	// StrategyAppend appends the next array to the existing array.  Maps and
+	// values are merged using the default semantics.
+	StrategyAppend
+
+	// StrategyDeepMerge merges the next array into the existing array one
+	// element at a time.  The elements at the same index are merged together
+	// and the extra elements of the next array are appended.  Maps and values
+	// are merged using the default semantics.
+	StrategyDeepMerge
+)
+
+// MergeRule is a function that is called when an Object in the existing tree
+// is merged with an Object in the next tree and no command is specified.  The
+// path is the list of map keys (and array indexes) to the Object.  One of the
+// Strategy values is returned.
+type MergeRule func(path []string) int
+
+// WithMergeRule sets the MergeRule to use to select how each Object is merged.
+func WithMergeRule(rule MergeRule) MergeOption {
+	return func(m *merger) {
+		m.rule = rule
+	}
+}
+
+// merger holds the configuration used while merging trees.
+type merger struct {
+	leaf   LeafMerger
+	rule   MergeRule
+	index  bool
+	extend bool
+	dedup  bool
+}
+
+// strategy returns the strategy to use for the path.
+func (m *merger) strategy(path []string) int {
+	if m.rule == nil {
+		return StrategyDefault
+	}
+	return m.rule(path)
+}
+
 // Merge performs a merge of the new Object tree onto the existing Object tree
 // using the default semantics and merge rules found in the key commands.
-func (obj Object) Merge(next Object) (Object, error) {
+func (obj Object) Merge(next Object, opts ...MergeOption) (Object, error) {
 	// The 'clear' command is special in that if it is found at all, it
 	// overwrites everything else in the existing tree and exists the merge.
 	for k := range next.Map {
@@ -574,22 +770,29 @@ func (obj Object) Merge(next Object) (Object, error) {
 		}
 	}
 
-	return obj.merge(command{}, next)
+	var m merger
+	for _, opt := range opts {
+		if opt != nil {
+			opt(&m)
+		}
+	}
+
+	return obj.merge(&m, nil, command{}, next)
 }
 
 // merge does the actual merging of the trees.
-func (obj Object) merge(cmd command, next Object) (Object, error) {
+func (obj Object) merge(m *merger, path []string, cmd command, next Object) (Object, error) {
 	switch obj.Kind() {
 	case Value:
-		return obj.mergeValue(cmd, next)
+		return obj.mergeValue(m, path, cmd, next)
 	case Array:
-		return obj.mergeArray(cmd, next)
+		return obj.mergeArray(m, cmd, next)
 	}
-	return obj.mergeMap(cmd, next)
+	return obj.mergeMap(m, path, cmd, next)
 }
 
 // mergeValue merges two values.  Don't directly call this, call merge() instead.
-func (obj Object) mergeValue(cmd command, next Object) (Object, error) {
+func (obj Object) mergeValue(m *merger, path []string, cmd command, next Object) (Object, error) {
 	rv := obj
 	switch cmd.cmd {
 	case cmdReplace, "":
@@ -598,6 +801,16 @@ func (obj Object) mergeValue(cmd command, next Object) (Object, error) {
 		if err != nil {
 			return Object{}, err
 		}
+
+		if cmd.cmd == "" && m.leaf != nil && next.Kind() == Value {
+			got, handled, err := m.leaf(path, obj, rv)
+			if err != nil {
+				return Object{}, err
+			}
+			if handled {
+				rv = got
+			}
+		}
 	case cmdFail:
 		return Object{}, fmt.Errorf("%w: merging a value with command 'fail'", ErrConflict)
 	case cmdKeep:
@@ -608,7 +821,7 @@ func (obj Object) mergeValue(cmd command, next Object) (Object, error) {
 }
 
 // mergeArray merges two array.  Don't directly call this, call merge() instead.
-func (obj Object) mergeArray(cmd command, next Object) (Object, error) {
+func (obj Object) mergeArray(m *merger, cmd command, next Object) (Object, error) {
 	rv := obj
 	next, err := next.resolveCommands(obj.secret)
 	if err != nil {
@@ -621,12 +834,18 @@ func (obj Object) mergeArray(cmd command, next Object) (Object, error) {
 		}
 		rv.Origins = append(obj.Origins, next.Origins...)
 		rv.Array = append(obj.Array, next.Array...)
+		if m.dedup {
+			rv.Array = dedup(rv.Array)
+		}
 	case cmdPrepend:
 		if obj.secret || next.secret || cmd.secret {
 			rv.secret = true
 		}
 		rv.Origins = append(next.Origins, obj.Origins...)
 		rv.Array = append(next.Array, obj.Array...)
+		if m.dedup {
+			rv.Array = dedup(rv.Array)
+		}
 	case cmdReplace:
 		rv.secret = cmd.secret
 		rv = next
@@ -637,8 +856,34 @@ func (obj Object) mergeArray(cmd command, next Object) (Object, error) {
 	return rv, nil
 }
 
+// dedup returns the array without the duplicate elements, keeping the first
+// occurrence of each.  The elements are compared by their values only.
+func dedup(array []Object) []Object {
+	rv := make([]Object, 0, len(array))
+	raws := make([]any, 0, len(array))
+
+	for _, item := range array {
+		raw := item.ToRaw()
+
+		found := false
+		for _, prev := range raws {
+			if reflect.DeepEqual(prev, raw) {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			rv = append(rv, item)
+			raws = append(raws, raw)
+		}
+	}
+
+	return rv
+}
+
 // mergeMap merges two maps.  Don't directly call this, call merge() instead.
-func (obj Object) mergeMap(cmd command, next Object) (Object, error) {
+func (obj Object) mergeMap(m *merger, path []string, cmd command, next Object) (Object, error) {
 	switch cmd.cmd {
 	case cmdFail:
 		return Object{}, fmt.Errorf("%w: merging a map with command 'fail'", ErrConflict)
@@ -672,8 +917,29 @@ func (obj Object) mergeMap(cmd command, next Object) (Object, error) {
 			continue
 		}
 
+		full := append(path[:len(path):len(path)], newCmd.final)
+		if newCmd.cmd == "" {
+			v, handled, err := existing.mergeStrategy(m, full, newCmd, val)
+			if err != nil {
+				return Object{}, err
+			}
+			if handled {
+				obj.Map[newCmd.final] = v
+				continue
+			}
+		}
+
 		if existing.Kind() == val.Kind() {
-			v, err := existing.merge(newCmd, val)
+			v, err := existing.merge(m, full, newCmd, val)
+			if err != nil {
+				return Object{}, err
+			}
+			obj.Map[newCmd.final] = v
+			continue
+		}
+
+		if m.index && newCmd.cmd == "" && existing.Kind() == Array && isIndexMap(val) {
+			v, err := existing.mergeIndexes(m, full, val)
 			if err != nil {
 				return Object{}, err
 			}
@@ -699,6 +965,151 @@ func (obj Object) mergeMap(cmd command, next Object) (Object, error) {
 	return obj, nil
 }
 
+// mergeStrategy merges the next Object using the strategy the MergeRule
+// selects for the path.  The handled bool is false if the default semantics
+// should be used instead.
+func (obj Object) mergeStrategy(m *merger, path []string, cmd command, next Object) (Object, bool, error) {
+	bothArrays := obj.Kind() == Array && next.Kind() == Array
+
+	switch m.strategy(path) {
+	case StrategyReplace:
+		rv, err := next.resolveCommands(cmd.secret)
+		if err != nil {
+			return Object{}, false, err
+		}
+		rv.secret = cmd.secret
+		return rv, true, nil
+	case StrategyAppend:
+		if bothArrays {
+			cmd.cmd = cmdAppend
+			rv, err := obj.mergeArray(m, cmd, next)
+			return rv, true, err
+		}
+	case StrategyDeepMerge:
+		if bothArrays {
+			rv, err := obj.mergeArrayDeep(m, path, next)
+			return rv, true, err
+		}
+	}
+
+	return Object{}, false, nil
+}
+
+// mergeArrayDeep merges the next array into the array one element at a time.
+// Don't directly call this, call merge() instead.
+func (obj Object) mergeArrayDeep(m *merger, path []string, next Object) (Object, error) {
+	// Don't alter the array in the existing tree.
+	obj.Array = append([]Object{}, obj.Array...)
+	obj.Origins = append(obj.Origins[:len(obj.Origins):len(obj.Origins)], next.Origins...)
+	obj.secret = obj.secret || next.secret
+
+	for i, val := range next.Array {
+		if i >= len(obj.Array) {
+			v, err := val.resolveCommands(false)
+			if err != nil {
+				return Object{}, err
+			}
+			obj.Array = append(obj.Array, v)
+			continue
+		}
+
+		existing := obj.Array[i]
+		full := append(path[:len(path):len(path)], strconv.Itoa(i))
+
+		// An empty map is merged into a map so it doesn't clear it.
+		var v Object
+		var err error
+		if existing.shape() == val.shape() {
+			v, err = existing.merge(m, full, command{}, val)
+		} else {
+			v, err = val.resolveCommands(false)
+		}
+		if err != nil {
+			return Object{}, err
+		}
+		obj.Array[i] = v
+	}
+
+	if m.dedup {
+		obj.Array = dedup(obj.Array)
+	}
+
+	return obj, nil
+}
+
+// isIndexMap returns if the object is a map with keys that are all array
+// indexes.
+func isIndexMap(obj Object) bool {
+	if obj.Kind() != Map || len(obj.Map) == 0 {
+		return false
+	}
+
+	for key := range obj.Map {
+		cmd, err := getCmd(key)
+		if err != nil {
+			return false
+		}
+		if idx, err := strconv.Atoi(cmd.final); err != nil || idx < 0 {
+			return false
+		}
+	}
+
+	return true
+}
+
+// mergeIndexes merges a map with keys that are array indexes into the array
+// one element at a time.  Don't directly call this, call merge() instead.
+func (obj Object) mergeIndexes(m *merger, path []string, next Object) (Object, error) {
+	keys := make([]string, 0, len(next.Map))
+	for key := range next.Map {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	// Don't alter the array in the existing tree.
+	obj.Array = append([]Object{}, obj.Array...)
+
+	for _, key := range keys {
+		val := next.Map[key]
+		cmd, err := getValidCmd(key, val)
+		if err != nil {
+			return Object{}, err
+		}
+
+		idx, _ := strconv.Atoi(cmd.final)
+		if idx >= len(obj.Array) {
+			if !m.extend {
+				return Object{}, fmt.Errorf("%w: index %d is past the end of the array",
+					ErrArrayOutOfBounds, idx)
+			}
+			for len(obj.Array) <= idx {
+				obj.Array = append(obj.Array, Object{})
+			}
+		}
+
+		existing := obj.Array[idx]
+		full := append(path[:len(path):len(path)], cmd.final)
+
+		var v Object
+		switch {
+		case existing.Kind() == val.Kind():
+			v, err = existing.merge(m, full, cmd, val)
+		case cmd.cmd == cmdKeep:
+			v = existing
+		case cmd.cmd == cmdFail:
+			err = fmt.Errorf("%w: merging array element", ErrConflict)
+		default:
+			v, err = val.resolveCommands(cmd.secret)
+		}
+		if err != nil {
+			return Object{}, err
+		}
+		obj.Array[idx] = v
+	}
+
+	return obj, nil
+}
+
 // getValidCmd gets the command from the key string and validates it is supported.
 func getValidCmd(key string, obj Object) (command, error) {
 	cmd, err := getCmd(key)
@@ -758,6 +1169,32 @@ func (obj Object) FilterNonSerializable() Object {
 	return obj
 }
 
+// FilterNulls builds a copy of the tree where any map entries with a null
+// (nil) value are excluded.  Array entries are left in place so the indexes
+// of the remaining entries are not altered.
+func (obj Object) FilterNulls() Object {
+	switch obj.Kind() {
+	case Array:
+		array := make([]Object, len(obj.Array))
+		for i, val := range obj.Array {
+			array[i] = val.FilterNulls()
+		}
+		obj.Array = array
+	case Map:
+		m := make(map[string]Object, len(obj.Map))
+
+		for key, val := range obj.Map {
+			if val.shape() == Value && val.Value == nil {
+				continue
+			}
+			m[key] = val.FilterNulls()
+		}
+		obj.Map = m
+	}
+
+	return obj
+}
+
 // ErrOnNonSerializable returns if the
 // types are excluded from the values.
 func (obj Object) ErrOnNonSerializable() error {
@@ -1007,6 +1007,47 @@ func TestToExpanded(t *testing.T) {
 	}
 }
 
+func TestToExpandedSecret(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	in := Object{
+		Map: map[string]Object{
+			"password": {Value: "${pw}"},
+			"user":     {Value: "admin"},
+			"list": {
+				Array: []Object{
+					{Value: "${pw}"},
+					{Value: "plain"},
+				},
+			},
+			"kept": {Value: "${kept}", secret: true},
+		},
+	}
+
+	got, err := in.ToExpandedSecret(100, "secrets", "${", "}", func(in string) (string, bool) {
+		if in == "pw" {
+			return "hunter2", true
+		}
+		return "", false
+	})
+	require.NoError(err)
+
+	assert.Equal(map[string]any{
+		"password": "hunter2",
+		"user":     "admin",
+		"list":     []any{"hunter2", "plain"},
+		"kept":     "${kept}",
+	}, got.ToRaw())
+
+	assert.Equal(map[string]any{
+		"password": "REDACTED",
+		"user":     "admin",
+		"list":     []any{"REDACTED", "plain"},
+		"kept":     "REDACTED",
+	}, got.ToRedacted().ToRaw())
+}
+
 func TestExpand(t *testing.T) {
 	tests := []struct {
 		in          string
@@ -1533,6 +1574,359 @@ func TestMerge(t *testing.T) {
 	}
 }
 
+func TestMergeWithLeafMerger(t *testing.T) {
+	testErr := errors.New("test error")
+
+	sum := func(path []string, existing, next Object) (Object, bool, error) {
+		if strings.Join(path, ".") != "stats.count" {
+			return Object{}, false, nil
+		}
+		next.Value = existing.Value.(float64) + next.Value.(float64)
+		return next, true, nil
+	}
+
+	tests := []struct {
+		description string
+		in          string
+		next        string
+		leaf        LeafMerger
+		expected    string
+		expectedErr error
+	}{
+		{
+			description: "No leaf merger.",
+			in:          `{"stats":{"count":1, "name":"a"}}`,
+			next:        `{"stats":{"count":2, "name":"b"}}`,
+			expected:    `{"stats":{"count":2, "name":"b"}}`,
+		}, {
+			description: "A summing leaf merger.",
+			in:          `{"stats":{"count":1, "name":"a"}}`,
+			next:        `{"stats":{"count":2, "name":"b"}}`,
+			leaf:        sum,
+			expected:    `{"stats":{"count":3, "name":"b"}}`,
+		}, {
+			description: "Commands take precedence over the leaf merger.",
+			in:          `{"stats":{"count":1}}`,
+			next:        `{"stats":{"count((replace))":2}}`,
+			leaf:        sum,
+			expected:    `{"stats":{"count":2}}`,
+		}, {
+			description: "Only conflicting values are passed to the leaf merger.",
+			in:          `{"stats":{"name":"a"}}`,
+			next:        `{"stats":{"count":2}}`,
+			leaf:        sum,
+			expected:    `{"stats":{"count":2, "name":"a"}}`,
+		}, {
+			description: "A leaf merger error.",
+			in:          `{"stats":{"count":1}}`,
+			next:        `{"stats":{"count":2}}`,
+			leaf: func([]string, Object, Object) (Object, bool, error) {
+				return Object{}, false, testErr
+			},
+			expectedErr: testErr,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			in, err := decode(tc.in).resolveCommands(false)
+			require.NoError(err)
+			next := decode(tc.next)
+
+			got, err := in.Merge(next, WithLeafMerger(tc.leaf))
+
+			if tc.expectedErr == nil {
+				assert.NoError(err)
+				assert.Equal(decode(tc.expected).ToRaw(), got.ToRaw())
+				return
+			}
+
+			assert.ErrorIs(err, tc.expectedErr)
+		})
+	}
+}
+
+func TestMergeWithDedupArrays(t *testing.T) {
+	tests := []struct {
+		description string
+		in          string
+		next        []string
+		opts        []MergeOption
+		expected    string
+	}{
+		{
+			description: "Without dedup the duplicates are kept.",
+			in:          `{"list":["a", "b"]}`,
+			next:        []string{`{"list":["b", "c"]}`},
+			expected:    `{"list":["a", "b", "b", "c"]}`,
+		}, {
+			description: "Overlapping lists across three layers.",
+			in:          `{"list":["a", "b"]}`,
+			next:        []string{`{"list":["b", "c"]}`, `{"list":["c", "a", "d"]}`},
+			opts:        []MergeOption{WithDedupArrays()},
+			expected:    `{"list":["a", "b", "c", "d"]}`,
+		}, {
+			description: "Prepended lists keep the first occurrence.",
+			in:          `{"list":["a", "b"]}`,
+			next:        []string{`{"list((prepend))":["b", "c"]}`},
+			opts:        []MergeOption{WithDedupArrays()},
+			expected:    `{"list":["b", "c", "a"]}`,
+		}, {
+			description: "Maps are compared deeply.",
+			in:          `{"list":[{"a":"b"}, {"c":"d"}]}`,
+			next:        []string{`{"list":[{"c":"d"}, {"c":"e"}]}`},
+			opts:        []MergeOption{WithDedupArrays()},
+			expected:    `{"list":[{"a":"b"}, {"c":"d"}, {"c":"e"}]}`,
+		}, {
+			description: "Replaced lists are not changed.",
+			in:          `{"list":["a", "b"]}`,
+			next:        []string{`{"list((replace))":["c", "c"]}`},
+			opts:        []MergeOption{WithDedupArrays()},
+			expected:    `{"list":["c", "c"]}`,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			got, err := decode(tc.in).resolveCommands(false)
+			require.NoError(err)
+
+			for _, next := range tc.next {
+				got, err = got.Merge(decode(next), tc.opts...)
+				require.NoError(err)
+			}
+
+			assert.Equal(decode(tc.expected).ToRaw(), got.ToRaw())
+		})
+	}
+}
+
+func TestMergeWithMergeRule(t *testing.T) {
+	rule := func(rules map[string]int) MergeOption {
+		return WithMergeRule(func(path []string) int {
+			return rules[strings.Join(path, ".")]
+		})
+	}
+
+	tests := []struct {
+		description string
+		in          string
+		next        []string
+		opts        []MergeOption
+		expected    string
+	}{
+		{
+			description: "Without rules the arrays are appended.",
+			in:          `{"list":["a"], "m":{"a":1}}`,
+			next:        []string{`{"list":["b"], "m":{"b":2}}`},
+			expected:    `{"list":["a", "b"], "m":{"a":1, "b":2}}`,
+		}, {
+			description: "Replace arrays and maps.",
+			in:          `{"list":["a"], "m":{"a":1}, "v":1}`,
+			next:        []string{`{"list":["b"], "m":{"b":2}, "v":2}`},
+			opts:        []MergeOption{rule(map[string]int{"list": StrategyReplace, "m": StrategyReplace, "v": StrategyReplace})},
+			expected:    `{"list":["b"], "m":{"b":2}, "v":2}`,
+		}, {
+			description: "Replace a value with a different kind.",
+			in:          `{"a":{"b":1}}`,
+			next:        []string{`{"a":["x"]}`},
+			opts:        []MergeOption{rule(map[string]int{"a": StrategyReplace})},
+			expected:    `{"a":["x"]}`,
+		}, {
+			description: "Append only applies to arrays.",
+			in:          `{"list":["a"], "m":{"a":1}}`,
+			next:        []string{`{"list":["b"], "m":{"b":2}}`},
+			opts:        []MergeOption{rule(map[string]int{"list": StrategyAppend, "m": StrategyAppend})},
+			expected:    `{"list":["a", "b"], "m":{"a":1, "b":2}}`,
+		}, {
+			description: "Deep merge arrays of maps.",
+			in:          `{"list":[{"a":1, "tags":["x"]}, {"b":2}]}`,
+			next:        []string{`{"list":[{"c":3, "tags":["y"]}, {"b":4}, {"d":5}]}`},
+			opts: []MergeOption{rule(map[string]int{
+				"list":        StrategyDeepMerge,
+				"list.1.b":    StrategyReplace,
+				"list.0.tags": StrategyReplace,
+			})},
+			expected: `{"list":[{"a":1, "c":3, "tags":["y"]}, {"b":4}, {"d":5}]}`,
+		}, {
+			description: "Deep merge nested arrays.",
+			in:          `{"list":[["a"], "b", {"c":1}]}`,
+			next:        []string{`{"list":[["d"], {"e":2}]}`},
+			opts:        []MergeOption{rule(map[string]int{"list": StrategyDeepMerge})},
+			expected:    `{"list":[["a", "d"], {"e":2}, {"c":1}]}`,
+		}, {
+			description: "Deep merge with dedup.",
+			in:          `{"list":["a", "b"]}`,
+			next:        []string{`{"list":["a", "c", "a"]}`},
+			opts:        []MergeOption{rule(map[string]int{"list": StrategyDeepMerge}), WithDedupArrays()},
+			expected:    `{"list":["a", "c"]}`,
+		}, {
+			description: "Commands take precedence over rules.",
+			in:          `{"list":["a"]}`,
+			next:        []string{`{"list((prepend))":["b"]}`},
+			opts:        []MergeOption{rule(map[string]int{"list": StrategyReplace})},
+			expected:    `{"list":["b", "a"]}`,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			got, err := decode(tc.in).resolveCommands(false)
+			require.NoError(err)
+
+			for _, next := range tc.next {
+				got, err = got.Merge(decode(next), tc.opts...)
+				require.NoError(err)
+			}
+
+			assert.Equal(decode(tc.expected).ToRaw(), got.ToRaw())
+		})
+	}
+}
+
+func TestMergeWithIndexMerge(t *testing.T) {
+	tests := []struct {
+		description string
+		in          string
+		next        string
+		opts        []MergeOption
+		expected    string
+		expectedErr error
+	}{
+		{
+			description: "Without index merging the array is replaced.",
+			in:          `{"servers":[{"host":"a"}, {"host":"b"}]}`,
+			next:        `{"servers":{"1":{"host":"c"}}}`,
+			expected:    `{"servers":{"1":{"host":"c"}}}`,
+		}, {
+			description: "A single element is merged.",
+			in:          `{"servers":[{"host":"a", "port":1}, {"host":"b", "port":2}]}`,
+			next:        `{"servers":{"1":{"host":"c"}}}`,
+			opts:        []MergeOption{WithIndexMerge(false)},
+			expected:    `{"servers":[{"host":"a", "port":1}, {"host":"c", "port":2}]}`,
+		}, {
+			description: "Multiple elements are merged.",
+			in:          `{"list":["a", "b", "c"]}`,
+			next:        `{"list":{"0":"x", "2":"z"}}`,
+			opts:        []MergeOption{WithIndexMerge(false)},
+			expected:    `{"list":["x", "b", "z"]}`,
+		}, {
+			description: "A different kind replaces the element.",
+			in:          `{"list":["a", "b"]}`,
+			next:        `{"list":{"1":{"c":"d"}}}`,
+			opts:        []MergeOption{WithIndexMerge(false)},
+			expected:    `{"list":["a", {"c":"d"}]}`,
+		}, {
+			description: "Commands on the element are honored.",
+			in:          `{"list":["a", {"b":"c"}]}`,
+			next:        `{"list":{"0((keep))":"x", "1((replace))":{"d":"e"}}}`,
+			opts:        []MergeOption{WithIndexMerge(false)},
+			expected:    `{"list":["a", {"d":"e"}]}`,
+		}, {
+			description: "A fail command on the element.",
+			in:          `{"list":["a", "b"]}`,
+			next:        `{"list":{"0((fail))":{"c":"d"}}}`,
+			opts:        []MergeOption{WithIndexMerge(false)},
+			expectedErr: ErrConflict,
+		}, {
+			description: "Non-index keys replace the array.",
+			in:          `{"list":["a", "b"]}`,
+			next:        `{"list":{"0":"x", "name":"y"}}`,
+			opts:        []MergeOption{WithIndexMerge(false)},
+			expected:    `{"list":{"0":"x", "name":"y"}}`,
+		}, {
+			description: "An out of range index is an error.",
+			in:          `{"list":["a", "b"]}`,
+			next:        `{"list":{"3":"x"}}`,
+			opts:        []MergeOption{WithIndexMerge(false)},
+			expectedErr: ErrArrayOutOfBounds,
+		}, {
+			description: "An out of range index extends the array.",
+			in:          `{"list":["a", "b"]}`,
+			next:        `{"list":{"3":"x"}}`,
+			opts:        []MergeOption{WithIndexMerge(true)},
+			expected:    `{"list":["a", "b", null, "x"]}`,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			in, err := decode(tc.in).resolveCommands(false)
+			require.NoError(err)
+			next := decode(tc.next)
+
+			got, err := in.Merge(next, tc.opts...)
+
+			if tc.expectedErr == nil {
+				assert.NoError(err)
+				assert.Equal(decode(tc.expected).ToRaw(), got.ToRaw())
+				return
+			}
+
+			assert.ErrorIs(err, tc.expectedErr)
+		})
+	}
+}
+
+func TestMarkSecret(t *testing.T) {
+	tests := []struct {
+		description string
+		in          string
+		want        string
+		redacted    string
+		expectedErr error
+	}{
+		{
+			description: "An empty tree.",
+			in:          `{}`,
+			want:        `{}`,
+		}, {
+			description: "Values, arrays and maps.",
+			in:          `{"a":"b", "c":["d"], "e":{"f":"g"}}`,
+			want:        `{"a((secret))":"b", "c((secret))":["d"], "e":{"f((secret))":"g"}}`,
+			redacted:    `{"a":"REDACTED", "c":"REDACTED", "e":{"f":"REDACTED"}}`,
+		}, {
+			description: "Existing commands are kept.",
+			in:          `{"a((replace))":"b", "c((secret))":"d", "e((keep))":{"f((append secret))":["g"]}}`,
+			want:        `{"a((replace secret))":"b", "c((secret))":"d", "e((keep))":{"f((append secret))":["g"]}}`,
+		}, {
+			description: "An invalid command.",
+			in:          `{"a((bad,command))":"b"}`,
+			expectedErr: ErrInvalidCommand,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			got, err := decode(tc.in).MarkSecret()
+
+			if tc.expectedErr != nil {
+				assert.ErrorIs(err, tc.expectedErr)
+				return
+			}
+
+			require.NoError(err)
+			assert.Equal(decode(tc.want).ToRaw(), got.ToRaw())
+
+			if tc.redacted != "" {
+				resolved, err := got.ResolveCommands()
+				require.NoError(err)
+				assert.Equal(decode(tc.redacted).ToRaw(), resolved.ToRedacted().ToRaw())
+			}
+		})
+	}
+}
+
 func TestOrigin_OriginString(t *testing.T) {
 	tests := []struct {
 		description string
@@ -1589,6 +1983,75 @@ func TestOrigin_OriginString(t *testing.T) {
 	}
 }
 
+func TestDump(t *testing.T) {
+	tests := []struct {
+		description string
+		obj         Object
+		expected    string
+	}{
+		{
+			description: "Output an empty object.",
+			expected:    "Value <nil>\n",
+		}, {
+			description: "Output a value.",
+			obj: Object{
+				Origins: []Origin{{File: "a.yml", Line: 1, Col: 2}},
+				Value:   "hello",
+			},
+			expected: "Value string 'hello' (a.yml:1[2])\n",
+		}, {
+			description: "Output a secret value.",
+			obj: Object{
+				Value:  "password",
+				secret: true,
+			},
+			expected: "Value string <secret>\n",
+		}, {
+			description: "Output a tree.",
+			obj: Object{
+				Origins: []Origin{{File: "a.yml", Line: 1, Col: 1}},
+				Map: map[string]Object{
+					"name": {
+						Origins: []Origin{{File: "a.yml", Line: 1, Col: 7}},
+						Value:   "example",
+					},
+					"ports": {
+						Origins: []Origin{{File: "a.yml", Line: 2, Col: 8}},
+						Array: []Object{
+							{
+								Origins: []Origin{
+									{File: "a.yml", Line: 2, Col: 9},
+									{File: "b.yml", Line: 3, Col: 4},
+								},
+								Value: 80,
+							}, {
+								Map: map[string]Object{
+									"tls": {Value: true},
+								},
+							},
+						},
+					},
+					"empty": {},
+				},
+			},
+			expected: "Map (a.yml:1[1])\n" +
+				"  empty: Value <nil>\n" +
+				"  name: Value string 'example' (a.yml:1[7])\n" +
+				"  ports: Array (a.yml:2[8])\n" +
+				"    0: Value int '80' (a.yml:2[9], b.yml:3[4])\n" +
+				"    1: Map\n" +
+				"      tls: Value bool 'true'\n",
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			assert := assert.New(t)
+
+			assert.Equal(tc.expected, tc.obj.Dump())
+		})
+	}
+}
+
 func TestIsSerializable(t *testing.T) {
 	testFunc := func() {}
 	ch := make(chan string)
@@ -1697,6 +2160,89 @@ func TestFilterNonSerializable(t *testing.T) {
 	}
 }
 
+func TestFilterNulls(t *testing.T) {
+	origin := Origin{
+		File: "file",
+		Line: 12,
+		Col:  36,
+	}
+	tests := []struct {
+		description string
+		thing       any
+		expected    any
+	}{
+		{
+			description: "no nulls",
+			thing: map[string]any{
+				"one": []any{"fish", "dog"},
+				"two": "blue",
+			},
+			expected: map[string]any{
+				"one": []any{"fish", "dog"},
+				"two": "blue",
+			},
+		}, {
+			description: "nulls in maps are removed",
+			thing: map[string]any{
+				"one": nil,
+				"two": map[string]any{
+					"fish":  nil,
+					"color": "blue",
+				},
+			},
+			expected: map[string]any{
+				"two": map[string]any{
+					"color": "blue",
+				},
+			},
+		}, {
+			description: "nulls in arrays are kept",
+			thing: map[string]any{
+				"one": []any{
+					nil,
+					map[string]any{"dog": nil, "cat": "red"},
+				},
+			},
+			expected: map[string]any{
+				"one": []any{
+					nil,
+					map[string]any{"cat": "red"},
+				},
+			},
+		}, {
+			description: "empty arrays and maps are kept",
+			thing: map[string]any{
+				"list((replace))": []any{},
+				"m((replace))":    map[string]any{},
+				"sub": map[string]any{
+					"list": []any{},
+					"m":    map[string]any{},
+					"null": nil,
+				},
+			},
+			expected: map[string]any{
+				"list((replace))": []any{},
+				"m((replace))":    map[string]any{},
+				"sub": map[string]any{
+					"list": []any{},
+					"m":    map[string]any{},
+				},
+			},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			assert := assert.New(t)
+
+			obj := ObjectFromRawWithOrigin(tc.thing, []Origin{origin})
+			want := ObjectFromRawWithOrigin(tc.expected, []Origin{origin})
+			got := obj.FilterNulls()
+
+			assert.Equal(want, got)
+		})
+	}
+}
+
 func TestErrOnNonSerializable(t *testing.T) {
 	origin := Origin{
 		File: "file",
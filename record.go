@@ -4,6 +4,9 @@
 package goschtalt
 
 import (
+	"context"
+	"io/fs"
+
 	"github.com/goschtalt/goschtalt/pkg/decoder"
 	"github.com/goschtalt/goschtalt/pkg/meta"
 )
@@ -14,13 +17,47 @@ type record struct {
 	name string
 	val  *value
 	buf  *buffer
+	kv   *kvStore
+	args *argList
 	tree meta.Object
+
+	// fs is the filesystem the record was read from, if any.
+	fs fs.FS
+
+	// dir is the directory in fs that contains the file the record was read
+	// from.  It is used to resolve references relative to the file.
+	dir string
+
+	// fromGroup is true if the record came from a filegroup.
+	fromGroup bool
+
+	// firstGroup is true if the record came from the first filegroup.
+	firstGroup bool
+
+	// isDefault is true if the record is a default.
+	isDefault bool
+
+	// priority is the explicit priority of the record, if any.
+	priority *int
+
+	// layer identifies the records added by the same AddLayeredDirs() option.
+	// Zero if the record isn't part of a layer.
+	layer int
+}
+
+// getPriority returns the priority of the record, which is 0 unless one was
+// specified.
+func (rec *record) getPriority() int {
+	if rec.priority == nil {
+		return 0
+	}
+	return *rec.priority
 }
 
 // fetch normalizes the calls to the val or encoded types of records.
-func (rec *record) fetch(delimiter string, u Unmarshaler, decoders *codecRegistry[decoder.Decoder], defaultOpts []ValueOption) error {
+func (rec *record) fetch(ctx context.Context, delimiter string, u Unmarshaler, decoders *codecRegistry[decoder.Decoder], cache *valueCache, defaultOpts []ValueOption, rejectDuplicateKeys bool) error {
 	if rec.val != nil {
-		tree, err := rec.val.toTree(delimiter, u, defaultOpts...)
+		tree, err := rec.val.toTree(ctx, delimiter, u, cache, defaultOpts...)
 		if err != nil {
 			return err
 		}
@@ -28,7 +65,23 @@ func (rec *record) fetch(delimiter string, u Unmarshaler, decoders *codecRegistr
 	}
 
 	if rec.buf != nil {
-		tree, err := rec.buf.toTree(delimiter, u, decoders)
+		tree, err := rec.buf.toTree(ctx, delimiter, u, decoders, rejectDuplicateKeys)
+		if err != nil {
+			return err
+		}
+		rec.tree = tree
+	}
+
+	if rec.kv != nil {
+		tree, err := rec.kv.toTree()
+		if err != nil {
+			return err
+		}
+		rec.tree = tree
+	}
+
+	if rec.args != nil {
+		tree, err := rec.args.toTree(delimiter)
 		if err != nil {
 			return err
 		}
@@ -5,6 +5,7 @@ package goschtalt
 
 import (
 	"fmt"
+	"mime"
 	"sort"
 	"strings"
 	"sync"
@@ -73,3 +74,35 @@ func (c *codecRegistry[C]) register(enc C) {
 		c.codecs[ext] = enc
 	}
 }
+
+// mimeTypes contains the MIME types that don't map to an extension by simply
+// using the subtype.
+var mimeTypes = map[string]string{
+	"text/x-java-properties": "properties",
+}
+
+// mimeToExtension maps a MIME type to the extension of the codec to use.
+func mimeToExtension(mimeType string) (string, error) {
+	media, _, err := mime.ParseMediaType(mimeType)
+	if err != nil {
+		return "", fmt.Errorf("%w: content type '%s' %v", ErrInvalidInput, mimeType, err) //nolint:errorlint
+	}
+
+	if ext, found := mimeTypes[media]; found {
+		return ext, nil
+	}
+
+	_, sub, _ := strings.Cut(media, "/")
+
+	// Handle the structured syntax suffixes like application/vnd.api+json
+	if i := strings.LastIndex(sub, "+"); i >= 0 {
+		sub = sub[i+1:]
+	}
+
+	sub = strings.TrimPrefix(sub, "x-")
+	if len(sub) == 0 {
+		return "", fmt.Errorf("%w: content type '%s' has no subtype", ErrInvalidInput, mimeType)
+	}
+
+	return sub, nil
+}
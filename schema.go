@@ -0,0 +1,117 @@
+// SPDX-FileCopyrightText: 2023 Weston Schmidt <weston_schmidt@alumni.purdue.edu>
+// SPDX-License-Identifier: Apache-2.0
+
+package goschtalt
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/goschtalt/goschtalt/internal/jsonschema"
+	"github.com/goschtalt/goschtalt/internal/print"
+	"github.com/goschtalt/goschtalt/pkg/meta"
+)
+
+// ValidateSchema validates the compiled configuration against the JSON Schema
+// provided.  If the configuration doesn't match the schema the compilation
+// fails with an [ErrSchemaViolation] error that lists each key that doesn't
+// match and why.  The whole configuration tree is validated before
+// [SelectRoot]() is applied.
+//
+// A subset of JSON Schema is supported:
+//   - type, enum, const
+//   - properties, required, additionalProperties
+//   - items, minItems, maxItems
+//   - minimum, maximum, exclusiveMinimum, exclusiveMaximum
+//   - minLength, maxLength, pattern
+//   - allOf, anyOf, oneOf, not
+//   - $ref to a location in the same schema, like "#/$defs/port"
+//
+// Other keywords are ignored.  Since many configuration sources only provide
+// strings, a string that can be parsed as a number or boolean satisfies the
+// "integer", "number" and "boolean" types.
+//
+// ValidateSchema may be specified multiple times and the configuration must
+// match all of the schemas.
+//
+// Example:
+//
+//	goschtalt.ValidateSchema([]byte(`{
+//		"type": "object",
+//		"required": ["port"],
+//		"properties": {
+//			"port": {"type": "integer", "minimum": 1, "maximum": 65535}
+//		}
+//	}`))
+//
+// # Default
+//
+// The configuration is not validated against a schema.
+func ValidateSchema(schema []byte) Option {
+	s, err := jsonschema.Parse(schema)
+	if err != nil {
+		return WithError(fmt.Errorf("%w, ValidateSchema() err: %w", ErrInvalidInput, err))
+	}
+
+	return &validateSchemaOption{schema: s}
+}
+
+type validateSchemaOption struct {
+	schema *jsonschema.Schema
+}
+
+func (v validateSchemaOption) apply(opts *options) error {
+	opts.schemas = append(opts.schemas, v.schema)
+	return nil
+}
+
+func (_ validateSchemaOption) ignoreDefaults() bool {
+	return false
+}
+
+func (_ validateSchemaOption) String() string {
+	return print.P("ValidateSchema", print.Literal("..."))
+}
+
+// validateSchemas checks the tree against each of the schemas.
+func validateSchemas(tree meta.Object, delimiter string, schemas []*jsonschema.Schema) error {
+	if len(schemas) == 0 {
+		return nil
+	}
+
+	raw := rawWithEmpty(tree)
+
+	var problems []string
+	for _, s := range schemas {
+		for _, v := range s.Validate(raw) {
+			problems = append(problems,
+				fmt.Sprintf("'%s' %s", strings.Join(v.Path, delimiter), v.Message))
+		}
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("%w: %s", ErrSchemaViolation, strings.Join(problems, ", "))
+	}
+
+	return nil
+}
+
+// rawWithEmpty is the same as meta.Object.ToRaw() except empty maps and arrays
+// are kept so they can be validated and encoded.
+func rawWithEmpty(obj meta.Object) any {
+	switch {
+	case obj.Array != nil:
+		rv := make([]any, len(obj.Array))
+		for i, val := range obj.Array {
+			rv[i] = rawWithEmpty(val)
+		}
+		return rv
+	case obj.Map != nil:
+		rv := make(map[string]any, len(obj.Map))
+		for key, val := range obj.Map {
+			rv[key] = rawWithEmpty(val)
+		}
+		return rv
+	}
+	return obj.Value
+}
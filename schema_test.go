@@ -0,0 +1,110 @@
+// SPDX-FileCopyrightText: 2023 Weston Schmidt <weston_schmidt@alumni.purdue.edu>
+// SPDX-License-Identifier: Apache-2.0
+
+package goschtalt
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateSchema(t *testing.T) {
+	const server = `{
+		"type": "object",
+		"required": ["name", "server"],
+		"properties": {
+			"name": {"type": "string", "minLength": 1},
+			"server": {
+				"type": "object",
+				"required": ["port"],
+				"properties": {
+					"port": {"type": "integer", "minimum": 1, "maximum": 65535},
+					"tls": {"type": "boolean"}
+				},
+				"additionalProperties": false
+			}
+		}
+	}`
+
+	tests := []struct {
+		description string
+		input       string
+		opts        []Option
+		expectedErr error
+		errText     string
+	}{
+		{
+			description: "No schema.",
+			input:       `{"name":""}`,
+		}, {
+			description: "A matching configuration.",
+			input:       `{"name":"example", "server":{"port":8080, "tls":true}}`,
+			opts:        []Option{ValidateSchema([]byte(server))},
+		}, {
+			description: "String values are converted.",
+			input:       `{"name":"example"}`,
+			opts: []Option{
+				ValidateSchema([]byte(server)),
+				AddArgs("zz-args", []string{"server.port=443", "server.tls=false"}),
+			},
+		}, {
+			description: "A configuration that doesn't match.",
+			input:       `{"name":"", "server":{"port":70000, "tls":"maybe", "extra":1}}`,
+			opts:        []Option{ValidateSchema([]byte(server))},
+			expectedErr: ErrSchemaViolation,
+			errText: "the configuration does not match the schema: " +
+				"'name' must be at least 1 characters long, " +
+				"'server.extra' is not an allowed property, " +
+				"'server.port' must be <= 65535, " +
+				"'server.tls' must be of type boolean",
+		}, {
+			description: "A required key is missing.",
+			input:       `{"name":"example", "server":{}}`,
+			opts: []Option{
+				ValidateSchema([]byte(server)),
+				SetKeyDelimiter("/"),
+			},
+			expectedErr: ErrSchemaViolation,
+			errText:     "the configuration does not match the schema: 'server/port' is required",
+		}, {
+			description: "All the schemas must match.",
+			input:       `{"name":"example", "server":{"port":80}}`,
+			opts: []Option{
+				ValidateSchema([]byte(server)),
+				ValidateSchema([]byte(`{"properties":{"name":{"enum":["other"]}}}`)),
+			},
+			expectedErr: ErrSchemaViolation,
+			errText:     "the configuration does not match the schema: 'name' must be one of the enumerated values",
+		}, {
+			description: "An invalid schema.",
+			input:       `{}`,
+			opts:        []Option{ValidateSchema([]byte(`{"type":`))},
+			expectedErr: ErrInvalidInput,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			opts := append([]Option{
+				WithDecoder(&testDecoder{extensions: []string{"json"}}),
+				AddBuffer("1.json", []byte(tc.input)),
+			}, tc.opts...)
+
+			_, err := New(opts...)
+
+			if tc.expectedErr != nil {
+				require.ErrorIs(err, tc.expectedErr)
+				if tc.errText != "" {
+					assert.Equal(tc.errText, err.Error())
+				}
+				return
+			}
+
+			assert.NoError(err)
+		})
+	}
+}
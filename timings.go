@@ -0,0 +1,95 @@
+// SPDX-FileCopyrightText: 2023 Weston Schmidt <weston_schmidt@alumni.purdue.edu>
+// SPDX-License-Identifier: Apache-2.0
+
+package goschtalt
+
+import "time"
+
+// Timings is the structure that represents how long each phase of the most
+// recent compilation took.  The values are populated at the end of each
+// compilation when the [WithTimings]() option is used, and are available from
+// [Config.Timings]().
+type Timings struct {
+	// Total is the time needed for the entire compilation.
+	Total time.Duration
+
+	// Enumerate is the time needed to find the files in all the file groups.
+	Enumerate time.Duration
+
+	// Files is the number of files found.
+	Files int
+
+	// Decode is the time needed to decode the files and produce the records
+	// from values and functions.
+	Decode time.Duration
+
+	// Decoded is the number of records decoded or produced.
+	Decoded int
+
+	// Merge is the time needed to merge the records together.
+	Merge time.Duration
+
+	// Merged is the number of records merged.
+	Merged int
+
+	// Expand is the time needed to expand the variables.
+	Expand time.Duration
+
+	// Expansions is the number of times the tree was expanded.
+	Expansions int
+
+	// Validate is the time needed to check the final tree.
+	Validate time.Duration
+}
+
+// phase is a specific part of the compilation that is timed.
+type phase int
+
+const (
+	phaseEnumerate phase = iota
+	phaseDecode
+	phaseMerge
+	phaseExpand
+	phaseValidate
+)
+
+// now returns the current time if the timings are being recorded, otherwise
+// it returns the zero time so there is no overhead.
+func (t *Timings) now() time.Time {
+	if t == nil {
+		return time.Time{}
+	}
+	return time.Now()
+}
+
+// record adds the time since the start and the count to the phase.
+func (t *Timings) record(p phase, start time.Time, count int) {
+	if t == nil {
+		return
+	}
+
+	elapsed := time.Since(start)
+	switch p {
+	case phaseEnumerate:
+		t.Enumerate += elapsed
+		t.Files += count
+	case phaseDecode:
+		t.Decode += elapsed
+		t.Decoded += count
+	case phaseMerge:
+		t.Merge += elapsed
+		t.Merged += count
+	case phaseExpand:
+		t.Expand += elapsed
+		t.Expansions += count
+	case phaseValidate:
+		t.Validate += elapsed
+	}
+}
+
+// finish records the total time of the compilation.
+func (t *Timings) finish(start time.Time) {
+	if t != nil {
+		t.Total = time.Since(start)
+	}
+}
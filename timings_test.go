@@ -0,0 +1,138 @@
+// SPDX-FileCopyrightText: 2023 Weston Schmidt <weston_schmidt@alumni.purdue.edu>
+// SPDX-License-Identifier: Apache-2.0
+
+package goschtalt
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithTimings(t *testing.T) {
+	fs := fstest.MapFS{
+		"conf/1.json": &fstest.MapFile{
+			Data: []byte(`{"Hello":"World"}`),
+			Mode: 0755,
+		},
+		"conf/2.json": &fstest.MapFile{
+			Data: []byte(`{"Blue":"sky"}`),
+			Mode: 0755,
+		},
+		"conf/ignored.txt": &fstest.MapFile{
+			Data: []byte(`ignored`),
+			Mode: 0755,
+		},
+	}
+
+	tests := []struct {
+		description string
+		opts        []Option
+		want        Timings
+	}{
+		{
+			description: "Only files.",
+			opts: []Option{
+				AddDir(fs, "conf"),
+			},
+			want: Timings{
+				Files:      3,
+				Decoded:    2,
+				Merged:     2,
+				Expansions: 3,
+			},
+		}, {
+			description: "Files and values.",
+			opts: []Option{
+				AddDir(fs, "conf"),
+				AddValue("value", Root, map[string]any{"Madd": "cat"}),
+				ExpandEnv(),
+			},
+			want: Timings{
+				Files:      3,
+				Decoded:    3,
+				Merged:     3,
+				Expansions: 4,
+			},
+		}, {
+			description: "No records.",
+			want: Timings{
+				Expansions: 1,
+			},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			var got Timings
+			opts := append([]Option{
+				WithDecoder(&testDecoder{extensions: []string{"json"}}),
+				WithTimings(&got),
+			}, tc.opts...)
+
+			c, err := New(opts...)
+			require.NoError(err)
+
+			assert.Equal(tc.want.Files, got.Files)
+			assert.Equal(tc.want.Decoded, got.Decoded)
+			assert.Equal(tc.want.Merged, got.Merged)
+			assert.Equal(tc.want.Expansions, got.Expansions)
+			assert.NotZero(got.Total)
+			assert.GreaterOrEqual(got.Total, got.Enumerate+got.Decode+got.Merge+got.Expand+got.Validate)
+
+			assert.Equal(got, c.Timings())
+
+			// The timings are replaced each compile.
+			require.NoError(c.Compile())
+			assert.Equal(tc.want.Files, got.Files)
+			assert.Equal(tc.want.Merged, got.Merged)
+
+			got = Timings{}
+			require.NoError(c.Reload())
+			assert.Equal(tc.want.Files, got.Files)
+			assert.Equal(tc.want.Merged, got.Merged)
+			assert.Equal(got, c.Timings())
+		})
+	}
+}
+
+func TestTimingsConcurrentReload(t *testing.T) {
+	require := require.New(t)
+
+	var timings Timings
+	c, err := New(
+		AddValue("value", Root, map[string]any{"Madd": "cat"}),
+		WithTimings(&timings),
+	)
+	require.NoError(err)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 20; i++ {
+			_ = c.Reload()
+		}
+	}()
+
+	for i := 0; i < 20; i++ {
+		_ = c.Timings()
+	}
+	<-done
+
+	require.Equal(1, c.Timings().Merged)
+}
+
+func TestTimingsNil(t *testing.T) {
+	assert := assert.New(t)
+
+	var timings *Timings
+
+	assert.True(timings.now().IsZero())
+	timings.record(phaseMerge, timings.now(), 1)
+	timings.finish(timings.now())
+	assert.Nil(timings)
+}
@@ -4,9 +4,11 @@
 package goschtalt
 
 import (
+	"encoding/base64"
 	"errors"
 	"fmt"
 	"reflect"
+	"sort"
 	"strings"
 	"time"
 
@@ -91,7 +93,7 @@ func (c *Config) Unmarshal(key string, result any, opts ...UnmarshalOption) erro
 		return ErrNotCompiled
 	}
 
-	return c.unmarshal(key, result, c.tree, opts...)
+	return c.unmarshal(key, result, c.tree, c.weakKeys, opts...)
 }
 
 // adapter is a function that maps a value from one form (from) to a different
@@ -136,7 +138,7 @@ func adapterIterator(ff []adapter) func(reflect.Value, reflect.Value) (any, erro
 	}
 }
 
-func (c *Config) unmarshal(key string, result any, tree meta.Object, opts ...UnmarshalOption) error {
+func (c *Config) unmarshal(key string, result any, tree meta.Object, weak []string, opts ...UnmarshalOption) error {
 	options := unmarshalOptions{
 		decoder: mapstructure.DecoderConfig{
 			Result:  result,
@@ -154,7 +156,50 @@ func (c *Config) unmarshal(key string, result any, tree meta.Object, opts ...Unm
 		}
 	}
 
-	options.decoder.DecodeHook = adapterIterator(options.adapters)
+	adapters := options.adapters
+	if len(options.base64Keys) > 0 {
+		var err error
+		tree, err = c.decodeBase64(tree, options.base64Keys)
+		if err != nil {
+			return err
+		}
+
+		// The base64 adapter must be first so the rest of the chain never sees
+		// the internal base64Value type.
+		adapters = append([]adapter{adaptBase64Value}, adapters...)
+	}
+
+	if len(options.interfaces) > 0 {
+		var err error
+		tree, err = c.markInterfaces(tree, options.interfaces)
+		if err != nil {
+			return err
+		}
+
+		// Like the base64 adapter, the interface adapter must be first so the
+		// rest of the chain never sees the internal interfaceValue type.
+		adapters = append([]adapter{adaptInterfaceValue(&options.decoder)}, adapters...)
+	}
+
+	if containsLazy(reflect.TypeOf(result), make(map[reflect.Type]bool)) {
+		// The lazy adapter must be first so the value is captured before any
+		// other adapter converts it.
+		adapters = append([]adapter{adaptLazy(&options.decoder)}, adapters...)
+	}
+
+	hook := adapterIterator(adapters)
+	if len(c.opts.deferred) > 0 {
+		hook = deferHook(hook, c.deferredExpander())
+	}
+	if len(weak) > 0 {
+		var err error
+		tree, err = c.markWeak(tree, key, weak)
+		if err != nil {
+			return err
+		}
+		hook = weakHook(hook)
+	}
+	options.decoder.DecodeHook = hook
 
 	options.decoder.MatchName = func(key, field string) bool {
 		encoded := options.mapper(field)
@@ -205,12 +250,14 @@ type UnmarshalOption interface {
 }
 
 type unmarshalOptions struct {
-	optional  bool
-	mappers   []Mapper
-	adapters  []adapter
-	reporters []KeymapReporter
-	decoder   mapstructure.DecoderConfig
-	validator Validator
+	optional   bool
+	mappers    []Mapper
+	adapters   []adapter
+	reporters  []KeymapReporter
+	decoder    mapstructure.DecoderConfig
+	validator  Validator
+	base64Keys []string
+	interfaces map[string]reflect.Type
 }
 
 // mapper is a helper function that applies the mapper function behavior
@@ -420,6 +467,18 @@ const (
 //     or it is an error.  Extra or missing configuration are both errors.
 //   - NONE - (default) Both extra or too few configuration values as well as
 //
+// Independent of the level, a field can be marked as required with the
+// `required` tag option.  It is an error if the configuration value for a
+// required field is missing, for example:
+//
+//	type Server struct {
+//		Host string `goschtalt:"host"`
+//		Port int    `goschtalt:"port,required"`
+//	}
+//
+// Required fields in a nested structure are only checked if the nested
+// configuration is present.
+//
 // # Default
 //
 // NONE
@@ -460,3 +519,276 @@ func (r remapOption) unmarshalApply(opts *unmarshalOptions) error {
 func (r remapOption) String() string {
 	return print.P("Strictness", print.String(r.level), print.SubOpt())
 }
+
+// WithBase64Decode provides a way to base64 decode specific values in the
+// configuration tree while they are being unmarshaled.  The keys are the full
+// paths (from the root of the configuration tree) to the values to decode,
+// using the configured key delimiter.  Keys that are not present in the
+// configuration tree are ignored.
+//
+// Values that are decoded may be unmarshaled into string, []byte or any
+// fields.  A targeted value that is not a string or is not valid base64
+// results in the [Unmarshal]() operation failing.
+//
+// Multiple WithBase64Decode options may be specified and the keys are
+// combined.
+//
+// # Default
+//
+// The default behavior is to not decode any values.
+func WithBase64Decode(keys ...string) UnmarshalOption {
+	return &base64DecodeOption{
+		keys: keys,
+	}
+}
+
+type base64DecodeOption struct {
+	keys []string
+}
+
+func (b base64DecodeOption) unmarshalApply(opts *unmarshalOptions) error {
+	opts.base64Keys = append(opts.base64Keys, b.keys...)
+	return nil
+}
+
+func (b base64DecodeOption) String() string {
+	return print.P("WithBase64Decode", print.Strings(b.keys), print.SubOpt())
+}
+
+// base64Value is the internal type used to hold a decoded base64 value in the
+// tree until it is adapted into the type of the destination field.
+type base64Value []byte
+
+// decodeBase64 returns a copy of the tree with the values at the specified keys
+// base64 decoded.
+func (c *Config) decodeBase64(tree meta.Object, keys []string) (meta.Object, error) {
+	tree = tree.Clone()
+
+	for _, key := range keys {
+		obj, err := tree.Fetch(strings.Split(key, c.opts.keyDelimiter), c.opts.keyDelimiter)
+		if err != nil {
+			if errors.Is(err, meta.ErrNotFound) {
+				continue
+			}
+			return meta.Object{}, err
+		}
+
+		s, ok := obj.Value.(string)
+		if obj.Kind() != meta.Value || !ok {
+			return meta.Object{}, fmt.Errorf("%w: the value for '%s' is not a string", ErrDecoding, key)
+		}
+
+		b, err := base64.StdEncoding.DecodeString(s)
+		if err != nil {
+			return meta.Object{}, fmt.Errorf("%w: the value for '%s' is not valid base64: %v", ErrDecoding, key, err) //nolint:errorlint
+		}
+
+		tree, err = tree.Add(c.opts.keyDelimiter, key, base64Value(b), obj.Origins...)
+		if err != nil {
+			return meta.Object{}, err
+		}
+	}
+
+	return tree, nil
+}
+
+// adaptBase64Value converts the internal base64Value into the destination
+// type if possible.
+func adaptBase64Value(from, to reflect.Value) (any, error) {
+	if !from.IsValid() || !from.CanInterface() {
+		return nil, ErrNotApplicable
+	}
+
+	b, ok := from.Interface().(base64Value)
+	if !ok {
+		return nil, ErrNotApplicable
+	}
+
+	// Use the type held by the interface if there is one.
+	if to.Kind() == reflect.Interface && !to.IsNil() {
+		to = to.Elem()
+	}
+
+	switch {
+	case to.Kind() == reflect.String:
+		return string(b), nil
+	case to.Kind() == reflect.Slice && to.Type().Elem().Kind() == reflect.Uint8,
+		to.Kind() == reflect.Interface:
+		return []byte(b), nil
+	}
+
+	return nil, fmt.Errorf("a base64 value can't be unmarshaled into a %s", to.Type())
+}
+
+// WithInterfaceType provides the concrete type to use when unmarshaling the
+// value at the field into an interface.  Without a concrete type, a map can't
+// be unmarshaled into an interface since the type to create is unknown.  The
+// field is the full path (from the root of the configuration tree) to the
+// value, using the configured key delimiter.  Fields that are not present in
+// the configuration tree are ignored.
+//
+// The concrete parameter is an example of the type to create, for example
+// Square{} or &Square{}.  If a pointer is provided, a pointer to a new value
+// is created.  The concrete type must be assignable to the interface or the
+// [Unmarshal]() operation fails.
+//
+// Multiple WithInterfaceType options may be specified.  If the same field is
+// specified more than once, the last concrete type is used.
+//
+// Slices of pointers (like []*Server) are supported without any options.
+//
+// # Default
+//
+// The default behavior is to not provide any concrete types.
+func WithInterfaceType(field string, concrete any) UnmarshalOption {
+	return &interfaceTypeOption{
+		field:    field,
+		concrete: concrete,
+	}
+}
+
+type interfaceTypeOption struct {
+	field    string
+	concrete any
+}
+
+func (i interfaceTypeOption) unmarshalApply(opts *unmarshalOptions) error {
+	if i.concrete == nil {
+		return fmt.Errorf("%w: WithInterfaceType concrete type must not be nil", ErrInvalidInput)
+	}
+
+	if opts.interfaces == nil {
+		opts.interfaces = make(map[string]reflect.Type)
+	}
+	opts.interfaces[i.field] = reflect.TypeOf(i.concrete)
+	return nil
+}
+
+func (i interfaceTypeOption) String() string {
+	return print.P("WithInterfaceType", print.String(i.field), print.Obj(i.concrete), print.SubOpt())
+}
+
+// interfaceValue is the internal type used to hold a value in the tree until
+// it is unmarshaled into the concrete type.
+type interfaceValue struct {
+	raw      any
+	concrete reflect.Type
+}
+
+// markInterfaces returns a copy of the tree with the values at the specified
+// fields wrapped with the concrete type to use.
+func (c *Config) markInterfaces(tree meta.Object, fields map[string]reflect.Type) (meta.Object, error) {
+	tree = tree.Clone()
+
+	// Mark the deepest fields first so they are included in the values of
+	// the fields that contain them.
+	keys := make([]string, 0, len(fields))
+	for key := range fields {
+		keys = append(keys, key)
+	}
+	sort.Sort(sort.Reverse(sort.StringSlice(keys)))
+
+	for _, key := range keys {
+		obj, err := tree.Fetch(strings.Split(key, c.opts.keyDelimiter), c.opts.keyDelimiter)
+		if err != nil {
+			if errors.Is(err, meta.ErrNotFound) {
+				continue
+			}
+			return meta.Object{}, err
+		}
+
+		val := interfaceValue{
+			raw:      obj.ToRaw(),
+			concrete: fields[key],
+		}
+		tree, err = tree.Add(c.opts.keyDelimiter, key, val, obj.Origins...)
+		if err != nil {
+			return meta.Object{}, err
+		}
+	}
+
+	return tree, nil
+}
+
+// adaptInterfaceValue returns an adapter that unmarshals the internal
+// interfaceValue into the concrete type using the same decoder configuration.
+func adaptInterfaceValue(cfg *mapstructure.DecoderConfig) adapter {
+	return func(from, to reflect.Value) (any, error) {
+		if !from.IsValid() || !from.CanInterface() {
+			return nil, ErrNotApplicable
+		}
+
+		iv, ok := from.Interface().(interfaceValue)
+		if !ok {
+			return nil, ErrNotApplicable
+		}
+
+		typ := iv.concrete
+		isPtr := typ.Kind() == reflect.Pointer
+		if isPtr {
+			typ = typ.Elem()
+		}
+
+		result := reflect.New(typ)
+		dc := *cfg
+		dc.Result = result.Interface()
+
+		decoder, err := mapstructure.NewDecoder(&dc)
+		if err != nil {
+			return nil, err
+		}
+		if err := decoder.Decode(iv.raw); err != nil {
+			return nil, err
+		}
+
+		if isPtr {
+			return result.Interface(), nil
+		}
+		return result.Elem().Interface(), nil
+	}
+}
+
+// WithBoolKeywords allows the common boolean keywords used by configuration
+// authors to be unmarshaled into bool fields.  The keywords are not case
+// sensitive and are:
+//
+//   - true: yes, y, on
+//   - false: no, n, off
+//
+// Only string values being unmarshaled into bool fields are converted, so
+// string fields that contain these values are not changed.  Other strings are
+// left for the remaining adapters to process.
+//
+// # Default
+//
+// The default behavior is to not convert boolean keywords.
+func WithBoolKeywords() UnmarshalOption {
+	return &boolKeywordsOption{}
+}
+
+type boolKeywordsOption struct{}
+
+func (boolKeywordsOption) unmarshalApply(opts *unmarshalOptions) error {
+	opts.adapters = append(opts.adapters, adaptBoolKeywords)
+	return nil
+}
+
+func (boolKeywordsOption) String() string {
+	return print.P("WithBoolKeywords", print.SubOpt())
+}
+
+// adaptBoolKeywords converts the boolean keywords into bool values.
+func adaptBoolKeywords(from, to reflect.Value) (any, error) {
+	if from.Kind() != reflect.String || to.Kind() != reflect.Bool {
+		return nil, ErrNotApplicable
+	}
+
+	switch strings.ToLower(from.String()) {
+	case "yes", "y", "on":
+		return true, nil
+	case "no", "n", "off":
+		return false, nil
+	}
+
+	return nil, ErrNotApplicable
+}
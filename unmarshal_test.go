@@ -16,6 +16,22 @@ import (
 	"github.com/stretchr/testify/require"
 )
 
+type testShape interface {
+	Area() int
+}
+
+type testSquare struct {
+	Side int
+}
+
+func (s testSquare) Area() int {
+	return s.Side * s.Side
+}
+
+type testServer struct {
+	Name string
+}
+
 func TestUnmarshal(t *testing.T) {
 	var zeroOpt UnmarshalOption
 	unknownErr := fmt.Errorf("unknown error")
@@ -37,6 +53,32 @@ func TestUnmarshal(t *testing.T) {
 		Duration time.Duration
 		Time     time.Time
 	}
+	type withSecrets struct {
+		Foo      string
+		Password string
+		Key      []byte
+	}
+	type withSecretInt struct {
+		Password int
+	}
+	type withBools struct {
+		A, B, C, D, E, F bool
+	}
+	type withServers struct {
+		Servers []*testServer
+	}
+	type withShapes struct {
+		Shape  testShape
+		Shapes []testShape
+	}
+	type withRequired struct {
+		Host string `goschtalt:"host"`
+		Port int    `goschtalt:"port,required"`
+	}
+	type withNestedRequired struct {
+		Name   string       `goschtalt:"name,required"`
+		Server withRequired `goschtalt:"server"`
+	}
 
 	tests := []struct {
 		description string
@@ -175,6 +217,39 @@ func TestUnmarshal(t *testing.T) {
 				Foo:   "bar",
 				Delta: "1s",
 			},
+		}, {
+			description: "A required field that is present.",
+			input:       `{"port":8080}`,
+			want:        withRequired{},
+			expected: withRequired{
+				Port: 8080,
+			},
+		}, {
+			description: "A required field that is missing.",
+			input:       `{"host":"localhost"}`,
+			want:        withRequired{},
+			expectedErr: unknownErr,
+		}, {
+			description: "A required field that is missing with Strictness(SUBSET).",
+			input:       `{"host":"localhost"}`,
+			opts:        []UnmarshalOption{Strictness(SUBSET)},
+			want:        withRequired{},
+			expectedErr: unknownErr,
+		}, {
+			description: "A nested required field that is missing.",
+			input:       `{"name":"app", "server":{"host":"localhost"}}`,
+			want:        withNestedRequired{},
+			expectedErr: unknownErr,
+		}, {
+			description: "Required fields at several levels that are present.",
+			input:       `{"name":"app", "server":{"port":80}}`,
+			want:        withNestedRequired{},
+			expected: withNestedRequired{
+				Name: "app",
+				Server: withRequired{
+					Port: 80,
+				},
+			},
 		}, {
 			description: "Verify the Strictness(NONE) behavior succeeds with exact match.",
 			input:       `{"Foo":"bar", "Delta": "1s"}`,
@@ -365,6 +440,178 @@ func TestUnmarshal(t *testing.T) {
 				}),
 			},
 			expected: time.Date(2022, time.May, 1, 0, 0, 0, 0, time.UTC),
+		}, {
+			description: "Base64 decode a string and a []byte.",
+			input:       `{"Foo":"Zm9v", "Password":"cGFzc3dvcmQ=", "Key":"a2V5"}`,
+			opts:        []UnmarshalOption{WithBase64Decode("Password", "Key")},
+			want:        withSecrets{},
+			expected: withSecrets{
+				Foo:      "Zm9v",
+				Password: "password",
+				Key:      []byte("key"),
+			},
+		}, {
+			description: "Base64 decode composing with other adapters.",
+			input:       `{"Foo":{"Password":"cGFzc3dvcmQ=", "Delta":"1s"}}`,
+			key:         "Foo",
+			opts: []UnmarshalOption{
+				adaptStringToDuration(),
+				WithBase64Decode("Foo.Password"),
+			},
+			want: struct {
+				Password string
+				Delta    time.Duration
+			}{},
+			expected: struct {
+				Password string
+				Delta    time.Duration
+			}{
+				Password: "password",
+				Delta:    time.Second,
+			},
+		}, {
+			description: "Base64 decode into an array element.",
+			input:       `{"Foo":["b25l", "dHdv"]}`,
+			key:         "Foo",
+			opts:        []UnmarshalOption{WithBase64Decode("Foo.1")},
+			want:        []string{},
+			expected:    []string{"b25l", "two"},
+		}, {
+			description: "Base64 decode a missing key is ignored.",
+			input:       `{"Foo":"Zm9v"}`,
+			opts:        []UnmarshalOption{WithBase64Decode("Password")},
+			want:        withSecrets{},
+			expected: withSecrets{
+				Foo: "Zm9v",
+			},
+		}, {
+			description: "Base64 decode into a string.",
+			input:       `{"Foo":"Zm9v"}`,
+			key:         "Foo",
+			opts:        []UnmarshalOption{WithBase64Decode("Foo")},
+			want:        "",
+			expected:    "foo",
+		}, {
+			description: "Base64 decode into an any value.",
+			input:       `{"Foo":{"Bar":"Zm9v"}}`,
+			key:         "Foo",
+			opts:        []UnmarshalOption{WithBase64Decode("Foo.Bar")},
+			want:        map[string]any{},
+			expected:    map[string]any{"Bar": []byte("foo")},
+		}, {
+			description: "Base64 decode fails with invalid base64.",
+			input:       `{"Password":"not base64!"}`,
+			opts:        []UnmarshalOption{WithBase64Decode("Password")},
+			want:        withSecrets{},
+			expectedErr: ErrDecoding,
+		}, {
+			description: "Base64 decode fails when the value isn't a string.",
+			input:       `{"Password":{"Foo":"bar"}}`,
+			opts:        []UnmarshalOption{WithBase64Decode("Password")},
+			want:        withSecrets{},
+			expectedErr: ErrDecoding,
+		}, {
+			description: "Base64 decode fails with an unsupported destination.",
+			input:       `{"Password":"MTIz"}`,
+			opts:        []UnmarshalOption{WithBase64Decode("Password")},
+			want:        withSecretInt{},
+			expectedErr: ErrAdaptFailure,
+		}, {
+			description: "Bool keywords are converted.",
+			input:       `{"A":"yes", "B":"Y", "C":"On", "D":"NO", "E":"n", "F":"off"}`,
+			opts:        []UnmarshalOption{WithBoolKeywords()},
+			want:        withBools{},
+			expected: withBools{
+				A: true,
+				B: true,
+				C: true,
+			},
+		}, {
+			description: "Bool keywords don't change string fields.",
+			input:       `{"Foo":"yes", "Delta":"off"}`,
+			opts:        []UnmarshalOption{WithBoolKeywords()},
+			want:        simple{},
+			expected: simple{
+				Foo:   "yes",
+				Delta: "off",
+			},
+		}, {
+			description: "Bool keywords compose with other adapters.",
+			input:       `{"Foo":{"Enabled":"on", "Delta":"1s"}}`,
+			key:         "Foo",
+			opts: []UnmarshalOption{
+				adaptStringToDuration(),
+				WithBoolKeywords(),
+			},
+			want: struct {
+				Enabled bool
+				Delta   time.Duration
+			}{},
+			expected: struct {
+				Enabled bool
+				Delta   time.Duration
+			}{
+				Enabled: true,
+				Delta:   time.Second,
+			},
+		}, {
+			description: "Other strings are not bool keywords.",
+			input:       `{"A":"maybe"}`,
+			opts:        []UnmarshalOption{WithBoolKeywords()},
+			want:        withBools{},
+			expectedErr: unknownErr,
+		}, {
+			description: "Unmarshal into a slice of pointers.",
+			input:       `{"Servers":[{"Name":"a"}, {"Name":"b"}]}`,
+			want:        withServers{},
+			expected: withServers{
+				Servers: []*testServer{{Name: "a"}, {Name: "b"}},
+			},
+		}, {
+			description: "Unmarshal into an interface with a concrete type.",
+			input:       `{"Shape":{"Side":2}, "Shapes":[{"Side":3}, {"Side":4}]}`,
+			opts: []UnmarshalOption{
+				WithInterfaceType("Shape", testSquare{}),
+				WithInterfaceType("Shapes.0", testSquare{}),
+				WithInterfaceType("Shapes.1", &testSquare{}),
+			},
+			want: withShapes{},
+			expected: withShapes{
+				Shape:  testSquare{Side: 2},
+				Shapes: []testShape{testSquare{Side: 3}, &testSquare{Side: 4}},
+			},
+		}, {
+			description: "Unmarshal into an interface below the key.",
+			input:       `{"Foo":{"Shape":{"Side":2}}}`,
+			key:         "Foo",
+			opts:        []UnmarshalOption{WithInterfaceType("Foo.Shape", testSquare{})},
+			want:        withShapes{},
+			expected: withShapes{
+				Shape: testSquare{Side: 2},
+			},
+		}, {
+			description: "Unmarshal into an interface with a missing field.",
+			input:       `{"Shapes":[]}`,
+			opts:        []UnmarshalOption{WithInterfaceType("Shape", testSquare{})},
+			want:        withShapes{},
+			expected:    withShapes{},
+		}, {
+			description: "Unmarshal into an interface without a concrete type.",
+			input:       `{"Shape":{"Side":2}}`,
+			want:        withShapes{},
+			expectedErr: unknownErr,
+		}, {
+			description: "Unmarshal into an interface with an unassignable type.",
+			input:       `{"Shape":{"Side":2}}`,
+			opts:        []UnmarshalOption{WithInterfaceType("Shape", testServer{})},
+			want:        withShapes{},
+			expectedErr: unknownErr,
+		}, {
+			description: "Unmarshal into an interface with a nil type.",
+			input:       `{"Shape":{"Side":2}}`,
+			opts:        []UnmarshalOption{WithInterfaceType("Shape", nil)},
+			want:        withShapes{},
+			expectedErr: ErrInvalidInput,
 		}, {
 			description: "Verify the DefaultUnmarshalOptions() works.",
 			input:       `{"Foo":"bar", "Delta": "bob"}`,
@@ -6,6 +6,7 @@ package goschtalt
 import (
 	"fmt"
 
+	"github.com/goschtalt/goschtalt/internal/casbab"
 	"github.com/goschtalt/goschtalt/internal/print"
 )
 
@@ -76,6 +77,45 @@ func (w wrapMap) Map(s string) string {
 	return s
 }
 
+// SnakeCaseMapper provides a [Mapper] that converts golang structure field
+// names into snake_case configuration names.  For example, "MaxRetries"
+// becomes "max_retries" and "HTTPPort" becomes "http_port".
+//
+// Use it with [KeymapMapper](), like this:
+//
+//	goschtalt.DefaultUnmarshalOptions(
+//		goschtalt.KeymapMapper(goschtalt.SnakeCaseMapper()),
+//	)
+func SnakeCaseMapper() Mapper {
+	return caseMapper("snake_case")
+}
+
+// CamelCaseMapper provides a [Mapper] that converts golang structure field
+// names into camelCase configuration names.  For example, "MaxRetries"
+// becomes "maxRetries" and "HTTPPort" becomes "httpPort".
+//
+// See [SnakeCaseMapper]() for an example.
+func CamelCaseMapper() Mapper {
+	return caseMapper("camelCase")
+}
+
+// KebabCaseMapper provides a [Mapper] that converts golang structure field
+// names into kebab-case configuration names.  For example, "MaxRetries"
+// becomes "max-retries" and "HTTPPort" becomes "http-port".
+//
+// See [SnakeCaseMapper]() for an example.
+func KebabCaseMapper() Mapper {
+	return caseMapper("kebab-case")
+}
+
+// caseMapper returns a Mapper for the named case format using the same mapper
+// as ConfigIs().
+func caseMapper(format string) Mapper {
+	return &casemapper{
+		toCase: casbab.Find(format),
+	}
+}
+
 // Keymap takes a map of strings to strings and adds it to the existing
 // chain of keymaps. The key of the map is the golang structure field name and
 // the value is the goschtalt configuration tree name string. The value of "-"
@@ -173,3 +213,27 @@ func (k keymapReportOption) valueApply(opts *valueOptions) error {
 func (k keymapReportOption) String() string {
 	return print.P("KeymapReporter", print.Obj(k.r), print.SubOpt())
 }
+
+// withKeymap returns a copy of the options where the keymap reporters that
+// report to from report to to instead.
+func (opts options) withKeymap(from, to KeymapReporter) options {
+	unmarshal := make([]UnmarshalOption, len(opts.unmarshalOptions))
+	for i, opt := range opts.unmarshalOptions {
+		if k, ok := opt.(*keymapReportOption); ok && k.r == from {
+			opt = &keymapReportOption{r: to}
+		}
+		unmarshal[i] = opt
+	}
+
+	value := make([]ValueOption, len(opts.valueOptions))
+	for i, opt := range opts.valueOptions {
+		if k, ok := opt.(*keymapReportOption); ok && k.r == from {
+			opt = &keymapReportOption{r: to}
+		}
+		value[i] = opt
+	}
+
+	opts.unmarshalOptions = unmarshal
+	opts.valueOptions = value
+	return opts
+}
@@ -7,6 +7,7 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestUnmarshalValueOption(t *testing.T) {
@@ -46,3 +47,64 @@ func TestUnmarshalValueOption(t *testing.T) {
 		})
 	}
 }
+
+func TestCaseMappers(t *testing.T) {
+	tests := []struct {
+		in    string
+		snake string
+		camel string
+		kebab string
+	}{
+		{in: "MaxRetries", snake: "max_retries", camel: "maxRetries", kebab: "max-retries"},
+		{in: "HTTPPort", snake: "http_port", camel: "httpPort", kebab: "http-port"},
+		{in: "ServerIP", snake: "server_ip", camel: "serverIp", kebab: "server-ip"},
+		{in: "Name", snake: "name", camel: "name", kebab: "name"},
+		{in: "-", snake: "-", camel: "-", kebab: "-"},
+		{in: "", snake: "", camel: "", kebab: ""},
+	}
+	for _, tc := range tests {
+		t.Run(tc.in, func(t *testing.T) {
+			assert := assert.New(t)
+
+			assert.Equal(tc.snake, SnakeCaseMapper().Map(tc.in))
+			assert.Equal(tc.camel, CamelCaseMapper().Map(tc.in))
+			assert.Equal(tc.kebab, KebabCaseMapper().Map(tc.in))
+		})
+	}
+}
+
+func TestCaseMappersInTheChain(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	type cfg struct {
+		MaxRetries int
+		HTTPPort   int
+		Other      string
+	}
+
+	c, err := New(
+		AddValue("record", Root,
+			cfg{
+				MaxRetries: 3,
+				HTTPPort:   8080,
+				Other:      "value",
+			},
+			KeymapMapper(SnakeCaseMapper()),
+		),
+		DefaultUnmarshalOptions(KeymapMapper(SnakeCaseMapper())),
+	)
+	require.NoError(err)
+
+	tree, err := Unmarshal[map[string]any](c, Root)
+	require.NoError(err)
+	assert.Equal(map[string]any{
+		"max_retries": 3,
+		"http_port":   8080,
+		"other":       "value",
+	}, tree)
+
+	got, err := Unmarshal[cfg](c, Root)
+	require.NoError(err)
+	assert.Equal(cfg{MaxRetries: 3, HTTPPort: 8080, Other: "value"}, got)
+}
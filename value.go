@@ -4,11 +4,14 @@
 package goschtalt
 
 import (
+	"context"
 	"fmt"
 	"reflect"
 	"strings"
+	"sync"
 
 	"github.com/goschtalt/goschtalt/internal/print"
+	"github.com/goschtalt/goschtalt/internal/strs"
 	"github.com/goschtalt/goschtalt/internal/structs"
 	"github.com/goschtalt/goschtalt/pkg/meta"
 )
@@ -34,6 +37,38 @@ func (f ValueGetterFunc) Get(rn string, u Unmarshaler) (any, error) {
 
 var _ ValueGetter = (*ValueGetterFunc)(nil)
 
+// ValueGetterCtx is the same as [ValueGetter] except the context passed to
+// [Config.CompileCtx]() is provided, so long running getters are able to stop
+// and return ctx.Err() when the context is done.
+type ValueGetterCtx interface {
+	// GetCtx is called each time the configuration is compiled.  The context
+	// of the compilation, the recordName and an Unmarshaler with the present
+	// stage of configuration and expanded variables are provided to assist.
+	// A data structure (object, string, int, etc) or an error is returned.
+	GetCtx(ctx context.Context, recordName string, u Unmarshaler) (any, error)
+}
+
+// The ValueGetterCtxFunc type is an adapter to allow the use of ordinary
+// functions as ValueGetterCtxs. If f is a function with the appropriate
+// signature, ValueGetterCtxFunc(f) is a ValueGetterCtx that calls f.
+type ValueGetterCtxFunc func(context.Context, string, Unmarshaler) (any, error)
+
+// GetCtx calls f(ctx, rn, u)
+func (f ValueGetterCtxFunc) GetCtx(ctx context.Context, rn string, u Unmarshaler) (any, error) {
+	return f(ctx, rn, u)
+}
+
+var _ ValueGetterCtx = (*ValueGetterCtxFunc)(nil)
+
+// valueGetterNoCtx adapts a ValueGetter into a ValueGetterCtx.
+type valueGetterNoCtx struct {
+	ValueGetter
+}
+
+func (v valueGetterNoCtx) GetCtx(_ context.Context, rn string, u Unmarshaler) (any, error) {
+	return v.Get(rn, u)
+}
+
 // AddValues provides a simple way to set additional configuration values at
 // runtime.
 //
@@ -50,8 +85,32 @@ func AddValue(recordName, key string, val any, opts ...ValueOption) Option {
 		text:       print.P("AddValue", print.String(recordName), print.String(key), print.Obj(val), print.LiteralStringers(opts)),
 		recordName: recordName,
 		key:        key,
-		getter: ValueGetterFunc(
-			func(_ string, _ Unmarshaler) (any, error) {
+		getter: ValueGetterCtxFunc(
+			func(context.Context, string, Unmarshaler) (any, error) {
+				return val, nil
+			}),
+		opts: opts,
+	}
+}
+
+// AddValueAt provides a simple way to set additional configuration values at
+// runtime at an explicit path.  Unlike [AddValue]() the path is not split
+// using the key delimiter, so the path segments may contain the key delimiter.
+//
+// To place the configuration at the root use an empty (or nil) path.
+//
+// Valid Option Types:
+//   - [BufferValueOption]
+//   - [GlobalOption]
+//   - [ValueOption]
+//   - [UnmarshalValueOption]
+func AddValueAt(recordName string, path []string, val any, opts ...ValueOption) Option {
+	return &value{
+		text:       print.P("AddValueAt", print.String(recordName), print.Strings(path), print.Obj(val), print.LiteralStringers(opts)),
+		recordName: recordName,
+		path:       append([]string{}, path...),
+		getter: ValueGetterCtxFunc(
+			func(context.Context, string, Unmarshaler) (any, error) {
 				return val, nil
 			}),
 		opts: opts,
@@ -72,15 +131,83 @@ func AddValue(recordName, key string, val any, opts ...ValueOption) Option {
 //   - [ValueOption]
 //   - [UnmarshalValueOption]
 func AddValueGetter(recordName, key string, getter ValueGetter, opts ...ValueOption) Option {
+	var ctxGetter ValueGetterCtx
+	if getter != nil {
+		ctxGetter = valueGetterNoCtx{getter}
+	}
+
 	return &value{
 		text:       print.P("AddValueGetter", print.String(recordName), print.String(key), print.Obj(getter), print.LiteralStringers(opts)),
 		recordName: recordName,
 		key:        key,
+		getter:     ctxGetter,
+		opts:       opts,
+	}
+}
+
+// AddValueGetterCtx is the same as [AddValueGetter]() except the getter is
+// provided the context used to compile the configuration.  See
+// [Config.CompileCtx]().
+//
+// Valid Option Types:
+//   - [BufferValueOption]
+//   - [GlobalOption]
+//   - [ValueOption]
+//   - [UnmarshalValueOption]
+func AddValueGetterCtx(recordName, key string, getter ValueGetterCtx, opts ...ValueOption) Option {
+	return &value{
+		text:       print.P("AddValueGetterCtx", print.String(recordName), print.String(key), print.Obj(getter), print.LiteralStringers(opts)),
+		recordName: recordName,
+		key:        key,
 		getter:     getter,
 		opts:       opts,
 	}
 }
 
+// AddStructDefaults provides a way to use a struct instance as a layer of
+// default values.  Unlike [AddValue]() with [AsDefault](), only the fields
+// that are non-zero or are marked with a `default:"..."` struct tag are
+// included, producing a clean defaults layer.  If a field is non-zero the
+// value of the field is used, otherwise the text of the default tag is used.
+// The text of the default tag is converted to a bool, int or float if
+// possible unless the field is a string.
+// Nested structs (and pointers to structs) are examined recursively.
+//
+// The val must be a struct or a pointer to a struct.  The record is always
+// treated as a default.
+//
+// Example:
+//
+//	type Config struct {
+//		Host    string `default:"localhost"`
+//		Port    int    `default:"8080"`
+//		Verbose bool
+//	}
+//
+//	goschtalt.AddStructDefaults("defaults", Root, Config{Verbose: true})
+//
+// To place the configuration at the root use `goschtalt.Root` ([Root]) instead
+// of "" for more clarity.
+//
+// Valid Option Types:
+//   - [BufferValueOption]
+//   - [GlobalOption]
+//   - [ValueOption]
+//   - [UnmarshalValueOption]
+func AddStructDefaults(recordName, key string, val any, opts ...ValueOption) Option {
+	return &value{
+		text:       print.P("AddStructDefaults", print.String(recordName), print.String(key), print.Obj(val), print.LiteralStringers(opts)),
+		recordName: recordName,
+		key:        key,
+		getter: ValueGetterCtxFunc(
+			func(context.Context, string, Unmarshaler) (any, error) {
+				return val, nil
+			}),
+		opts:           append(opts[:len(opts):len(opts)], AsDefault()),
+		structDefaults: true,
+	}
+}
+
 // value defines a key and value that is injected into the configuration tree.
 type value struct {
 	text string
@@ -91,8 +218,15 @@ type value struct {
 	// The key to set the value at.
 	key string
 
+	// The path to set the value at.  If not nil, this is used instead of key.
+	path []string
+
 	// The getter to use to get the value.
-	getter ValueGetter
+	getter ValueGetterCtx
+
+	// structDefaults specifies that only the non-zero or default tagged fields
+	// of the struct are included.
+	structDefaults bool
 
 	// Options that configure how to process the Value provided.
 	// These options are in addition to any default settings set with
@@ -102,7 +236,7 @@ type value struct {
 
 // toTree does the work of converting from a structure of some sort to the
 // normalized object tree goschtalt uses.
-func (v value) toTree(delimiter string, u Unmarshaler, defaultOpts ...ValueOption) (meta.Object, error) {
+func (v value) toTree(ctx context.Context, delimiter string, u Unmarshaler, cache *valueCache, defaultOpts ...ValueOption) (meta.Object, error) {
 	cfg := valueOptions{
 		tagName: defaultTag,
 	}
@@ -114,9 +248,14 @@ func (v value) toTree(delimiter string, u Unmarshaler, defaultOpts ...ValueOptio
 		}
 	}
 
-	data, err := v.getter.Get(v.recordName, u)
-	if err != nil {
-		return meta.Object{}, err
+	var err error
+	data, found := cache.get(cfg.cacheKey)
+	if !found {
+		data, err = v.getter.GetCtx(ctx, v.recordName, u)
+		if err != nil {
+			return meta.Object{}, err
+		}
+		cache.set(cfg.cacheKey, data)
 	}
 
 	if data == nil {
@@ -128,15 +267,33 @@ func (v value) toTree(delimiter string, u Unmarshaler, defaultOpts ...ValueOptio
 		data = reflect.ValueOf(data).Elem().Interface()
 	}
 
+	if v.structDefaults && reflect.TypeOf(data).Kind() != reflect.Struct {
+		return meta.Object{}, fmt.Errorf("%w: AddStructDefaults requires a struct, not %T", ErrInvalidInput, data)
+	}
+
 	if reflect.TypeOf(data).Kind() == reflect.Struct {
 		s := structs.New(data)
 		s.TagName = cfg.tagName
-		data = s.Map()
+		m := s.Map()
+		if v.structDefaults {
+			m = filterStructDefaults(reflect.ValueOf(data), m, cfg.tagName)
+		}
+		data = m
+	}
+
+	path := v.path
+	if path == nil {
+		path = strings.Split(v.key, delimiter)
+	}
+
+	origin := v.recordName
+	if len(cfg.origin) > 0 {
+		origin = cfg.origin
 	}
 
 	tree := meta.ObjectFromRawWithOrigin(data,
-		[]meta.Origin{{File: v.recordName}},
-		strings.Split(v.key, delimiter)...)
+		[]meta.Origin{{File: origin}},
+		path...)
 
 	tree = tree.AlterKeyCase(func(s string) string {
 		return cfg.mapper(s)
@@ -166,6 +323,7 @@ func (v value) apply(opts *options) error {
 		val:  &v,
 	}
 
+	var isDefault bool
 	for _, opt := range v.opts {
 		var info valueOptions
 
@@ -173,12 +331,17 @@ func (v value) apply(opts *options) error {
 			return err
 		}
 
-		if info.isDefault {
-			opts.defaults = append(opts.defaults, r)
-			return nil
+		isDefault = isDefault || info.isDefault
+		if info.priority != nil {
+			r.priority = info.priority
 		}
 	}
 
+	if isDefault {
+		opts.defaults = append(opts.defaults, r)
+		return nil
+	}
+
 	opts.values = append(opts.values, r)
 	return nil
 }
@@ -211,6 +374,9 @@ type valueOptions struct {
 	reporters             []KeymapReporter
 	failOnNonSerializable bool
 	isDefault             bool
+	origin                string
+	priority              *int
+	cacheKey              string
 }
 
 // mapper is a simple helper that does the mapping based on the specified
@@ -229,6 +395,129 @@ func (v valueOptions) mapper(s string) string {
 	return s
 }
 
+// Cached causes the value returned by the ValueGetter to be remembered using
+// the provided cache key.  The cached values are shared by all the values that
+// use the same cache key, so the ValueGetter is only called once.  Errors are
+// not cached.
+//
+// The cache is cleared at the start of each compilation, including the ones
+// done by [Config.Reload]() and [Config.Watch](), so the values are never stale.
+// Use [KeepCache]() to keep the cached values across compilations, so the
+// ValueGetter is only called the first time the configuration is compiled.
+// This is useful for values that are expensive to get, like values fetched
+// from a remote service.  Use [Config.InvalidateCache]() to remove the kept
+// values so the ValueGetter is called again during the next compilation.
+//
+// An empty key disables caching.
+//
+// # Default
+//
+// The ValueGetter is called each time the configuration is compiled.
+func Cached(key string) ValueOption {
+	return cachedOption(key)
+}
+
+type cachedOption string
+
+func (c cachedOption) valueApply(opts *valueOptions) error {
+	opts.cacheKey = string(c)
+	return nil
+}
+
+func (c cachedOption) String() string {
+	return print.P("Cached", print.String(string(c)), print.SubOpt())
+}
+
+// KeepCache keeps the values remembered by the [Cached]() option across
+// compilations instead of clearing them at the start of each compilation.
+// The kept values last as long as the [Config] or until they are removed by
+// [Config.InvalidateCache]().
+//
+// The keep bool value is optional & assumed to be `true` if omitted.  The
+// first specified value is used if provided.  A value of `false` disables the
+// option.
+//
+// # Default
+//
+// The cache is cleared at the start of each compilation.
+func KeepCache(keep ...bool) Option {
+	keep = append(keep, true)
+	return keepCacheOption(keep[0])
+}
+
+type keepCacheOption bool
+
+func (k keepCacheOption) apply(opts *options) error {
+	opts.keepCache = bool(k)
+	return nil
+}
+
+func (_ keepCacheOption) ignoreDefaults() bool {
+	return false
+}
+
+func (k keepCacheOption) String() string {
+	return print.P("KeepCache", print.BoolSilentTrue(bool(k)))
+}
+
+// valueCache holds the values remembered by the [Cached]() option.  A nil
+// valueCache caches nothing.
+type valueCache struct {
+	mutex   sync.Mutex
+	entries map[string]any
+}
+
+func newValueCache() *valueCache {
+	return &valueCache{
+		entries: make(map[string]any),
+	}
+}
+
+// get returns the cached value for the key, if present.
+func (vc *valueCache) get(key string) (any, bool) {
+	if vc == nil || len(key) == 0 {
+		return nil, false
+	}
+
+	vc.mutex.Lock()
+	defer vc.mutex.Unlock()
+
+	val, found := vc.entries[key]
+	return val, found
+}
+
+// set remembers the value for the key.
+func (vc *valueCache) set(key string, val any) {
+	if vc == nil || len(key) == 0 {
+		return
+	}
+
+	vc.mutex.Lock()
+	defer vc.mutex.Unlock()
+
+	vc.entries[key] = val
+}
+
+// invalidate removes the cached values for the keys, or all the cached values
+// if no keys are provided.
+func (vc *valueCache) invalidate(keys ...string) {
+	if vc == nil {
+		return
+	}
+
+	vc.mutex.Lock()
+	defer vc.mutex.Unlock()
+
+	if len(keys) == 0 {
+		vc.entries = make(map[string]any)
+		return
+	}
+
+	for _, key := range keys {
+		delete(vc.entries, key)
+	}
+}
+
 // FailOnNonSerializable specifies that an error should be returned if any
 // non-serializable objects (channels, functions, unsafe pointers) are
 // encountered in the resulting configuration tree.  Non-serializable objects
@@ -314,3 +603,87 @@ func (a adaptToCfgOption) String() string {
 	}
 	return print.P("AdaptToCfg", print.Obj(a.adapter, labels...), print.SubOpt())
 }
+
+// filterStructDefaults returns the portion of the converted struct map that
+// is made up of the non-zero fields and the fields with a default tag.
+func filterStructDefaults(v reflect.Value, converted map[string]any, tagName string) map[string]any {
+	rv := make(map[string]any)
+
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		tag := strings.Split(field.Tag.Get(tagName), ",")
+		name := tag[0]
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		fv := v.Field(i)
+		sv := fv
+		if sv.Kind() == reflect.Ptr {
+			if sv.IsNil() {
+				sv = reflect.Zero(sv.Type().Elem())
+			} else {
+				sv = sv.Elem()
+			}
+		}
+
+		if sv.Kind() == reflect.Struct && hasExportedFields(sv.Type()) {
+			if strs.Contains(tag[1:], "flatten") {
+				for k, val := range filterStructDefaults(sv, converted, tagName) {
+					rv[k] = val
+				}
+				continue
+			}
+
+			sub, ok := converted[name].(map[string]any)
+			if !ok {
+				s := structs.New(sv.Interface())
+				s.TagName = tagName
+				sub = s.Map()
+			}
+
+			if m := filterStructDefaults(sv, sub, tagName); len(m) > 0 {
+				rv[name] = m
+			}
+			continue
+		}
+
+		got, present := converted[name]
+		if !present {
+			continue
+		}
+
+		if !fv.IsZero() {
+			rv[name] = got
+			continue
+		}
+
+		if def, found := field.Tag.Lookup("default"); found {
+			if fv.Kind() == reflect.String {
+				rv[name] = def
+			} else {
+				rv[name] = meta.StringToBestType(def)
+			}
+		}
+	}
+
+	return rv
+}
+
+// hasExportedFields returns if the struct type has any exported fields.
+func hasExportedFields(t reflect.Type) bool {
+	for i := 0; i < t.NumField(); i++ {
+		if t.Field(i).IsExported() {
+			return true
+		}
+	}
+	return false
+}
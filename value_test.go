@@ -10,6 +10,7 @@ import (
 
 	"github.com/goschtalt/goschtalt/internal/mapstructure"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestValueOptions(t *testing.T) {
@@ -57,6 +58,13 @@ func TestValueOptions(t *testing.T) {
 				isDefault: true,
 			},
 			str: "AsDefault()",
+		}, {
+			description: "Verify Cached(key)",
+			opt:         Cached("key"),
+			want: valueOptions{
+				cacheKey: "key",
+			},
+			str: "Cached('key')",
 		}, {
 			description: "Verify AsDefault(false)",
 			opt:         WithError(testErr),
@@ -124,3 +132,108 @@ func TestAdapterToCfgFunc(t *testing.T) {
 		})
 	}
 }
+
+func TestCached(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	calls := map[string]int{}
+	getter := func(name string, fail *bool) ValueGetter {
+		return ValueGetterFunc(func(string, Unmarshaler) (any, error) {
+			calls[name]++
+			if fail != nil && *fail {
+				return nil, errors.New("fetch failed")
+			}
+			return name + "-" + string(rune('0'+calls[name])), nil
+		})
+	}
+
+	fail := true
+	cfg, err := New(
+		AddValueGetter("1", "cached", getter("cached", nil), Cached("key")),
+		AddValueGetter("2", "shared", getter("shared", nil), Cached("key")),
+		AddValueGetter("3", "uncached", getter("uncached", nil)),
+		AddValueGetter("4", "failing", getter("failing", &fail), Cached("failing")),
+		AutoCompile(false),
+		KeepCache(),
+	)
+	require.NoError(err)
+
+	// Errors are not cached.
+	require.Error(cfg.Compile())
+	fail = false
+
+	check := func(cached, shared, uncached string) {
+		require.NoError(cfg.Compile())
+
+		got, err := Unmarshal[string](cfg, "cached")
+		require.NoError(err)
+		assert.Equal(cached, got)
+
+		got, err = Unmarshal[string](cfg, "shared")
+		require.NoError(err)
+		assert.Equal(shared, got)
+
+		got, err = Unmarshal[string](cfg, "uncached")
+		require.NoError(err)
+		assert.Equal(uncached, got)
+	}
+
+	// The failed compile cached the first call of the cached getter, which is
+	// shared by the values with the same cache key.
+	check("cached-1", "cached-1", "uncached-2")
+	check("cached-1", "cached-1", "uncached-3")
+	assert.Equal(1, calls["cached"])
+	assert.Equal(0, calls["shared"])
+	assert.Equal(2, calls["failing"])
+
+	// Reload also uses the cache.
+	require.NoError(cfg.Reload())
+	assert.Equal(1, calls["cached"])
+	assert.Equal(2, calls["failing"])
+
+	// Invalidating a key that isn't used changes nothing.
+	cfg.InvalidateCache("unknown")
+	check("cached-1", "cached-1", "uncached-5")
+
+	cfg.InvalidateCache("key")
+	check("cached-2", "cached-2", "uncached-6")
+	assert.Equal(2, calls["failing"])
+
+	cfg.InvalidateCache()
+	check("cached-3", "cached-3", "uncached-7")
+	assert.Equal(3, calls["failing"])
+}
+
+func TestCachedClearedEachCompile(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	var calls int
+	getter := ValueGetterFunc(func(string, Unmarshaler) (any, error) {
+		calls++
+		return "value-" + string(rune('0'+calls)), nil
+	})
+
+	cfg, err := New(
+		AddValueGetter("1", "a", getter, Cached("key")),
+		AddValueGetter("2", "b", getter, Cached("key")),
+	)
+	require.NoError(err)
+
+	// The values with the same key share the value within a compile.
+	assert.Equal(1, calls)
+	got, err := Unmarshal[map[string]any](cfg, Root)
+	require.NoError(err)
+	assert.Equal(map[string]any{"a": "value-1", "b": "value-1"}, got)
+
+	// Each compile gets a fresh value.
+	require.NoError(cfg.Compile())
+	assert.Equal(2, calls)
+	require.NoError(cfg.Reload())
+	assert.Equal(3, calls)
+
+	got, err = Unmarshal[map[string]any](cfg, Root)
+	require.NoError(err)
+	assert.Equal(map[string]any{"a": "value-3", "b": "value-3"}, got)
+}
@@ -0,0 +1,145 @@
+// SPDX-FileCopyrightText: 2023 Weston Schmidt <weston_schmidt@alumni.purdue.edu>
+// SPDX-License-Identifier: Apache-2.0
+
+package goschtalt
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/goschtalt/goschtalt/internal/print"
+	"github.com/goschtalt/goschtalt/pkg/meta"
+)
+
+// A Severity represents how important a [Warning] is.
+type Severity string
+
+const (
+	SeverityInfo    Severity = "INFO"
+	SeverityWarning Severity = "WARNING"
+)
+
+// Warning describes a problem with the configuration that did not prevent it
+// from being compiled.  Warnings are collected each time the configuration is
+// compiled and are available via [Config.Warnings]().
+type Warning struct {
+	// Key is the full key of the value the warning is about, joined using the
+	// key delimiter.  If the warning is not about a specific value, the Key
+	// is empty.
+	Key string
+
+	// Message describes the problem.
+	Message string
+
+	// Severity is how important the warning is.
+	Severity Severity
+
+	// Origin is where the problem was found, if known.
+	Origin meta.Origin
+}
+
+// String returns a useful representation for the warning.
+func (w Warning) String() string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "%s: ", w.Severity)
+	if len(w.Key) > 0 {
+		fmt.Fprintf(&b, "'%s' ", w.Key)
+	}
+	fmt.Fprintf(&b, "%s (%s)", w.Message, w.Origin)
+
+	return b.String()
+}
+
+// Error returns the same representation as String() so a Warning can be
+// provided to the handler set with [WithErrorHandler]().
+func (w Warning) Error() string {
+	return w.String()
+}
+
+// SuppressWarnings removes the warnings about the specified keys from
+// [Config.Warnings]() and the handler set with [WithErrorHandler]().  This
+// allows known and accepted warnings to be silenced while new warnings are
+// still reported.
+//
+// The keys are split using the key delimiter and each part may be a pattern
+// as supported by path.Match(), so `*` matches any single part of a key.  A
+// part that is exactly `**` matches any number of parts.  For example, with
+// the default delimiter of "." the key `servers.*.password` matches
+// `servers.0.password` and `**.token` matches `token` and `a.b.token`.
+//
+// Warnings that are not about a specific key can't be suppressed.
+//
+// SuppressWarnings may be specified multiple times and the keys are combined.
+//
+// # Default
+//
+// No warnings are suppressed.
+func SuppressWarnings(keys ...string) Option {
+	return suppressWarningsOption(keys)
+}
+
+type suppressWarningsOption []string
+
+func (s suppressWarningsOption) apply(opts *options) error {
+	for _, key := range s {
+		// Validate the patterns so problems are found early.
+		if _, err := path.Match(key, ""); err != nil {
+			return fmt.Errorf("%w, SuppressWarnings key '%s' is invalid: %v", ErrInvalidInput, key, err) //nolint:errorlint
+		}
+	}
+
+	opts.suppressWarnings = append(opts.suppressWarnings, s...)
+	return nil
+}
+
+func (_ suppressWarningsOption) ignoreDefaults() bool {
+	return false
+}
+
+func (s suppressWarningsOption) String() string {
+	return print.P("SuppressWarnings", print.Strings(s))
+}
+
+// suppressed returns if the warning matches any of the key patterns.
+func suppressed(w Warning, patterns []string, delimiter string) bool {
+	if len(w.Key) == 0 {
+		return false
+	}
+
+	key := strings.Split(w.Key, delimiter)
+	for _, pattern := range patterns {
+		if matchKey(strings.Split(pattern, delimiter), key) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// matchKey returns if the parts of the key match the parts of the pattern.
+func matchKey(pattern, key []string) bool {
+	if len(pattern) == 0 {
+		return len(key) == 0
+	}
+
+	if pattern[0] == "**" {
+		for i := 0; i <= len(key); i++ {
+			if matchKey(pattern[1:], key[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+
+	if len(key) == 0 {
+		return false
+	}
+
+	if ok, _ := path.Match(pattern[0], key[0]); !ok {
+		return false
+	}
+
+	return matchKey(pattern[1:], key[1:])
+}
@@ -0,0 +1,266 @@
+// SPDX-FileCopyrightText: 2023 Weston Schmidt <weston_schmidt@alumni.purdue.edu>
+// SPDX-License-Identifier: Apache-2.0
+
+package goschtalt
+
+import (
+	"errors"
+	"testing"
+	"testing/fstest"
+
+	"github.com/goschtalt/goschtalt/pkg/meta"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWarning_String(t *testing.T) {
+	tests := []struct {
+		description string
+		in          Warning
+		want        string
+	}{
+		{
+			description: "An empty warning.",
+			want:        ":  (unknown)",
+		}, {
+			description: "A warning without a key.",
+			in: Warning{
+				Message:  "skipped",
+				Severity: SeverityWarning,
+				Origin:   meta.Origin{File: "file.json"},
+			},
+			want: "WARNING: skipped (file.json)",
+		}, {
+			description: "A warning with a key.",
+			in: Warning{
+				Key:      "a.b",
+				Message:  "is deprecated",
+				Severity: SeverityInfo,
+				Origin:   meta.Origin{File: "file.json", Line: 1, Col: 2},
+			},
+			want: "INFO: 'a.b' is deprecated (file.json:1[2])",
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			assert := assert.New(t)
+
+			assert.Equal(tc.want, tc.in.String())
+		})
+	}
+}
+
+func TestConfigWarnings(t *testing.T) {
+	fs := fstest.MapFS{
+		"conf/1.json": &fstest.MapFile{
+			Data: []byte(`{"Hello":"World"}`),
+			Mode: 0755,
+		},
+		"drop/2.json": &fstest.MapFile{
+			Data: []byte(`not valid json`),
+			Mode: 0755,
+		},
+		"drop/3.json": &fstest.MapFile{
+			Data: []byte(`{"Blue":"sky"}`),
+			Mode: 0755,
+		},
+		"vars/4.json": &fstest.MapFile{
+			Data: []byte(`{"Name":"${known}-${unknown}"}`),
+			Mode: 0755,
+		},
+	}
+
+	known := Expand(ExpanderFunc(func(s string) (string, bool) {
+		return "value", s == "known"
+	}))
+
+	tests := []struct {
+		description string
+		opts        []Option
+		want        []Warning
+	}{
+		{
+			description: "No warnings.",
+			opts: []Option{
+				AddDir(fs, "conf"),
+			},
+		}, {
+			description: "A skipped file is a warning.",
+			opts: []Option{
+				AddDir(fs, "conf"),
+				AddDir(fs, "drop", BestEffort()),
+			},
+			want: []Warning{
+				{
+					Severity: SeverityWarning,
+					Origin:   meta.Origin{File: "drop/2.json"},
+				},
+			},
+		}, {
+			description: "A skipped missing file is a warning.",
+			opts: []Option{
+				AddDir(fs, "conf"),
+				AddFile(fs, "missing.json", BestEffort()),
+			},
+			want: []Warning{
+				{
+					Severity: SeverityWarning,
+				},
+			},
+		}, {
+			description: "A variable that isn't expanded is a warning.",
+			opts: []Option{
+				AddDir(fs, "vars"),
+				known,
+				// The same delimiters are only reported once.
+				Expand(ExpanderFunc(func(string) (string, bool) { return "", false })),
+			},
+			want: []Warning{
+				{
+					Key:      "Name",
+					Severity: SeverityWarning,
+					Origin:   meta.Origin{File: "4.json", Line: 2, Col: 123},
+				},
+			},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			var handled []error
+			opts := append([]Option{
+				WithDecoder(&testDecoder{extensions: []string{"json"}}),
+				WithErrorHandler(func(err error) {
+					handled = append(handled, err)
+				}),
+			}, tc.opts...)
+
+			c, err := New(opts...)
+			require.NoError(err)
+
+			got := c.Warnings()
+			require.Len(got, len(tc.want))
+			require.Len(handled, len(tc.want))
+			for i := range tc.want {
+				assert.Equal(tc.want[i].Key, got[i].Key)
+				assert.Equal(tc.want[i].Severity, got[i].Severity)
+				assert.Equal(tc.want[i].Origin, got[i].Origin)
+				assert.NotEmpty(got[i].Message)
+
+				var w Warning
+				require.True(errors.As(handled[i], &w))
+				assert.Equal(got[i], w)
+			}
+
+			// The warnings are replaced each compile.
+			require.NoError(c.Compile())
+			assert.Len(c.Warnings(), len(tc.want))
+			assert.Len(handled, 2*len(tc.want))
+		})
+	}
+}
+
+func TestSuppressWarnings(t *testing.T) {
+	tests := []struct {
+		description string
+		opts        []Option
+		want        []string
+		expectedErr error
+	}{
+		{
+			description: "No warnings are suppressed.",
+			want:        []string{"a.token", "b.c.token", "servers.0.password", "servers.1.password"},
+		}, {
+			description: "A specific key is suppressed.",
+			opts:        []Option{SuppressWarnings("a.token")},
+			want:        []string{"b.c.token", "servers.0.password", "servers.1.password"},
+		}, {
+			description: "A glob suppresses a single part.",
+			opts:        []Option{SuppressWarnings("servers.*.password")},
+			want:        []string{"a.token", "b.c.token"},
+		}, {
+			description: "A double star suppresses any number of parts.",
+			opts:        []Option{SuppressWarnings("**.token")},
+			want:        []string{"servers.0.password", "servers.1.password"},
+		}, {
+			description: "Multiple options are combined.",
+			opts: []Option{
+				SuppressWarnings("a.*"),
+				SuppressWarnings("servers.1.password", "b.*"),
+			},
+			want: []string{"b.c.token", "servers.0.password"},
+		}, {
+			description: "The key delimiter is honored.",
+			opts: []Option{
+				SetKeyDelimiter("/"),
+				SuppressWarnings("b/*/token", "a.token"),
+			},
+			want: []string{"a/token", "servers/0/password", "servers/1/password"},
+		}, {
+			description: "An invalid pattern.",
+			opts:        []Option{SuppressWarnings("a.[")},
+			expectedErr: ErrInvalidInput,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			var handled []string
+			opts := append([]Option{
+				WithDecoder(&testDecoder{extensions: []string{"json"}}),
+				AddBuffer("1.json", []byte(`{
+					"a":{"token":"secret-1"},
+					"b":{"c":{"token":"secret-2"}},
+					"servers":[{"password":"secret-3"}, {"password":"secret-4"}]
+				}`)),
+				DetectLeakedSecrets(LeakPatterns("^secret-")),
+				WithErrorHandler(func(err error) {
+					var w Warning
+					if errors.As(err, &w) {
+						handled = append(handled, w.Key)
+					}
+				}),
+			}, tc.opts...)
+
+			c, err := New(opts...)
+			if tc.expectedErr != nil {
+				assert.ErrorIs(err, tc.expectedErr)
+				return
+			}
+			require.NoError(err)
+
+			var got []string
+			for _, w := range c.Warnings() {
+				got = append(got, w.Key)
+			}
+			assert.Equal(tc.want, got)
+			assert.Equal(tc.want, handled)
+		})
+	}
+}
+
+func TestErrorHandlerBeforeFailure(t *testing.T) {
+	assert := assert.New(t)
+
+	var handled []error
+	_, err := New(
+		WithDecoder(&testDecoder{extensions: []string{"json"}}),
+		AddBuffer("1.json", []byte(`{"token":"a8Kd93jfLq0ZpX2mVb7Nc4Rt","name":"${missing}"}`)),
+		Expand(ExpanderFunc(func(string) (string, bool) { return "", false })),
+		DetectLeakedSecrets(LeakIsError()),
+		WithErrorHandler(func(err error) {
+			handled = append(handled, err)
+		}),
+	)
+
+	// The warning from the expansion is provided even though the validation
+	// fails the compilation.
+	assert.ErrorIs(err, ErrLeakedSecret)
+	if assert.Len(handled, 1) {
+		assert.Contains(handled[0].Error(), "'${missing}' was not expanded")
+	}
+}
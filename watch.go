@@ -0,0 +1,148 @@
+// SPDX-FileCopyrightText: 2023 Weston Schmidt <weston_schmidt@alumni.purdue.edu>
+// SPDX-License-Identifier: Apache-2.0
+
+package goschtalt
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"maps"
+	"time"
+
+	"github.com/goschtalt/goschtalt/internal/print"
+)
+
+// Watch polls the filesystems of the files and directories added with
+// [AddFile](), [AddDir](), [AddTree]() and similar options and recompiles the
+// configuration with [Config.Reload]() when a file is added, removed or
+// changed.  A file is considered changed when its modification time or size
+// changes.  After each recompilation fn is called with the configuration and
+// the error returned by Reload(), if any.  When the compilation fails the
+// previous configuration is left in place.
+//
+// The filesystems are polled at the interval set by [SetWatchInterval]().
+// Changes are debounced: the configuration is only recompiled once the files
+// are unchanged for a full interval, so a burst of changes results in a
+// single recompilation.
+//
+// Watch blocks until the context is canceled, so it is normally called in a
+// goroutine:
+//
+//	go cfg.Watch(ctx, func(c *goschtalt.Config, err error) {
+//		...
+//	})
+//
+// Only polling is used since all filesystems (including [os.DirFS]() and
+// [testing/fstest.MapFS]) support it without additional dependencies.
+func (c *Config) Watch(ctx context.Context, fn func(*Config, error)) {
+	c.mutex.Lock()
+	interval := c.opts.watchInterval
+	c.mutex.Unlock()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	last := c.watchSnapshot()
+	pending := false
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		now := c.watchSnapshot()
+		if !maps.Equal(last, now) {
+			last = now
+			pending = true
+			continue
+		}
+
+		if !pending {
+			continue
+		}
+		pending = false
+
+		err := c.Reload()
+		if fn != nil {
+			fn(c, err)
+		}
+	}
+}
+
+// watchKey identifies a file in a filegroup.
+type watchKey struct {
+	group int
+	file  string
+}
+
+// watchState is what is known about a file (or the filegroup if the file is
+// empty) when it was examined.
+type watchState struct {
+	modTime time.Time
+	size    int64
+	err     string
+}
+
+// watchSnapshot examines all the files in the filegroups.
+func (c *Config) watchSnapshot() map[watchKey]watchState {
+	c.mutex.Lock()
+	groups := c.opts.filegroups
+	c.mutex.Unlock()
+
+	snapshot := make(map[watchKey]watchState)
+	for i, g := range groups {
+		files, err := g.enumerate()
+		if err != nil {
+			snapshot[watchKey{group: i}] = watchState{err: err.Error()}
+			continue
+		}
+
+		for _, file := range files {
+			var state watchState
+
+			info, err := fs.Stat(g.fs, file)
+			if err != nil {
+				state.err = err.Error()
+			} else {
+				state.modTime = info.ModTime()
+				state.size = info.Size()
+			}
+
+			snapshot[watchKey{group: i, file: file}] = state
+		}
+	}
+
+	return snapshot
+}
+
+// SetWatchInterval sets how often [Config.Watch]() polls the filesystems for
+// changes.  The interval must be greater than 0.
+//
+// # Default
+//
+// The default value is 1 second.
+func SetWatchInterval(interval time.Duration) Option {
+	if interval <= 0 {
+		return WithError(
+			fmt.Errorf("%w, SetWatchInterval must be greater than 0", ErrInvalidInput),
+		)
+	}
+	return setWatchIntervalOption(interval)
+}
+
+type setWatchIntervalOption time.Duration
+
+func (s setWatchIntervalOption) apply(opts *options) error {
+	opts.watchInterval = time.Duration(s)
+	return nil
+}
+
+func (_ setWatchIntervalOption) ignoreDefaults() bool {
+	return false
+}
+
+func (s setWatchIntervalOption) String() string {
+	return print.P("SetWatchInterval", print.Literal(time.Duration(s).String()))
+}
@@ -0,0 +1,267 @@
+// SPDX-FileCopyrightText: 2023 Weston Schmidt <weston_schmidt@alumni.purdue.edu>
+// SPDX-License-Identifier: Apache-2.0
+
+package goschtalt
+
+import (
+	"context"
+	"io/fs"
+	"strconv"
+	"sync"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// mutableFS is a fstest.MapFS that can be changed while it is being read.
+// Files are replaced, never modified, so open files are not changed.
+type mutableFS struct {
+	m     sync.Mutex
+	files fstest.MapFS
+	opens int
+}
+
+func (m *mutableFS) Open(name string) (fs.File, error) {
+	m.m.Lock()
+	defer m.m.Unlock()
+	m.opens++
+	return m.files.Open(name)
+}
+
+// watching starts Watch and returns once it has examined the filesystem so
+// changes made after it returns are seen.
+func (m *mutableFS) watching(t *testing.T, ctx context.Context, cfg *Config, fn func(*Config, error)) <-chan struct{} {
+	m.m.Lock()
+	before := m.opens
+	m.m.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		cfg.Watch(ctx, fn)
+	}()
+
+	require.Eventually(t, func() bool {
+		m.m.Lock()
+		defer m.m.Unlock()
+		return m.opens > before
+	}, 5*time.Second, time.Millisecond)
+
+	return done
+}
+
+func (m *mutableFS) set(name, data string, modTime time.Time) {
+	m.m.Lock()
+	defer m.m.Unlock()
+	m.files[name] = &fstest.MapFile{
+		Data:    []byte(data),
+		Mode:    0644,
+		ModTime: modTime,
+	}
+}
+
+func (m *mutableFS) remove(name string) {
+	m.m.Lock()
+	defer m.m.Unlock()
+	delete(m.files, name)
+}
+
+func TestWatch(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	start := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	fsys := &mutableFS{files: fstest.MapFS{}}
+	fsys.set("conf/1.json", `{"a":"one"}`, start)
+
+	cfg, err := New(
+		AddDir(fsys, "conf"),
+		SetWatchInterval(5*time.Millisecond),
+		AutoCompile(),
+	)
+	require.NoError(err)
+
+	get := func() string {
+		got, err := Unmarshal[string](cfg, "a")
+		require.NoError(err)
+		return got
+	}
+	assert.Equal("one", get())
+
+	results := make(chan error)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := fsys.watching(t, ctx, cfg, func(c *Config, err error) {
+		assert.Same(cfg, c)
+		results <- err
+	})
+
+	wait := func() error {
+		select {
+		case err := <-results:
+			return err
+		case <-time.After(5 * time.Second):
+			require.FailNow("the configuration was not recompiled")
+		}
+		return nil
+	}
+
+	// A changed modification time.
+	fsys.set("conf/1.json", `{"a":"two"}`, start.Add(time.Second))
+	require.NoError(wait())
+	assert.Equal("two", get())
+
+	// A changed size with the same modification time.
+	fsys.set("conf/1.json", `{"a":"three"}`, start.Add(time.Second))
+	require.NoError(wait())
+	assert.Equal("three", get())
+
+	// A new file.
+	fsys.set("conf/2.json", `{"a":"four"}`, start)
+	require.NoError(wait())
+	assert.Equal("four", get())
+
+	// A failed compile leaves the previous configuration in place.
+	fsys.set("conf/2.json", `{"a":`, start)
+	assert.Error(wait())
+	assert.Equal("four", get())
+
+	// A removed file.
+	fsys.remove("conf/2.json")
+	require.NoError(wait())
+	assert.Equal("three", get())
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		require.FailNow("Watch did not return")
+	}
+}
+
+func TestWatchDebounce(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	start := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	fsys := &mutableFS{files: fstest.MapFS{}}
+	fsys.set("conf.json", `{"a":0}`, start)
+
+	cfg, err := New(
+		AddFile(fsys, "conf.json"),
+		SetWatchInterval(20*time.Millisecond),
+		AutoCompile(),
+	)
+	require.NoError(err)
+
+	var m sync.Mutex
+	var calls int
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := fsys.watching(t, ctx, cfg, func(*Config, error) {
+		m.Lock()
+		calls++
+		m.Unlock()
+	})
+
+	// Change the file faster than the interval so the changes are seen
+	// as one.
+	for i := 1; i <= 10; i++ {
+		fsys.set("conf.json", `{"a":`+strconv.Itoa(i)+`}`, start.Add(time.Duration(i)*time.Second))
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	require.Eventually(func() bool {
+		got, err := Unmarshal[int](cfg, "a")
+		return err == nil && got == 10
+	}, 5*time.Second, 5*time.Millisecond)
+
+	// Give it a chance to call again if it were going to.
+	time.Sleep(100 * time.Millisecond)
+	cancel()
+	<-done
+
+	m.Lock()
+	defer m.Unlock()
+	assert.Positive(calls)
+	assert.Less(calls, 10)
+}
+
+func TestWatchNilFunc(t *testing.T) {
+	require := require.New(t)
+
+	fsys := &mutableFS{files: fstest.MapFS{}}
+	fsys.set("conf.json", `{"a":"one"}`, time.Time{})
+
+	cfg, err := New(
+		AddFile(fsys, "conf.json"),
+		SetWatchInterval(time.Millisecond),
+		AutoCompile(),
+	)
+	require.NoError(err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := fsys.watching(t, ctx, cfg, nil)
+
+	fsys.set("conf.json", `{"a":"three"}`, time.Time{})
+	require.Eventually(func() bool {
+		got, err := Unmarshal[string](cfg, "a")
+		return err == nil && got == "three"
+	}, 5*time.Second, time.Millisecond)
+
+	cancel()
+	<-done
+}
+
+func TestSetWatchInterval(t *testing.T) {
+	tests := []struct {
+		description string
+		opts        []Option
+		interval    time.Duration
+		expectedErr error
+	}{
+		{
+			description: "The default.",
+			interval:    time.Second,
+		}, {
+			description: "Set 10ms",
+			opts: []Option{
+				SetWatchInterval(10 * time.Millisecond),
+			},
+			interval: 10 * time.Millisecond,
+		}, {
+			description: "Set 0",
+			opts: []Option{
+				SetWatchInterval(0),
+			},
+			expectedErr: ErrInvalidInput,
+		}, {
+			description: "Set -1s",
+			opts: []Option{
+				SetWatchInterval(-time.Second),
+			},
+			expectedErr: ErrInvalidInput,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			cfg, err := New(tc.opts...)
+
+			if tc.expectedErr != nil {
+				assert.ErrorIs(err, tc.expectedErr)
+				return
+			}
+
+			require.NotNil(cfg)
+			require.NoError(err)
+
+			assert.Equal(tc.interval, cfg.opts.watchInterval)
+		})
+	}
+}